@@ -33,7 +33,7 @@ var reUserControlReleased = regexp.MustCompile(`>>\s+([0-9A-Fa-f]{2}):45`)
 func main() {
 	hdmiInput := flag.Int("hdmi", 2, "HDMI input number to activate before listening (<=0 skips the switch).")
 	skipPower := flag.Bool("skip-power", false, "Skip sending the TV power on command before listening.")
-	powerOnDelay := flag.Duration("power-delay", 10*time.Second, "Delay after powering on the TV before switching inputs.")
+	powerOnDelay := flag.Duration("power-delay", 10*time.Second, "Maximum time to wait for the TV to report it is on before switching inputs.")
 	inputDelay := flag.Duration("input-delay", 5*time.Second, "Delay after switching HDMI inputs before starting cec-client.")
 
 	flag.Parse()
@@ -43,9 +43,12 @@ func main() {
 		if err := cec.PowerOnTV(); err != nil {
 			log.Printf("PowerOnTV failed: %v", err)
 		} else if *powerOnDelay > 0 {
-			delay := *powerOnDelay
-			fmt.Printf("Waiting %s for the TV to wake up...\n", delay)
-			time.Sleep(delay)
+			fmt.Printf("Waiting up to %s for the TV to report it is on...\n", *powerOnDelay)
+			if err := cec.WaitForPowerOn(*powerOnDelay); err != nil {
+				log.Printf("TV power status: %v", err)
+			} else {
+				fmt.Println("TV reports power on.")
+			}
 		}
 	} else {
 		fmt.Println("Skipping TV power on step.")
@@ -64,6 +67,11 @@ func main() {
 		fmt.Println("Skipping HDMI input switch step.")
 	}
 
+	// Release the adapter held by the command session before opening our own.
+	if err := cec.Close(); err != nil {
+		log.Printf("Closing CEC session: %v", err)
+	}
+
 	fmt.Println("Starting cec-client in traffic mode; listening for user control pressed/released.")
 
 	// cec-client options:
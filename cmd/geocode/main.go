@@ -2,12 +2,15 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/rwcarlsen/goexif/exif"
 )
@@ -24,11 +27,20 @@ type ImageMetadata struct {
 func main() {
 	// Parse command-line flag for the root directory
 	rootDir := flag.String("root", "", "Root directory containing sub-directories with images")
+	includeNoGPS := flag.Bool("include-no-gps", false, "Also record images without GPS data, with an empty friendly_location")
+	concurrency := flag.Int("concurrency", 1, "Number of images to process at once")
+	rate := flag.Float64("rate", 0, "Most geocoder lookups per second, shared by all workers; 0 means no limit")
 	flag.Parse()
 
 	if *rootDir == "" {
 		log.Fatal("Please provide a root directory using the -root flag")
 	}
+	if *concurrency < 1 {
+		log.Fatal("-concurrency must be at least 1")
+	}
+	if *rate < 0 {
+		log.Fatal("-rate must not be negative")
+	}
 
 	// List entries in the root directory.
 	entries, err := os.ReadDir(*rootDir)
@@ -36,62 +48,175 @@ func main() {
 		log.Fatalf("Failed to read root directory: %v", err)
 	}
 
-	// Process each sub-directory.
+	// List every sub-directory's images first, so progress has a total.
+	var dirs []imageDir
+	total := 0
 	for _, entry := range entries {
 		if entry.IsDir() {
-			subDirPath := filepath.Join(*rootDir, entry.Name())
-			log.Printf("Processing sub-directory: %s", subDirPath)
-			processSubDir(subDirPath)
+			dir := imageDir{path: filepath.Join(*rootDir, entry.Name())}
+			if dir.images, err = listImages(dir.path); err != nil {
+				log.Printf("Failed to read directory %s: %v", dir.path, err)
+				continue
+			}
+			dirs = append(dirs, dir)
+			total += len(dir.images)
 		}
 	}
+
+	p := &processor{
+		includeNoGPS: *includeNoGPS,
+		concurrency:  *concurrency,
+		progress:     newProgress(total),
+	}
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+		defer ticker.Stop()
+		p.limiter = ticker.C
+	}
+	for _, dir := range dirs {
+		log.Printf("Processing sub-directory: %s", dir.path)
+		p.processSubDir(dir)
+	}
+
+	noGPS := "left out"
+	if *includeNoGPS {
+		noGPS = "recorded"
+	}
+	c := p.counts
+	log.Printf("Done: %d images in %d directories; %d located, %d without GPS data (%s), %d failed; wrote %d metadata files.",
+		total, len(dirs), c.located, c.noGPS, noGPS, c.failed, c.written)
 }
 
-// processSubDir processes one sub-directory:
-// it scans for image files, extracts metadata from each image,
-// and writes a metadata.json file mapping image filenames to their metadata.
-func processSubDir(dir string) {
-	// Map of image filename to its metadata.
-	metadataMap := make(map[string]ImageMetadata)
+// imageDir is a sub-directory of the root and the image files in it.
+type imageDir struct {
+	path   string
+	images []string
+}
 
+// listImages returns the names of the image files in dir.
+func listImages(dir string) ([]string, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
-		log.Printf("Failed to read directory %s: %v", dir, err)
-		return
+		return nil, err
 	}
-
+	var images []string
 	for _, entry := range entries {
 		// Skip directories
-		if entry.IsDir() {
-			continue
+		if !entry.IsDir() && isImage(entry.Name()) {
+			images = append(images, entry.Name())
 		}
+	}
+	return images, nil
+}
 
-		// Process files with an image extension.
-		if isImage(entry.Name()) {
-			filePath := filepath.Join(dir, entry.Name())
-			meta, err := extractMetadata(filePath)
-			if err != nil {
-				log.Printf("Error processing %s: %v", filePath, err)
-				continue
+// processor geocodes images with a pool of workers that share one rate
+// limit, and keeps count of the results.
+type processor struct {
+	includeNoGPS bool
+	concurrency  int
+	// limiter delivers a tick for each geocoder lookup allowed; nil means
+	// lookups are not limited.
+	limiter  <-chan time.Time
+	progress *progress
+
+	mu     sync.Mutex
+	counts struct {
+		located, noGPS, failed, written int
+	}
+}
+
+// processSubDir processes one sub-directory:
+// it extracts metadata from each of its images,
+// and writes a metadata.json file mapping image filenames to their metadata.
+func (p *processor) processSubDir(dir imageDir) {
+	// Map of image filename to its metadata.
+	metadataMap := make(map[string]ImageMetadata)
+
+	names := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				filePath := filepath.Join(dir.path, name)
+				meta, err := extractMetadata(filePath, p.limiter)
+
+				p.mu.Lock()
+				switch {
+				case errors.Is(err, errNoGPS):
+					p.counts.noGPS++
+					if p.includeNoGPS {
+						metadataMap[name] = ImageMetadata{}
+					}
+				case err != nil:
+					log.Printf("Error processing %s: %v", filePath, err)
+					p.counts.failed++
+				default:
+					p.counts.located++
+					metadataMap[name] = meta
+				}
+				p.mu.Unlock()
+				p.progress.done()
 			}
-			metadataMap[entry.Name()] = meta
-		}
+		}()
+	}
+	for _, name := range dir.images {
+		names <- name
 	}
+	close(names)
+	wg.Wait()
 
 	// Write the metadata map as JSON into metadata.json in the current sub-directory.
-	jsonPath := filepath.Join(dir, "metadata.json")
+	jsonPath := filepath.Join(dir.path, "metadata.json")
 	jsonData, err := json.MarshalIndent(metadataMap, "", "  ")
 	if err != nil {
-		log.Printf("Failed to marshal JSON for directory %s: %v", dir, err)
+		log.Printf("Failed to marshal JSON for directory %s: %v", dir.path, err)
 		return
 	}
 	if err := os.WriteFile(jsonPath, jsonData, 0644); err != nil {
 		log.Printf("Failed to write JSON file %s: %v", jsonPath, err)
 		return
 	}
+	p.counts.written++
 
 	log.Printf("Wrote metadata file: %s", jsonPath)
 }
 
+// progressInterval is how often progress is logged.
+const progressInterval = 5 * time.Second
+
+// progress logs how many of the images have been processed, with an
+// estimate of the time left, at most every progressInterval.
+type progress struct {
+	mu         sync.Mutex
+	total      int
+	processed  int
+	start      time.Time
+	lastLogged time.Time
+}
+
+func newProgress(total int) *progress {
+	now := time.Now()
+	return &progress{total: total, start: now, lastLogged: now}
+}
+
+// done counts one more image processed.
+func (p *progress) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.processed++
+	now := time.Now()
+	if now.Sub(p.lastLogged) < progressInterval && p.processed < p.total {
+		return
+	}
+	p.lastLogged = now
+	elapsed := now.Sub(p.start)
+	eta := time.Duration(float64(elapsed) / float64(p.processed) * float64(p.total-p.processed))
+	log.Printf("Processed %d/%d images (%.0f%%), ETA %s",
+		p.processed, p.total, 100*float64(p.processed)/float64(p.total), eta.Round(time.Second))
+}
+
 // isImage returns true if the fileName has a common image extension.
 func isImage(fileName string) bool {
 	lower := strings.ToLower(fileName)
@@ -100,10 +225,14 @@ func isImage(fileName string) bool {
 		strings.HasSuffix(lower, ".png")
 }
 
+// errNoGPS marks an image without EXIF GPS data.
+var errNoGPS = errors.New("no GPS data")
+
 // extractMetadata opens the image file, extracts EXIF GPS information,
-// and returns an ImageMetadata struct.
-// If no GPS data is found, it returns an error.
-func extractMetadata(filePath string) (ImageMetadata, error) {
+// and returns an ImageMetadata struct. Each geocoder lookup waits for a tick
+// from limiter, unless limiter is nil.
+// If no GPS data is found, it returns an error wrapping errNoGPS.
+func extractMetadata(filePath string, limiter <-chan time.Time) (ImageMetadata, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return ImageMetadata{}, fmt.Errorf("opening file: %w", err)
@@ -118,10 +247,13 @@ func extractMetadata(filePath string) (ImageMetadata, error) {
 
 	lat, long, err := x.LatLong()
 	if err != nil {
-		return ImageMetadata{}, fmt.Errorf("no GPS data: %w", err)
+		return ImageMetadata{}, fmt.Errorf("%w: %v", errNoGPS, err)
 	}
 
 	// Get a human friendly location name from the coordinates.
+	if limiter != nil {
+		<-limiter
+	}
 	friendly := reverseGeocode(lat, long)
 
 	return ImageMetadata{
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// EXIF tags uprightJPEG rewrites.
+const (
+	tagOrientation     = 0x0112
+	tagExifIFD         = 0x8769
+	tagPixelXDimension = 0xa002
+	tagPixelYDimension = 0xa003
+)
+
+// exifHeader starts the APP1 segment that holds EXIF.
+const exifHeader = "Exif\x00\x00"
+
+// uprightJPEG returns encoded, a JPEG as written by image/jpeg, with the
+// metadata segments of original put back: every APPn and COM segment ahead
+// of its image data, in order. In the EXIF, the orientation is reset to 1
+// and the pixel dimensions set to width x height.
+func uprightJPEG(original, encoded []byte, width, height int) ([]byte, error) {
+	segments, err := metadataSegments(original)
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) < 2 || encoded[0] != 0xff || encoded[1] != 0xd8 {
+		return nil, errors.New("encoded image is not a JPEG")
+	}
+	out := []byte{0xff, 0xd8}
+	for _, seg := range segments {
+		if seg[1] == 0xe1 && bytes.HasPrefix(seg[4:], []byte(exifHeader)) {
+			seg = bytes.Clone(seg)
+			if err := resetEXIF(seg[4+len(exifHeader):], width, height); err != nil {
+				return nil, fmt.Errorf("rewrite EXIF: %w", err)
+			}
+		}
+		out = append(out, seg...)
+	}
+	return append(out, encoded[2:]...), nil
+}
+
+// metadataSegments returns the APPn and COM segments of the JPEG in data,
+// markers and lengths included, up to its first frame or scan.
+func metadataSegments(data []byte) ([][]byte, error) {
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, errors.New("not a JPEG")
+	}
+	var segments [][]byte
+	for off := 2; ; {
+		if off+4 > len(data) || data[off] != 0xff {
+			return nil, errors.New("truncated JPEG header")
+		}
+		marker := data[off+1]
+		// Metadata always comes ahead of the tables and image data.
+		if marker < 0xe0 || marker > 0xef && marker != 0xfe {
+			return segments, nil
+		}
+		end := off + 2 + int(binary.BigEndian.Uint16(data[off+2:]))
+		if end > len(data) {
+			return nil, errors.New("truncated JPEG header")
+		}
+		segments = append(segments, data[off:end])
+		off = end
+	}
+}
+
+// resetEXIF sets, in place, the orientation in IFD0 of the TIFF structure
+// tiff to 1 and the pixel dimensions in its EXIF IFD to width x height.
+func resetEXIF(tiff []byte, width, height int) error {
+	if len(tiff) < 8 {
+		return errors.New("truncated TIFF header")
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return errors.New("bad TIFF byte order")
+	}
+	exifIFD, err := setTags(tiff, order, order.Uint32(tiff[4:]), map[uint16]uint32{tagOrientation: 1})
+	if err != nil {
+		return err
+	}
+	if exifIFD == 0 {
+		return nil
+	}
+	_, err = setTags(tiff, order, exifIFD, map[uint16]uint32{
+		tagPixelXDimension: uint32(width),
+		tagPixelYDimension: uint32(height),
+	})
+	return err
+}
+
+// setTags overwrites the SHORT or LONG value of each tag in values that the
+// IFD at offset holds, and returns the offset of the EXIF IFD it points to,
+// or 0.
+func setTags(tiff []byte, order binary.ByteOrder, offset uint32, values map[uint16]uint32) (exifIFD uint32, err error) {
+	if uint64(offset)+2 > uint64(len(tiff)) {
+		return 0, errors.New("IFD out of range")
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	entries := int(offset) + 2
+	if entries+12*count > len(tiff) {
+		return 0, errors.New("IFD out of range")
+	}
+	for i := 0; i < count; i++ {
+		entry := tiff[entries+12*i : entries+12*(i+1)]
+		tag, typ := order.Uint16(entry), order.Uint16(entry[2:])
+		if tag == tagExifIFD {
+			exifIFD = order.Uint32(entry[8:])
+		}
+		v, ok := values[tag]
+		if !ok || order.Uint32(entry[4:]) != 1 {
+			continue
+		}
+		switch typ {
+		case 3: // SHORT
+			order.PutUint16(entry[8:], uint16(v))
+		case 4: // LONG
+			order.PutUint32(entry[8:], v)
+		}
+	}
+	return exifIFD, nil
+}
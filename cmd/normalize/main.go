@@ -0,0 +1,131 @@
+// Command normalize rotates JPEGs whose EXIF orientation is 2-8 so that
+// their pixels are stored upright, and resets the orientation to 1, sparing
+// the slideshow from turning them every time they are shown. Only the files
+// named on the command line are touched, and by default it only reports
+// what it would do; pass -dry-run=false to rewrite them. Each original is
+// kept beside the rewritten file with -backup-suffix added to its name.
+//
+// The image is re-encoded, so some quality is lost; the other metadata
+// segments (EXIF, XMP, ICC profile, comments) are copied over unchanged
+// apart from the orientation and pixel size tags.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image/jpeg"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/electronjoe/OpenFrame/internal/imgproc"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", true, "Only list the files that would be rotated.")
+	backupSuffix := flag.String("backup-suffix", ".orig", "Suffix added to the name of each original kept as a backup.")
+	quality := flag.Int("quality", 95, "JPEG quality of the rewritten files, 1-100.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] photo.jpg...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *backupSuffix == "" {
+		log.Fatal("-backup-suffix must not be empty; originals are always kept")
+	}
+	if *quality < 1 || *quality > 100 {
+		log.Fatalf("-quality must be from 1 to 100, got %d", *quality)
+	}
+
+	var rotated, upright, failed int
+	for _, path := range flag.Args() {
+		orientation, err := normalize(path, *backupSuffix, *quality, *dryRun)
+		switch {
+		case err != nil:
+			log.Printf("%s: %v", path, err)
+			failed++
+		case orientation <= 1:
+			upright++
+		case *dryRun:
+			log.Printf("%s: would rotate (orientation %d)", path, orientation)
+			rotated++
+		default:
+			log.Printf("%s: rotated (orientation %d), original kept as %s", path, orientation, path+*backupSuffix)
+			rotated++
+		}
+	}
+	verb := "Rotated"
+	if *dryRun {
+		verb = "Would rotate"
+	}
+	log.Printf("%s %d, already upright %d, failed %d.", verb, rotated, upright, failed)
+	if *dryRun && rotated > 0 {
+		log.Printf("Dry run: nothing was changed; pass -dry-run=false to rewrite the files.")
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// normalize rewrites the JPEG at path upright, keeping the original as
+// path+backupSuffix, and returns the orientation it had. Files already
+// upright, and every file when dryRun is set, are left alone.
+func normalize(path, backupSuffix string, quality int, dryRun bool) (int, error) {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".jpg" && ext != ".jpeg" {
+		return 0, fmt.Errorf("not a JPEG")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	orientation := photo.ReadOrientation(bytes.NewReader(data))
+	if orientation <= 1 || dryRun {
+		return orientation, nil
+	}
+	backup := path + backupSuffix
+	if _, err := os.Lstat(backup); err == nil {
+		return 0, fmt.Errorf("backup %s already exists", backup)
+	}
+
+	src, err := photo.DecodeReader(bytes.NewReader(data), path)
+	if err != nil {
+		return 0, err
+	}
+	img := imgproc.ApplyEXIFOrientation(src, orientation)
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: quality}); err != nil {
+		return 0, fmt.Errorf("encode: %w", err)
+	}
+	out, err := uprightJPEG(data, encoded.Bytes(), img.Bounds().Dx(), img.Bounds().Dy())
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	// The new mod time tells the metadata cache and thumbgen to look at the
+	// file again.
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, out, info.Mode().Perm()); err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+	if err := os.Rename(path, backup); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("back up original: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return 0, fmt.Errorf("replace original, which is kept as %s: %w", backup, err)
+	}
+	return orientation, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rwcarlsen/goexif/exif"
+
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+// fixture is an 8x6 JPEG with orientation 6, so upright it is 6x8.
+const fixture = "../../internal/photo/testdata/orientation6.jpg"
+
+func TestNormalizeRotatesAndKeepsBackup(t *testing.T) {
+	original, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(path, original, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if orientation, err := normalize(path, ".orig", 95, true); err != nil || orientation != 6 {
+		t.Fatalf("dry run normalize() = %d, %v; want 6, nil", orientation, err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, original) {
+		t.Fatal("dry run changed the file")
+	}
+
+	if orientation, err := normalize(path, ".orig", 95, false); err != nil || orientation != 6 {
+		t.Fatalf("normalize() = %d, %v; want 6, nil", orientation, err)
+	}
+	if backup, err := os.ReadFile(path + ".orig"); err != nil || !bytes.Equal(backup, original) {
+		t.Errorf("backup does not match the original (err %v)", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := photo.ReadOrientation(bytes.NewReader(data)); got != 1 {
+		t.Errorf("orientation after normalize = %d, want 1", got)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 6 || cfg.Height != 8 {
+		t.Errorf("normalized size = %dx%d, want 6x8", cfg.Width, cfg.Height)
+	}
+
+	// The rest of the EXIF survives.
+	before, err := exif.Decode(bytes.NewReader(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTime, _ := before.DateTime()
+	if gotTime, err := after.DateTime(); err != nil || !gotTime.Equal(wantTime) {
+		t.Errorf("DateTime after normalize = %v, %v; want %v", gotTime, err, wantTime)
+	}
+
+	// A second run finds the file upright and leaves the backup alone.
+	if orientation, err := normalize(path, ".orig", 95, false); err != nil || orientation != 1 {
+		t.Errorf("second normalize() = %d, %v; want 1, nil", orientation, err)
+	}
+}
+
+func TestNormalizeRefusesToOverwriteBackup(t *testing.T) {
+	original, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(path, original, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".orig", []byte("older backup"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := normalize(path, ".orig", 95, false); err == nil {
+		t.Error("normalize() with an existing backup succeeded, want an error")
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, original) {
+		t.Error("normalize() changed the file despite failing")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/electronjoe/OpenFrame/internal/photo"
+	"github.com/electronjoe/OpenFrame/internal/player"
+)
+
+// withCover returns slides built from photos by build, led by a pinned
+// slide of the cover photo at path. The cover is taken out of photos first, so that
+// it is not shown again among them. Without a path, or if the cover cannot
+// be read, it is the slides of photos alone.
+func withCover(path string, photos []photo.Photo, build func([]photo.Photo) []player.Slide) []player.Slide {
+	if path == "" {
+		return build(photos)
+	}
+	cover, err := photo.ReadMetadata(path)
+	if err != nil {
+		debugf("Cover photo %s not shown: %v", path, err)
+		return build(photos)
+	}
+	photos = slices.DeleteFunc(slices.Clone(photos), func(p photo.Photo) bool {
+		return samePath(p.FilePath, path)
+	})
+	return append([]player.Slide{{Photos: []photo.Photo{cover}, Pinned: true}}, build(photos)...)
+}
+
+// samePath reports whether a and b name the same file, however written.
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
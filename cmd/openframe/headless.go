@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/cec"
+	"github.com/electronjoe/OpenFrame/internal/player"
+)
+
+// headlessTick matches the 60 updates per second Ebiten would drive.
+const headlessTick = time.Second / 60
+
+// runHeadless drives show without a window or cec-client: remote command
+// names (as accepted over MQTT) are read one per line from in, and every
+// slide change is logged instead of drawn. It returns on a "quit" line; end of
+// input leaves the slideshow running on its timer. Commands sent to
+// remoteEvents by others, such as the HTTP API, are handled too. It also
+// returns when ctx is cancelled.
+func runHeadless(ctx context.Context, show *player.Player, in io.Reader, remoteEvents chan cec.RemoteCommand) {
+	quit := make(chan struct{})
+	go readHeadlessCommands(in, remoteEvents, quit)
+
+	show.SetSlideChangeHandler(func(index, total int, slide player.Slide) {
+		if slide.IsTitleCard() {
+			log.Printf("Slide %d/%d: title card for %s (%d photos)", index+1, total, slide.Title.Date.Format(time.DateOnly), slide.Title.Photos)
+			return
+		}
+		log.Printf("Slide %d/%d: %s", index+1, total, strings.Join(slide.Paths(), ", "))
+	})
+	show.SetIdleHandler(func(idle bool) {
+		log.Printf("Idle: %t", idle)
+	})
+	show.SetRemoteCommandChan(remoteEvents)
+	show.LoadDisplayableSlide()
+	if err := show.LoadingError(); err != nil {
+		log.Printf("Error loading image(s): %v", err)
+	}
+
+	ticker := time.NewTicker(headlessTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-quit:
+			return
+		case <-ticker.C:
+			show.Update()
+		}
+	}
+}
+
+// readHeadlessCommands forwards each recognised line of in to remoteEvents
+// and closes quit when it reads "quit".
+func readHeadlessCommands(in io.Reader, remoteEvents chan<- cec.RemoteCommand, quit chan<- struct{}) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.EqualFold(line, "quit"):
+			close(quit)
+			return
+		}
+		cmd, ok := cec.ParseRemoteCommand(line)
+		if !ok {
+			log.Printf("Unknown command %q", line)
+			continue
+		}
+		remoteEvents <- cmd
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Reading commands: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/electronjoe/OpenFrame/internal/config"
+)
+
+// runInit writes an example config where the slideshow would look for one,
+// with ~/Pictures as its album, and says what to do next. An existing config
+// is left alone.
+func runInit(configFlag string, w io.Writer) error {
+	path, err := config.ResolvePath(configFlag)
+	if err != nil {
+		return err
+	}
+	album := "/path/to/photos"
+	if home, err := os.UserHomeDir(); err == nil {
+		album = filepath.Join(home, "Pictures")
+	}
+	if err := config.WriteExample(path, album); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%s already exists; leaving it as it is", path)
+		}
+		return err
+	}
+	fmt.Fprintf(w, "Wrote an example config to %s.\nEdit its albums and other settings, then run openframe again.\n", path)
+	return nil
+}
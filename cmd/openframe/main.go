@@ -1,72 +1,627 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
 	"log"
-	"math/rand"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 
 	"github.com/electronjoe/OpenFrame/internal/cec"
 	"github.com/electronjoe/OpenFrame/internal/config"
+	"github.com/electronjoe/OpenFrame/internal/discovery"
+	"github.com/electronjoe/OpenFrame/internal/favorites"
+	"github.com/electronjoe/OpenFrame/internal/httpapi"
+	"github.com/electronjoe/OpenFrame/internal/mapthumb"
+	"github.com/electronjoe/OpenFrame/internal/mqtt"
 	"github.com/electronjoe/OpenFrame/internal/photo"
+	"github.com/electronjoe/OpenFrame/internal/player"
+	"github.com/electronjoe/OpenFrame/internal/playlist"
+	"github.com/electronjoe/OpenFrame/internal/remote"
+	"github.com/electronjoe/OpenFrame/internal/schedule"
 	"github.com/electronjoe/OpenFrame/internal/slideshow"
+	"github.com/electronjoe/OpenFrame/internal/thumbnail"
 )
 
 func main() {
+	configFlag := flag.String("config", "", "Path to config.json (default $"+config.EnvConfigPath+" or ~/"+config.DefaultConfigPath+").")
+	headless := flag.Bool("headless", false, "Run the slideshow without a window or CEC adapter, reading remote commands from stdin.")
+	flag.BoolVar(&debugLogging, "debug", false, "Log routine events, such as each re-selection of the HDMI input.")
+	selftest := flag.Bool("selftest", false, "Check the config, albums, state directory, CEC adapter and display, print a report and exit (non-zero on failure).")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n       %s [--config path] init\n\ninit writes an example config to start from, unless there is one already.\n\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.Arg(0) == "init" {
+		if err := runInit(*configFlag, os.Stdout); err != nil {
+			log.Fatalf("init: %v", err)
+		}
+		return
+	}
+
+	if *selftest {
+		if !runSelftest(*configFlag, os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 1. Read config
-	cfg, err := config.Read()
+	configPath, err := config.ResolvePath(*configFlag)
+	if err != nil {
+		log.Fatalf("Failed to locate config: %v", err)
+	}
+	cfg, err := config.Read(configPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("Failed to read config: %v\nRun %s init to write an example config there.", err, os.Args[0])
+	}
 	if err != nil {
 		log.Fatalf("Failed to read config: %v", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid config:\n%v", err)
+	}
 
-	// 2. Load photos
-	photos, err := photo.Load(cfg.Albums)
-	if err != nil {
-		log.Fatalf("Failed to load photos: %v", err)
+	// Everything started below runs until ctx is cancelled: on SIGINT or
+	// SIGTERM (e.g. systemctl stop), or once the slideshow exits.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 2. Load photos, order them and build slides, or take the photos and
+	// their order from the playlist when one is set. If none are found the
+	// slideshow starts in standby and keeps rescanning until some appear.
+	// The HTTP API lists whatever the latest scan found, and uploaded photos
+	// are scanned like any other album (unless a playlist is in charge).
+	loadOpts := loadOptions(cfg, configPath)
+	api := httpapi.New(cfg.HTTP)
+	albums := cfg.Albums
+	if cfg.HTTP.Enabled() && cfg.HTTP.UploadDir != "" && !slices.Contains(albums, cfg.HTTP.UploadDir) {
+		albums = append(slices.Clone(albums), cfg.HTTP.UploadDir)
+	}
+	// Albums given as URLs are fetched over HTTP rather than walked.
+	var localAlbums, remoteAlbums []string
+	for _, album := range albums {
+		if remote.IsURL(album) {
+			remoteAlbums = append(remoteAlbums, album)
+		} else {
+			localAlbums = append(localAlbums, album)
+		}
+	}
+	var remotes *remote.Client
+	if len(remoteAlbums) > 0 {
+		remotes = remote.New(loadOpts.StateDir, remoteAlbums)
+	}
+	// A smart shuffle or shuffle bag reorders slides on every pass, which
+	// would scatter the days that groupByDate gathers and undo
+	// interleaving, and never overrides a playlist.
+	var shuffler player.Shuffler
+	if cfg.Playlist == "" {
+		switch cfg.Randomize {
+		case "smart":
+			shuffler = player.NewSmartShuffle(time.Now().UnixNano())
+		case "bag":
+			shuffler = player.NewShuffleBag(time.Now().UnixNano())
+		}
+	}
+	// Slides are laid out for the screen as it is mounted.
+	displayWidth, displayHeight := player.RotatedSize(cfg.DisplayWidth, cfg.DisplayHeight, cfg.Rotate)
+	slideOpts := player.SlideOptions{
+		GroupByDate:   cfg.GroupByDate && shuffler == nil,
+		Interleave:    cfg.Interleave && shuffler == nil,
+		DisplayWidth:  displayWidth,
+		DisplayHeight: displayHeight,
+		PairTolerance: cfg.PairTolerance,
+
+		AutoRotateToFill: cfg.AutoRotateToFill,
+		StackLandscapes:  cfg.StackLandscapes,
+	}
+	buildSlides := func(photos []photo.Photo) []player.Slide {
+		return player.BuildSlidesFromPhotos(photos, slideOpts)
+	}
+	scan := func() ([]player.Slide, error) {
+		if cfg.Playlist != "" {
+			photos, slides, err := loadPlaylist(cfg.Playlist, loadOpts, slideOpts)
+			if err != nil {
+				return nil, err
+			}
+			api.SetPhotos(photos)
+			return slides, nil
+		}
+		photos, err := loadPhotos(localAlbums, remotes, loadOpts, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.StartupSnapshot {
+			if err := photo.SaveSnapshot(loadOpts.StateDir, localAlbums, photos); err != nil {
+				log.Printf("Saving photo list snapshot failed: %v", err)
+			}
+		}
+		api.SetPhotos(photos)
+		return withCover(cfg.CoverPhoto, photos, buildSlides), nil
+	}
+	// A snapshot of the last scan starts the slideshow without walking the
+	// albums; they are rescanned once the player is running.
+	var slides []player.Slide
+	fromSnapshot := false
+	if cfg.StartupSnapshot && cfg.Playlist == "" {
+		if photos, ok := photo.LoadSnapshot(loadOpts.StateDir, localAlbums); ok {
+			log.Printf("Starting from the snapshot of %d photos; rescanning albums in the background", len(photos))
+			api.SetPhotos(photos)
+			slides, fromSnapshot = withCover(cfg.CoverPhoto, photos, buildSlides), true
+		}
+	}
+	if !fromSnapshot {
+		slides, err = scan()
+		if err != nil {
+			log.Fatalf("Failed to load photos: %v", err)
+		}
+	}
+	if len(slides) == 0 {
+		log.Printf("No photos found; showing standby message and rescanning every %ds.", cfg.RescanInterval)
+	}
+
+	// Favorites are optional; a damaged file only disables the command.
+	var favs player.Favorites
+	if store, err := favorites.Open(loadOpts.StateDir); err != nil {
+		log.Printf("Favorites disabled: %v", err)
+	} else {
+		favs = store
+	}
+
+	// A resumed slideshow opens on the photo it was showing when stopped.
+	var resumeAt string
+	if cfg.StartAt == "resume" {
+		resumeAt = readLastShown(loadOpts.StateDir)
+	}
+
+	// 3. Create the slideshow player. Headless runs decode each photo but
+	// never upload it anywhere.
+	processing := thumbnail.Options{
+		Width:      displayWidth,
+		Height:     displayHeight,
+		AutoLevels: cfg.AutoLevels,
+
+		ColorManagement: cfg.ColorManagement,
+	}
+	loadImage := slideshow.NewImageLoader(loadOpts.StateDir, processing)
+	decodeStream := slideshow.NewStreamLoader(processing)
+	if *headless {
+		loadImage = player.DecodeImage
+		decodeStream = player.DecodeImageFrom
+	}
+	if remotes != nil {
+		loadImage = remotes.ImageLoader(loadImage, decodeStream)
 	}
-	if len(photos) == 0 {
-		log.Println("No photos found. Exiting.")
+	// Remote photos fail to preload and are loaded as usual.
+	var preloader *player.Preloader
+	if !*headless && cfg.PreloadAhead > 0 {
+		preloader = player.NewPreloader(slideshow.NewDecoder(loadOpts.StateDir, processing), slideshow.UploadImage)
+	}
+	show := player.New(slides, player.Options{
+		Interval:       time.Duration(cfg.Interval) * time.Second,
+		TitleDuration:  time.Duration(cfg.TitleCardDuration) * time.Second,
+		LoadImage:      loadImage,
+		StandbyMessage: cfg.StandbyMessage,
+		Scan:           scan,
+		RescanInterval: time.Duration(cfg.RescanInterval) * time.Second,
+		IdleTimeout:    time.Duration(cfg.IdleTimeout) * time.Second,
+		Trash: func(path string) (string, error) {
+			return photo.MoveToTrash(loadOpts.StateDir, path)
+		},
+		Favorites: favs,
+		Shuffler:  shuffler,
+
+		StartRandom: cfg.StartAt == "random",
+		ResumeAt:    resumeAt,
+		Placeholder: cfg.PlaceholderImage,
+		// Animated transitions draw the outgoing slide too.
+		KeepPrevious: !*headless && cfg.Transition != "none" && cfg.Transition != "cut",
+		MemoryBudget: int64(cfg.MemoryBudgetMB) << 20,
+		Preloader:    preloader,
+		PreloadAhead: cfg.PreloadAhead,
+		Durations: player.Durations{
+			Landscape:    cfg.SlideDurations.Landscape,
+			Portrait:     cfg.SlideDurations.Portrait,
+			PortraitPair: cfg.SlideDurations.PortraitPair,
+			Favorite:     cfg.SlideDurations.Favorite,
+		},
+	})
+
+	// Uploads through the HTTP API rescan the running player, its commands
+	// join those of the remote and GET /current reads the player's status;
+	// the API can be advertised so apps find it.
+	remoteEvents := make(chan cec.RemoteCommand, 10)
+	api.SetCommandChan(remoteEvents)
+	api.SetStatusFunc(show.Status)
+	api.Start(ctx, show.Rescan)
+	advertiser := advertise(ctx, cfg.HTTP)
+	if fromSnapshot {
+		show.Rescan()
+	}
+
+	// On this day's photos change at midnight.
+	if cfg.OnThisDay && cfg.Playlist == "" {
+		go rescanAtMidnight(ctx, show.Rescan)
+	}
+
+	// 4. Watch for a stalled slideshow
+	show.StartWatchdog(ctx, time.Duration(cfg.WatchdogThreshold)*time.Second)
+
+	if *headless {
+		runHeadless(ctx, show, os.Stdin, remoteEvents)
+		stop()
+		show.Close()
+		waitForShutdown(api.Done(), advertiser.Done())
 		return
 	}
 
-	// 3. Shuffle photos for display; slideshow always runs in random order.
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(photos), func(i, j int) {
-		photos[i], photos[j] = photos[j], photos[i]
+	// 5. Start the optional MQTT bridge and publish each slide as it is
+	// shown, remembering it to resume at. Its TV power commands need
+	// cec-client.
+	haveCEC := cec.Available()
+	bridge := mqtt.Start(ctx, cfg.MQTT, remoteEvents, haveCEC)
+	show.SetSlideChangeHandler(func(index, total int, slide player.Slide) {
+		bridge.PublishSlide(index, total, slide.Paths())
+		if cfg.StartAt == "resume" && !slide.IsTitleCard() {
+			if err := saveLastShown(loadOpts.StateDir, slide.Photos[0].FilePath); err != nil {
+				log.Printf("Saving the last shown photo failed: %v", err)
+			}
+		}
 	})
 
-	// 4. Build slides
-	slides := slideshow.BuildSlidesFromPhotos(photos)
+	// 6. Put the TV in standby when idle and wake it on remote activity,
+	// turn it on and off on schedule, and keep it on the frame's input
+	// while awake. A display without cec-client is left to itself; the
+	// keyboard, MQTT and the HTTP API still control the slideshow.
+	if haveCEC {
+		power := newTVPower(cfg.HDMIInput)
+		show.SetIdleHandler(power.setIdle)
+		if week, ok := schedule.FromConfig(cfg.Schedule); ok {
+			go followSchedule(ctx, week, power)
+		}
+		if cfg.AssertInputInterval > 0 {
+			go power.assertInput(ctx, time.Duration(cfg.AssertInputInterval)*time.Second)
+		}
+		if cfg.CECVolumePassthrough {
+			show.SetVolumeHandler(passVolume)
+		}
+	} else {
+		log.Printf("cec-client not found; running without the CEC remote or TV power control")
+	}
 
-	// 5. Create the slideshow game
-	game := slideshow.NewSlideshowGame(
-		slides,
-		time.Duration(cfg.Interval)*time.Second,
-		cfg.DateOverlay,
-	)
+	// 7. Load the first slide, skipping any that cannot be decoded
+	show.LoadDisplayableSlide()
 
-	// 6. Load the first slide
-	if err := game.LoadCurrentSlide(); err != nil {
-		game.SetLoadingError(err)
+	// 8. Start the CEC listener in a goroutine; it shares the remote command
+	// channel with the MQTT bridge and the HTTP API.
+	var cecDone <-chan struct{}
+	if haveCEC {
+		cecDone = cec.StartCECListener(ctx, remoteEvents, cec.KeyFormat(cfg.CECKeyFormat))
 	}
 
-	// 7. Prepare remote command channel
-	remoteEvents := make(chan cec.RemoteCommand, 10)
-	// Start the CEC listener in a goroutine
-	cec.StartCECListener(remoteEvents)
+	// 9. Assign the channel to the player
+	show.SetRemoteCommandChan(remoteEvents)
 
-	// 8. Assign the channel to the game
-	game.SetRemoteCommandChan(remoteEvents)
+	// 10. Wrap the player in the Ebiten renderer. The QR code links to the
+	// HTTP API, so it needs the API on; maps are drawn and cached in the
+	// background.
+	opts := slideshow.OptionsFromConfig(cfg)
+	opts.QR.Enabled = opts.QR.Enabled && api != nil
+	opts.QR.Link = api.Share
+	if opts.Map.Enabled {
+		opts.Map.Map = mapthumb.New(ctx, loadOpts.StateDir, mapthumb.Options{
+			TileURL: cfg.MapOverlay.TileURL,
+			Zoom:    cfg.MapOverlay.Zoom,
+		}).Map
+	}
+	opts.Stop = ctx.Done()
+	game := slideshow.NewSlideshowGame(show, opts)
 
-	// 9. Configure Ebiten
+	// 11. Configure Ebiten
 	ebiten.SetFullscreen(true)
 	ebiten.SetWindowResizable(false)
 	ebiten.SetWindowTitle("OpenFrame Slideshow")
 	ebiten.SetCursorMode(ebiten.CursorModeHidden)
 
-	// 10. Run the Ebiten game loop
-	if err := ebiten.RunGame(game); err != nil {
+	// 12. Run the Ebiten game loop; ESC or a signal ends it after the
+	// slide textures have been freed
+	err = ebiten.RunGame(game)
+
+	// 13. Stop the background work, giving cec-client time to release the
+	// adapter and the MQTT bridge time to report offline
+	stop()
+	waitForShutdown(cecDone, bridge.Done(), api.Done(), advertiser.Done())
+
+	// 14. Put the TV in standby if asked to, then quit the cec-client the
+	// command started, so none is left behind
+	if cfg.PowerOffOnExit && haveCEC {
+		if err := cec.PowerOffTV(); err != nil {
+			log.Printf("Standby on exit failed: %v", err)
+		}
+		if err := cec.Close(); err != nil {
+			log.Printf("Stopping cec-client: %v", err)
+		}
+	}
+	if err != nil {
 		log.Fatalf("Ebiten run error: %v", err)
 	}
+	log.Printf("Slideshow stopped")
+}
+
+// rescanAtMidnight calls rescan at every local midnight until ctx is
+// cancelled.
+func rescanAtMidnight(ctx context.Context, rescan func()) {
+	for {
+		now := time.Now()
+		y, m, d := now.Date()
+		midnight := time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(midnight.Sub(now)):
+			rescan()
+		}
+	}
+}
+
+// shutdownTimeout bounds how long to wait for background work to stop.
+const shutdownTimeout = 20 * time.Second
+
+// waitForShutdown waits until every channel in done is closed, skipping nil
+// ones for work that never started, or gives up after shutdownTimeout.
+func waitForShutdown(done ...<-chan struct{}) {
+	timeout := time.After(shutdownTimeout)
+	for _, d := range done {
+		if d == nil {
+			continue
+		}
+		select {
+		case <-d:
+		case <-timeout:
+			log.Printf("Shutdown timed out; exiting anyway")
+			return
+		}
+	}
+}
+
+// loadOptions returns the options albums are scanned with.
+func loadOptions(cfg config.Config, configPath string) photo.LoadOptions {
+	return photo.LoadOptions{
+		StateDir:     config.StateDir(configPath),
+		MinDimension: cfg.MinDimension,
+		MinFileSize:  int64(cfg.MinFileSizeKB) << 10,
+
+		FollowSymlinks: cfg.FollowSymlinks,
+		Dedupe:         cfg.Dedupe,
+
+		IgnoreExtensions: cfg.IgnoreExtensions,
+	}
+}
+
+// loadPhotos loads every photo in albums and any remote albums, drops those
+// filtered out by rating, keyword or date and puts the rest in the
+// configured order.
+func loadPhotos(albums []string, remotes *remote.Client, opts photo.LoadOptions, cfg config.Config) ([]photo.Photo, error) {
+	photos, err := photo.Load(albums, opts)
+	if err != nil {
+		return nil, err
+	}
+	if remotes != nil {
+		photos = append(photos, remotes.Load()...)
+	}
+	photos = photo.FilterByRating(photos, cfg.MinRating, cfg.IncludeUnrated)
+	photos = photo.FilterByKeywords(photos, cfg.IncludeKeywords, cfg.ExcludeKeywords, cfg.IncludeUntagged)
+	if cfg.OnThisDay {
+		today := photo.FilterOnThisDay(photos, time.Now())
+		if len(today) > 0 || cfg.OnThisDayFallback == "none" {
+			photos = today
+		} else {
+			log.Printf("No photos taken on this day; showing all %d", len(photos))
+		}
+	}
+	photo.Order(photos, photo.SortOrder(cfg.SortBy))
+	return photos, nil
+}
+
+// loadPlaylist loads the photos listed in the playlist at path, in its order,
+// and builds their slides with any per-photo durations. Listed files that
+// are missing or unreadable are skipped.
+func loadPlaylist(path string, opts photo.LoadOptions, slideOpts player.SlideOptions) ([]photo.Photo, []player.Slide, error) {
+	entries, err := playlist.Read(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.Path
+	}
+	photos, err := photo.LoadFiles(paths, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// photos is entries minus the skipped files, so match them up in order.
+	durations := make([]time.Duration, len(photos))
+	i := 0
+	for _, e := range entries {
+		if i < len(photos) && photos[i].FilePath == e.Path {
+			durations[i] = e.Duration
+			i++
+		}
+	}
+	return photos, player.BuildSlidesFromPlaylist(photos, durations, slideOpts), nil
+}
+
+// advertise announces the HTTP API over mDNS when configured, until ctx is
+// cancelled. A failure only costs discovery, so it is logged.
+func advertise(ctx context.Context, cfg config.HTTP) *discovery.Advertiser {
+	if !cfg.Enabled() || !cfg.Advertise {
+		return nil
+	}
+	port, _ := cfg.Port() // checked by Validate
+	advertiser, err := discovery.Advertise(ctx, cfg.Name, port)
+	if err != nil {
+		log.Printf("mDNS advertisement disabled: %v", err)
+		return nil
+	}
+	return advertiser
+}
+
+// wakeTimeout bounds how long to wait for the TV to report power on after a
+// wake before selecting the HDMI input.
+const wakeTimeout = 20 * time.Second
+
+// tvPower serializes the slow cec-client power calls triggered by idle
+// transitions so they run off the game loop and never interleave.
+type tvPower struct {
+	mu        sync.Mutex
+	hdmiInput int
+	idle      bool // the TV has been put in standby
+	// want holds the latest idle state the worker has yet to apply.
+	want chan bool
+}
+
+func newTVPower(hdmiInput int) *tvPower {
+	p := &tvPower{hdmiInput: hdmiInput, want: make(chan bool, 1)}
+	go p.run()
+	return p
+}
+
+// setIdle is a slideshow idle handler, and followSchedule's switch; each
+// reports a transition only once, so the TV is not sent standby commands
+// over and over. It never blocks: a state still waiting for the worker is
+// replaced, so a quick standby and wake leaves the TV awake.
+func (p *tvPower) setIdle(idle bool) {
+	for {
+		select {
+		case p.want <- idle:
+			return
+		default:
+		}
+		select {
+		case <-p.want:
+		default:
+		}
+	}
+}
+
+// run applies the states from setIdle one at a time, in order.
+func (p *tvPower) run() {
+	for idle := range p.want {
+		p.apply(idle)
+	}
+}
+
+func (p *tvPower) apply(idle bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle = idle
+	if idle {
+		if err := cec.PowerOffTV(); err != nil {
+			log.Printf("Idle standby failed: %v", err)
+		}
+		return
+	}
+	if err := cec.PowerOnTV(); err != nil {
+		log.Printf("Wake power on failed: %v", err)
+	}
+	if p.hdmiInput <= 0 {
+		return
+	}
+	// The TV ignores input changes until it has finished waking.
+	if err := cec.WaitForPowerOn(wakeTimeout); err != nil {
+		log.Printf("Wake: %v; switching input anyway", err)
+	}
+	if err := cec.SwitchToHDMI(p.hdmiInput); err != nil {
+		log.Printf("Wake input switch failed: %v", err)
+	}
+}
+
+// assertInput re-selects the HDMI input every interval until ctx is
+// cancelled, so that the frame reclaims the TV from another CEC device that
+// switched it away. It leaves the TV alone while idle, since selecting an
+// input can wake it.
+func (p *tvPower) assertInput(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p.mu.Lock()
+		if !p.idle {
+			if err := cec.SwitchToHDMI(p.hdmiInput); err != nil {
+				log.Printf("Re-selecting HDMI input %d failed: %v", p.hdmiInput, err)
+			} else {
+				debugf("Re-selected HDMI input %d", p.hdmiInput)
+			}
+		}
+		p.mu.Unlock()
+	}
+}
+
+// passVolume is a slideshow volume handler that presses the key on the TV,
+// off the game loop.
+func passVolume(cmd cec.RemoteCommand) {
+	go func() {
+		if err := cec.Volume(cmd); err != nil {
+			log.Printf("Volume passthrough failed: %v", err)
+		}
+	}()
+}
+
+// scheduleRecheck bounds how long followSchedule sleeps, so that a clock set
+// late, as on a Raspberry Pi without a real-time clock that boots before
+// NTP, is caught up with.
+const scheduleRecheck = time.Hour
+
+// followSchedule wakes the TV as the week's on hours begin and puts it in
+// standby as they end, until ctx is cancelled. The TV is left as it is at
+// start.
+func followSchedule(ctx context.Context, week schedule.Week, power *tvPower) {
+	on := week.IsOn(time.Now())
+	for {
+		now := time.Now()
+		wait := scheduleRecheck
+		if next, ok := week.Next(now); ok {
+			wait = min(wait, next.Sub(now))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if isOn := week.IsOn(time.Now()); isOn != on {
+			on = isOn
+			if on {
+				log.Printf("Schedule: on hours begin; waking the TV")
+			} else {
+				log.Printf("Schedule: on hours end; putting the TV in standby")
+			}
+			power.setIdle(!on)
+		}
+	}
+}
+
+// debugLogging turns on debugf, from the -debug flag.
+var debugLogging bool
+
+// debugf logs routine events, which are only of interest when debugging.
+func debugf(format string, args ...any) {
+	if debugLogging {
+		log.Printf(format, args...)
+	}
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lastShownFileName, in the state directory, holds the path of the photo on
+// screen, for startAt "resume".
+const lastShownFileName = "last_shown"
+
+// readLastShown returns the photo last recorded by saveLastShown in
+// stateDir, or "" if there is none.
+func readLastShown(stateDir string) string {
+	data, err := os.ReadFile(filepath.Join(stateDir, lastShownFileName))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Warning: could not read the last shown photo: %v", err)
+		}
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// saveLastShown records path as the photo on screen. The file is replaced
+// in one step, so the frame losing power mid-write leaves the previous
+// photo in place.
+func saveLastShown(stateDir, path string) error {
+	file := filepath.Join(stateDir, lastShownFileName)
+	if err := os.WriteFile(file+".tmp", []byte(path+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(file+".tmp", file)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/electronjoe/OpenFrame/internal/cec"
+	"github.com/electronjoe/OpenFrame/internal/config"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+	"github.com/electronjoe/OpenFrame/internal/remote"
+)
+
+// adapterTimeout bounds how long `cec-client -l` may take to list adapters.
+const adapterTimeout = 30 * time.Second
+
+// selftest reports the result of each check in turn.
+type selftest struct {
+	out    io.Writer
+	failed bool
+}
+
+func (s *selftest) pass(check, format string, args ...any) {
+	fmt.Fprintf(s.out, "PASS  %-8s %s\n", check, fmt.Sprintf(format, args...))
+}
+
+func (s *selftest) fail(check, format string, args ...any) {
+	s.failed = true
+	fmt.Fprintf(s.out, "FAIL  %-8s %s\n", check, fmt.Sprintf(format, args...))
+}
+
+func (s *selftest) skip(check, format string, args ...any) {
+	fmt.Fprintf(s.out, "SKIP  %-8s %s\n", check, fmt.Sprintf(format, args...))
+}
+
+// runSelftest checks what the frame needs to run, the way the slideshow
+// itself would use it: the config, each album, the state directory, the CEC
+// adapter and the display. It prints a line per check to out and reports
+// whether they all passed.
+func runSelftest(configFlag string, out io.Writer) bool {
+	s := &selftest{out: out}
+
+	configPath, err := config.ResolvePath(configFlag)
+	if err != nil {
+		s.fail("config", "%v", err)
+	}
+	var cfg config.Config
+	if configPath != "" {
+		cfg, err = config.Read(configPath)
+		if err == nil {
+			err = cfg.Validate()
+		}
+		if err != nil {
+			s.fail("config", "%s: %s", configPath, strings.ReplaceAll(err.Error(), "\n", "; "))
+			s.skip("album", "config not usable")
+		} else {
+			s.pass("config", "%s", configPath)
+			s.checkAlbums(cfg, loadOptions(cfg, configPath))
+		}
+		s.checkStateDir(config.StateDir(configPath))
+	} else {
+		s.skip("album", "no config")
+		s.skip("state", "no config")
+	}
+
+	s.checkCEC()
+	s.checkDisplay()
+
+	if s.failed {
+		fmt.Fprintln(out, "Self-test failed")
+	} else {
+		fmt.Fprintln(out, "Self-test passed")
+	}
+	return !s.failed
+}
+
+// checkAlbums lists each local album and counts the photos the slideshow
+// would find in it, before rating, keyword and date filters.
+func (s *selftest) checkAlbums(cfg config.Config, opts photo.LoadOptions) {
+	var local []string
+	for _, album := range cfg.Albums {
+		if remote.IsURL(album) {
+			s.skip("album", "%s: remote albums are not checked", album)
+			continue
+		}
+		if _, err := os.ReadDir(album); err != nil {
+			s.fail("album", "%v", err)
+			continue
+		}
+		local = append(local, album)
+	}
+	if len(local) == 0 {
+		return
+	}
+
+	// One load for every album, as a rescan does, keeps the metadata cache
+	// of the others intact.
+	photos, err := photo.Load(local, opts)
+	if err != nil {
+		s.fail("album", "%v", err)
+		return
+	}
+	counts := make(map[string]int)
+	for _, p := range photos {
+		counts[p.Album]++
+	}
+	for _, album := range local {
+		s.pass("album", "%s: %d photos", album, counts[album])
+	}
+}
+
+// checkStateDir makes sure favorites, the metadata cache and the trash can be
+// written to dir.
+func (s *selftest) checkStateDir(dir string) {
+	f, err := os.CreateTemp(dir, ".selftest-*")
+	if err != nil {
+		s.fail("state", "%s is not writable: %v", dir, err)
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+	s.pass("state", "%s is writable", dir)
+}
+
+// checkCEC looks for cec-client and a CEC adapter. The frame runs without
+// either, so only an adapter that cec-client cannot list fails the check.
+func (s *selftest) checkCEC() {
+	if !cec.Available() {
+		s.skip("cec", "cec-client not installed; no remote or TV power control")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), adapterTimeout)
+	defer cancel()
+	adapters, err := cec.Adapters(ctx)
+	switch {
+	case err != nil:
+		s.fail("cec", "%v", err)
+	case len(adapters) == 0:
+		s.skip("cec", "%v; no remote or TV power control", cec.ErrNoAdapter)
+	default:
+		s.pass("cec", "adapter %s", strings.Join(adapters, ", "))
+	}
+}
+
+// checkDisplay starts Ebiten for a single frame, reporting why if it cannot.
+// It must run last: Ebiten can only be started once.
+func (s *selftest) checkDisplay() {
+	err := func() (err error) {
+		// Ebiten panics, rather than returning an error, on some
+		// graphics failures.
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("%v", r)
+			}
+		}()
+		ebiten.SetWindowTitle("OpenFrame Self-test")
+		return ebiten.RunGame(selftestGame{})
+	}()
+	if err != nil {
+		s.fail("display", "Ebiten could not start: %v", err)
+		return
+	}
+	s.pass("display", "Ebiten started")
+}
+
+// selftestGame ends Ebiten's game loop on its first update.
+type selftestGame struct{}
+
+func (selftestGame) Update() error { return ebiten.Termination }
+
+func (selftestGame) Draw(*ebiten.Image) {}
+
+func (selftestGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return outsideWidth, outsideHeight
+}
@@ -0,0 +1,280 @@
+// Command render draws slides the way the slideshow would, with the overlays,
+// pairing and transitions of a config, and writes each frame to a PNG file
+// instead of a TV. It is for tuning a layout on a dev machine and for
+// screenshots in bug reports. Ebiten still needs a display to start, so a
+// window opens briefly while it runs.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/electronjoe/OpenFrame/internal/config"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+	"github.com/electronjoe/OpenFrame/internal/player"
+	"github.com/electronjoe/OpenFrame/internal/remote"
+	"github.com/electronjoe/OpenFrame/internal/slideshow"
+	"github.com/electronjoe/OpenFrame/internal/thumbnail"
+)
+
+func main() {
+	configFlag := flag.String("config", "", "Path to config.json (default $"+config.EnvConfigPath+" or ~/"+config.DefaultConfigPath+").")
+	outDir := flag.String("out", "render", "Directory to write the frames to.")
+	slides := flag.Int("slides", 5, "Number of slides to render; 0 renders them all.")
+	transitionFrames := flag.Int("transition-frames", 3, "Frames to render part way through each transition.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [photo or directory...]\n\nWithout photos, the config's albums are rendered.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	configPath, err := config.ResolvePath(*configFlag)
+	if err != nil {
+		log.Fatalf("Failed to locate config: %v", err)
+	}
+	cfg, err := config.Read(configPath)
+	if err != nil {
+		log.Fatalf("Failed to read config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid config:\n%v", err)
+	}
+
+	stateDir := config.StateDir(configPath)
+	loadOpts := photo.LoadOptions{
+		StateDir:     stateDir,
+		MinDimension: cfg.MinDimension,
+		MinFileSize:  int64(cfg.MinFileSizeKB) << 10,
+
+		FollowSymlinks: cfg.FollowSymlinks,
+		Dedupe:         cfg.Dedupe,
+
+		IgnoreExtensions: cfg.IgnoreExtensions,
+	}
+	photos, err := loadPhotos(flag.Args(), cfg, loadOpts)
+	if err != nil {
+		log.Fatalf("Failed to load photos: %v", err)
+	}
+
+	// Slides are laid out as the slideshow lays them out, but in order:
+	// randomize is ignored so that a render can be repeated.
+	opts := slideshow.OptionsFromConfig(cfg)
+	built := player.BuildSlidesFromPhotos(photos, player.SlideOptions{
+		GroupByDate:   cfg.GroupByDate,
+		Interleave:    cfg.Interleave,
+		DisplayWidth:  opts.Width,
+		DisplayHeight: opts.Height,
+		PairTolerance: cfg.PairTolerance,
+
+		AutoRotateToFill: cfg.AutoRotateToFill,
+		StackLandscapes:  cfg.StackLandscapes,
+	})
+	if len(built) == 0 {
+		log.Fatalf("No photos to render")
+	}
+	if *slides <= 0 || *slides > len(built) {
+		*slides = len(built)
+	}
+
+	clock := &stepClock{now: time.Now()}
+	show := player.New(built, player.Options{
+		Interval: time.Duration(cfg.Interval) * time.Second,
+		LoadImage: slideshow.NewImageLoader(stateDir, thumbnail.Options{
+			Width:           opts.Width,
+			Height:          opts.Height,
+			AutoLevels:      cfg.AutoLevels,
+			ColorManagement: cfg.ColorManagement,
+		}),
+		Clock:        clock,
+		KeepPrevious: cfg.Transition != "none" && cfg.Transition != "cut",
+		Durations: player.Durations{
+			Landscape:    cfg.SlideDurations.Landscape,
+			Portrait:     cfg.SlideDurations.Portrait,
+			PortraitPair: cfg.SlideDurations.PortraitPair,
+			Favorite:     cfg.SlideDurations.Favorite,
+		},
+	})
+	// Every frame is drawn on demand, so the frame rate cap does not apply,
+	// and the first slide is saved whole rather than part way through its
+	// fade from black.
+	opts.MaxFPS = 0
+	opts.FadeIn = 0
+	r := &renderer{
+		show:             show,
+		game:             slideshow.NewSlideshowGame(show, opts),
+		clock:            clock,
+		transition:       opts.Transition.Duration,
+		outDir:           *outDir,
+		slides:           *slides,
+		transitionFrames: *transitionFrames,
+	}
+	if err := os.MkdirAll(r.outDir, 0o755); err != nil {
+		log.Fatalf("Failed to create output directory: %v", err)
+	}
+	show.LoadDisplayableSlide()
+
+	ebiten.SetWindowTitle("OpenFrame Render")
+	if err := ebiten.RunGame(r); err != nil {
+		log.Fatalf("Ebiten run error: %v", err)
+	}
+	show.Close()
+	if r.err != nil {
+		log.Fatalf("Render failed: %v", r.err)
+	}
+	log.Printf("Done: %d frames in %s", r.frames, r.outDir)
+}
+
+// loadPhotos loads the photos named by args, each a file or a directory to
+// walk, in the order given. Without args it loads the config's local albums
+// in the configured order.
+func loadPhotos(args []string, cfg config.Config, opts photo.LoadOptions) ([]photo.Photo, error) {
+	if len(args) == 0 {
+		var albums []string
+		for _, album := range cfg.Albums {
+			if !remote.IsURL(album) {
+				albums = append(albums, album)
+			}
+		}
+		photos, err := photo.Load(albums, opts)
+		if err != nil {
+			return nil, err
+		}
+		photo.Order(photos, photo.SortOrder(cfg.SortBy))
+		return photos, nil
+	}
+
+	// Directories are walked here rather than loaded as albums, which
+	// would drop the configured albums from the metadata cache.
+	var paths []string
+	for _, arg := range args {
+		err := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// A file named outright is loaded even with another extension.
+			if !d.IsDir() && (path == arg || photo.IsImageFile(path)) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return photo.LoadFiles(paths, opts)
+}
+
+// stepClock is the player's clock, moved by hand from frame to frame.
+type stepClock struct {
+	now time.Time
+}
+
+func (c *stepClock) Now() time.Time { return c.now }
+
+// renderer is an Ebiten game that renders every frame in its first update
+// and then ends the game loop. Textures can only be read back once the loop
+// is running.
+type renderer struct {
+	show             *player.Player
+	game             *slideshow.SlideshowGame
+	clock            *stepClock
+	transition       time.Duration
+	outDir           string
+	slides           int
+	transitionFrames int
+
+	frames int
+	err    error
+}
+
+func (r *renderer) Update() error {
+	r.err = r.render()
+	return ebiten.Termination
+}
+
+func (r *renderer) Draw(*ebiten.Image) {}
+
+func (r *renderer) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return r.game.Layout(outsideWidth, outsideHeight)
+}
+
+// render steps the player through the slides, saving each once its
+// transition is over, and the given number of frames of the transition
+// before it.
+func (r *renderer) render() error {
+	w, h := r.game.Layout(0, 0)
+	target := ebiten.NewImage(w, h)
+	defer target.Dispose()
+
+	for i := 0; i < r.slides; i++ {
+		if i > 0 {
+			// Just past the switch time, as the timer would advance.
+			_, interval := r.show.SlideTiming()
+			r.clock.now = r.show.SlideStart().Add(interval + time.Millisecond)
+			r.show.Update()
+			if _, _, _, ok := r.show.PreviousSlide(); ok && r.transition > 0 {
+				for f := 1; f <= r.transitionFrames; f++ {
+					r.clock.now = r.show.SlideStart().Add(r.transition * time.Duration(f) / time.Duration(r.transitionFrames+1))
+					if err := r.save(target, fmt.Sprintf("transition %d/%d into slide %d", f, r.transitionFrames, i+1)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		r.clock.now = r.show.SlideStart().Add(r.transition)
+		if err := r.save(target, r.describe(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// describe names slide i by what is on screen.
+func (r *renderer) describe(i int) string {
+	if err := r.show.LoadingError(); err != nil {
+		return fmt.Sprintf("slide %d, error: %v", i+1, err)
+	}
+	slide, _, ok := r.show.CurrentSlide()
+	switch {
+	case !ok:
+		return fmt.Sprintf("slide %d, standby", i+1)
+	case slide.IsTitleCard():
+		return fmt.Sprintf("slide %d, title card for %s", i+1, slide.Title.Date.Format(time.DateOnly))
+	}
+	return fmt.Sprintf("slide %d, %s", i+1, strings.Join(slide.Paths(), ", "))
+}
+
+// save draws the frame at the clock's time and writes it to the next
+// numbered PNG file.
+func (r *renderer) save(target *ebiten.Image, what string) error {
+	target.Clear()
+	r.game.DrawAt(target, r.clock.now)
+	img := image.NewRGBA(target.Bounds())
+	target.ReadPixels(img.Pix)
+
+	r.frames++
+	path := filepath.Join(r.outDir, fmt.Sprintf("frame-%04d.png", r.frames))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("encode %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Printf("%s: %s", path, what)
+	return nil
+}
@@ -0,0 +1,83 @@
+// Command thumbgen pre-generates display-sized thumbnails for every photo in
+// the configured albums so the slideshow does not have to decode
+// full-resolution originals. Run it after adding photos; unchanged photos
+// are skipped.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/electronjoe/OpenFrame/internal/config"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+	"github.com/electronjoe/OpenFrame/internal/player"
+	"github.com/electronjoe/OpenFrame/internal/remote"
+	"github.com/electronjoe/OpenFrame/internal/thumbnail"
+)
+
+func main() {
+	configFlag := flag.String("config", "", "Path to config.json (default $"+config.EnvConfigPath+" or ~/"+config.DefaultConfigPath+").")
+	flag.Parse()
+
+	configPath, err := config.ResolvePath(*configFlag)
+	if err != nil {
+		log.Fatalf("Failed to locate config: %v", err)
+	}
+	cfg, err := config.Read(configPath)
+	if err != nil {
+		log.Fatalf("Failed to read config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid config:\n%v", err)
+	}
+
+	// Thumbnails live beside the metadata cache, where the slideshow
+	// running with the same config looks for them. Remote albums are left
+	// out, since their photos are cached as they are shown.
+	stateDir := config.StateDir(configPath)
+	var albums []string
+	for _, album := range cfg.Albums {
+		if !remote.IsURL(album) {
+			albums = append(albums, album)
+		}
+	}
+	photos, err := photo.Load(albums, photo.LoadOptions{
+		StateDir:     stateDir,
+		MinDimension: cfg.MinDimension,
+		MinFileSize:  int64(cfg.MinFileSizeKB) << 10,
+
+		FollowSymlinks: cfg.FollowSymlinks,
+
+		IgnoreExtensions: cfg.IgnoreExtensions,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load photos: %v", err)
+	}
+
+	width, height := player.RotatedSize(cfg.DisplayWidth, cfg.DisplayHeight, cfg.Rotate)
+	opts := thumbnail.Options{
+		Width:           width,
+		Height:          height,
+		AutoLevels:      cfg.AutoLevels,
+		ColorManagement: cfg.ColorManagement,
+	}
+	var generated, upToDate, notNeeded, failed int
+	for i, p := range photos {
+		result, err := thumbnail.Generate(stateDir, p, opts)
+		if err != nil {
+			log.Printf("[%d/%d] %s: %v", i+1, len(photos), p.FilePath, err)
+			failed++
+			continue
+		}
+		switch result {
+		case thumbnail.Generated:
+			log.Printf("[%d/%d] %s: generated", i+1, len(photos), p.FilePath)
+			generated++
+		case thumbnail.UpToDate:
+			upToDate++
+		case thumbnail.NotNeeded:
+			notNeeded++
+		}
+	}
+	log.Printf("Done: %d generated, %d up to date, %d already screen-sized, %d failed.", generated, upToDate, notNeeded, failed)
+}
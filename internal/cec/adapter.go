@@ -0,0 +1,46 @@
+package cec
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// Available reports whether cec-client is on PATH. Without it there is no
+// CEC remote or TV power control, but nothing else needs it.
+func Available() bool {
+	_, err := exec.LookPath("cec-client")
+	return err == nil
+}
+
+// Adapters runs `cec-client -l` and returns the com port of each CEC adapter
+// it finds, e.g. "RPI" for a Raspberry Pi's built-in one. It fails if
+// cec-client is not installed.
+func Adapters(ctx context.Context) ([]string, error) {
+	path, err := exec.LookPath("cec-client")
+	if err != nil {
+		return nil, fmt.Errorf("cec: cec-client not installed: %w", err)
+	}
+	out, err := exec.CommandContext(ctx, path, "-l").Output()
+	if err != nil {
+		return nil, fmt.Errorf("cec: cec-client -l: %w", err)
+	}
+	return parseAdapterList(strings.NewReader(string(out))), nil
+}
+
+// parseAdapterList extracts the "com port:" of each device in the output of
+// `cec-client -l`.
+func parseAdapterList(r io.Reader) []string {
+	var ports []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok && strings.TrimSpace(strings.ToLower(key)) == "com port" {
+			ports = append(ports, strings.TrimSpace(value))
+		}
+	}
+	return ports
+}
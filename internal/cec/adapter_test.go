@@ -0,0 +1,41 @@
+package cec
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseAdapterList(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{
+			name: "raspberry pi",
+			out: `libCEC version: 6.0.2, compiled on Linux-6.1.21-v8+ ... , features: P8_USB, DRM, P8_detect, randr, RPi, Exynos, Linux, AOCEC
+Found devices: 1
+
+device:              1
+com port:            RPI
+vendor id:           2708
+product id:          1001
+firmware version:    1
+type:                Raspberry Pi
+`,
+			want: []string{"RPI"},
+		},
+		{
+			name: "none",
+			out: `libCEC version: 6.0.2, compiled on Linux-6.1.21-v8+
+Found devices: NONE
+`,
+		},
+	}
+	for _, tt := range tests {
+		if got := parseAdapterList(strings.NewReader(tt.out)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
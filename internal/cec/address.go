@@ -0,0 +1,60 @@
+package cec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PhysicalAddress is an HDMI-CEC physical address such as 2.1.0.0: one
+// nibble per level of the HDMI topology, with the TV at 0.0.0.0.
+type PhysicalAddress [4]uint8
+
+// InputAddress returns the physical address of a device plugged directly
+// into the TV's HDMI input (1-15), e.g. input 3 is 3.0.0.0.
+func InputAddress(input int) (PhysicalAddress, error) {
+	return PhysicalAddress{}.Child(input)
+}
+
+// ParsePhysicalAddress parses dotted notation such as "2.1.0.0".
+func ParsePhysicalAddress(s string) (PhysicalAddress, error) {
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	if len(parts) != 4 {
+		return PhysicalAddress{}, fmt.Errorf("physical address %q: want four dot-separated digits", s)
+	}
+	var a PhysicalAddress
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 16, 4)
+		if err != nil {
+			return PhysicalAddress{}, fmt.Errorf("physical address %q: invalid digit %q", s, p)
+		}
+		a[i] = uint8(n)
+	}
+	return a, nil
+}
+
+// Child returns the address of port (1-15) on the device at a, e.g. port 1
+// of 2.0.0.0 is 2.1.0.0. It fails when the port is out of range or a is
+// already at the deepest level of the topology.
+func (a PhysicalAddress) Child(port int) (PhysicalAddress, error) {
+	if port < 1 || port > 15 {
+		return PhysicalAddress{}, fmt.Errorf("HDMI input %d out of range 1-15", port)
+	}
+	for i, n := range a {
+		if n == 0 {
+			a[i] = uint8(port)
+			return a, nil
+		}
+	}
+	return PhysicalAddress{}, fmt.Errorf("physical address %s has no room for another level", a)
+}
+
+// String returns dotted notation, e.g. "2.1.0.0".
+func (a PhysicalAddress) String() string {
+	return fmt.Sprintf("%x.%x.%x.%x", a[0], a[1], a[2], a[3])
+}
+
+// Hex returns the two-byte form used in cec-client "tx" frames, e.g. "21:00".
+func (a PhysicalAddress) Hex() string {
+	return fmt.Sprintf("%X%X:%X%X", a[0], a[1], a[2], a[3])
+}
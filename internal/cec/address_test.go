@@ -0,0 +1,70 @@
+package cec
+
+import "testing"
+
+func TestInputAddress(t *testing.T) {
+	tests := []struct {
+		input   int
+		dotted  string
+		hex     string
+		wantErr bool
+	}{
+		{input: 1, dotted: "1.0.0.0", hex: "10:00"},
+		{input: 2, dotted: "2.0.0.0", hex: "20:00"},
+		{input: 3, dotted: "3.0.0.0", hex: "30:00"},
+		{input: 4, dotted: "4.0.0.0", hex: "40:00"},
+		{input: 15, dotted: "f.0.0.0", hex: "F0:00"},
+		{input: 0, wantErr: true},
+		{input: -1, wantErr: true},
+		{input: 16, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := InputAddress(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("InputAddress(%d) = %s, want error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("InputAddress(%d) error: %v", tt.input, err)
+			continue
+		}
+		if got.String() != tt.dotted || got.Hex() != tt.hex {
+			t.Errorf("InputAddress(%d) = %s (%s), want %s (%s)", tt.input, got, got.Hex(), tt.dotted, tt.hex)
+		}
+	}
+}
+
+func TestPhysicalAddressChild(t *testing.T) {
+	base, err := ParsePhysicalAddress("2.1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := base.Child(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := child.Hex(), "21:30"; got != want {
+		t.Errorf("2.1.0.0 port 3 = %s, want %s", got, want)
+	}
+
+	if _, err := (PhysicalAddress{1, 2, 3, 4}).Child(1); err == nil {
+		t.Error("Child of a full-depth address should fail")
+	}
+}
+
+func TestParsePhysicalAddress(t *testing.T) {
+	for _, bad := range []string{"", "2.1.0", "2.1.0.0.0", "2.x.0.0", "2.10.0.0"} {
+		if a, err := ParsePhysicalAddress(bad); err == nil {
+			t.Errorf("ParsePhysicalAddress(%q) = %s, want error", bad, a)
+		}
+	}
+	a, err := ParsePhysicalAddress(" 3.2.1.0 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != (PhysicalAddress{3, 2, 1, 0}) {
+		t.Errorf("ParsePhysicalAddress = %v", a)
+	}
+}
@@ -2,38 +2,39 @@ package cec
 
 import (
     "fmt"
-    "os/exec"
+)
+
+// Outgoing frames confirming each command, as logged by cec-client.
+var (
+    ackImageViewOn  = transmitted(`[0-9a-f]0:04`)
+    ackStandby      = transmitted(`[0-9a-f]0:36`)
+    ackActiveSource = transmitted(`1f:82`)
 )
 
 // PowerOffTV sends a "standby" command to the TV (logical address 0).
 func PowerOffTV() error {
-    cmdString := `echo "standby 0" | cec-client -s -d 1`
-    cmd := exec.Command("sh", "-c", cmdString)
-    return cmd.Run()
+    return defaultSession.send("standby 0", ackStandby)
 }
 
 // PowerOnTV attempts to turn the TV on by sending "on 0" over CEC.
 func PowerOnTV() error {
-    cmdString := `echo "on 0" | cec-client -s -d 1`
-    cmd := exec.Command("sh", "-c", cmdString)
-    return cmd.Run()
+    return defaultSession.send("on 0", ackImageViewOn)
 }
 
-// SwitchToHDMI sends an "Active Source" command based on your hdmiInput (1, 2, etc.).
-// See the cec-client spec for physical address codes. For example, "2.0.0.0" = 20:00 in hex.
+// SwitchToHDMI makes the TV show the given HDMI input (1-15) by broadcasting
+// an "Active Source" message with that input's physical address, e.g. input 3
+// => 3.0.0.0 => "30:00". Out-of-range inputs are an error.
 func SwitchToHDMI(input int) error {
-    // For simplicity, assume input=1 => "10:00", input=2 => "20:00", etc.
-    var address string
-    switch input {
-    case 1:
-        address = "10:00"
-    case 2:
-        address = "20:00"
-    default:
-        address = "10:00" // fallback
+    address, err := InputAddress(input)
+    if err != nil {
+        return err
     }
+    return SwitchToAddress(address)
+}
 
-    cmdString := fmt.Sprintf(`echo "tx 1F:82:%s" | cec-client -s -d 1`, address)
-    cmd := exec.Command("sh", "-c", cmdString)
-    return cmd.Run()
+// SwitchToAddress broadcasts "Active Source" for an arbitrary physical
+// address, for setups where the Pi sits behind a receiver or switch (e.g.
+// 2.1.0.0).
+func SwitchToAddress(address PhysicalAddress) error {
+    return defaultSession.send(fmt.Sprintf("tx 1F:82:%s", address.Hex()), ackActiveSource)
 }
@@ -0,0 +1,94 @@
+package cec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PowerState is the TV power status reported over CEC.
+type PowerState int
+
+const (
+	PowerUnknown PowerState = iota
+	PowerOn
+	PowerStandby
+	PowerTransitionToOn      // waking up
+	PowerTransitionToStandby // shutting down
+)
+
+func (s PowerState) String() string {
+	switch s {
+	case PowerOn:
+		return "on"
+	case PowerStandby:
+		return "standby"
+	case PowerTransitionToOn:
+		return "in transition from standby to on"
+	case PowerTransitionToStandby:
+		return "in transition from on to standby"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrNoAdapter is returned when cec-client cannot find or open a CEC adapter.
+var ErrNoAdapter = errors.New("cec: no CEC adapter found")
+
+// PowerStatus asks the TV (logical address 0) for its power status.
+func PowerStatus() (PowerState, error) {
+	state := PowerUnknown
+	err := defaultSession.send("pow 0", func(line string) (bool, error) {
+		var ok bool
+		state, ok = parsePowerStatus(line)
+		return ok, nil
+	})
+	return state, err
+}
+
+// parsePowerStatus extracts the state from a cec-client line such as
+// "power status: standby", reporting whether the line was a status line.
+func parsePowerStatus(line string) (PowerState, bool) {
+	_, status, ok := strings.Cut(strings.ToLower(line), "power status:")
+	if !ok {
+		return PowerUnknown, false
+	}
+	status = strings.TrimSpace(status)
+	switch {
+	case status == "on":
+		return PowerOn, true
+	case status == "standby":
+		return PowerStandby, true
+	case strings.Contains(status, "standby to on"):
+		return PowerTransitionToOn, true
+	case strings.Contains(status, "on to standby"):
+		return PowerTransitionToStandby, true
+	default:
+		return PowerUnknown, true
+	}
+}
+
+// WaitForPowerOn polls PowerStatus until the TV reports it is on or timeout
+// elapses. ErrNoAdapter is returned immediately since retrying cannot help.
+func WaitForPowerOn(timeout time.Duration) error {
+	const pollInterval = time.Second
+
+	deadline := time.Now().Add(timeout)
+	for {
+		state, err := PowerStatus()
+		if errors.Is(err, ErrNoAdapter) {
+			return err
+		}
+		if err == nil && state == PowerOn {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("cec: TV not on after %s: %w", timeout, err)
+			}
+			return fmt.Errorf("cec: TV not on after %s (last status: %s)", timeout, state)
+		}
+		time.Sleep(pollInterval)
+	}
+}
@@ -0,0 +1,77 @@
+package cec
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePowerStatus(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   PowerState
+		wantOK bool
+	}{
+		{line: "power status: on", want: PowerOn, wantOK: true},
+		{line: "power status: standby", want: PowerStandby, wantOK: true},
+		{line: "power status: in transition from standby to on", want: PowerTransitionToOn, wantOK: true},
+		{line: "power status: in transition from on to standby", want: PowerTransitionToStandby, wantOK: true},
+		{line: "power status: unknown", want: PowerUnknown, wantOK: true},
+		{line: "  Power Status: On\r", want: PowerOn, wantOK: true},
+		{line: "TRAFFIC: [  4316]\t>> 01:90:01", want: PowerUnknown},
+		{line: "opening a connection to the CEC adapter...", want: PowerUnknown},
+		{line: "", want: PowerUnknown},
+	}
+	for _, tt := range tests {
+		got, ok := parsePowerStatus(tt.line)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parsePowerStatus(%q) = %v, %t; want %v, %t", tt.line, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestPowerStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		fake    *fakeCEC
+		want    PowerState
+		wantErr error
+	}{
+		{
+			name: "standby",
+			fake: &fakeCEC{respond: func(string) []string {
+				return []string{"TRAFFIC: [  4316]\t<< 10:8f", "TRAFFIC: [  4400]\t>> 01:90:01", "power status: standby"}
+			}},
+			want: PowerStandby,
+		},
+		{
+			name: "no adapter",
+			fake: &fakeCEC{startup: []string{
+				"opening a connection to the CEC adapter...",
+				"autodetect FAILED",
+			}},
+			want:    PowerUnknown,
+			wantErr: ErrNoAdapter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFake(t, tt.fake)
+			got, err := PowerStatus()
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("PowerStatus() = %v, %v; want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWaitForPowerOnGivesUpWithoutAdapter(t *testing.T) {
+	useFake(t, &fakeCEC{startup: []string{"autodetect FAILED"}})
+	start := time.Now()
+	if err := WaitForPowerOn(time.Minute); !errors.Is(err, ErrNoAdapter) {
+		t.Errorf("WaitForPowerOn() = %v, want %v", err, ErrNoAdapter)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("WaitForPowerOn() retried for %s without an adapter", elapsed)
+	}
+}
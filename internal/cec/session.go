@@ -0,0 +1,310 @@
+package cec
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// commandTimeout covers cec-client's adapter start-up when a command
+	// is the first thing sent to a fresh process.
+	commandTimeout = 15 * time.Second
+	// restartDelay spaces out restarts of a listening session.
+	restartDelay = 5 * time.Second
+)
+
+// Session is one long-lived cec-client process. Commands are written to its
+// stdin and acknowledged by watching its traffic log on stdout, which is also
+// scanned for remote key presses, so sending and listening share a single
+// adapter connection.
+type Session struct {
+	// launch starts cec-client with the given arguments; nil runs the real
+	// one. Tests swap in a fake process.
+	launch func(args ...string) (process, error)
+	// timeout bounds the wait for each command's response; zero means
+	// commandTimeout.
+	timeout time.Duration
+
+	sendMu sync.Mutex // one command in flight at a time
+
+	mu      sync.Mutex
+	stdin   io.WriteCloser
+	exited  chan struct{} // closed when the current process exits; nil before the first start
+	exitErr error
+	waiter  chan<- string // receives output lines while a command is in flight
+	remote  chan<- RemoteCommand
+	format  KeyFormat // where remote key presses are read from
+	debug   bool      // the running process logs at debug level
+}
+
+// process is a running cec-client.
+type process struct {
+	stdin  io.WriteCloser
+	stdout io.Reader
+	wait   func() error // reaps the process once stdout is drained
+}
+
+// defaultSession backs the package-level helpers (PowerOnTV, StartCECListener, ...).
+var defaultSession = &Session{}
+
+// Close stops the shared cec-client process, if running, so another program
+// can open the adapter. A later command starts a fresh process.
+func Close() error {
+	return defaultSession.Close()
+}
+
+// Listen forwards remote key presses, read as format says, to remoteEvents
+// and keeps the process running, restarting it whenever it exits, until ctx
+// is cancelled. Then cec-client is asked to quit, freeing the adapter, and
+// the returned channel is closed.
+func (s *Session) Listen(ctx context.Context, remoteEvents chan<- RemoteCommand, format KeyFormat) <-chan struct{} {
+	s.mu.Lock()
+	s.remote = remoteEvents
+	s.format = format
+	// A command may have started cec-client already, without the debug
+	// logging that "key pressed:" lines need, or with it needlessly.
+	restart := s.exited != nil && s.debug != (format == KeyFormatKeyPress)
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if restart {
+			if err := s.Close(); err != nil {
+				log.Printf("Restarting cec-client: %v", err)
+			}
+		}
+		for {
+			exited, err := s.start()
+			if err != nil {
+				log.Printf("Failed to start cec-client: %v", err)
+			} else {
+				select {
+				case <-ctx.Done():
+					if err := s.Close(); err != nil {
+						log.Printf("Stopping cec-client: %v", err)
+					}
+					return
+				case <-exited:
+				}
+				s.mu.Lock()
+				err = s.exitErr
+				s.mu.Unlock()
+				log.Printf("CEC session ended: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(restartDelay):
+			}
+		}
+	}()
+	return done
+}
+
+// Close asks cec-client to quit and waits for it to exit.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	exited, stdin := s.exited, s.stdin
+	s.mu.Unlock()
+	if exited == nil {
+		return nil
+	}
+	select {
+	case <-exited:
+		return nil
+	default:
+	}
+	if _, err := io.WriteString(stdin, "q\n"); err != nil {
+		return err
+	}
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(s.responseTimeout()):
+		return errors.New("cec: cec-client did not quit")
+	}
+}
+
+func (s *Session) responseTimeout() time.Duration {
+	if s.timeout > 0 {
+		return s.timeout
+	}
+	return commandTimeout
+}
+
+// start launches cec-client unless it is already running, returning a channel
+// closed when the process exits.
+func (s *Session) start() (<-chan struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exited != nil {
+		select {
+		case <-s.exited:
+		default:
+			return s.exited, nil
+		}
+	}
+
+	// -t p: register as a playback device; -d 8: log traffic so replies and
+	// key presses can be seen, adding debug (16) for "key pressed:" lines.
+	debug := s.format == KeyFormatKeyPress
+	level := "8"
+	if debug {
+		level = "24"
+	}
+	launch := s.launch
+	if launch == nil {
+		launch = execCECClient
+	}
+	proc, err := launch("-t", "p", "-d", level)
+	if err != nil {
+		return nil, err
+	}
+
+	exited := make(chan struct{})
+	s.stdin = proc.stdin
+	s.exited = exited
+	s.exitErr = nil
+	s.debug = debug
+	go s.readLoop(proc, exited, &remoteParser{format: s.format})
+	return exited, nil
+}
+
+// execCECClient starts the real cec-client.
+func execCECClient(args ...string) (process, error) {
+	cmd := exec.Command("cec-client", args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return process{}, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return process{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return process{}, err
+	}
+	return process{stdin: stdin, stdout: stdout, wait: cmd.Wait}, nil
+}
+
+// readLoop dispatches the output of proc until it exits, reading key presses
+// with keys.
+func (s *Session) readLoop(proc process, exited chan struct{}, keys *remoteParser) {
+	noAdapter := false
+	scanner := bufio.NewScanner(proc.stdout)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if isNoAdapterLine(line) {
+			noAdapter = true
+		}
+
+		s.mu.Lock()
+		remote, waiter := s.remote, s.waiter
+		s.mu.Unlock()
+
+		if remote != nil {
+			if rc, ok := keys.parse(line); ok {
+				remote <- rc
+			}
+		}
+		if waiter != nil {
+			select {
+			case waiter <- line:
+			default:
+			}
+		}
+	}
+
+	err := proc.wait()
+	s.mu.Lock()
+	switch {
+	case noAdapter:
+		s.exitErr = ErrNoAdapter
+	case err != nil:
+		s.exitErr = fmt.Errorf("cec-client exited: %w", err)
+	default:
+		s.exitErr = errors.New("cec-client exited")
+	}
+	s.mu.Unlock()
+	close(exited)
+}
+
+// send writes command to cec-client and feeds subsequent output lines to
+// match until it reports the command complete or failed.
+func (s *Session) send(command string, match func(line string) (done bool, err error)) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	exited, err := s.start()
+	if err != nil {
+		return fmt.Errorf("cec: start cec-client: %w", err)
+	}
+
+	lines := make(chan string, 64)
+	s.mu.Lock()
+	s.waiter = lines
+	stdin := s.stdin
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.waiter = nil
+		s.mu.Unlock()
+	}()
+
+	if _, err := io.WriteString(stdin, command+"\n"); err != nil {
+		return fmt.Errorf("cec: send %q: %w", command, err)
+	}
+
+	timeout := time.NewTimer(s.responseTimeout())
+	defer timeout.Stop()
+	for {
+		select {
+		case line := <-lines:
+			done, err := match(line)
+			if err != nil {
+				return fmt.Errorf("cec: %q: %w", command, err)
+			}
+			if done {
+				return nil
+			}
+		case <-exited:
+			s.mu.Lock()
+			err := s.exitErr
+			s.mu.Unlock()
+			return err
+		case <-timeout.C:
+			return fmt.Errorf("cec: no response to %q after %s", command, s.responseTimeout())
+		}
+	}
+}
+
+// transmitted returns a matcher that completes when cec-client logs an
+// outgoing frame matching frame (e.g. `[0-9a-f]0:04` for "Image View On"),
+// and fails if it reports the transmission was not acknowledged.
+func transmitted(frame string) func(string) (bool, error) {
+	re := regexp.MustCompile(`(?i)<<\s+` + frame)
+	return func(line string) (bool, error) {
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "not acked") || strings.Contains(lower, "transmit failed") {
+			return false, errors.New(strings.TrimSpace(line))
+		}
+		return re.MatchString(line), nil
+	}
+}
+
+// isNoAdapterLine recognizes cec-client's messages for a missing adapter.
+func isNoAdapterLine(line string) bool {
+	lower := strings.ToLower(line)
+	return strings.Contains(lower, "autodetect failed") ||
+		strings.Contains(lower, "could not open a connection")
+}
@@ -0,0 +1,192 @@
+package cec
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCEC stands in for cec-client over pipes. It prints startup when
+// launched, then answers each command line with the lines respond returns;
+// with no respond it exits after startup, as cec-client does without an
+// adapter.
+type fakeCEC struct {
+	t       *testing.T
+	startup []string
+	respond func(command string) []string
+
+	mu  sync.Mutex
+	log []string // "> command" as each arrives and "< line" as it is printed
+}
+
+// useFake makes the package-level helpers talk to f for the rest of the test.
+func useFake(t *testing.T, f *fakeCEC) *Session {
+	t.Helper()
+	f.t = t
+	s := &Session{launch: f.launch}
+	saved := defaultSession
+	defaultSession = s
+	t.Cleanup(func() {
+		s.Close()
+		defaultSession = saved
+	})
+	return s
+}
+
+func (f *fakeCEC) record(entry string) {
+	f.mu.Lock()
+	f.log = append(f.log, entry)
+	f.mu.Unlock()
+}
+
+func (f *fakeCEC) entries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.log...)
+}
+
+func (f *fakeCEC) launch(args ...string) (process, error) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	f.t.Cleanup(func() { inR.Close() })
+
+	// Commands are read as soon as they are written, like a real pipe's
+	// buffer would take them, whether or not the process answers.
+	commands := make(chan string, 64)
+	go func() {
+		scanner := bufio.NewScanner(inR)
+		for scanner.Scan() {
+			if scanner.Text() != "q" {
+				f.record("> " + scanner.Text())
+			}
+			commands <- scanner.Text()
+		}
+	}()
+
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		defer outW.Close()
+		for _, line := range f.startup {
+			fmt.Fprintln(outW, line)
+		}
+		if f.respond == nil {
+			return
+		}
+		for command := range commands {
+			if command == "q" {
+				return
+			}
+			for _, line := range f.respond(command) {
+				f.record("< " + line)
+				fmt.Fprintln(outW, line)
+			}
+		}
+	}()
+	return process{stdin: inW, stdout: outR, wait: func() error { <-exited; return nil }}, nil
+}
+
+func TestSendMatchesAcks(t *testing.T) {
+	tests := []struct {
+		name    string
+		power   func() error
+		reply   []string
+		wantErr string
+	}{
+		{
+			name:  "on acked",
+			power: PowerOnTV,
+			reply: []string{"TRAFFIC: [  8112]\t>> 0f:87:00:00:f0", "TRAFFIC: [  8200]\t<< 10:04"},
+		},
+		{
+			name:  "standby acked",
+			power: PowerOffTV,
+			reply: []string{"TRAFFIC: [  8200]\t<< 10:36"},
+		},
+		{
+			name:    "not acked",
+			power:   PowerOnTV,
+			reply:   []string{"TRAFFIC: [  8112]\t>> 0f:87:00:00:f0", "ERROR:   [  8700]\tcommand 'image view on' was not acked by the controller"},
+			wantErr: `cec: "on 0": ERROR:   [  8700]` + "\tcommand 'image view on' was not acked by the controller",
+		},
+		{
+			name:    "someone else's frame",
+			power:   PowerOnTV,
+			reply:   []string{"TRAFFIC: [  8200]\t<< 10:36"},
+			wantErr: `cec: no response to "on 0" after 50ms`,
+		},
+		{
+			name:    "no reply",
+			power:   PowerOffTV,
+			wantErr: `cec: no response to "standby 0" after 50ms`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := useFake(t, &fakeCEC{respond: func(string) []string { return tt.reply }})
+			s.timeout = 50 * time.Millisecond
+			err := tt.power()
+			if got := fmt.Sprint(err); (err != nil || tt.wantErr != "") && got != tt.wantErr {
+				t.Errorf("error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSendAfterTimeout(t *testing.T) {
+	// A command that is never acked times out without holding up the next.
+	acks := map[string][]string{"standby 0": nil, "on 0": {"TRAFFIC: [  8200]\t<< 10:04"}}
+	s := useFake(t, &fakeCEC{respond: func(command string) []string { return acks[command] }})
+	s.timeout = 50 * time.Millisecond
+	if err := PowerOffTV(); err == nil {
+		t.Fatal("PowerOffTV() without an ack succeeded")
+	}
+	if err := PowerOnTV(); err != nil {
+		t.Errorf("PowerOnTV() after a timeout = %v", err)
+	}
+}
+
+func TestSendSerializesCommands(t *testing.T) {
+	f := &fakeCEC{respond: func(command string) []string {
+		time.Sleep(5 * time.Millisecond)
+		if command == "on 0" {
+			return []string{"TRAFFIC: [  8200]\t<< 10:04"}
+		}
+		return []string{"TRAFFIC: [  8200]\t<< 10:36"}
+	}}
+	useFake(t, f)
+
+	var wg sync.WaitGroup
+	for i := range 6 {
+		power := PowerOnTV
+		if i%2 == 1 {
+			power = PowerOffTV
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := power(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each command is acked before the next is written.
+	log := f.entries()
+	if len(log) != 12 {
+		t.Fatalf("log = %q, want 6 commands and 6 acks", log)
+	}
+	for i := 0; i < len(log); i += 2 {
+		want := "< TRAFFIC: [  8200]\t<< 10:04"
+		if log[i] == "> standby 0" {
+			want = "< TRAFFIC: [  8200]\t<< 10:36"
+		}
+		if log[i+1] != want {
+			t.Fatalf("log = %q, want each command followed by its ack", log)
+		}
+	}
+}
@@ -1,9 +1,7 @@
 package cec
 
 import (
-    "bufio"
-    "log"
-    "os/exec"
+    "context"
     "regexp"
     "strings"
 )
@@ -16,65 +14,130 @@ const (
     RemoteLeft
     RemoteRight
     RemoteSelect
+    RemoteDelete
+    RemoteFavorite
+    RemoteHold
+    RemoteFaster
+    RemoteSlower
+    // RemoteBlank has no CEC key; it comes from the other controllers.
+    RemoteBlank
+    RemoteRescan
+    // The volume keys are the TV's; see Volume.
+    RemoteVolumeUp
+    RemoteVolumeDown
+    RemoteMute
 )
 
-// We’ll capture user-control-pressed lines like: ">> 04:44:03" (where 03 is the key code)
+// remoteCommandNames maps the textual command names accepted by non-CEC
+// controllers (MQTT, stdin) onto RemoteCommands.
+var remoteCommandNames = map[string]RemoteCommand{
+    "prev":     RemoteLeft,
+    "next":     RemoteRight,
+    "pause":    RemoteSelect,
+    "delete":   RemoteDelete,
+    "favorite": RemoteFavorite,
+    "hold":     RemoteHold,
+    "faster":   RemoteFaster,
+    "slower":   RemoteSlower,
+    "blank":    RemoteBlank,
+    "rescan":   RemoteRescan,
+}
+
+// ParseRemoteCommand maps a command name such as "next" onto its RemoteCommand.
+func ParseRemoteCommand(name string) (RemoteCommand, bool) {
+    cmd, ok := remoteCommandNames[strings.ToLower(strings.TrimSpace(name))]
+    return cmd, ok
+}
+
+// We’ll capture user-control-pressed lines like: ">> 04:44:03" (where 03 is the key code),
+// or "key pressed: left (3)"
 // Key codes mapped to user-friendly names:
 var cecUserControlMap = map[string]RemoteCommand{
-    "03": RemoteLeft,   // "Left"
-    "04": RemoteRight,  // "Right"
-    "00": RemoteSelect, // "Select/Enter"
+    "03": RemoteLeft,       // "Left"
+    "04": RemoteRight,      // "Right"
+    "00": RemoteSelect,     // "Select/Enter"
+    "01": RemoteFaster,     // "Up"
+    "02": RemoteSlower,     // "Down"
+    "72": RemoteDelete,     // "F2 (Red)"
+    "73": RemoteHold,       // "F3 (Green)"
+    "74": RemoteFavorite,   // "F4 (Yellow)"
+    "71": RemoteRescan,     // "F1 (Blue)"
+    "41": RemoteVolumeUp,   // "Volume Up"
+    "42": RemoteVolumeDown, // "Volume Down"
+    "43": RemoteMute,       // "Mute"
     // Add more if needed...
 }
 
-var reUserControlPressed = regexp.MustCompile(`>>\s+([0-9A-Fa-f]{2}):44:([0-9A-Fa-f]{2})`)
+// KeyFormat selects the lines of cec-client output that key presses are read
+// from. cec-client versions and builds differ in what they print: the raw
+// traffic log shows each "User Control Pressed" frame, e.g. ">> 04:44:03",
+// while others log "key pressed: left (3)" instead, or as well.
+type KeyFormat string
 
-// StartCECListener spawns cec-client in a goroutine, parses its output,
-// and sends recognized remote commands into remoteEvents.
-func StartCECListener(remoteEvents chan<- RemoteCommand) {
-    go func() {
-        defer func() {
-            log.Println("CEC listener goroutine exiting.")
-        }()
-
-        // Start cec-client in traffic mode:
-        cmd := exec.Command("cec-client", "-t", "p", "-d", "8")
+const (
+    // KeyFormatAuto accepts either kind of line and keeps to whichever
+    // shows a key press first, so a press logged both ways counts once.
+    KeyFormatAuto KeyFormat = "auto"
+    // KeyFormatTraffic reads only the traffic log.
+    KeyFormatTraffic KeyFormat = "traffic"
+    // KeyFormatKeyPress reads only "key pressed:" lines, which libCEC logs
+    // at debug level, so cec-client is started with debug logging on.
+    KeyFormatKeyPress KeyFormat = "keypress"
+)
 
-        stdout, err := cmd.StdoutPipe()
-        if err != nil {
-            log.Printf("Error getting stdout pipe: %v", err)
-            return
-        }
-        defer stdout.Close()
+var (
+    // The spacing after ">>" varies between versions.
+    reUserControlPressed = regexp.MustCompile(`>>\s*([0-9A-Fa-f]{2}):44:([0-9A-Fa-f]{2})`)
+    // The key name may itself hold parentheses ("F2 (red) (72)"), and the
+    // code is printed in hex with or without a leading zero.
+    reKeyPressed = regexp.MustCompile(`(?i)key pressed:\s*.*?\s\(([0-9a-f]{1,2})\)(?:\s|$)`)
+)
 
-        if err := cmd.Start(); err != nil {
-            log.Printf("Failed to start cec-client: %v", err)
-            return
-        }
+// StartCECListener starts the shared cec-client session in the background
+// and sends recognized remote commands into remoteEvents, reading key presses
+// from the lines format picks. The session is restarted if cec-client exits,
+// until ctx is cancelled; the returned channel is closed once cec-client has
+// quit.
+func StartCECListener(ctx context.Context, remoteEvents chan<- RemoteCommand, format KeyFormat) <-chan struct{} {
+    return defaultSession.Listen(ctx, remoteEvents, format)
+}
 
-        scanner := bufio.NewScanner(stdout)
-        for scanner.Scan() {
-            line := scanner.Text()
-            // Look for "User Control Pressed" lines
-            if match := reUserControlPressed.FindStringSubmatch(line); len(match) == 3 {
-                keyCode := strings.ToUpper(match[2]) // e.g., "03"
-                cmdVal, ok := cecUserControlMap[keyCode]
-                if !ok {
-                    cmdVal = RemoteUnknown
-                }
-                if cmdVal != RemoteUnknown {
-                    remoteEvents <- cmdVal
-                }
-            }
-        }
+// remoteParser recognizes key presses in the output of one cec-client
+// process. Under KeyFormatAuto it settles on the format of the first key
+// press it sees.
+type remoteParser struct {
+    format KeyFormat
+}
 
-        if err := scanner.Err(); err != nil {
-            log.Printf("Scanner error: %v", err)
-        }
+// parse returns the command for a line reporting a press of a mapped key.
+func (r *remoteParser) parse(line string) (RemoteCommand, bool) {
+    code, format, ok := keyCode(line)
+    if !ok {
+        return RemoteUnknown, false
+    }
+    switch r.format {
+    case format:
+    case KeyFormatAuto, "":
+        r.format = format
+    default:
+        return RemoteUnknown, false
+    }
+    cmdVal, ok := cecUserControlMap[code]
+    return cmdVal, ok && cmdVal != RemoteUnknown
+}
 
-        // cec-client exit code:
-        if err := cmd.Wait(); err != nil {
-            log.Printf("cec-client ended with error: %v", err)
+// keyCode extracts the two-digit, upper-case key code from a line of either
+// format, and which format it was.
+func keyCode(line string) (string, KeyFormat, bool) {
+    if match := reUserControlPressed.FindStringSubmatch(line); match != nil {
+        return strings.ToUpper(match[2]), KeyFormatTraffic, true
+    }
+    if match := reKeyPressed.FindStringSubmatch(line); match != nil {
+        code := strings.ToUpper(match[1])
+        if len(code) == 1 {
+            code = "0" + code
         }
-    }()
+        return code, KeyFormatKeyPress, true
+    }
+    return "", "", false
 }
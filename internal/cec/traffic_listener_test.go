@@ -0,0 +1,101 @@
+package cec
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRemoteParser(t *testing.T) {
+	tests := []struct {
+		name   string
+		format KeyFormat
+		out    string
+		want   []RemoteCommand
+	}{
+		{
+			name:   "libCEC 6 traffic",
+			format: KeyFormatAuto,
+			out: `TRAFFIC: [          109812]	<< 40:04
+TRAFFIC: [          118457]	>> 04:44:03
+TRAFFIC: [          118601]	>> 04:45
+TRAFFIC: [          120022]	>> 04:44:04
+TRAFFIC: [          121300]	>> 04:44:72
+TRAFFIC: [          122718]	>> 04:44:41
+TRAFFIC: [          123090]	>> 04:44:43
+`,
+			want: []RemoteCommand{RemoteLeft, RemoteRight, RemoteDelete, RemoteVolumeUp, RemoteMute},
+		},
+		{
+			name:   "libCEC 4 traffic, lower case and no space",
+			format: KeyFormatAuto,
+			out: `TRAFFIC: [  2044]	>>04:44:00
+TRAFFIC: [  2210]	>> 04:44:0a
+TRAFFIC: [  2387]	>> 0f:44:74
+`,
+			// 0a (Setup menu) is not mapped.
+			want: []RemoteCommand{RemoteSelect, RemoteFavorite},
+		},
+		{
+			name:   "key pressed lines",
+			format: KeyFormatAuto,
+			out: `DEBUG:   [           49120]	key pressed: left (3) current(ff) duration(0)
+DEBUG:   [           49231]	key released: left (3) D:111ms
+DEBUG:   [           50004]	SetCurrentButton right (4) D:0ms cur:3
+DEBUG:   [           50004]	key pressed: right (4) current(3) duration(0)
+DEBUG:   [           51670]	key pressed: F2 (red) (72) current(ff) duration(0)
+`,
+			want: []RemoteCommand{RemoteLeft, RemoteRight, RemoteDelete},
+		},
+		{
+			name:   "older key pressed lines",
+			format: KeyFormatAuto,
+			out: `key pressed: left (03)
+key pressed: select (0)
+key pressed: F1 (blue) (71)
+`,
+			want: []RemoteCommand{RemoteLeft, RemoteSelect, RemoteRescan},
+		},
+		{
+			name:   "auto counts a press logged both ways once",
+			format: KeyFormatAuto,
+			out: `TRAFFIC: [   8811]	>> 04:44:01
+DEBUG:   [   8811]	key pressed: up (1) current(ff) duration(0)
+TRAFFIC: [   9902]	>> 04:44:02
+DEBUG:   [   9902]	key pressed: down (2) current(ff) duration(0)
+`,
+			want: []RemoteCommand{RemoteFaster, RemoteSlower},
+		},
+		{
+			name:   "traffic only",
+			format: KeyFormatTraffic,
+			out: `DEBUG:   [   8811]	key pressed: up (1) current(ff) duration(0)
+TRAFFIC: [   9902]	>> 04:44:02
+`,
+			want: []RemoteCommand{RemoteSlower},
+		},
+		{
+			name:   "key pressed only",
+			format: KeyFormatKeyPress,
+			out: `TRAFFIC: [   8811]	>> 04:44:01
+DEBUG:   [   8811]	key pressed: up (1) current(ff) duration(0)
+TRAFFIC: [   9902]	>> 04:44:02
+`,
+			want: []RemoteCommand{RemoteFaster},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := &remoteParser{format: tt.format}
+			var got []RemoteCommand
+			for _, line := range strings.Split(tt.out, "\n") {
+				if cmd, ok := keys.parse(line); ok {
+					got = append(got, cmd)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package cec
+
+import "fmt"
+
+// Outgoing "User Control Pressed" and "User Control Released" frames to the
+// TV, as logged by cec-client.
+var (
+	ackKeyPressed  = transmitted(`[0-9a-f]0:44`)
+	ackKeyReleased = transmitted(`[0-9a-f]0:45`)
+)
+
+// volumeKeys maps the volume commands onto their CEC key codes.
+var volumeKeys = map[RemoteCommand]string{
+	RemoteVolumeUp:   "41",
+	RemoteVolumeDown: "42",
+	RemoteMute:       "43",
+}
+
+// Volume presses and releases a volume key (RemoteVolumeUp, RemoteVolumeDown
+// or RemoteMute) on the TV (logical address 0), which turns its own volume or
+// that of an audio system it passes the key on to. Other commands are an
+// error.
+func Volume(cmd RemoteCommand) error {
+	key, ok := volumeKeys[cmd]
+	if !ok {
+		return fmt.Errorf("cec: %d is not a volume key", cmd)
+	}
+	if err := defaultSession.send("tx 10:44:"+key, ackKeyPressed); err != nil {
+		return err
+	}
+	return defaultSession.send("tx 10:45", ackKeyReleased)
+}
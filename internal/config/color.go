@@ -0,0 +1,26 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// ParseColor parses a "#RRGGBB" or "#RRGGBBAA" hex string. The alpha channel
+// defaults to opaque; the result is non-premultiplied.
+func ParseColor(s string) (color.NRGBA, error) {
+	digits := strings.TrimPrefix(s, "#")
+	if len(digits) != 6 && len(digits) != 8 {
+		return color.NRGBA{}, fmt.Errorf("%q is not a #RRGGBB or #RRGGBBAA color", s)
+	}
+	b, err := hex.DecodeString(digits)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("%q is not a #RRGGBB or #RRGGBBAA color", s)
+	}
+	c := color.NRGBA{R: b[0], G: b[1], B: b[2], A: 0xff}
+	if len(b) == 4 {
+		c.A = b[3]
+	}
+	return c, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+// stripComments blanks out the // line comments in data, which JSON does
+// not allow but a config written by hand is clearer with. Comments are
+// replaced by spaces rather than removed, so that a parse error still
+// points at the right offset. "//" inside a string, as in a URL, is left
+// alone.
+func stripComments(data []byte) []byte {
+	out := make([]byte, len(data))
+	copy(out, data)
+	inString, escaped := false, false
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		}
+	}
+	return out
+}
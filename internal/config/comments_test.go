@@ -0,0 +1,28 @@
+package config
+
+import "testing"
+
+func TestReadAllowsComments(t *testing.T) {
+	path := writeConfig(t, map[string]string{
+		"config.json": `// The family frame.
+{
+  "albums": ["/photos/a"], // more to come
+  "mapOverlay": {"tileURL": "https://tiles.example.com/{z}/{x}/{y}.png"},
+  "standbyMessage": "say \"hi\" // not a comment"
+}`,
+		"config.d/10-site.json": "{\n  // A drop-in too.\n  \"interval\": 20\n}",
+	})
+	cfg, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MapOverlay.TileURL != "https://tiles.example.com/{z}/{x}/{y}.png" {
+		t.Errorf("tileURL = %q, want the // in it kept", cfg.MapOverlay.TileURL)
+	}
+	if cfg.StandbyMessage != `say "hi" // not a comment` {
+		t.Errorf("standbyMessage = %q, want the // in it kept", cfg.StandbyMessage)
+	}
+	if cfg.Interval != 20 {
+		t.Errorf("interval = %d, want 20 from the drop-in", cfg.Interval)
+	}
+}
@@ -3,32 +3,521 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
 const (
 	DefaultConfigPath = ".openframe/config.json"
+
+	// EnvConfigPath names the environment variable that overrides the
+	// config file location.
+	EnvConfigPath = "OPENFRAME_CONFIG"
+
+	defaultMQTTTopicPrefix = "openframe"
+	defaultMQTTClientID    = "openframe"
+
+	defaultHTTPMaxUploadMB = 50
+	defaultHTTPName        = "OpenFrame"
+
+	defaultClockPosition = "topRight"
+	defaultClockFormat   = "24h"
+
+	defaultDisplayWidth  = 1920
+	defaultDisplayHeight = 1080
+
+	defaultBackgroundColor = "#000000"
+	defaultBackgroundFill  = "color"
+
+	defaultPairAlign = "fit"
+
+	defaultMinDimension = 200
+
+	defaultProgressColor  = "#FFFFFF80"
+	defaultProgressHeight = 4
+
+	// The slideshow has always shuffled, so that stays the default.
+	defaultSortBy = "random"
+
+	defaultStartAt = "first"
+
+	defaultCECKeyFormat = "auto"
+
+	// defaultFilenameTitleIgnore matches the names cameras and phones give
+	// photos, e.g. IMG_1234, DSCF0001, PXL_20240101_123456789.MP and
+	// IMG-20240101-WA0001, and bare dates and numbers.
+	defaultFilenameTitleIgnore = `(?i)^(img|dsc[fn]?|dji|gopr|pxl|mvimg)?[\d_ -]*(wa\d+)?(\.mp)?( \(\d+\))?$`
+
+	defaultOnThisDayFallback = "all"
+
+	defaultWatchdogIntervals = 2
+
+	defaultDimBrightness = 0.3
+
+	defaultTitleCardDuration = 3
+
+	defaultTransition   = "none"
+	defaultTransitionMs = 800
+	defaultFadeInMs     = 400
+
+	defaultMirror = "none"
+
+	defaultStandbyMessage = "Waiting for photos..."
+	defaultStandbyMode    = "message"
+	defaultRescanInterval = 300
 )
 
+// SortOrders lists the accepted sortBy values.
+var SortOrders = []string{"random", "time", "name", "path"}
+
+// StartAtModes lists the accepted startAt values: open on the first slide,
+// a random one, or the one last shown before the restart.
+var StartAtModes = []string{"first", "random", "resume"}
+
+// CECKeyFormats lists the accepted cecKeyFormat values: read remote key
+// presses from whichever cec-client prints, its traffic log, or its "key
+// pressed:" lines.
+var CECKeyFormats = []string{"auto", "traffic", "keypress"}
+
+// OnThisDayFallbacks lists the accepted onThisDayFallback values: on a day
+// with no photos, show every photo, or none (the standby message).
+var OnThisDayFallbacks = []string{"all", "none"}
+
+// RandomizeModes lists the accepted randomize values besides "" (off).
+var RandomizeModes = []string{"smart", "bag"}
+
+// MaxFPSLimit is the highest maxFPS accepted: the rate the slideshow runs at
+// uncapped, which animations always get.
+const MaxFPSLimit = 60
+
+// MaxFadeInMs is the longest fadeInMs accepted; the fade is only meant to
+// soften the first slide's arrival.
+const MaxFadeInMs = 2000
+
+// Transitions lists the accepted transition values: the slideshow's
+// animated effects, an instant cut ("none" or "cut"), or "random".
+var Transitions = []string{"none", "cut", "crossfade", "push", "kenburns", "random"}
+
+// StandbyModes lists the accepted standbyMode values: the standby message, a
+// black screen or a large clock.
+var StandbyModes = []string{"message", "black", "clock"}
+
+// Mirrors lists the accepted mirror values: no flip, or the whole screen
+// flipped left to right or upside down.
+var Mirrors = []string{"none", "horizontal", "vertical"}
+
+// Rotations lists the accepted rotate values, in degrees clockwise.
+var Rotations = []int{0, 90, 180, 270}
+
+// BackgroundFills lists the accepted backgroundFill values: backgroundColor,
+// or each photo's sampled edge colour.
+var BackgroundFills = []string{"color", "edge"}
+
+// PairAligns lists the accepted pairAlign values: each photo of a side-by-side
+// pair fitted to its half on its own, or both scaled to a common height.
+var PairAligns = []string{"fit", "height"}
+
+// ClockPositions lists the accepted clockOverlay.position values.
+var ClockPositions = []string{"topLeft", "topRight", "bottomLeft", "bottomRight"}
+
 // Config represents the JSON config structure.
 type Config struct {
-	Albums      []string `json:"albums"`
-	DateOverlay bool     `json:"dateOverlay"`
-	Interval    int      `json:"interval"`
+	Albums       []string     `json:"albums"`
+	DateOverlay  bool         `json:"dateOverlay"`
+	ClockOverlay ClockOverlay `json:"clockOverlay"`
+	Interval     int          `json:"interval"`
+	SortBy       string       `json:"sortBy"` // "random", "time", "name" or "path"
+
+	// LocationOverlay shows where the photo was taken: the place name from
+	// cmd/geocode, or else its GPS coordinates.
+	LocationOverlay bool `json:"locationOverlay"`
+
+	// CameraOverlay shows the camera, lens and exposure settings the photo
+	// was taken with, from its EXIF.
+	CameraOverlay bool `json:"cameraOverlay"`
+
+	// CounterOverlay shows the slide's place in the slideshow, e.g.
+	// "42 / 1200".
+	CounterOverlay bool `json:"counterOverlay"`
+
+	// FilenameTitleOverlay shows a title made from the file name of a photo
+	// without a caption, unless FilenameTitleIgnore, a regular expression
+	// for the name without its extension, matches it.
+	FilenameTitleOverlay bool   `json:"filenameTitleOverlay"`
+	FilenameTitleIgnore  string `json:"filenameTitleIgnore"`
+
+	// MapOverlay shows a small map of where a geotagged photo was taken.
+	MapOverlay MapOverlay `json:"mapOverlay"`
+
+	// Overlays places each overlay (see OverlayTypes) on screen. Types it
+	// leaves out follow DateOverlay, LocationOverlay, CameraOverlay,
+	// CounterOverlay, FilenameTitleOverlay, ClockOverlay, MapOverlay and
+	// ShowProgress; Read fills in every type.
+	Overlays map[string]Overlay `json:"overlays"`
+
+	// Randomize "smart" reshuffles the slides on every pass, keeping photos
+	// from the same album or day apart, in place of SortBy. "bag" also
+	// reshuffles every pass, and shows every slide once before any repeats,
+	// even across rescans.
+	Randomize Randomize `json:"randomize"`
+
+	// StartAt picks the slide the slideshow opens on: "first", "random"
+	// or "resume" (the photo last shown, if it is still there).
+	StartAt string `json:"startAt"`
+
+	// CoverPhoto is the path of a photo that always leads the slideshow,
+	// ahead of the sorted or shuffled rest; it is skipped if it cannot be
+	// read.
+	CoverPhoto string `json:"coverPhoto"`
+
+	// PlaceholderImage is the path of an image to show in place of a photo
+	// whose file cannot be read, e.g. while a network share is down; the
+	// photo is tried again on its next turn. Empty skips such photos.
+	PlaceholderImage string `json:"placeholderImage"`
+
+	// Playlist names a text or JSON file listing the photos to show, in
+	// order; when set the albums are not walked.
+	Playlist string `json:"playlist"`
+
+	// MinRating, when 1–5, shows only photos rated at least that many stars
+	// in XMP or EXIF; unrated photos are shown only with IncludeUnrated.
+	MinRating      int  `json:"minRating"`
+	IncludeUnrated bool `json:"includeUnrated"`
+
+	// MinDimension leaves out images narrower or shorter than this many
+	// pixels, and MinFileSizeKB those smaller than this many kilobytes,
+	// so that stickers and icons in an export folder are not shown.
+	MinDimension  int `json:"minDimension"`
+	MinFileSizeKB int `json:"minFileSizeKB"`
+
+	// FollowSymlinks walks symlinked directories inside albums, and albums
+	// that are symlinks, skipping any link that leads back into a
+	// directory already scanned.
+	FollowSymlinks bool `json:"followSymlinks"`
+
+	// IgnoreExtensions, e.g. ".mov", are of files in the albums left out
+	// without a warning; other files that are not supported images are
+	// warned about once per extension. Unset means videos, sidecars and
+	// system files (photo.DefaultIgnoreExtensions).
+	IgnoreExtensions []string `json:"ignoreExtensions"`
+
+	// Dedupe shows only one of each set of identical photo files found in
+	// the albums, such as a photo copied into two folders.
+	Dedupe bool `json:"dedupe"`
+
+	// StartupSnapshot saves the photo list after every scan and, at the
+	// next start, shows it straight away while the albums are rescanned in
+	// the background, if their top directories look unchanged.
+	StartupSnapshot bool `json:"startupSnapshot"`
+
+	// IncludeKeywords limits the slideshow to photos tagged (in XMP or IPTC)
+	// with one of these keywords, plus untagged photos with IncludeUntagged.
+	// Photos tagged with any of ExcludeKeywords are never shown. Keywords
+	// match case-insensitively.
+	IncludeKeywords []string `json:"includeKeywords"`
+	ExcludeKeywords []string `json:"excludeKeywords"`
+	IncludeUntagged bool     `json:"includeUntagged"`
+
+	// OnThisDay limits the slideshow to photos taken on today's date in
+	// any year, changing over at midnight. OnThisDayFallback decides what
+	// to show on a day without any: "all" photos or "none".
+	OnThisDay         bool   `json:"onThisDay"`
+	OnThisDayFallback string `json:"onThisDayFallback"` // one of OnThisDayFallbacks
+
+	// AutoLevels stretches the contrast of dull or dark photos; it is the
+	// strength from 0 (off) to 1.
+	AutoLevels float64 `json:"autoLevels"`
+
+	// ColorManagement converts photos with an embedded ICC profile, such as
+	// Display P3 or Adobe RGB, to sRGB for the TV.
+	ColorManagement bool `json:"colorManagement"`
+
+	// SlideDurations multiplies Interval for particular kinds of slide.
+	SlideDurations SlideDurations `json:"slideDurations"`
+
+	// GroupByDate gathers the photos of each day together and introduces
+	// every day with a title card shown for TitleCardDuration seconds.
+	GroupByDate       bool `json:"groupByDate"`
+	TitleCardDuration int  `json:"titleCardDuration"`
+
+	// Interleave takes slides from each album in turn, each album keeping
+	// its SortBy order.
+	Interleave bool `json:"interleave"`
+
+	// Transition animates each slide change over TransitionMs milliseconds;
+	// "random" picks a different effect for every change.
+	Transition   string `json:"transition"` // one of Transitions
+	TransitionMs int    `json:"transitionMs"`
+	// FadeInMs fades the first slide, and the first after the error or
+	// standby screen, in from black over that many milliseconds; a
+	// negative value turns the fade off.
+	FadeInMs int `json:"fadeInMs"`
+
+	// SinglePhotoKenBurns slowly zooms and pans across the photo when there
+	// is only one to show.
+	SinglePhotoKenBurns bool `json:"singlePhotoKenBurns"`
+
+	// MemoryBudgetMB caps the decoded photos held in memory; a transition
+	// that would need more is replaced by a cut. Zero means no cap.
+	MemoryBudgetMB int `json:"memoryBudgetMB"`
+
+	// PreloadAhead is how many upcoming slides to decode in the background,
+	// within MemoryBudgetMB, so that moving on only has to upload them. Zero
+	// turns preloading off.
+	PreloadAhead int `json:"preloadAhead"`
+
+	// DisplayWidth and DisplayHeight are the logical screen size the
+	// slideshow is laid out at; match the display's aspect ratio.
+	DisplayWidth  int `json:"displayWidth"`
+	DisplayHeight int `json:"displayHeight"`
+
+	// MaxFPS caps how many times a second the screen is updated and
+	// redrawn while nothing is animating, sparing a Pi's CPU and GPU. Zero
+	// keeps Ebiten's 60. Vsync is left on: frames that are not redrawn are
+	// never presented, and Ebiten waits between them on its own, so turning
+	// it off would save nothing while idle and only tear transitions.
+	MaxFPS int `json:"maxFPS"`
+
+	// Mirror flips the whole screen, overlays included, for a frame
+	// projected through glass or viewed in a mirror.
+	Mirror string `json:"mirror"` // one of Mirrors
+	// Rotate turns the whole screen clockwise by this many degrees, for a
+	// TV mounted on its side; a quarter turn lays the slideshow out at
+	// DisplayHeight x DisplayWidth.
+	Rotate int `json:"rotate"` // one of Rotations
+
+	// BackgroundColor fills the screen around photos ("#RRGGBB").
+	BackgroundColor string `json:"backgroundColor"`
+	// BackgroundFill is "color" to use BackgroundColor, or "edge" to fill
+	// each photo's letterbox with the average colour of its border.
+	BackgroundFill string `json:"backgroundFill"`
+
+	// PairAlign is "fit" to size each photo of a side-by-side pair to its
+	// half independently, or "height" to give both the same height.
+	PairAlign string `json:"pairAlign"` // one of PairAligns
+	// PairTolerance, when positive, only pairs portraits (or stacks
+	// landscapes) whose aspect ratios differ by at most this many percent;
+	// 0 pairs any two.
+	PairTolerance float64 `json:"pairTolerance"`
+	// AutoRotateToFill turns a landscape on its side to pair it with a
+	// portrait that would otherwise be shown alone, if both were taken on
+	// the same day.
+	AutoRotateToFill bool `json:"autoRotateToFill"`
+	// StackLandscapes shows two consecutive landscapes one above the other
+	// when together they fill more of the screen than either alone.
+	StackLandscapes bool `json:"stackLandscapes"`
+
+	// ShowProgress draws a bar along the bottom edge that fills up as the
+	// current slide's interval elapses.
+	ShowProgress   bool   `json:"showProgress"`
+	ProgressColor  string `json:"progressColor"`  // "#RRGGBB" or "#RRGGBBAA"
+	ProgressHeight int    `json:"progressHeight"` // in pixels
+
+	// PauseDim darkens the photo while paused, in percent: 0 leaves it
+	// alone, 100 fades to black. HidePauseIndicator drops the "Slideshow
+	// Paused" label.
+	PauseDim           int  `json:"pauseDim"`
+	HidePauseIndicator bool `json:"hidePauseIndicator"`
+
+	// StandbyMessage is shown while no photos can be displayed; the albums
+	// are rescanned every RescanInterval seconds until some reappear.
+	StandbyMessage string `json:"standbyMessage"`
+	RescanInterval int    `json:"rescanInterval"`
+	// StandbyMode is what the standby screen shows. Unless it is the
+	// message, the screen also replaces the slides outside the schedule's
+	// on hours, for a slideshow left running overnight.
+	StandbyMode string `json:"standbyMode"` // one of StandbyModes
+
+	// WatchdogThreshold is how many seconds a slide may stay up past its
+	// scheduled change (while unpaused) before the slideshow is forced
+	// forward. Zero means two intervals; a negative value disables the
+	// watchdog.
+	WatchdogThreshold int `json:"watchdogThreshold"`
+
+	// IdleTimeout is how many seconds without remote activity (including
+	// time spent paused) before the TV is put in standby; 0 disables it.
+	// The next remote command powers the TV back on.
+	IdleTimeout int `json:"idleTimeout"`
+
+	// PowerOffOnExit puts the TV in standby when the slideshow exits, e.g.
+	// on systemctl stop, rather than leaving it on the frame's input.
+	PowerOffOnExit bool `json:"powerOffOnExit"`
+
+	// AssertInputInterval, when positive, re-selects HDMIInput every this
+	// many seconds while the TV is on, reclaiming the screen from another
+	// CEC device that switched the TV away; 0 disables it.
+	AssertInputInterval int `json:"assertInputInterval"`
+
+	// CECKeyFormat picks the cec-client output remote key presses are read
+	// from; one of CECKeyFormats.
+	CECKeyFormat string `json:"cecKeyFormat"`
+
+	// CECVolumePassthrough passes the remote's volume and mute keys on to
+	// the TV, for TVs that send them to the frame instead of acting on them.
+	CECVolumePassthrough bool `json:"cecVolumePassthrough"`
+
+	HDMIInput   int         `json:"hdmiInput"`
+	Schedule    Schedule    `json:"schedule"`
+	DimSchedule DimSchedule `json:"dimSchedule"`
+	MQTT        MQTT        `json:"mqtt"`
+	HTTP        HTTP        `json:"http"`
 }
 
-// Read retrieves and parses the JSON config from ~/.openframe/config.json.
-func Read() (Config, error) {
+// ClockOverlay configures the live clock drawn over the slideshow.
+type ClockOverlay struct {
+	Enabled  bool   `json:"enabled"`
+	Position string `json:"position"` // one of ClockPositions
+	Format   string `json:"format"`   // "12h" or "24h"
+	ShowDate bool   `json:"showDate"`
+}
+
+// MapOverlay configures the map overlay and where its tiles come from.
+type MapOverlay struct {
+	Enabled bool `json:"enabled"`
+	// TileURL is a slippy map tile address with {z}, {x} and {y} in it;
+	// empty means OpenStreetMap's.
+	TileURL string `json:"tileURL"`
+	// Zoom is the tile zoom level, 1 to 19; zero means 10.
+	Zoom int `json:"zoom"`
+}
+
+// Schedule holds the daily on/off times as "HH:MM" strings.
+type Schedule struct {
+	OnTime  string `json:"onTime"`
+	OffTime string `json:"offTime"`
+	// Days gives some weekdays, keyed by name (see Weekdays), times of
+	// their own; a day listed without times stays off.
+	Days map[string]DaySchedule `json:"days"`
+}
+
+// DaySchedule holds one weekday's on/off times as "HH:MM" strings. An off
+// time earlier than the on time is the next morning.
+type DaySchedule struct {
+	OnTime  string `json:"onTime"`
+	OffTime string `json:"offTime"`
+}
+
+// Weekdays names the keys of Schedule.Days, indexed by time.Weekday.
+var Weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// DimSchedule lowers the screen brightness every night between Start and End
+// ("HH:MM"; the range may wrap past midnight) without turning the TV off.
+// It is disabled while Start and End are unset.
+type DimSchedule struct {
+	Start      string  `json:"start"`
+	End        string  `json:"end"`
+	Brightness float64 `json:"brightness"` // 0 < brightness <= 1
+}
+
+// Enabled reports whether a dimming window has been configured.
+func (d DimSchedule) Enabled() bool {
+	return d.Start != "" || d.End != ""
+}
+
+// SlideDurations holds per-slide multipliers of the interval; zero leaves the
+// interval unchanged. A favorite slide's multiplier combines with its
+// orientation's.
+type SlideDurations struct {
+	Landscape    float64 `json:"landscape"`
+	Portrait     float64 `json:"portrait"`     // a lone portrait
+	PortraitPair float64 `json:"portraitPair"` // two portraits side by side
+	Favorite     float64 `json:"favorite"`
+}
+
+// MQTT configures the optional MQTT bridge. The bridge is disabled when
+// Broker is empty.
+type MQTT struct {
+	Broker      string `json:"broker"` // e.g. tcp://homeassistant.local:1883
+	TopicPrefix string `json:"topicPrefix"`
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	ClientID    string `json:"clientId"`
+}
+
+// Enabled reports whether a broker has been configured.
+func (m MQTT) Enabled() bool {
+	return m.Broker != ""
+}
+
+// HTTP configures the optional HTTP API. The server is disabled when Listen
+// is empty, and uploads are refused while UploadDir is empty.
+type HTTP struct {
+	Listen      string `json:"listen"` // e.g. ":8080"
+	Token       string `json:"token"`  // required as a bearer token when set
+	UploadDir   string `json:"uploadDir"`
+	MaxUploadMB int    `json:"maxUploadMB"`
+
+	// Advertise announces the API over mDNS as an _openframe._tcp service
+	// called Name, so apps on the local network can find the frame.
+	Advertise bool   `json:"advertise"`
+	Name      string `json:"name"`
+
+	// PublicURL is the address the QR code overlay links to, e.g.
+	// "http://frame.local:8080"; empty means this host's name and the
+	// listen port.
+	PublicURL string `json:"publicURL"`
+}
+
+// Enabled reports whether a listen address has been configured.
+func (h HTTP) Enabled() bool {
+	return h.Listen != ""
+}
+
+// Port returns the TCP port of the listen address.
+func (h HTTP) Port() (int, error) {
+	_, port, err := net.SplitHostPort(h.Listen)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return 0, fmt.Errorf("%q is not a valid port", port)
+	}
+	return n, nil
+}
+
+// ResolvePath picks the config file location: flagValue when non-empty, then
+// $OPENFRAME_CONFIG, then ~/.openframe/config.json.
+func ResolvePath(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if env := os.Getenv(EnvConfigPath); env != "" {
+		return env, nil
+	}
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return Config{}, fmt.Errorf("failed to get user home directory: %w", err)
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
 	}
-	configPath := filepath.Join(homeDir, DefaultConfigPath)
+	return filepath.Join(homeDir, DefaultConfigPath), nil
+}
+
+// StateDir returns the directory holding caches and other state for the
+// instance configured by configPath: the directory containing the config.
+func StateDir(configPath string) string {
+	return filepath.Dir(configPath)
+}
+
+// Randomize is the randomize setting. Before sortBy it was a boolean, which
+// is still accepted and means nothing now (off).
+type Randomize string
 
-	data, err := os.ReadFile(configPath)
+func (r *Randomize) UnmarshalJSON(data []byte) error {
+	if string(data) == "true" || string(data) == "false" {
+		*r = ""
+		return nil
+	}
+	return json.Unmarshal(data, (*string)(r))
+}
+
+// Read retrieves and parses the JSON config at configPath, with the files in
+// DropInDir beside it merged over it.
+func Read(configPath string) (Config, error) {
+	data, err := readWithDropIns(configPath)
 	if err != nil {
-		return Config{}, fmt.Errorf("failed to read config file at %s: %w", configPath, err)
+		return Config{}, err
 	}
 
 	var cfg Config
@@ -36,12 +525,108 @@ func Read() (Config, error) {
 		return Config{}, fmt.Errorf("failed to parse config JSON: %w", err)
 	}
 
-	// The decoder silently ignores unknown fields (e.g. legacy `randomize`).
+	// The decoder silently ignores unknown fields.
 
-	// Default interval if not set or invalid
-	if cfg.Interval <= 0 {
+	// Default interval if not set; negative values are reported by Validate.
+	if cfg.Interval == 0 {
 		cfg.Interval = 10
 	}
 
+	if cfg.ClockOverlay.Position == "" {
+		cfg.ClockOverlay.Position = defaultClockPosition
+	}
+	if cfg.ClockOverlay.Format == "" {
+		cfg.ClockOverlay.Format = defaultClockFormat
+	}
+	cfg.applyOverlayDefaults()
+
+	if cfg.Transition == "" {
+		cfg.Transition = defaultTransition
+	}
+	if cfg.TransitionMs == 0 {
+		cfg.TransitionMs = defaultTransitionMs
+	}
+	if cfg.FadeInMs == 0 {
+		cfg.FadeInMs = defaultFadeInMs
+	}
+
+	if cfg.Mirror == "" {
+		cfg.Mirror = defaultMirror
+	}
+	if cfg.DisplayWidth == 0 && cfg.DisplayHeight == 0 {
+		cfg.DisplayWidth, cfg.DisplayHeight = defaultDisplayWidth, defaultDisplayHeight
+	}
+	if cfg.BackgroundColor == "" {
+		cfg.BackgroundColor = defaultBackgroundColor
+	}
+	if cfg.BackgroundFill == "" {
+		cfg.BackgroundFill = defaultBackgroundFill
+	}
+	if cfg.PairAlign == "" {
+		cfg.PairAlign = defaultPairAlign
+	}
+
+	if cfg.ProgressColor == "" {
+		cfg.ProgressColor = defaultProgressColor
+	}
+	if cfg.ProgressHeight == 0 {
+		cfg.ProgressHeight = defaultProgressHeight
+	}
+
+	if cfg.SortBy == "" {
+		cfg.SortBy = defaultSortBy
+	}
+	if cfg.StartAt == "" {
+		cfg.StartAt = defaultStartAt
+	}
+	if cfg.CECKeyFormat == "" {
+		cfg.CECKeyFormat = defaultCECKeyFormat
+	}
+	if cfg.FilenameTitleIgnore == "" {
+		cfg.FilenameTitleIgnore = defaultFilenameTitleIgnore
+	}
+	if cfg.OnThisDayFallback == "" {
+		cfg.OnThisDayFallback = defaultOnThisDayFallback
+	}
+	if cfg.MinDimension == 0 {
+		cfg.MinDimension = defaultMinDimension
+	}
+
+	if cfg.TitleCardDuration == 0 {
+		cfg.TitleCardDuration = defaultTitleCardDuration
+	}
+
+	if cfg.WatchdogThreshold == 0 {
+		cfg.WatchdogThreshold = defaultWatchdogIntervals * cfg.Interval
+	}
+
+	if cfg.DimSchedule.Enabled() && cfg.DimSchedule.Brightness == 0 {
+		cfg.DimSchedule.Brightness = defaultDimBrightness
+	}
+
+	if cfg.StandbyMessage == "" {
+		cfg.StandbyMessage = defaultStandbyMessage
+	}
+	if cfg.StandbyMode == "" {
+		cfg.StandbyMode = defaultStandbyMode
+	}
+	if cfg.RescanInterval == 0 {
+		cfg.RescanInterval = defaultRescanInterval
+	}
+
+	if cfg.MQTT.TopicPrefix == "" {
+		cfg.MQTT.TopicPrefix = defaultMQTTTopicPrefix
+	}
+	if cfg.MQTT.ClientID == "" {
+		cfg.MQTT.ClientID = defaultMQTTClientID
+	}
+
+	if cfg.HTTP.MaxUploadMB == 0 {
+		cfg.HTTP.MaxUploadMB = defaultHTTPMaxUploadMB
+	}
+	if cfg.HTTP.Name == "" {
+		cfg.HTTP.Name = defaultHTTPName
+	}
+
 	return cfg, nil
 }
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DropInDir is the directory, next to the config file, whose *.json files
+// are merged over it in lexical order: each sets or overrides keys of the
+// config so far, nested objects key by key. Their albums are added to the
+// list unless the file sets albumsMerge to "replace".
+const DropInDir = "config.d"
+
+// AlbumsMerges lists the accepted albumsMerge values of a drop-in file.
+var AlbumsMerges = []string{"append", "replace"}
+
+// readWithDropIns returns the config at configPath with the files in its
+// DropInDir merged over it, as JSON. Every file may have // comments.
+func readWithDropIns(configPath string) ([]byte, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file at %s: %w", configPath, err)
+	}
+	data = stripComments(data)
+	paths, err := filepath.Glob(filepath.Join(filepath.Dir(configPath), DropInDir, "*.json"))
+	if err != nil || len(paths) == 0 {
+		return data, nil
+	}
+	slices.Sort(paths)
+
+	merged, err := decodeObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config JSON: %w", err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file at %s: %w", path, err)
+		}
+		dropIn, err := decodeObject(stripComments(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config JSON in %s: %w", path, err)
+		}
+		if err := mergeDropIn(merged, dropIn); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// decodeObject decodes a JSON object, keeping numbers as written.
+func decodeObject(data []byte) (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		obj = make(map[string]any)
+	}
+	return obj, nil
+}
+
+// mergeDropIn merges the drop-in file dropIn over cfg.
+func mergeDropIn(cfg, dropIn map[string]any) error {
+	mode := "append"
+	if v, ok := dropIn["albumsMerge"]; ok {
+		s, _ := v.(string)
+		if !slices.Contains(AlbumsMerges, s) {
+			return fmt.Errorf("albumsMerge: %v is not one of %s", v, strings.Join(AlbumsMerges, ", "))
+		}
+		mode = s
+		delete(dropIn, "albumsMerge")
+	}
+	albums, ok := dropIn["albums"].([]any)
+	if ok && mode == "append" {
+		earlier, _ := cfg["albums"].([]any)
+		dropIn["albums"] = append(slices.Clone(earlier), albums...)
+	}
+	mergeObjects(cfg, dropIn)
+	return nil
+}
+
+// mergeObjects sets each key of src in dst, merging objects found in both.
+func mergeObjects(dst, src map[string]any) {
+	for k, v := range src {
+		srcObj, srcIsObj := v.(map[string]any)
+		dstObj, dstIsObj := dst[k].(map[string]any)
+		if srcIsObj && dstIsObj {
+			mergeObjects(dstObj, srcObj)
+			continue
+		}
+		dst[k] = v
+	}
+}
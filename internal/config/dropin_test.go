@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes files, keyed by path relative to a new directory, and
+// returns the path of the config.json among them.
+func writeConfig(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "config.json")
+}
+
+func TestReadMergesDropInsInLexicalOrder(t *testing.T) {
+	path := writeConfig(t, map[string]string{
+		"config.json": `{"albums": ["/photos/common"], "interval": 10, "sortBy": "time",
+			"mqtt": {"broker": "tcp://hub:1883", "topicPrefix": "frames"}}`,
+		"config.d/20-device.json": `{"albums": ["/photos/kitchen"], "interval": 30}`,
+		"config.d/10-site.json":   `{"albums": ["/photos/family"], "interval": 20, "mqtt": {"topicPrefix": "kitchen"}}`,
+		"config.d/notes.txt":      `{"interval": 99}`,
+	})
+	cfg, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/photos/common", "/photos/family", "/photos/kitchen"}; !slices.Equal(cfg.Albums, want) {
+		t.Errorf("albums = %v, want %v", cfg.Albums, want)
+	}
+	if cfg.Interval != 30 {
+		t.Errorf("interval = %d, want 30 from the last drop-in", cfg.Interval)
+	}
+	if cfg.SortBy != "time" {
+		t.Errorf("sortBy = %q, want %q from config.json", cfg.SortBy, "time")
+	}
+	if cfg.MQTT.Broker != "tcp://hub:1883" || cfg.MQTT.TopicPrefix != "kitchen" {
+		t.Errorf("mqtt = %+v, want the broker from config.json and the topic prefix from 10-site.json", cfg.MQTT)
+	}
+}
+
+func TestReadDropInReplacesAlbums(t *testing.T) {
+	path := writeConfig(t, map[string]string{
+		"config.json":          `{"albums": ["/photos/common"]}`,
+		"config.d/a.json":      `{"albums": ["/photos/a"], "albumsMerge": "replace"}`,
+		"config.d/b.json":      `{"albums": ["/photos/b"]}`,
+		"config.d/c-none.json": `{"interval": 5}`,
+	})
+	cfg, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/photos/a", "/photos/b"}; !slices.Equal(cfg.Albums, want) {
+		t.Errorf("albums = %v, want %v", cfg.Albums, want)
+	}
+}
+
+func TestReadDropInErrorsNameTheFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		dropIn  string
+		wantErr string
+	}{
+		{name: "bad JSON", dropIn: `{"interval": `, wantErr: "bad.json"},
+		{name: "bad albumsMerge", dropIn: `{"albumsMerge": "prepend"}`, wantErr: "albumsMerge"},
+	}
+	for _, tt := range tests {
+		path := writeConfig(t, map[string]string{
+			"config.json":       `{"albums": ["/photos"]}`,
+			"config.d/bad.json": tt.dropIn,
+		})
+		_, err := Read(path)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error %v, want one mentioning %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
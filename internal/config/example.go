@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// exampleConfig is the config WriteExample writes, with the album in place
+// of %ALBUM%. Settings left out take their defaults; the README lists them
+// all.
+const exampleConfig = `// OpenFrame config. Lines starting with // are comments; the README
+// lists every setting. Edit this file, then run openframe again.
+{
+  // Directories of photos to show (subdirectories included), or http(s)
+  // addresses of remote albums.
+  "albums": [
+    %ALBUM%
+  ],
+
+  // Seconds each photo stays on screen.
+  "interval": 30,
+
+  // "random", "time", "name" or "path".
+  "sortBy": "random",
+
+  // What is drawn over the photos, and where: topLeft, top, topRight,
+  // bottomLeft, bottom or bottomRight.
+  "overlays": {
+    "date":     {"enabled": true,  "position": "bottomLeft"},
+    "location": {"enabled": false, "position": "bottomRight"},
+    "caption":  {"enabled": false, "position": "top"},
+    "clock":    {"enabled": false, "position": "topRight"},
+    "progress": {"enabled": false, "position": "bottom"}
+  },
+
+  // The TV input the frame is on, switched to on wake; 0 leaves it alone.
+  "hdmiInput": 0,
+
+  // Daily on and off times (HH:MM); leave both empty to stay on.
+  "schedule": {
+    "onTime": "",
+    "offTime": ""
+  }
+}
+`
+
+// WriteExample writes a commented example config to path, with album as
+// its one album, creating the directory it goes in. It never replaces an
+// existing file: the error then wraps fs.ErrExist.
+func WriteExample(path, album string) error {
+	quoted, err := json.Marshal(album)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(strings.Replace(exampleConfig, "%ALBUM%", string(quoted), 1)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
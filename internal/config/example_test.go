@@ -0,0 +1,29 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWriteExampleReadsBackAndNeverOverwrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".openframe", "config.json")
+	if err := WriteExample(path, `/home/me/My "Photos"`); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`/home/me/My "Photos"`}; !slices.Equal(cfg.Albums, want) {
+		t.Errorf("albums = %q, want %q", cfg.Albums, want)
+	}
+	if !cfg.Overlays["date"].Enabled || cfg.Interval != 30 {
+		t.Errorf("example config = %+v, want the date overlay on and a 30s interval", cfg)
+	}
+	if err := WriteExample(path, "/other"); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("second WriteExample() = %v, want fs.ErrExist", err)
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// OverlayTypes lists the overlays the overlays map places, in drawing order.
+var OverlayTypes = []string{"date", "location", "caption", "title", "camera", "clock", "qr", "map", "counter", "progress"}
+
+// OverlayPositions lists where the text overlays can go: the corners, or
+// centred along the top or bottom edge. The progress bar takes only "top" or
+// "bottom", the edge it runs along.
+var OverlayPositions = []string{"topLeft", "top", "topRight", "bottomLeft", "bottom", "bottomRight"}
+
+// Overlay places one overlay on screen.
+type Overlay struct {
+	Enabled  bool   `json:"enabled"`
+	Position string `json:"position"` // one of OverlayPositions
+
+	// defaulted is set when Position was left out and filled in from
+	// defaultOverlayPositions.
+	defaulted bool
+}
+
+// defaultOverlayPositions keeps every overlay apart when all are enabled,
+// but for the QR code and the map, which both fit only in a corner, the
+// camera settings, which share the location's corner, and the file name
+// title, which stands in for the caption.
+var defaultOverlayPositions = map[string]string{
+	"date":     "bottomLeft",
+	"location": "bottomRight",
+	"caption":  "top",
+	"title":    "top",
+	"camera":   "bottomRight",
+	"clock":    "topRight",
+	"qr":       "topLeft",
+	"map":      "topLeft",
+	"counter":  "bottom",
+	"progress": "bottom",
+}
+
+// applyOverlayDefaults completes the overlays map. A type it leaves out
+// follows the dateOverlay, locationOverlay, cameraOverlay, counterOverlay,
+// filenameTitleOverlay, clockOverlay, mapOverlay and showProgress shorthands,
+// and an entry without a position gets its default.
+func (c *Config) applyOverlayDefaults() {
+	legacy := map[string]Overlay{
+		"date":     {Enabled: c.DateOverlay},
+		"location": {Enabled: c.LocationOverlay},
+		"camera":   {Enabled: c.CameraOverlay},
+		"counter":  {Enabled: c.CounterOverlay},
+		"title":    {Enabled: c.FilenameTitleOverlay},
+		"clock":    {Enabled: c.ClockOverlay.Enabled, Position: c.ClockOverlay.Position},
+		"map":      {Enabled: c.MapOverlay.Enabled},
+		"progress": {Enabled: c.ShowProgress},
+	}
+	if c.Overlays == nil {
+		c.Overlays = make(map[string]Overlay)
+	}
+	for _, name := range OverlayTypes {
+		o, ok := c.Overlays[name]
+		if !ok {
+			o = legacy[name]
+		}
+		if o.Position == "" {
+			o.Position = defaultOverlayPositions[name]
+			o.defaulted = true
+		}
+		c.Overlays[name] = o
+	}
+}
+
+// validateOverlays checks the overlays map: known types, valid positions,
+// and no two enabled text overlays given the same position. Overlays left at
+// a default position may share it, as location and camera or the QR code and
+// the map do, since the slideshow moves the later one to the next free
+// position clockwise; so may the caption and the file name title, which are
+// never shown for the same photo.
+func (c Config) validateOverlays() []error {
+	var errs []error
+	var unknown []string
+	for name := range c.Overlays {
+		if !slices.Contains(OverlayTypes, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, fmt.Errorf("overlays: %q is not one of %s", name, strings.Join(OverlayTypes, ", ")))
+	}
+
+	taken := make(map[string]string)
+	for _, name := range OverlayTypes {
+		o, ok := c.Overlays[name]
+		if !ok {
+			continue
+		}
+		if name == "progress" {
+			if o.Position != "top" && o.Position != "bottom" {
+				errs = append(errs, fmt.Errorf("overlays.progress.position: must be \"top\" or \"bottom\", got %q", o.Position))
+			}
+			continue
+		}
+		if !slices.Contains(OverlayPositions, o.Position) {
+			errs = append(errs, fmt.Errorf("overlays.%s.position: %q is not one of %s",
+				name, o.Position, strings.Join(OverlayPositions, ", ")))
+			continue
+		}
+		if !o.Enabled {
+			continue
+		}
+		if other, ok := taken[o.Position]; ok {
+			// A photo has a caption or a file name title, never both.
+			shared := name == "title" && other == "caption"
+			if !shared && !o.defaulted && !c.Overlays[other].defaulted {
+				errs = append(errs, fmt.Errorf("overlays.%s.position: %s is already taken by %s", name, o.Position, other))
+			}
+			continue
+		}
+		taken[o.Position] = name
+	}
+	return errs
+}
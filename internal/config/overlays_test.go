@@ -0,0 +1,60 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateOverlayPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{
+			name: "location and camera at their default corner",
+			cfg:  Config{LocationOverlay: true, CameraOverlay: true},
+		},
+		{
+			name: "QR code and map at their default corner",
+			cfg: Config{Overlays: map[string]Overlay{
+				"qr":  {Enabled: true},
+				"map": {Enabled: true},
+			}},
+		},
+		{
+			name: "one left at its default",
+			cfg: Config{Overlays: map[string]Overlay{
+				"location": {Enabled: true, Position: "bottomRight"},
+				"camera":   {Enabled: true},
+			}},
+		},
+		{
+			name: "caption and title given the same position",
+			cfg: Config{Overlays: map[string]Overlay{
+				"caption": {Enabled: true, Position: "top"},
+				"title":   {Enabled: true, Position: "top"},
+			}},
+		},
+		{
+			name: "both given the same position",
+			cfg: Config{Overlays: map[string]Overlay{
+				"location": {Enabled: true, Position: "bottomRight"},
+				"camera":   {Enabled: true, Position: "bottomRight"},
+			}},
+			wantErr: "overlays.camera.position: bottomRight is already taken by location",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.applyOverlayDefaults()
+			var got []string
+			for _, err := range tt.cfg.validateOverlays() {
+				got = append(got, err.Error())
+			}
+			if joined := strings.Join(got, "; "); joined != tt.wantErr {
+				t.Errorf("validateOverlays() = %q, want %q", joined, tt.wantErr)
+			}
+		})
+	}
+}
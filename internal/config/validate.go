@@ -0,0 +1,400 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Validate checks the config for problems that would otherwise surface only
+// once the slideshow is running. Every problem found is reported in a single
+// joined error rather than stopping at the first.
+func (c Config) Validate() error {
+	var errs []error
+
+	if len(c.Albums) == 0 && c.Playlist == "" {
+		errs = append(errs, errors.New("albums: at least one album directory (or a playlist) is required"))
+	}
+	for _, dir := range c.Albums {
+		check := checkReadableDir
+		if isAlbumURL(dir) {
+			// A remote album is not fetched here: the server may simply
+			// be down, which only costs its photos until it is back.
+			check = checkAlbumURL
+		}
+		if err := check(dir); err != nil {
+			errs = append(errs, fmt.Errorf("albums: %w", err))
+		}
+	}
+
+	if c.Playlist != "" {
+		if err := checkReadableFile(c.Playlist); err != nil {
+			errs = append(errs, fmt.Errorf("playlist: %w", err))
+		}
+	}
+	if c.PlaceholderImage != "" {
+		if err := checkReadableFile(c.PlaceholderImage); err != nil {
+			errs = append(errs, fmt.Errorf("placeholderImage: %w", err))
+		}
+	}
+
+	if c.MinRating < 0 || c.MinRating > 5 {
+		errs = append(errs, fmt.Errorf("minRating: must be between 1 and 5 stars (or 0 to show every photo), got %d", c.MinRating))
+	}
+	for _, k := range c.IncludeKeywords {
+		if strings.TrimSpace(k) == "" {
+			errs = append(errs, errors.New("includeKeywords: keywords must not be blank"))
+			break
+		}
+	}
+	for _, k := range c.ExcludeKeywords {
+		if strings.TrimSpace(k) == "" {
+			errs = append(errs, errors.New("excludeKeywords: keywords must not be blank"))
+			break
+		}
+	}
+	if !slices.Contains(SortOrders, c.SortBy) {
+		errs = append(errs, fmt.Errorf("sortBy: %q is not one of %s", c.SortBy, strings.Join(SortOrders, ", ")))
+	}
+	if !slices.Contains(StartAtModes, c.StartAt) {
+		errs = append(errs, fmt.Errorf("startAt: %q is not one of %s", c.StartAt, strings.Join(StartAtModes, ", ")))
+	}
+	if !slices.Contains(CECKeyFormats, c.CECKeyFormat) {
+		errs = append(errs, fmt.Errorf("cecKeyFormat: %q is not one of %s", c.CECKeyFormat, strings.Join(CECKeyFormats, ", ")))
+	}
+	if c.Randomize != "" && !slices.Contains(RandomizeModes, string(c.Randomize)) {
+		errs = append(errs, fmt.Errorf("randomize: %q is not one of %s", c.Randomize, strings.Join(RandomizeModes, ", ")))
+	}
+	if !slices.Contains(Transitions, c.Transition) {
+		errs = append(errs, fmt.Errorf("transition: %q is not one of %s", c.Transition, strings.Join(Transitions, ", ")))
+	}
+	if c.TransitionMs < 0 {
+		errs = append(errs, fmt.Errorf("transitionMs: must not be negative, got %d", c.TransitionMs))
+	}
+	if c.FadeInMs > MaxFadeInMs {
+		errs = append(errs, fmt.Errorf("fadeInMs: must be at most %d, or negative to turn the fade off, got %d", MaxFadeInMs, c.FadeInMs))
+	}
+	if !slices.Contains(ClockPositions, c.ClockOverlay.Position) {
+		errs = append(errs, fmt.Errorf("clockOverlay.position: %q is not one of %s",
+			c.ClockOverlay.Position, strings.Join(ClockPositions, ", ")))
+	}
+	if f := c.ClockOverlay.Format; f != "12h" && f != "24h" {
+		errs = append(errs, fmt.Errorf("clockOverlay.format: must be \"12h\" or \"24h\", got %q", f))
+	}
+	errs = append(errs, c.validateOverlays()...)
+
+	if c.AutoLevels < 0 || c.AutoLevels > 1 {
+		errs = append(errs, fmt.Errorf("autoLevels: must be between 0 (off) and 1, got %g", c.AutoLevels))
+	}
+	if c.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("interval: must be a positive number of seconds, got %d", c.Interval))
+	}
+	for _, m := range []struct {
+		name  string
+		value float64
+	}{
+		{"landscape", c.SlideDurations.Landscape},
+		{"portrait", c.SlideDurations.Portrait},
+		{"portraitPair", c.SlideDurations.PortraitPair},
+		{"favorite", c.SlideDurations.Favorite},
+	} {
+		if m.value < 0 {
+			errs = append(errs, fmt.Errorf("slideDurations.%s: must not be negative, got %g", m.name, m.value))
+		}
+	}
+	if c.TitleCardDuration < 0 {
+		errs = append(errs, fmt.Errorf("titleCardDuration: must be a positive number of seconds, got %d", c.TitleCardDuration))
+	}
+	if c.WatchdogThreshold > 0 && c.WatchdogThreshold <= c.Interval {
+		errs = append(errs, fmt.Errorf("watchdogThreshold: must exceed interval (%ds) or be negative to disable, got %d", c.Interval, c.WatchdogThreshold))
+	}
+	if c.IdleTimeout < 0 {
+		errs = append(errs, fmt.Errorf("idleTimeout: must not be negative, got %d", c.IdleTimeout))
+	}
+	if c.RescanInterval < 0 {
+		errs = append(errs, fmt.Errorf("rescanInterval: must be a positive number of seconds, got %d", c.RescanInterval))
+	}
+	if _, err := regexp.Compile(c.FilenameTitleIgnore); err != nil {
+		errs = append(errs, fmt.Errorf("filenameTitleIgnore: %w", err))
+	}
+	if _, err := ParseColor(c.BackgroundColor); err != nil {
+		errs = append(errs, fmt.Errorf("backgroundColor: %w", err))
+	}
+	if c.DisplayWidth <= 0 || c.DisplayHeight <= 0 {
+		errs = append(errs, fmt.Errorf("displayWidth, displayHeight: both must be positive, got %dx%d", c.DisplayWidth, c.DisplayHeight))
+	}
+	if c.MaxFPS < 0 || c.MaxFPS > MaxFPSLimit {
+		errs = append(errs, fmt.Errorf("maxFPS: must be between 1 and %d, or 0 for no cap, got %d", MaxFPSLimit, c.MaxFPS))
+	}
+	if !slices.Contains(BackgroundFills, c.BackgroundFill) {
+		errs = append(errs, fmt.Errorf("backgroundFill: %q is not one of %s", c.BackgroundFill, strings.Join(BackgroundFills, ", ")))
+	}
+	if !slices.Contains(OnThisDayFallbacks, c.OnThisDayFallback) {
+		errs = append(errs, fmt.Errorf("onThisDayFallback: %q is not one of %s", c.OnThisDayFallback, strings.Join(OnThisDayFallbacks, ", ")))
+	}
+	if c.MinDimension < 0 {
+		errs = append(errs, fmt.Errorf("minDimension: must not be negative, got %d", c.MinDimension))
+	}
+	if c.MinFileSizeKB < 0 {
+		errs = append(errs, fmt.Errorf("minFileSizeKB: must not be negative, got %d", c.MinFileSizeKB))
+	}
+	for _, ext := range c.IgnoreExtensions {
+		if !strings.HasPrefix(ext, ".") {
+			errs = append(errs, fmt.Errorf("ignoreExtensions: %q must start with a dot, e.g. \".mov\"", ext))
+		}
+	}
+	if c.MemoryBudgetMB < 0 {
+		errs = append(errs, fmt.Errorf("memoryBudgetMB: must not be negative, got %d", c.MemoryBudgetMB))
+	}
+	if c.PreloadAhead < 0 {
+		errs = append(errs, fmt.Errorf("preloadAhead: must not be negative, got %d", c.PreloadAhead))
+	}
+	if c.PairTolerance < 0 {
+		errs = append(errs, fmt.Errorf("pairTolerance: must not be negative, got %g", c.PairTolerance))
+	}
+	if !slices.Contains(StandbyModes, c.StandbyMode) {
+		errs = append(errs, fmt.Errorf("standbyMode: %q is not one of %s", c.StandbyMode, strings.Join(StandbyModes, ", ")))
+	}
+	if !slices.Contains(Mirrors, c.Mirror) {
+		errs = append(errs, fmt.Errorf("mirror: %q is not one of %s", c.Mirror, strings.Join(Mirrors, ", ")))
+	}
+	if !slices.Contains(Rotations, c.Rotate) {
+		errs = append(errs, fmt.Errorf("rotate: %d is not one of 0, 90, 180, 270", c.Rotate))
+	}
+	if !slices.Contains(PairAligns, c.PairAlign) {
+		errs = append(errs, fmt.Errorf("pairAlign: %q is not one of %s", c.PairAlign, strings.Join(PairAligns, ", ")))
+	}
+	if _, err := ParseColor(c.ProgressColor); err != nil {
+		errs = append(errs, fmt.Errorf("progressColor: %w", err))
+	}
+	if c.ProgressHeight < 0 {
+		errs = append(errs, fmt.Errorf("progressHeight: must not be negative, got %d", c.ProgressHeight))
+	}
+	if c.PauseDim < 0 || c.PauseDim > 100 {
+		errs = append(errs, fmt.Errorf("pauseDim: must be a percentage between 0 and 100, got %d", c.PauseDim))
+	}
+	if c.HDMIInput < 0 || c.HDMIInput > 15 {
+		errs = append(errs, fmt.Errorf("hdmiInput: must be between 1 and 15 (or 0 to leave the input alone), got %d", c.HDMIInput))
+	}
+	switch {
+	case c.AssertInputInterval < 0:
+		errs = append(errs, fmt.Errorf("assertInputInterval: must not be negative, got %d", c.AssertInputInterval))
+	case c.AssertInputInterval > 0 && c.HDMIInput == 0:
+		errs = append(errs, errors.New("assertInputInterval: needs hdmiInput to know which input to select"))
+	}
+
+	if c.Schedule.OnTime != "" {
+		if _, err := ParseTimeOfDay(c.Schedule.OnTime); err != nil {
+			errs = append(errs, fmt.Errorf("schedule.onTime: %w", err))
+		}
+	}
+	if c.Schedule.OffTime != "" {
+		if _, err := ParseTimeOfDay(c.Schedule.OffTime); err != nil {
+			errs = append(errs, fmt.Errorf("schedule.offTime: %w", err))
+		}
+	}
+	errs = append(errs, c.Schedule.validateDays()...)
+
+	if c.DimSchedule.Enabled() {
+		start, startErr := ParseTimeOfDay(c.DimSchedule.Start)
+		if startErr != nil {
+			errs = append(errs, fmt.Errorf("dimSchedule.start: %w", startErr))
+		}
+		end, endErr := ParseTimeOfDay(c.DimSchedule.End)
+		if endErr != nil {
+			errs = append(errs, fmt.Errorf("dimSchedule.end: %w", endErr))
+		}
+		if startErr == nil && endErr == nil && start == end {
+			errs = append(errs, fmt.Errorf("dimSchedule: start and end are both %s", c.DimSchedule.Start))
+		}
+		if b := c.DimSchedule.Brightness; b <= 0 || b > 1 {
+			errs = append(errs, fmt.Errorf("dimSchedule.brightness: must be above 0 and at most 1, got %g", b))
+		}
+	}
+
+	if m := c.MapOverlay; m.TileURL != "" {
+		if err := checkTileURL(m.TileURL); err != nil {
+			errs = append(errs, fmt.Errorf("mapOverlay.tileURL: %w", err))
+		}
+	}
+	if z := c.MapOverlay.Zoom; z < 0 || z > 19 {
+		errs = append(errs, fmt.Errorf("mapOverlay.zoom: must be 1 to 19, got %d", z))
+	}
+
+	if c.MQTT.Enabled() {
+		if err := checkBrokerURL(c.MQTT.Broker); err != nil {
+			errs = append(errs, fmt.Errorf("mqtt.broker: %w", err))
+		}
+		// MQTT 3.1.1 has no password without a username.
+		if c.MQTT.Password != "" && c.MQTT.Username == "" {
+			errs = append(errs, errors.New("mqtt.password: needs mqtt.username as well"))
+		}
+	}
+
+	if c.HTTP.Enabled() {
+		if _, err := c.HTTP.Port(); err != nil {
+			errs = append(errs, fmt.Errorf("http.listen: %w", err))
+		}
+		if c.HTTP.UploadDir != "" {
+			if err := checkReadableDir(c.HTTP.UploadDir); err != nil {
+				errs = append(errs, fmt.Errorf("http.uploadDir: %w", err))
+			}
+		}
+		if c.HTTP.MaxUploadMB < 0 {
+			errs = append(errs, fmt.Errorf("http.maxUploadMB: must be a positive number of megabytes, got %d", c.HTTP.MaxUploadMB))
+		}
+		if c.HTTP.PublicURL != "" {
+			if err := checkPublicURL(c.HTTP.PublicURL); err != nil {
+				errs = append(errs, fmt.Errorf("http.publicURL: %w", err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateDays checks schedule.days: known weekday names, each with both
+// times or neither.
+func (s Schedule) validateDays() []error {
+	var errs []error
+	var days []string
+	for day := range s.Days {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	for _, day := range days {
+		d := s.Days[day]
+		if !slices.Contains(Weekdays, day) {
+			errs = append(errs, fmt.Errorf("schedule.days: %q is not one of %s", day, strings.Join(Weekdays, ", ")))
+			continue
+		}
+		if (d.OnTime == "") != (d.OffTime == "") {
+			errs = append(errs, fmt.Errorf("schedule.days.%s: needs both onTime and offTime, or neither to stay off", day))
+			continue
+		}
+		for _, t := range []struct{ name, value string }{{"onTime", d.OnTime}, {"offTime", d.OffTime}} {
+			if t.value == "" {
+				continue
+			}
+			if _, err := ParseTimeOfDay(t.value); err != nil {
+				errs = append(errs, fmt.Errorf("schedule.days.%s.%s: %w", day, t.name, err))
+			}
+		}
+	}
+	return errs
+}
+
+// ParseTimeOfDay parses an "HH:MM" string into the offset from midnight.
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a valid HH:MM time", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// checkReadableDir reports whether dir exists, is a directory and can be listed.
+func checkReadableDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s is not readable: %w", dir, err)
+	}
+	return nil
+}
+
+// checkReadableFile reports whether path exists, is a regular file and can be
+// opened.
+func checkReadableFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// isAlbumURL reports whether an albums entry is a remote album URL rather
+// than a directory.
+func isAlbumURL(album string) bool {
+	lower := strings.ToLower(album)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+func checkAlbumURL(album string) error {
+	u, err := url.Parse(album)
+	if err != nil {
+		return err
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("%s has no host", u.Redacted())
+	}
+	return nil
+}
+
+func checkPublicURL(publicURL string) error {
+	u, err := url.Parse(publicURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q (use http:// or https://)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("%q has no host", publicURL)
+	}
+	return nil
+}
+
+// checkTileURL checks a tile address: an http(s) URL with the {z}, {x} and
+// {y} placeholders.
+func checkTileURL(tileURL string) error {
+	if err := checkPublicURL(tileURL); err != nil {
+		return err
+	}
+	for _, p := range []string{"{z}", "{x}", "{y}"} {
+		if !strings.Contains(tileURL, p) {
+			return fmt.Errorf("%q has no %s", tileURL, p)
+		}
+	}
+	return nil
+}
+
+func checkBrokerURL(broker string) error {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt", "ssl", "tls", "mqtts":
+	default:
+		return fmt.Errorf("unsupported scheme %q (use tcp:// or ssl://)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("%q has no host", broker)
+	}
+	return nil
+}
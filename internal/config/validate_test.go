@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	albums := t.TempDir()
+	locked := filepath.Join(t.TempDir(), "locked")
+	if err := os.Mkdir(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	notADir := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(notADir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		edit func(c *Config)
+		want []string // one per error, in order
+		root bool     // root can list any directory, so skip the case
+	}{
+		{name: "valid", edit: func(c *Config) {}},
+		{
+			name: "missing album",
+			edit: func(c *Config) { c.Albums = append(c.Albums, filepath.Join(albums, "gone")) },
+			want: []string{"albums: stat " + filepath.Join(albums, "gone") + ": no such file or directory"},
+		},
+		{
+			name: "album is a file",
+			edit: func(c *Config) { c.Albums = []string{notADir} },
+			want: []string{"albums: " + notADir + " is not a directory"},
+		},
+		{
+			name: "unreadable album",
+			edit: func(c *Config) { c.Albums = []string{locked} },
+			want: []string{"albums: open " + locked + ": permission denied"},
+			root: true,
+		},
+		{
+			name: "no albums",
+			edit: func(c *Config) { c.Albums = nil },
+			want: []string{"albums: at least one album directory (or a playlist) is required"},
+		},
+		{
+			name: "zero interval",
+			edit: func(c *Config) { c.Interval = 0 },
+			want: []string{"interval: must be a positive number of seconds, got 0"},
+		},
+		{
+			name: "negative interval",
+			edit: func(c *Config) { c.Interval = -5 },
+			want: []string{"interval: must be a positive number of seconds, got -5"},
+		},
+		{
+			name: "bad schedule time",
+			edit: func(c *Config) { c.Schedule.OnTime = "7am" },
+			want: []string{`schedule.onTime: "7am" is not a valid HH:MM time`},
+		},
+		{
+			name: "every problem reported together",
+			edit: func(c *Config) {
+				c.Albums = []string{notADir}
+				c.Interval = -1
+				c.Schedule.OffTime = "25:00"
+				c.PairAlign = "stretch"
+			},
+			want: []string{
+				"albums: " + notADir + " is not a directory",
+				"interval: must be a positive number of seconds, got -1",
+				`pairAlign: "stretch" is not one of fit, height`,
+				`schedule.offTime: "25:00" is not a valid HH:MM time`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.root && os.Geteuid() == 0 {
+				t.Skip("permissions do not apply to root")
+			}
+			cfg, err := Read(writeConfig(t, map[string]string{
+				"config.json": fmt.Sprintf(`{"albums": [%q]}`, albums),
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(&cfg)
+			var got []string
+			if err := cfg.Validate(); err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "00:00", want: 0},
+		{in: "07:30", want: 7*time.Hour + 30*time.Minute},
+		{in: "7:30", want: 7*time.Hour + 30*time.Minute},
+		{in: "23:59", want: 23*time.Hour + 59*time.Minute},
+		{in: "24:00", wantErr: true},
+		{in: "12:60", wantErr: true},
+		{in: "7:5", wantErr: true},
+		{in: "0730", wantErr: true},
+		{in: "07:30 ", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeOfDay(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTimeOfDay(%q) = %v, %v; want %v, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
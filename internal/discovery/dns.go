@@ -0,0 +1,217 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// The responder only needs a handful of record types, so DNS messages are
+// encoded by hand rather than pulling in a full DNS library.
+const (
+	typeA   = 1
+	typePTR = 12
+	typeTXT = 16
+	typeSRV = 33
+	typeANY = 255
+
+	classIN = 1
+	// cacheFlush marks a record set this host owns outright (RFC 6762 10.2).
+	cacheFlush = 0x8000
+	// unicastResponse is the top bit of a question's class (RFC 6762 5.4).
+	unicastResponse = 0x8000
+
+	flagResponse      = 0x8000
+	flagAuthoritative = 0x0400
+
+	// maxPointers bounds compression pointer chains in a malformed query.
+	maxPointers = 16
+	// maxNameLength is the longest a name may be on the wire (RFC 1035
+	// 3.1).
+	maxNameLength = 255
+)
+
+// question is one entry of a query's question section.
+type question struct {
+	name  string // fully qualified, with trailing dot
+	qtype uint16
+	class uint16
+}
+
+// record is one resource record to send.
+type record struct {
+	name  string
+	rtype uint16
+	flush bool
+	ttl   uint32
+	data  []byte
+}
+
+// message is a parsed query.
+type message struct {
+	id        uint16
+	response  bool
+	questions []question
+}
+
+var errMalformed = errors.New("malformed DNS message")
+
+// parseMessage decodes the header and question section of msg.
+func parseMessage(msg []byte) (message, error) {
+	if len(msg) < 12 {
+		return message{}, errMalformed
+	}
+	m := message{
+		id:       binary.BigEndian.Uint16(msg[0:]),
+		response: binary.BigEndian.Uint16(msg[2:])&flagResponse != 0,
+	}
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	off := 12
+	for i := 0; i < qdcount; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			return message{}, err
+		}
+		if next+4 > len(msg) {
+			return message{}, errMalformed
+		}
+		m.questions = append(m.questions, question{
+			name:  name,
+			qtype: binary.BigEndian.Uint16(msg[next:]),
+			class: binary.BigEndian.Uint16(msg[next+2:]),
+		})
+		off = next + 4
+	}
+	return m, nil
+}
+
+// readName decodes the possibly compressed name at off and returns it with
+// the offset just past it. Dots within a label are escaped as "\.", the
+// form appendName takes.
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	end := -1
+	length := 1 // the root label
+	for pointers := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errMalformed
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return strings.Join(labels, ".") + ".", end, nil
+		case n&0xc0 == 0xc0:
+			if off+1 >= len(msg) || pointers >= maxPointers {
+				return "", 0, errMalformed
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			pointers++
+		case n&0xc0 != 0:
+			return "", 0, errMalformed
+		default:
+			length += 1 + n
+			if off+1+n > len(msg) || length > maxNameLength {
+				return "", 0, errMalformed
+			}
+			labels = append(labels, strings.ReplaceAll(string(msg[off+1:off+1+n]), ".", `\.`))
+			off += 1 + n
+		}
+	}
+}
+
+// encodeResponse builds an authoritative response. Names are written without
+// compression; the messages stay far below the 9000 byte mDNS limit.
+func encodeResponse(id uint16, questions []question, answers, additional []record) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], flagResponse|flagAuthoritative)
+	binary.BigEndian.PutUint16(msg[4:], uint16(len(questions)))
+	binary.BigEndian.PutUint16(msg[6:], uint16(len(answers)))
+	binary.BigEndian.PutUint16(msg[10:], uint16(len(additional)))
+
+	for _, q := range questions {
+		msg = appendName(msg, q.name)
+		msg = binary.BigEndian.AppendUint16(msg, q.qtype)
+		msg = binary.BigEndian.AppendUint16(msg, q.class&^unicastResponse)
+	}
+	for _, r := range append(answers, additional...) {
+		msg = appendName(msg, r.name)
+		msg = binary.BigEndian.AppendUint16(msg, r.rtype)
+		class := uint16(classIN)
+		if r.flush {
+			class |= cacheFlush
+		}
+		msg = binary.BigEndian.AppendUint16(msg, class)
+		msg = binary.BigEndian.AppendUint32(msg, r.ttl)
+		msg = binary.BigEndian.AppendUint16(msg, uint16(len(r.data)))
+		msg = append(msg, r.data...)
+	}
+	return msg
+}
+
+// appendName writes name as uncompressed labels. Instance names may contain
+// dots, so callers escape those as "\.".
+func appendName(b []byte, name string) []byte {
+	for _, label := range splitName(name) {
+		if len(label) > 63 {
+			label = label[:63]
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+// splitName splits a name on unescaped dots. Empty labels, which would end
+// the name on the wire, are dropped, so the root "." has none.
+func splitName(name string) []string {
+	var labels []string
+	var label strings.Builder
+	for i := 0; i < len(name); i++ {
+		switch {
+		case name[i] == '\\' && i+1 < len(name):
+			i++
+			label.WriteByte(name[i])
+		case name[i] == '.':
+			if label.Len() > 0 {
+				labels = append(labels, label.String())
+			}
+			label.Reset()
+		default:
+			label.WriteByte(name[i])
+		}
+	}
+	if label.Len() > 0 {
+		labels = append(labels, label.String())
+	}
+	return labels
+}
+
+// sameName compares two names the way DNS does: case-insensitively.
+func sameName(a, b string) bool {
+	return strings.EqualFold(strings.Join(splitName(a), "."), strings.Join(splitName(b), "."))
+}
+
+func srvData(port uint16, target string) []byte {
+	b := make([]byte, 6) // priority and weight stay 0
+	binary.BigEndian.PutUint16(b[4:], port)
+	return appendName(b, target)
+}
+
+func txtData(entries ...string) []byte {
+	var b []byte
+	for _, e := range entries {
+		b = append(b, byte(len(e)))
+		b = append(b, e...)
+	}
+	if b == nil {
+		b = []byte{0} // a TXT record must hold at least one string
+	}
+	return b
+}
@@ -0,0 +1,220 @@
+package discovery
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// header returns a query header asking qdcount questions.
+func header(qdcount uint16) []byte {
+	h := make([]byte, 12)
+	binary.BigEndian.PutUint16(h[0:], 0x1234)
+	binary.BigEndian.PutUint16(h[4:], qdcount)
+	return h
+}
+
+func TestParseMessage(t *testing.T) {
+	ptrQuery := appendName(header(1), "_openframe._tcp.local.")
+	ptrQuery = binary.BigEndian.AppendUint16(ptrQuery, typePTR)
+	ptrQuery = binary.BigEndian.AppendUint16(ptrQuery, classIN|unicastResponse)
+
+	// The second question points back at the first name's "local".
+	compressed := slices.Clone(ptrQuery)
+	binary.BigEndian.PutUint16(compressed[4:], 2)
+	compressed = append(compressed, 5, 'f', 'r', 'a', 'm', 'e', 0xc0, byte(12+1+len("_openframe")+1+len("_tcp")))
+	compressed = binary.BigEndian.AppendUint16(compressed, typeA)
+	compressed = binary.BigEndian.AppendUint16(compressed, classIN)
+
+	tests := []struct {
+		name    string
+		msg     []byte
+		want    []question
+		wantErr bool
+	}{
+		{
+			name: "PTR query",
+			msg:  ptrQuery,
+			want: []question{{name: "_openframe._tcp.local.", qtype: typePTR, class: classIN | unicastResponse}},
+		},
+		{
+			name: "compressed name",
+			msg:  compressed,
+			want: []question{
+				{name: "_openframe._tcp.local.", qtype: typePTR, class: classIN | unicastResponse},
+				{name: "frame.local.", qtype: typeA, class: classIN},
+			},
+		},
+		{name: "no questions", msg: header(0)},
+		{name: "short header", msg: header(0)[:11], wantErr: true},
+		{name: "missing question", msg: header(1), wantErr: true},
+		{name: "question cut short", msg: ptrQuery[:len(ptrQuery)-1], wantErr: true},
+		{name: "name cut short", msg: append(header(1), 5, 'f', 'r'), wantErr: true},
+		{name: "pointer cut short", msg: append(header(1), 0xc0), wantErr: true},
+		{name: "pointer past the end", msg: append(header(1), 0xc0, 0xff, 0, 1, 0, 1), wantErr: true},
+		{name: "pointer to itself", msg: append(header(1), 0xc0, 12, 0, 1, 0, 1), wantErr: true},
+		{name: "pointer loop", msg: append(header(1), 1, 'a', 0xc0, 12, 0, 1, 0, 1), wantErr: true},
+		{name: "reserved label type", msg: append(header(1), 0x40, 'a', 0, 0, 1, 0, 1), wantErr: true},
+		{name: "name too long", msg: append(append(header(1), bytes.Repeat(append([]byte{63}, bytes.Repeat([]byte{'a'}, 63)...), 4)...), 0, 0, 1, 0, 1), wantErr: true},
+		{name: "more questions than sent", msg: append(slices.Clone(ptrQuery[:4]), append([]byte{0xff, 0xff}, ptrQuery[6:]...)...), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseMessage(tt.msg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMessage() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil && (m.id != 0x1234 || m.response) {
+				t.Errorf("parseMessage() id %#x, response %t; want 0x1234, false", m.id, m.response)
+			}
+			if !slices.Equal(m.questions, tt.want) {
+				t.Errorf("parseMessage() questions = %+v, want %+v", m.questions, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels []string
+	}{
+		{name: "_openframe._tcp.local.", labels: []string{"_openframe", "_tcp", "local"}},
+		{name: "frame.local", labels: []string{"frame", "local"}},
+		{name: `Mum\.s Frame._openframe._tcp.local.`, labels: []string{"Mum.s Frame", "_openframe", "_tcp", "local"}},
+		{name: "frame..local.", labels: []string{"frame", "local"}},
+		{name: "."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitName(tt.name); !slices.Equal(got, tt.labels) {
+				t.Errorf("splitName() = %q, want %q", got, tt.labels)
+			}
+			wire := appendName([]byte{0xaa}, tt.name)
+			got, end, err := readName(wire, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if end != len(wire) || !sameName(got, tt.name) {
+				t.Errorf("readName(appendName()) = %q ending at %d, want %q ending at %d", got, end, tt.name, len(wire))
+			}
+		})
+	}
+}
+
+func TestAppendNameTruncatesLongLabels(t *testing.T) {
+	wire := appendName(nil, strings.Repeat("x", 70)+".local.")
+	if wire[0] != 63 || len(wire) != 1+63+1+len("local")+1 {
+		t.Errorf("appendName() = % x, want a 63-byte first label", wire)
+	}
+}
+
+func TestEncodeResponse(t *testing.T) {
+	questions := []question{{name: "frame.local.", qtype: typeA, class: classIN | unicastResponse}}
+	answers := []record{{name: "frame.local.", rtype: typeA, flush: true, ttl: hostTTL, data: []byte{192, 168, 1, 20}}}
+	additional := []record{{name: "_openframe._tcp.local.", rtype: typePTR, ttl: otherTTL, data: appendName(nil, "Frame._openframe._tcp.local.")}}
+	msg := encodeResponse(0x1234, questions, answers, additional)
+
+	m, err := parseMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.response || m.id != 0x1234 {
+		t.Errorf("parsed id %#x, response %t; want 0x1234, true", m.id, m.response)
+	}
+	// The unicast-response bit is a query's, not echoed back.
+	if want := []question{{name: "frame.local.", qtype: typeA, class: classIN}}; !slices.Equal(m.questions, want) {
+		t.Errorf("questions = %+v, want %+v", m.questions, want)
+	}
+	if flags := binary.BigEndian.Uint16(msg[2:]); flags != flagResponse|flagAuthoritative {
+		t.Errorf("flags = %#x, want an authoritative response", flags)
+	}
+	if an, ns, ar := binary.BigEndian.Uint16(msg[6:]), binary.BigEndian.Uint16(msg[8:]), binary.BigEndian.Uint16(msg[10:]); an != 1 || ns != 0 || ar != 1 {
+		t.Errorf("counts = %d answers, %d authority, %d additional; want 1, 0, 1", an, ns, ar)
+	}
+
+	// Walk the records after the question.
+	_, off, _ := readName(msg, 12)
+	off += 4
+	for _, want := range append(answers, additional...) {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rtype := binary.BigEndian.Uint16(msg[next:])
+		class := binary.BigEndian.Uint16(msg[next+2:])
+		ttl := binary.BigEndian.Uint32(msg[next+4:])
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		data := msg[next+10 : next+10+length]
+		wantClass := uint16(classIN)
+		if want.flush {
+			wantClass |= cacheFlush
+		}
+		if name != want.name || rtype != want.rtype || class != wantClass || ttl != want.ttl || !bytes.Equal(data, want.data) {
+			t.Errorf("record %s type %d class %#x ttl %d data % x, want %+v", name, rtype, class, ttl, data, want)
+		}
+		off = next + 10 + length
+	}
+	if off != len(msg) {
+		t.Errorf("%d bytes left over after the records", len(msg)-off)
+	}
+}
+
+func TestRecordsFor(t *testing.T) {
+	a := &Advertiser{
+		instance: `Mum\.s Frame._openframe._tcp.local.`,
+		service:  "_openframe._tcp.local.",
+		host:     "frame.local.",
+		port:     8080,
+	}
+	tests := []struct {
+		name  string
+		q     question
+		want  []uint16 // answer types; A records depend on the host's addresses
+		empty bool
+	}{
+		{name: "service browse", q: question{name: servicesName, qtype: typePTR}, want: []uint16{typePTR}},
+		{name: "service PTR", q: question{name: "_OpenFrame._tcp.local.", qtype: typePTR}, want: []uint16{typePTR}},
+		{name: "instance SRV", q: question{name: "Mum\\.s frame._openframe._tcp.local.", qtype: typeSRV}, want: []uint16{typeSRV, typeTXT}},
+		{name: "instance TXT", q: question{name: a.instance, qtype: typeTXT}, want: []uint16{typeSRV, typeTXT}},
+		{name: "instance ANY", q: question{name: a.instance, qtype: typeANY}, want: []uint16{typeSRV, typeTXT}},
+		{name: "host A", q: question{name: "FRAME.local.", qtype: typeA}},
+		{name: "instance A", q: question{name: a.instance, qtype: typeA}, empty: true},
+		{name: "host AAAA", q: question{name: a.host, qtype: 28}, empty: true},
+		{name: "another service", q: question{name: "_http._tcp.local.", qtype: typePTR}, empty: true},
+		{name: "another host", q: question{name: "printer.local.", qtype: typeA}, empty: true},
+		{name: "another instance", q: question{name: "Other._openframe._tcp.local.", qtype: typeSRV}, empty: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answers, additional := a.recordsFor(tt.q)
+			if tt.empty {
+				if len(answers)+len(additional) != 0 {
+					t.Errorf("recordsFor() = %+v, %+v; want nothing", answers, additional)
+				}
+				return
+			}
+			var got []uint16
+			for _, r := range answers {
+				if r.rtype == typeA {
+					if !sameName(r.name, a.host) {
+						t.Errorf("A record for %s, want %s", r.name, a.host)
+					}
+					continue
+				}
+				got = append(got, r.rtype)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("recordsFor() answer types = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The SRV record points browsers at the host and port.
+	answers, _ := a.recordsFor(question{name: a.instance, qtype: typeSRV})
+	if want := srvData(8080, "frame.local."); !bytes.Equal(answers[0].data, want) {
+		t.Errorf("SRV data = % x, want % x", answers[0].data, want)
+	}
+}
@@ -0,0 +1,235 @@
+// Package discovery advertises the HTTP API on the local network over
+// multicast DNS (Bonjour), so phone apps can find the frame without knowing
+// its address.
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ServiceType is the DNS-SD service the frame registers.
+	ServiceType = "_openframe._tcp"
+
+	servicesName = "_services._dns-sd._udp.local."
+	mdnsPort     = 5353
+
+	// TTLs recommended by RFC 6762 10: host-bound records expire quickly,
+	// the others may be cached longer.
+	hostTTL  = 120
+	otherTTL = 4500
+
+	announceCount    = 2
+	announceInterval = time.Second
+)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: mdnsPort}
+
+// Advertiser answers mDNS queries for one service instance until Close.
+// Only IPv4 is served, and the instance name is not probed for conflicts.
+type Advertiser struct {
+	conn     *net.UDPConn
+	instance string // e.g. `Living Room._openframe._tcp.local.`, dots in the label escaped
+	service  string
+	host     string
+	port     uint16
+
+	closeOnce sync.Once
+	done      chan struct{} // closed when Close starts
+	closed    chan struct{} // closed once the goodbye has been sent
+}
+
+// Advertise starts announcing an _openframe._tcp service called name on
+// port, served by this host, until ctx is cancelled or Close is called.
+func Advertise(ctx context.Context, name string, port int) (*Advertiser, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("determine host name: %w", err)
+	}
+	hostname, _, _ = strings.Cut(hostname, ".")
+
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return nil, fmt.Errorf("join mDNS group: %w", err)
+	}
+
+	service := ServiceType + ".local."
+	a := &Advertiser{
+		conn:     conn,
+		instance: strings.ReplaceAll(name, ".", `\.`) + "." + service,
+		service:  service,
+		host:     hostname + ".local.",
+		port:     uint16(port),
+		done:     make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	go a.serve()
+	go a.announce()
+	go func() {
+		select {
+		case <-ctx.Done():
+			a.Close()
+		case <-a.done:
+		}
+	}()
+	log.Printf("Advertising %q (%s) on port %d via mDNS", name, ServiceType, port)
+	return a, nil
+}
+
+// Close sends a goodbye so clients drop the service at once, then stops
+// answering. The host's address records are left alone, since other
+// responders (such as avahi) may publish them too. It is safe to call more
+// than once, and on a nil Advertiser.
+func (a *Advertiser) Close() {
+	if a == nil {
+		return
+	}
+	a.closeOnce.Do(func() {
+		close(a.done)
+		a.send(encodeResponse(0, nil, a.serviceRecords(0), nil), mdnsGroup)
+		a.conn.Close()
+		close(a.closed)
+	})
+}
+
+// Done returns a channel closed once the goodbye has been sent. For a nil
+// Advertiser it is already closed.
+func (a *Advertiser) Done() <-chan struct{} {
+	if a == nil {
+		return closedChan
+	}
+	return a.closed
+}
+
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// announce sends unsolicited responses so caches pick up the service
+// straight away (RFC 6762 8.3).
+func (a *Advertiser) announce() {
+	for i := 0; i < announceCount; i++ {
+		if i > 0 {
+			select {
+			case <-a.done:
+				return
+			case <-time.After(announceInterval):
+			}
+		}
+		a.send(encodeResponse(0, nil, a.serviceRecords(1), a.hostRecords(1)), mdnsGroup)
+	}
+}
+
+// serve answers queries until the connection is closed.
+func (a *Advertiser) serve() {
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("mDNS: read failed: %v", err)
+			}
+			return
+		}
+		query, err := parseMessage(buf[:n])
+		if err != nil || query.response {
+			continue
+		}
+		a.answer(query, from)
+	}
+}
+
+// answer replies to the questions in query that concern this service.
+func (a *Advertiser) answer(query message, from *net.UDPAddr) {
+	var answers, additional []record
+	unicast := false
+	for _, q := range query.questions {
+		recs, extra := a.recordsFor(q)
+		if len(recs) == 0 {
+			continue
+		}
+		answers = append(answers, recs...)
+		additional = append(additional, extra...)
+		unicast = unicast || q.class&unicastResponse != 0
+	}
+	if len(answers) == 0 {
+		return
+	}
+
+	// Legacy resolvers (not sending from port 5353) need a plain unicast
+	// DNS reply echoing their ID and questions (RFC 6762 6.7).
+	if from.Port != mdnsPort {
+		a.send(encodeResponse(query.id, query.questions, answers, nil), from)
+		return
+	}
+	to := mdnsGroup
+	if unicast {
+		to = from
+	}
+	a.send(encodeResponse(0, nil, answers, additional), to)
+}
+
+// recordsFor returns the answers to q and the records worth adding to them.
+func (a *Advertiser) recordsFor(q question) (answers, additional []record) {
+	matches := func(name string, rtype uint16) bool {
+		return sameName(q.name, name) && (q.qtype == rtype || q.qtype == typeANY)
+	}
+	switch {
+	case matches(servicesName, typePTR):
+		return []record{{name: servicesName, rtype: typePTR, ttl: otherTTL, data: appendName(nil, a.service)}}, nil
+	case matches(a.service, typePTR):
+		return a.serviceRecords(1)[:1], append(a.serviceRecords(1)[1:], a.hostRecords(1)...)
+	case sameName(q.name, a.instance) && (q.qtype == typeSRV || q.qtype == typeTXT || q.qtype == typeANY):
+		return a.serviceRecords(1)[1:], a.hostRecords(1)
+	case matches(a.host, typeA):
+		return a.hostRecords(1), nil
+	}
+	return nil, nil
+}
+
+// serviceRecords returns the PTR, SRV and TXT records for the instance. TTLs
+// are multiplied by scale, so 0 produces goodbye records.
+func (a *Advertiser) serviceRecords(scale uint32) []record {
+	return []record{
+		{name: a.service, rtype: typePTR, ttl: otherTTL * scale, data: appendName(nil, a.instance)},
+		{name: a.instance, rtype: typeSRV, flush: true, ttl: hostTTL * scale, data: srvData(a.port, a.host)},
+		{name: a.instance, rtype: typeTXT, flush: true, ttl: otherTTL * scale, data: txtData("path=/")},
+	}
+}
+
+// hostRecords returns an A record for each IPv4 address of the host, with
+// TTLs multiplied by scale.
+func (a *Advertiser) hostRecords(scale uint32) []record {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		log.Printf("mDNS: list addresses: %v", err)
+		return nil
+	}
+	var recs []record
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() {
+			continue
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			recs = append(recs, record{name: a.host, rtype: typeA, flush: true, ttl: hostTTL * scale, data: ip4})
+		}
+	}
+	return recs
+}
+
+func (a *Advertiser) send(msg []byte, to *net.UDPAddr) {
+	if _, err := a.conn.WriteToUDP(msg, to); err != nil {
+		log.Printf("mDNS: send failed: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+// Package favorites persists the photos flagged as favorites from the remote
+// in a single favorites.json under the state directory.
+package favorites
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const fileName = "favorites.json"
+
+// Store is the set of favorite photo paths. It is safe for concurrent use;
+// every change is written straight through to disk.
+type Store struct {
+	path string
+
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+// fileFormat is the on-disk layout, kept sorted so diffs stay readable.
+type fileFormat struct {
+	Favorites []string `json:"favorites"`
+}
+
+// Open loads the favorites kept under stateDir. A missing file is an empty
+// set.
+func Open(stateDir string) (*Store, error) {
+	s := &Store{
+		path:  filepath.Join(stateDir, fileName),
+		paths: make(map[string]bool),
+	}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read favorites: %w", err)
+	}
+	var f fileFormat
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("unmarshal favorites: %w", err)
+	}
+	for _, p := range f.Favorites {
+		s.paths[p] = true
+	}
+	return s, nil
+}
+
+// Has reports whether the photo at path is a favorite.
+func (s *Store) Has(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paths[path]
+}
+
+// Set marks or unmarks the photo at path as a favorite and saves the set.
+// On error the in-memory set is left unchanged.
+func (s *Store) Set(path string, favorite bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paths[path] == favorite {
+		return nil
+	}
+
+	if favorite {
+		s.paths[path] = true
+	} else {
+		delete(s.paths, path)
+	}
+	if err := s.save(); err != nil {
+		if favorite {
+			delete(s.paths, path)
+		} else {
+			s.paths[path] = true
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the set atomically; s.mu must be held.
+func (s *Store) save() error {
+	f := fileFormat{Favorites: make([]string, 0, len(s.paths))}
+	for p := range s.paths {
+		f.Favorites = append(f.Favorites, p)
+	}
+	sort.Strings(f.Favorites)
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal favorites: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create favorites directory: %w", err)
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("write favorites: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("replace favorites: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package favorites
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []bool // favorite values passed to Set, in order
+		want  bool
+	}{
+		{name: "never set"},
+		{name: "marked", steps: []bool{true}, want: true},
+		{name: "marked twice", steps: []bool{true, true}, want: true},
+		{name: "unmarked", steps: []bool{true, false}},
+		{name: "unmarked without being marked", steps: []bool{false}},
+		{name: "marked again", steps: []bool{true, false, true}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s, err := Open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, favorite := range tt.steps {
+				if err := s.Set("/albums/a.jpg", favorite); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := s.Has("/albums/a.jpg"); got != tt.want {
+				t.Errorf("Has() = %t, want %t", got, tt.want)
+			}
+			if s.Has("/albums/b.jpg") {
+				t.Error("Has() = true for a photo never marked")
+			}
+
+			// The set survives a reopen.
+			reopened, err := Open(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := reopened.Has("/albums/a.jpg"); got != tt.want {
+				t.Errorf("Has() after reopening = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // of favorites.json; none when empty
+		want    []string
+		wantErr bool
+	}{
+		{name: "no file"},
+		{name: "saved set", content: `{"favorites": ["/albums/a.jpg", "/albums/b.jpg"]}`, want: []string{"/albums/a.jpg", "/albums/b.jpg"}},
+		{name: "corrupt", content: `{"favorites": [`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.content != "" {
+				if err := os.WriteFile(filepath.Join(dir, fileName), []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s, err := Open(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Open() error = %v, want error %t", err, tt.wantErr)
+			}
+			for _, path := range tt.want {
+				if !s.Has(path) {
+					t.Errorf("Has(%q) = false, want true", path)
+				}
+			}
+		})
+	}
+}
+
+func TestSetRollsBackWhenSaveFails(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("/albums/a.jpg", true); err != nil {
+		t.Fatal(err)
+	}
+	// Permissions don't stop root, but a directory where the temporary file
+	// goes stops everyone.
+	if err := os.Mkdir(filepath.Join(dir, fileName+".tmp"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Set("/albums/b.jpg", true); err == nil {
+		t.Error("Set(b, true) succeeded without saving")
+	}
+	if s.Has("/albums/b.jpg") {
+		t.Error("b is a favorite after a failed save")
+	}
+	if err := s.Set("/albums/a.jpg", false); err == nil {
+		t.Error("Set(a, false) succeeded without saving")
+	}
+	if !s.Has("/albums/a.jpg") {
+		t.Error("a is no longer a favorite after a failed save")
+	}
+}
+
+func TestSetConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each photo is marked, unmarked and marked again by its own goroutine
+	// while the others do the same.
+	const photos = 20
+	var wg sync.WaitGroup
+	for i := range photos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path := fmt.Sprintf("/albums/%d.jpg", i)
+			for _, favorite := range []bool{true, false, true} {
+				if err := s.Set(path, favorite); err != nil {
+					t.Error(err)
+				}
+				s.Has(path)
+			}
+		}()
+	}
+	wg.Wait()
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range photos {
+		if path := fmt.Sprintf("/albums/%d.jpg", i); !reopened.Has(path) {
+			t.Errorf("%s is not a favorite after reopening", path)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+// Package httpapi serves the optional HTTP API for browsing the photo library
+// and adding photos over the network.
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/cec"
+	"github.com/electronjoe/OpenFrame/internal/config"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+	"github.com/electronjoe/OpenFrame/internal/player"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+
+	// shutdownTimeout bounds how long in-flight requests may run on after
+	// the server is told to stop.
+	shutdownTimeout = 5 * time.Second
+
+	// uploadField is the multipart form field holding the photo.
+	uploadField = "file"
+)
+
+// Server answers:
+//
+//	GET  /photos?offset=N&limit=M  a page of the photo library as JSON
+//	POST /upload                   a multipart "file" saved to the upload album
+//	GET  /current                  the slide on screen and the playback state
+//	POST /commands/{name}          a remote command such as "next" or "blank"
+//	GET  /shared/{key}[/{n}]       a photo offered with Share
+//
+// When a token is configured every request but those for shared photos must
+// carry it as "Authorization: Bearer <token>".
+type Server struct {
+	cfg    config.HTTP
+	rescan func()
+	done   chan struct{} // closed once the server has shut down
+
+	mu       sync.Mutex
+	photos   []photo.Photo
+	shares   []share // oldest first
+	commands chan<- cec.RemoteCommand
+	status   func() player.Status
+}
+
+// photoJSON is one entry of the GET /photos listing.
+type photoJSON struct {
+	Path      string    `json:"path"`
+	TakenTime time.Time `json:"takenTime"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+}
+
+// currentJSON is the GET /current response.
+type currentJSON struct {
+	Index    int      `json:"index"`
+	Total    int      `json:"total"`
+	Photos   []string `json:"photos"`
+	Paused   bool     `json:"paused"`
+	Held     bool     `json:"held"`
+	Blanked  bool     `json:"blanked"`
+	Interval float64  `json:"interval"` // in seconds
+	Error    string   `json:"error,omitempty"`
+}
+
+// photoPage is the GET /photos response.
+type photoPage struct {
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Photos []photoJSON `json:"photos"`
+}
+
+// New returns a Server for cfg, or nil when the API is disabled; all Server
+// methods are no-ops on a nil Server. Call Start once the slideshow exists.
+func New(cfg config.HTTP) *Server {
+	if !cfg.Enabled() {
+		return nil
+	}
+	return &Server{cfg: cfg, done: make(chan struct{})}
+}
+
+// Start listens on the configured address in a background goroutine until
+// ctx is cancelled. rescan is called after each successful upload so the new
+// photo joins the slideshow.
+func (s *Server) Start(ctx context.Context, rescan func()) {
+	if s == nil {
+		return
+	}
+	s.rescan = rescan
+	srv := &http.Server{
+		Addr:              s.cfg.Listen,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		log.Printf("HTTP API listening on %s", s.cfg.Listen)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("HTTP API stopped: %v", err)
+		}
+	}()
+	go func() {
+		defer close(s.done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("HTTP API shutdown: %v", err)
+		}
+	}()
+}
+
+// Done returns a channel closed once the server has shut down after its
+// context was cancelled. For a nil Server it is already closed.
+func (s *Server) Done() <-chan struct{} {
+	if s == nil {
+		return closedChan
+	}
+	return s.done
+}
+
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// SetPhotos replaces the library listed by GET /photos. It is safe to call
+// from any goroutine, e.g. from a rescan.
+func (s *Server) SetPhotos(photos []photo.Photo) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.photos = photos
+	s.mu.Unlock()
+}
+
+// SetCommandChan sets the channel POST /commands delivers remote commands
+// to, the one the player reads. Until it is set the endpoint answers 503.
+func (s *Server) SetCommandChan(ch chan<- cec.RemoteCommand) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.commands = ch
+	s.mu.Unlock()
+}
+
+// SetStatusFunc sets where GET /current reads the slideshow's state from,
+// typically the player's Status, which is safe to call from the server's
+// goroutines. Until it is set the endpoint answers 503.
+func (s *Server) SetStatusFunc(fn func() player.Status) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.status = fn
+	s.mu.Unlock()
+}
+
+// Handler returns the API's routes, wrapped in the token check. Shared
+// photos are guarded by their key instead.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /photos", s.handlePhotos)
+	mux.HandleFunc("POST /upload", s.handleUpload)
+	mux.HandleFunc("GET /current", s.handleCurrent)
+	mux.HandleFunc("POST /commands/{name}", s.handleCommand)
+
+	root := http.NewServeMux()
+	root.Handle("/", s.requireToken(mux))
+	root.HandleFunc("GET /shared/{key}", s.handleShared)
+	root.HandleFunc("GET /shared/{key}/{n}", s.handleShared)
+	return root
+}
+
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	if s.cfg.Token == "" {
+		return next
+	}
+	want := []byte("Bearer " + s.cfg.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			http.Error(w, "missing or wrong token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handlePhotos(w http.ResponseWriter, r *http.Request) {
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		http.Error(w, "offset must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+	limit, err := queryInt(r, "limit", defaultPageSize)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxPageSize), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	photos := s.photos
+	s.mu.Unlock()
+
+	page := photoPage{Total: len(photos), Offset: offset, Photos: []photoJSON{}}
+	for _, p := range photos[min(offset, len(photos)):min(offset+limit, len(photos))] {
+		page.Photos = append(page.Photos, photoJSON{
+			Path:      p.FilePath,
+			TakenTime: p.TakenTime,
+			Width:     p.Width,
+			Height:    p.Height,
+		})
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.UploadDir == "" {
+		http.Error(w, "uploads are disabled; set http.uploadDir", http.StatusForbidden)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, int64(s.cfg.MaxUploadMB)<<20)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "expected a multipart/form-data body", http.StatusUnsupportedMediaType)
+		return
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			http.Error(w, "missing \""+uploadField+"\" field", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			uploadError(w, err)
+			return
+		}
+		if part.FormName() != uploadField {
+			continue
+		}
+
+		name := filepath.Base(part.FileName())
+		if !photo.IsImageFile(name) {
+			http.Error(w, fmt.Sprintf("%q is not a supported image file", name), http.StatusUnsupportedMediaType)
+			return
+		}
+		if !acceptedContentType(name, part.Header.Get("Content-Type")) {
+			http.Error(w, "content type must be an image type", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		dest, err := saveUpload(s.cfg.UploadDir, name, part)
+		if err != nil {
+			uploadError(w, err)
+			return
+		}
+		log.Printf("HTTP API: uploaded %s", dest)
+		if s.rescan != nil {
+			s.rescan()
+		}
+		writeJSON(w, http.StatusCreated, map[string]string{"path": dest})
+		return
+	}
+}
+
+func (s *Server) handleCurrent(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+	if status == nil {
+		http.Error(w, "the slideshow is not running yet", http.StatusServiceUnavailable)
+		return
+	}
+	st := status()
+	photos := st.Photos
+	if photos == nil {
+		photos = []string{}
+	}
+	writeJSON(w, http.StatusOK, currentJSON{
+		Index:    st.Index,
+		Total:    st.Total,
+		Photos:   photos,
+		Paused:   st.Paused,
+		Held:     st.Held,
+		Blanked:  st.Blanked,
+		Interval: st.Interval.Seconds(),
+		Error:    st.Error,
+	})
+}
+
+func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	cmd, ok := cec.ParseRemoteCommand(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown command %q", name), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	commands := s.commands
+	s.mu.Unlock()
+
+	if commands == nil {
+		http.Error(w, "the slideshow is not taking commands yet", http.StatusServiceUnavailable)
+		return
+	}
+	// Don't hold the request up behind a stalled player.
+	select {
+	case commands <- cmd:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "too many commands pending", http.StatusServiceUnavailable)
+	}
+}
+
+// acceptedContentType checks a part's declared type. Browsers rarely know a
+// MIME type for camera RAW files, so those may also be sent as octet-stream.
+func acceptedContentType(name, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "image/") {
+		return true
+	}
+	return photo.IsRawFile(name) && mediaType == "application/octet-stream"
+}
+
+// saveUpload writes src into dir under name, adding a numeric suffix rather
+// than overwriting an existing photo. The data is written to a temporary file
+// first, so a scan never sees a half-written photo.
+func saveUpload(dir, name string, src io.Reader) (string, error) {
+	// No image extension, so a concurrent scan skips it.
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	dest, err := claimName(dir, name)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		os.Remove(tmp.Name())
+		os.Remove(dest)
+		return "", err
+	}
+	return dest, nil
+}
+
+// claimName creates an empty file for name in dir, or for name with a
+// numeric suffix if it is taken, and returns its path.
+func claimName(dir, name string) (string, error) {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 0; ; i++ {
+		dest := filepath.Join(dir, name)
+		if i > 0 {
+			dest = filepath.Join(dir, stem+"-"+strconv.Itoa(i)+ext)
+		}
+		f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return dest, f.Close()
+	}
+}
+
+// uploadError reports a failed upload, distinguishing an oversized body.
+func uploadError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("upload exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	log.Printf("HTTP API: upload failed: %v", err)
+	http.Error(w, "upload failed", http.StatusInternalServerError)
+}
+
+func queryInt(r *http.Request, key string, def int) (int, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("HTTP API: write response: %v", err)
+	}
+}
@@ -0,0 +1,424 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/cec"
+	"github.com/electronjoe/OpenFrame/internal/config"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+	"github.com/electronjoe/OpenFrame/internal/player"
+)
+
+// uploadRequest builds a POST /upload carrying data as the file name.
+func uploadRequest(t *testing.T, name, contentType string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, uploadField, name))
+	h.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestUpload(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    []string
+		fileName    string
+		contentType string
+		size        int
+		wantStatus  int
+		wantFile    string // relative to the upload directory
+	}{
+		{
+			name:        "saved under its own name",
+			fileName:    "beach.jpg",
+			contentType: "image/jpeg",
+			size:        10,
+			wantStatus:  http.StatusCreated,
+			wantFile:    "beach.jpg",
+		},
+		{
+			name:        "directories are stripped",
+			fileName:    "../../etc/beach.jpg",
+			contentType: "image/jpeg",
+			size:        10,
+			wantStatus:  http.StatusCreated,
+			wantFile:    "beach.jpg",
+		},
+		{
+			name:        "a taken name gets a suffix",
+			existing:    []string{"beach.jpg", "beach-1.jpg"},
+			fileName:    "beach.jpg",
+			contentType: "image/jpeg",
+			size:        10,
+			wantStatus:  http.StatusCreated,
+			wantFile:    "beach-2.jpg",
+		},
+		{
+			name:        "RAW as octet-stream",
+			fileName:    "beach.CR2",
+			contentType: "application/octet-stream",
+			size:        10,
+			wantStatus:  http.StatusCreated,
+			wantFile:    "beach.CR2",
+		},
+		{
+			name:        "not an image",
+			fileName:    "notes.txt",
+			contentType: "text/plain",
+			size:        10,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "JPEG as octet-stream",
+			fileName:    "beach.jpg",
+			contentType: "application/octet-stream",
+			size:        10,
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "too large",
+			fileName:    "beach.jpg",
+			contentType: "image/jpeg",
+			size:        2 << 20,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte("old"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			rescans := 0
+			s := New(config.HTTP{Listen: ":0", UploadDir: dir, MaxUploadMB: 1})
+			s.rescan = func() { rescans++ }
+
+			data := bytes.Repeat([]byte{0xff}, tt.size)
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, uploadRequest(t, tt.fileName, tt.contentType, data))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", w.Code, strings.TrimSpace(w.Body.String()), tt.wantStatus)
+			}
+			if tt.wantFile == "" {
+				// Nothing new is left behind, not even a temporary file.
+				entries, _ := os.ReadDir(dir)
+				if len(entries) != len(tt.existing) || rescans != 0 {
+					t.Errorf("left %d files and rescanned %d times after a failed upload", len(entries), rescans)
+				}
+				return
+			}
+			var resp map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(dir, tt.wantFile); resp["path"] != want {
+				t.Errorf("path = %q, want %q", resp["path"], want)
+			}
+			if got, err := os.ReadFile(filepath.Join(dir, tt.wantFile)); err != nil || !bytes.Equal(got, data) {
+				t.Errorf("saved file does not hold the upload (err %v)", err)
+			}
+			if rescans != 1 {
+				t.Errorf("rescanned %d times, want 1", rescans)
+			}
+		})
+	}
+}
+
+func TestPhotos(t *testing.T) {
+	taken := time.Date(2024, 7, 14, 16, 2, 5, 0, time.UTC)
+	var photos []photo.Photo
+	for i := range 5 {
+		photos = append(photos, photo.Photo{FilePath: fmt.Sprintf("/albums/%d.jpg", i), TakenTime: taken, Width: 4000, Height: 3000})
+	}
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantPaths  []string
+	}{
+		{name: "default page", query: "", wantStatus: http.StatusOK, wantPaths: []string{"/albums/0.jpg", "/albums/1.jpg", "/albums/2.jpg", "/albums/3.jpg", "/albums/4.jpg"}},
+		{name: "offset and limit", query: "?offset=1&limit=2", wantStatus: http.StatusOK, wantPaths: []string{"/albums/1.jpg", "/albums/2.jpg"}},
+		{name: "last page runs short", query: "?offset=4&limit=2", wantStatus: http.StatusOK, wantPaths: []string{"/albums/4.jpg"}},
+		{name: "past the end", query: "?offset=9", wantStatus: http.StatusOK, wantPaths: []string{}},
+		{name: "negative offset", query: "?offset=-1", wantStatus: http.StatusBadRequest},
+		{name: "limit too large", query: "?limit=1001", wantStatus: http.StatusBadRequest},
+		{name: "limit not a number", query: "?limit=ten", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(config.HTTP{Listen: ":0"})
+			s.SetPhotos(photos)
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/photos"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var page photoPage
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, p := range page.Photos {
+				got = append(got, p.Path)
+			}
+			if page.Total != len(photos) || !slices.Equal(got, tt.wantPaths) {
+				t.Errorf("got %d of %d: %q, want %q", len(got), page.Total, got, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+	}{
+		{name: "missing", wantStatus: http.StatusUnauthorized},
+		{name: "wrong", header: "Bearer nope", wantStatus: http.StatusUnauthorized},
+		{name: "right", header: "Bearer secret", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(config.HTTP{Listen: ":0", Token: "secret"})
+			req := httptest.NewRequest(http.MethodGet, "/photos", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestShared(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.jpg")
+	os.WriteFile(a, []byte("photo a"), 0o644)
+	os.WriteFile(b, []byte("photo b"), 0o644)
+
+	s := New(config.HTTP{Listen: ":8080", Token: "secret", PublicURL: "http://frame.local:8080/"})
+	// A remote photo is not offered.
+	if link := s.Share([]string{"https://nas.local/c.jpg"}); link != "" {
+		t.Errorf("Share() of a remote photo = %q, want none", link)
+	}
+	one := strings.TrimPrefix(s.Share([]string{a, "https://nas.local/c.jpg"}), "http://frame.local:8080")
+	pair := strings.TrimPrefix(s.Share([]string{a, b}), "http://frame.local:8080")
+	if !strings.HasPrefix(one, "/shared/") || !strings.HasPrefix(pair, "/shared/") || one == pair {
+		t.Fatalf("Share() = %q and %q, want two links under /shared/", one, pair)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "shared photo", path: one, wantStatus: http.StatusOK, wantBody: "photo a"},
+		{name: "pair lists both", path: pair, wantStatus: http.StatusOK, wantBody: `<a href="` + pair + `/1">b.jpg</a>`},
+		{name: "photo of a pair", path: pair + "/1", wantStatus: http.StatusOK, wantBody: "photo b"},
+		{name: "past the end of a pair", path: pair + "/2", wantStatus: http.StatusNotFound},
+		{name: "unknown key", path: "/shared/0123456789abcdef0123456789abcdef", wantStatus: http.StatusNotFound},
+		{name: "no key needs the token", path: "/shared/", wantStatus: http.StatusUnauthorized},
+		{name: "lookalike path needs the token", path: "/sharedx" + strings.TrimPrefix(one, "/shared"), wantStatus: http.StatusUnauthorized},
+		{name: "POST needs the token", method: http.MethodPost, path: one, wantStatus: http.StatusUnauthorized},
+		{name: "API needs the token", path: "/photos", wantStatus: http.StatusUnauthorized},
+		{name: "API with the token", path: "/photos", token: true, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			if tt.token {
+				req.Header.Set("Authorization", "Bearer secret")
+			}
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestShareEvictsOldest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.jpg")
+	os.WriteFile(path, []byte("photo a"), 0o644)
+	s := New(config.HTTP{Listen: ":8080", PublicURL: "http://frame.local:8080"})
+
+	var links []string
+	for range maxShares + 1 {
+		links = append(links, strings.TrimPrefix(s.Share([]string{path}), "http://frame.local:8080"))
+	}
+	for i, link := range links {
+		want := http.StatusOK
+		if i == 0 {
+			want = http.StatusNotFound
+		}
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, link, nil))
+		if w.Code != want {
+			t.Errorf("share %d: status = %d, want %d", i, w.Code, want)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		command    string
+		noChan     bool
+		full       bool
+		wantStatus int
+		want       cec.RemoteCommand
+	}{
+		{name: "prev", command: "prev", wantStatus: http.StatusNoContent, want: cec.RemoteLeft},
+		{name: "next", command: "next", wantStatus: http.StatusNoContent, want: cec.RemoteRight},
+		{name: "pause", command: "pause", wantStatus: http.StatusNoContent, want: cec.RemoteSelect},
+		{name: "delete", command: "delete", wantStatus: http.StatusNoContent, want: cec.RemoteDelete},
+		{name: "favorite", command: "favorite", wantStatus: http.StatusNoContent, want: cec.RemoteFavorite},
+		{name: "hold", command: "hold", wantStatus: http.StatusNoContent, want: cec.RemoteHold},
+		{name: "faster", command: "faster", wantStatus: http.StatusNoContent, want: cec.RemoteFaster},
+		{name: "slower", command: "slower", wantStatus: http.StatusNoContent, want: cec.RemoteSlower},
+		{name: "blank", command: "blank", wantStatus: http.StatusNoContent, want: cec.RemoteBlank},
+		{name: "rescan", command: "rescan", wantStatus: http.StatusNoContent, want: cec.RemoteRescan},
+		{name: "any case", command: "NEXT", wantStatus: http.StatusNoContent, want: cec.RemoteRight},
+		{name: "unknown", command: "reboot", wantStatus: http.StatusBadRequest},
+		{name: "power is MQTT only", command: "on", wantStatus: http.StatusBadRequest},
+		{name: "GET", method: http.MethodGet, command: "next", wantStatus: http.StatusMethodNotAllowed},
+		{name: "slideshow not started", command: "next", noChan: true, wantStatus: http.StatusServiceUnavailable},
+		{name: "commands backed up", command: "next", full: true, wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(config.HTTP{Listen: ":0"})
+			commands := make(chan cec.RemoteCommand, 1)
+			if tt.full {
+				commands <- cec.RemoteHold
+			}
+			if !tt.noChan {
+				s.SetCommandChan(commands)
+			}
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, httptest.NewRequest(method, "/commands/"+tt.command, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", w.Code, strings.TrimSpace(w.Body.String()), tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusNoContent {
+				if len(commands) != 0 && !tt.full {
+					t.Errorf("sent %v, want nothing", <-commands)
+				}
+				return
+			}
+			select {
+			case got := <-commands:
+				if got != tt.want {
+					t.Errorf("sent %v, want %v", got, tt.want)
+				}
+			default:
+				t.Error("sent nothing")
+			}
+		})
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     *player.Status
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "photo pair",
+			status: &player.Status{
+				Index:    4,
+				Total:    120,
+				Photos:   []string{"/albums/a.jpg", "/albums/b.jpg"},
+				Held:     true,
+				Interval: 10 * time.Second,
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"index":4,"total":120,"photos":["/albums/a.jpg","/albums/b.jpg"],"paused":false,"held":true,"blanked":false,"interval":10}`,
+		},
+		{
+			name:       "title card",
+			status:     &player.Status{Index: 0, Total: 3, Paused: true, Blanked: true, Interval: 2500 * time.Millisecond},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"index":0,"total":3,"photos":[],"paused":true,"held":false,"blanked":true,"interval":2.5}`,
+		},
+		{
+			name:       "nothing to show",
+			status:     &player.Status{Interval: 10 * time.Second, Error: "no photos found"},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"index":0,"total":0,"photos":[],"paused":false,"held":false,"blanked":false,"interval":10,"error":"no photos found"}`,
+		},
+		{name: "slideshow not started", wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(config.HTTP{Listen: ":0"})
+			if tt.status != nil {
+				s.SetStatusFunc(func() player.Status { return *tt.status })
+			}
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/current", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/electronjoe/OpenFrame/internal/remote"
+)
+
+// maxShares is how many share links stay valid; each new one retires the
+// oldest.
+const maxShares = 32
+
+// share is a set of photos offered for download under a secret key.
+type share struct {
+	key   string
+	paths []string
+}
+
+// Share offers the local photos among paths for download at an unguessable
+// link, which works without the API token so that guests can open it from
+// the QR code on screen. It returns the link, or "" if there is nothing to
+// offer. Only the latest maxShares links stay valid. It is safe to call from
+// any goroutine.
+func (s *Server) Share(paths []string) string {
+	if s == nil {
+		return ""
+	}
+	var local []string
+	for _, p := range paths {
+		if !remote.IsURL(p) {
+			local = append(local, p)
+		}
+	}
+	if len(local) == 0 {
+		return ""
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	key := hex.EncodeToString(b)
+
+	s.mu.Lock()
+	s.shares = append(s.shares, share{key: key, paths: local})
+	if len(s.shares) > maxShares {
+		s.shares = s.shares[len(s.shares)-maxShares:]
+	}
+	s.mu.Unlock()
+	return s.baseURL() + "/shared/" + key
+}
+
+// shared returns the photos shared under key, or nil.
+func (s *Server) shared(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sh := range s.shares {
+		if sh.key == key {
+			return sh.paths
+		}
+	}
+	return nil
+}
+
+// baseURL is the address guests reach the API at: http.publicURL, or else
+// this host's mDNS name and the listen port.
+func (s *Server) baseURL() string {
+	if s.cfg.PublicURL != "" {
+		return strings.TrimSuffix(s.cfg.PublicURL, "/")
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	} else if !strings.Contains(host, ".") {
+		host += ".local"
+	}
+	port, _ := s.cfg.Port() // checked by Validate
+	return fmt.Sprintf("http://%s:%d", host, port)
+}
+
+// handleShared serves a shared photo. A share of two photos (a side-by-side
+// slide) lists them, each at /shared/{key}/{n}.
+func (s *Server) handleShared(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	paths := s.shared(key)
+	if paths == nil {
+		http.NotFound(w, r)
+		return
+	}
+	i := 0
+	if n := r.PathValue("n"); n != "" {
+		var err error
+		if i, err = strconv.Atoi(n); err != nil || i < 0 || i >= len(paths) {
+			http.NotFound(w, r)
+			return
+		}
+	} else if len(paths) > 1 {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<!DOCTYPE html>\n<title>OpenFrame</title>\n<ul>\n")
+		for n, p := range paths {
+			fmt.Fprintf(w, "<li><a href=\"/shared/%s/%d\">%s</a></li>\n", key, n, html.EscapeString(filepath.Base(p)))
+		}
+		fmt.Fprint(w, "</ul>\n")
+		return
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filepath.Base(paths[i])}))
+	http.ServeFile(w, r, paths[i])
+}
@@ -0,0 +1,16 @@
+package imgproc
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Crop returns the part of src inside r, given relative to src's top-left
+// corner, with bounds starting at (0, 0). r is clipped to src.
+func Crop(src image.Image, r image.Rectangle) image.Image {
+	b := src.Bounds()
+	r = r.Add(b.Min).Intersect(b)
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, r.Min, draw.Src)
+	return dst
+}
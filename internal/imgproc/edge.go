@@ -0,0 +1,47 @@
+package imgproc
+
+import (
+	"image"
+	"image/color"
+)
+
+// edgeSamples caps how many pixels EdgeColor reads along each side.
+const edgeSamples = 256
+
+// EdgeColor averages the pixels in a thin band around the border of src,
+// which makes a letterbox fill that blends into the photo. ok is false for
+// an empty image.
+func EdgeColor(src image.Image) (c color.RGBA, ok bool) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return color.RGBA{}, false
+	}
+	// The band is 2% of the shorter side, so a photo's own thin frame or
+	// vignetting does not dominate.
+	band := max(1, min(w, h)/50)
+
+	var r, g, bl, n uint64
+	sample := func(x, y int) {
+		pr, pg, pb, _ := src.At(x, y).RGBA()
+		r, g, bl, n = r+uint64(pr), g+uint64(pg), bl+uint64(pb), n+1
+	}
+	stepX := max(1, w/edgeSamples)
+	stepY := max(1, h/edgeSamples)
+	for d := 0; d < band; d++ {
+		for x := b.Min.X; x < b.Max.X; x += stepX {
+			sample(x, b.Min.Y+d)
+			sample(x, b.Max.Y-1-d)
+		}
+		for y := b.Min.Y; y < b.Max.Y; y += stepY {
+			sample(b.Min.X+d, y)
+			sample(b.Max.X-1-d, y)
+		}
+	}
+	return color.RGBA{
+		R: uint8(r / n >> 8),
+		G: uint8(g / n >> 8),
+		B: uint8(bl / n >> 8),
+		A: 0xff,
+	}, true
+}
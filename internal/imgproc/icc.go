@@ -0,0 +1,266 @@
+package imgproc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// xyzToSRGB takes ICC profile connection space XYZ, relative to D50, to
+// linear sRGB; the Bradford adaptation to D65 is folded in.
+var xyzToSRGB = [3][3]float64{
+	{3.1338561, -1.6168667, -0.4906146},
+	{-0.9787684, 1.9161415, 0.0334540},
+	{0.0719453, -0.2289914, 1.4052427},
+}
+
+// srgbEncode maps linear light, in 4096 steps from 0 to 1, to 8-bit sRGB.
+var srgbEncode = func() (lut [4096]uint8) {
+	for i := range lut {
+		lut[i] = uint8(math.Round(255 * linearToSRGB(float64(i)/float64(len(lut)-1))))
+	}
+	return lut
+}()
+
+// srgbTolerance is how far a profile may stray from sRGB, in linear light,
+// and still be treated as sRGB, so that the common sRGB profiles and their
+// rounding leave the pixels alone.
+const srgbTolerance = 0.002
+
+// ColorProfile converts images from the colour space of an embedded ICC
+// profile to sRGB. Only matrix/TRC RGB profiles, such as Display P3, Adobe
+// RGB and ProPhoto RGB, are supported; they cover what cameras and phones
+// embed.
+type ColorProfile struct {
+	// toSRGB takes linear profile RGB to linear sRGB.
+	toSRGB [3][3]float64
+	// linear maps each 8-bit channel value to linear light.
+	linear [3][256]float64
+	// srgb is set when converting would change nothing.
+	srgb bool
+}
+
+// ParseColorProfile reads an ICC profile. It fails on profiles it cannot
+// convert from: not RGB, or built from lookup tables rather than a matrix
+// and tone curves.
+func ParseColorProfile(data []byte) (*ColorProfile, error) {
+	if len(data) < 132 || string(data[36:40]) != "acsp" {
+		return nil, errors.New("icc: not an ICC profile")
+	}
+	if string(data[16:20]) != "RGB " || string(data[20:24]) != "XYZ " {
+		return nil, fmt.Errorf("icc: unsupported %q profile with %q connection space", data[16:20], data[20:24])
+	}
+	tags := make(map[string][]byte)
+	count := int(binary.BigEndian.Uint32(data[128:]))
+	for i := 0; i < count && 132+12*(i+1) <= len(data); i++ {
+		entry := data[132+12*i:]
+		off, size := binary.BigEndian.Uint32(entry[4:]), binary.BigEndian.Uint32(entry[8:])
+		if uint64(off)+uint64(size) > uint64(len(data)) {
+			return nil, errors.New("icc: tag out of range")
+		}
+		tags[string(entry[:4])] = data[off : off+size]
+	}
+
+	var p ColorProfile
+	var toXYZ [3][3]float64
+	for c, name := range []string{"r", "g", "b"} {
+		xyz, err := parseXYZ(tags[name+"XYZ"])
+		if err != nil {
+			return nil, fmt.Errorf("icc: %sXYZ: %w", name, err)
+		}
+		for row := range toXYZ {
+			toXYZ[row][c] = xyz[row]
+		}
+		curve, err := parseCurve(tags[name+"TRC"])
+		if err != nil {
+			return nil, fmt.Errorf("icc: %sTRC: %w", name, err)
+		}
+		for v := range p.linear[c] {
+			p.linear[c][v] = curve(float64(v) / 255)
+		}
+	}
+	p.toSRGB = multiply(xyzToSRGB, toXYZ)
+	p.srgb = p.isSRGB()
+	return &p, nil
+}
+
+// isSRGB reports whether the profile is sRGB, or near enough.
+func (p *ColorProfile) isSRGB() bool {
+	for i := range p.toSRGB {
+		for j := range p.toSRGB[i] {
+			want := 0.0
+			if i == j {
+				want = 1
+			}
+			if math.Abs(p.toSRGB[i][j]-want) > 10*srgbTolerance {
+				return false
+			}
+		}
+	}
+	for c := range p.linear {
+		for v, lin := range p.linear[c] {
+			if math.Abs(lin-srgbToLinear(float64(v)/255)) > srgbTolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// IsSRGB reports whether the profile is sRGB, or near enough that
+// converting would change nothing.
+func (p *ColorProfile) IsSRGB() bool {
+	return p.srgb
+}
+
+// ToSRGB returns src converted from the profile's colours to sRGB. Colours
+// outside sRGB are clipped. An sRGB profile returns src unchanged.
+func (p *ColorProfile) ToSRGB(src image.Image) image.Image {
+	if p == nil || p.srgb {
+		return src
+	}
+	b := src.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, src, b.Min, draw.Src)
+
+	for i := 0; i+3 < len(dst.Pix); i += 4 {
+		a := dst.Pix[i+3]
+		if a == 0 {
+			continue
+		}
+		var in [3]uint8
+		for c := range in {
+			// The pixels are premultiplied; the curves want straight
+			// colour.
+			v := dst.Pix[i+c]
+			if a != 255 {
+				v = uint8((int(v)*255 + int(a)/2) / int(a))
+			}
+			in[c] = v
+		}
+		lr, lg, lb := p.linear[0][in[0]], p.linear[1][in[1]], p.linear[2][in[2]]
+		for c := range in {
+			m := p.toSRGB[c]
+			out := m[0]*lr + m[1]*lg + m[2]*lb
+			v := srgbEncode[int(max(0, min(1, out))*float64(len(srgbEncode)-1)+0.5)]
+			if a != 255 {
+				v = uint8((int(v)*int(a) + 127) / 255)
+			}
+			dst.Pix[i+c] = v
+		}
+	}
+	return dst
+}
+
+// parseXYZ reads an XYZType tag: one s15Fixed16 XYZ triple.
+func parseXYZ(tag []byte) ([3]float64, error) {
+	if len(tag) < 20 || string(tag[:4]) != "XYZ " {
+		return [3]float64{}, errors.New("missing or not an XYZ tag")
+	}
+	return [3]float64{s15Fixed16(tag[8:]), s15Fixed16(tag[12:]), s15Fixed16(tag[16:])}, nil
+}
+
+// parseCurve reads a tone curve tag, curveType or parametricCurveType, as
+// a function from encoded values to linear light, both from 0 to 1.
+func parseCurve(tag []byte) (func(float64) float64, error) {
+	if len(tag) < 12 {
+		return nil, errors.New("missing tone curve")
+	}
+	switch string(tag[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		switch {
+		case n == 0:
+			return func(x float64) float64 { return x }, nil
+		case n == 1 && len(tag) >= 14:
+			gamma := float64(binary.BigEndian.Uint16(tag[12:])) / 256
+			return func(x float64) float64 { return math.Pow(x, gamma) }, nil
+		case len(tag) < 12+2*n:
+			return nil, errors.New("short curve")
+		}
+		table := make([]float64, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+2*i:])) / 65535
+		}
+		return func(x float64) float64 {
+			pos := x * float64(n-1)
+			i := min(int(pos), n-2)
+			return table[i] + (pos-float64(i))*(table[i+1]-table[i])
+		}, nil
+	case "para":
+		fn := binary.BigEndian.Uint16(tag[8:])
+		params := []int{1, 3, 4, 5, 7}
+		if int(fn) >= len(params) || len(tag) < 12+4*params[fn] {
+			return nil, fmt.Errorf("unsupported parametric curve %d", fn)
+		}
+		var v [7]float64
+		for i := 0; i < params[fn]; i++ {
+			v[i] = s15Fixed16(tag[12+4*i:])
+		}
+		return parametric(fn, v), nil
+	}
+	return nil, fmt.Errorf("unsupported tone curve type %q", tag[:4])
+}
+
+// parametric returns parametric curve function fn with parameters g, a, b,
+// c, d, e and f, as the ICC specification numbers them.
+func parametric(fn uint16, v [7]float64) func(float64) float64 {
+	g, a, b, c, d, e, f := v[0], v[1], v[2], v[3], v[4], v[5], v[6]
+	pow := func(x float64) float64 { return math.Pow(max(0, a*x+b), g) }
+	switch fn {
+	case 0:
+		return func(x float64) float64 { return math.Pow(x, g) }
+	case 1:
+		return pow
+	case 2:
+		return func(x float64) float64 { return pow(x) + c }
+	case 3:
+		return func(x float64) float64 {
+			if x >= d {
+				return pow(x)
+			}
+			return c * x
+		}
+	}
+	return func(x float64) float64 {
+		if x >= d {
+			return pow(x) + e
+		}
+		return c*x + f
+	}
+}
+
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+func multiply(a, b [3][3]float64) [3][3]float64 {
+	var m [3][3]float64
+	for i := range m {
+		for j := range m[i] {
+			for k := range a[i] {
+				m[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return m
+}
+
+// srgbToLinear and linearToSRGB are the sRGB transfer function and its
+// inverse.
+func srgbToLinear(x float64) float64 {
+	if x <= 0.04045 {
+		return x / 12.92
+	}
+	return math.Pow((x+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(x float64) float64 {
+	if x <= 0.0031308 {
+		return x * 12.92
+	}
+	return 1.055*math.Pow(x, 1/2.4) - 0.055
+}
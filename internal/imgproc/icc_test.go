@@ -0,0 +1,120 @@
+package imgproc
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// testProfile builds a matrix/TRC RGB profile from D50 colorants and one
+// tone curve tag shared by the three channels.
+func testProfile(colorants [3][3]float64, trc []byte) []byte {
+	fixed := func(b []byte, v float64) []byte {
+		return binary.BigEndian.AppendUint32(b, uint32(int32(math.Round(v*65536))))
+	}
+	var tags [][2]any
+	for c, name := range []string{"r", "g", "b"} {
+		xyz := []byte("XYZ \x00\x00\x00\x00")
+		for _, v := range colorants[c] {
+			xyz = fixed(xyz, v)
+		}
+		tags = append(tags, [2]any{name + "XYZ", xyz}, [2]any{name + "TRC", trc})
+	}
+	header := make([]byte, 128)
+	copy(header[16:], "RGB XYZ ")
+	copy(header[36:], "acsp")
+	table := binary.BigEndian.AppendUint32(nil, uint32(len(tags)))
+	var data []byte
+	off := 128 + 4 + 12*len(tags)
+	for _, tag := range tags {
+		body := tag[1].([]byte)
+		table = append(table, tag[0].(string)...)
+		table = binary.BigEndian.AppendUint32(table, uint32(off+len(data)))
+		table = binary.BigEndian.AppendUint32(table, uint32(len(body)))
+		data = append(data, body...)
+	}
+	return append(append(header, table...), data...)
+}
+
+// srgbCurve is the sRGB transfer function as a parametric curve.
+func srgbCurve() []byte {
+	trc := []byte("para\x00\x00\x00\x00\x00\x03\x00\x00")
+	for _, v := range []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045} {
+		trc = binary.BigEndian.AppendUint32(trc, uint32(int32(math.Round(v*65536))))
+	}
+	return trc
+}
+
+var (
+	srgbColorants      = [3][3]float64{{0.4361, 0.2225, 0.0139}, {0.3851, 0.7169, 0.0971}, {0.1431, 0.0606, 0.7141}}
+	displayP3Colorants = [3][3]float64{{0.5151, 0.2412, -0.0011}, {0.2919, 0.6922, 0.0419}, {0.1572, 0.0666, 0.7841}}
+)
+
+func TestColorProfileToSRGB(t *testing.T) {
+	srgb, err := ParseColorProfile(testProfile(srgbColorants, srgbCurve()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !srgb.IsSRGB() {
+		t.Error("sRGB profile: IsSRGB() = false, want true")
+	}
+	p3, err := ParseColorProfile(testProfile(displayP3Colorants, srgbCurve()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p3.IsSRGB() {
+		t.Error("Display P3 profile: IsSRGB() = true, want false")
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	src.Set(0, 0, color.RGBA{128, 128, 128, 255})
+	src.Set(1, 0, color.RGBA{200, 100, 100, 255})
+	src.Set(2, 0, color.RGBA{255, 0, 0, 255})
+	if got := srgb.ToSRGB(src); got != image.Image(src) {
+		t.Error("an sRGB profile converted the image, want it returned unchanged")
+	}
+	got := p3.ToSRGB(src)
+	at := func(x int) color.RGBA { return got.At(x, 0).(color.RGBA) }
+	// Both share the D65 white point, so greys stay put.
+	if c := at(0); c != (color.RGBA{128, 128, 128, 255}) {
+		t.Errorf("grey = %v, want it unchanged", c)
+	}
+	// P3 colours are more saturated than the same values in sRGB.
+	if c := at(1); c.R <= 200 || c.G >= 100 || c.B >= 100 {
+		t.Errorf("P3 (200, 100, 100) = %v, want redder", c)
+	}
+	// P3 red lies outside sRGB, so is clipped to sRGB red.
+	if c := at(2); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("P3 red = %v, want sRGB red", c)
+	}
+}
+
+func TestParseColorProfileCurves(t *testing.T) {
+	gamma22 := []byte("curv\x00\x00\x00\x00\x00\x00\x00\x01\x02\x33") // 2.2 in u8Fixed8
+	table := []byte("curv\x00\x00\x00\x00\x00\x00\x00\x03\x00\x00\x40\x00\xff\xff")
+	tests := []struct {
+		name string
+		trc  []byte
+		at   float64 // linear light for the 8-bit value 128
+	}{
+		{name: "gamma", trc: gamma22, at: math.Pow(128.0/255, 2.2)},
+		{name: "table", trc: table, at: 0.25 + (2*128.0/255-1)*0.75},
+		{name: "parametric", trc: srgbCurve(), at: srgbToLinear(128.0 / 255)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseColorProfile(testProfile(srgbColorants, tt.trc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.linear[1][128]; math.Abs(got-tt.at) > 0.005 {
+				t.Errorf("linear(128) = %.4f, want %.4f", got, tt.at)
+			}
+		})
+	}
+	if _, err := ParseColorProfile(testProfile(srgbColorants, []byte("mAB \x00\x00\x00\x00\x00\x00\x00\x00"))); err == nil {
+		t.Error("ParseColorProfile() with a LUT curve = nil error, want one")
+	}
+}
@@ -0,0 +1,80 @@
+package imgproc
+
+import (
+	"image"
+	"image/draw"
+)
+
+const (
+	// levelsClip is the fraction of pixels at each end of the histogram
+	// treated as outliers, so a few specks of black or white do not stop
+	// the stretch.
+	levelsClip = 0.005
+	// minLevelsRange skips near-flat images (fog, a blank scan), where a
+	// stretch would only amplify noise.
+	minLevelsRange = 16
+)
+
+// AutoLevels stretches the luminance histogram of src so its darkest and
+// brightest pixels (ignoring outliers) span the full range, which lifts dull
+// or underexposed scans. The same curve is applied to every channel, so
+// colours keep their hue. strength, from 0 to 1, blends between the original
+// (0) and the full stretch (1). Images that need no stretch are returned
+// unchanged.
+func AutoLevels(src image.Image, strength float64) image.Image {
+	if strength <= 0 {
+		return src
+	}
+	strength = min(strength, 1)
+
+	b := src.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, src, b.Min, draw.Src)
+
+	var hist [256]int
+	for i := 0; i+3 < len(dst.Pix); i += 4 {
+		hist[luminance(dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2])]++
+	}
+	lo, hi := histogramBounds(hist, b.Dx()*b.Dy())
+	if hi-lo < minLevelsRange || (lo == 0 && hi == 255) {
+		return src
+	}
+
+	var curve [256]uint8
+	for v := range curve {
+		stretched := float64(v-lo) * 255 / float64(hi-lo)
+		out := float64(v) + strength*(stretched-float64(v))
+		curve[v] = uint8(max(0, min(255, out+0.5)))
+	}
+	for i := 0; i+3 < len(dst.Pix); i += 4 {
+		dst.Pix[i] = curve[dst.Pix[i]]
+		dst.Pix[i+1] = curve[dst.Pix[i+1]]
+		dst.Pix[i+2] = curve[dst.Pix[i+2]]
+	}
+	return dst
+}
+
+// luminance is the Rec. 601 luma of an 8-bit RGB pixel.
+func luminance(r, g, b uint8) uint8 {
+	return uint8((299*int(r) + 587*int(g) + 114*int(b) + 500) / 1000)
+}
+
+// histogramBounds returns the luminance levels below and above which
+// levelsClip of the total pixels fall.
+func histogramBounds(hist [256]int, total int) (lo, hi int) {
+	clip := int(float64(total) * levelsClip)
+	for n := 0; lo < 255; lo++ {
+		n += hist[lo]
+		if n > clip {
+			break
+		}
+	}
+	hi = 255
+	for n := 0; hi > 0; hi-- {
+		n += hist[hi]
+		if n > clip {
+			break
+		}
+	}
+	return lo, hi
+}
@@ -0,0 +1,99 @@
+// Package imgproc holds pixel-level image transforms shared by the slideshow
+// renderer and offline tools such as cmd/thumbgen.
+package imgproc
+
+import "image"
+
+// ApplyEXIFOrientation rotates/flips the image based on the EXIF orientation value (1–8).
+// Unknown values leave src as it is.
+// Orientation reference:
+//
+//	1 - 0° (normal),   2 - flip horizontal,  3 - 180°,       4 - flip vertical
+//	5 - transpose,     6 - rotate 90 CW,     7 - transverse, 8 - rotate 270 CW
+func ApplyEXIFOrientation(src image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return FlipHorizontal(src)
+	case 3:
+		return Rotate180(src)
+	case 4:
+		return FlipVertical(src)
+	case 5:
+		return Transpose(src)
+	case 6:
+		return Rotate90(src)
+	case 7:
+		return Transverse(src)
+	case 8:
+		return Rotate270(src)
+	default:
+		// 1 => no transform
+		return src
+	}
+}
+
+// The flips and rotations below each return a new RGBA image whose bounds
+// start at (0, 0), whatever src's bounds are.
+
+// FlipHorizontal mirrors src left to right.
+func FlipHorizontal(src image.Image) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	return remap(src, w, h, func(x, y int) (int, int) { return w - 1 - x, y })
+}
+
+// FlipVertical mirrors src top to bottom.
+func FlipVertical(src image.Image) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	return remap(src, w, h, func(x, y int) (int, int) { return x, h - 1 - y })
+}
+
+// Rotate180 turns src upside down.
+func Rotate180(src image.Image) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	return remap(src, w, h, func(x, y int) (int, int) { return w - 1 - x, h - 1 - y })
+}
+
+// Rotate90 turns src 90° clockwise.
+func Rotate90(src image.Image) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	return remap(src, h, w, func(x, y int) (int, int) { return h - 1 - y, x })
+}
+
+// Rotate270 turns src 270° clockwise (90° counter-clockwise).
+func Rotate270(src image.Image) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	return remap(src, h, w, func(x, y int) (int, int) { return y, w - 1 - x })
+}
+
+// Transpose mirrors src over its top-left to bottom-right diagonal.
+func Transpose(src image.Image) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	return remap(src, h, w, func(x, y int) (int, int) { return y, x })
+}
+
+// Transverse mirrors src over its top-right to bottom-left diagonal.
+func Transverse(src image.Image) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	return remap(src, h, w, func(x, y int) (int, int) { return h - 1 - y, w - 1 - x })
+}
+
+// remap copies each pixel of src to where to puts it, in a new dw x dh
+// image. Coordinates on both sides are relative to the image's top-left.
+func remap(src image.Image, dw, dh int, to func(x, y int) (int, int)) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dx, dy := to(x, y)
+			dst.Set(dx, dy, src.At(x+b.Min.X, y+b.Min.Y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,80 @@
+package imgproc
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+// labelled returns an image whose pixels are the grey levels of rows, each
+// string one row with a letter per pixel, with its top-left corner at min.
+func labelled(min image.Point, rows ...string) *image.Gray {
+	img := image.NewGray(image.Rectangle{Min: min, Max: min.Add(image.Pt(len(rows[0]), len(rows)))})
+	for y, row := range rows {
+		for x, c := range []byte(row) {
+			img.SetGray(min.X+x, min.Y+y, color.Gray{Y: c})
+		}
+	}
+	return img
+}
+
+// labels reads img back into rows of letters, as labelled builds them.
+func labels(img image.Image) []string {
+	b := img.Bounds()
+	var rows []string
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := make([]byte, 0, b.Dx())
+		for x := b.Min.X; x < b.Max.X; x++ {
+			row = append(row, color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+		rows = append(rows, string(row))
+	}
+	return rows
+}
+
+func TestApplyEXIFOrientation(t *testing.T) {
+	// Every orientation of a 3x2 image with distinct pixels, each turned
+	// upright.
+	tests := []struct {
+		orientation int
+		want        []string
+	}{
+		{0, []string{"abc", "def"}},
+		{1, []string{"abc", "def"}},
+		{2, []string{"cba", "fed"}},
+		{3, []string{"fed", "cba"}},
+		{4, []string{"def", "abc"}},
+		{5, []string{"ad", "be", "cf"}},
+		{6, []string{"da", "eb", "fc"}},
+		{7, []string{"fc", "eb", "da"}},
+		{8, []string{"cf", "be", "ad"}},
+		{9, []string{"abc", "def"}},
+	}
+	for _, tt := range tests {
+		// Images cut from a larger one do not start at (0, 0).
+		for _, min := range []image.Point{{0, 0}, {5, 7}} {
+			got := labels(ApplyEXIFOrientation(labelled(min, "abc", "def"), tt.orientation))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ApplyEXIFOrientation(orientation %d, origin %v) = %q, want %q", tt.orientation, min, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestRotationsCompose(t *testing.T) {
+	src := labelled(image.Point{}, "abc", "def")
+	want := labels(src)
+	if got := labels(Rotate90(Rotate270(src))); !slices.Equal(got, want) {
+		t.Errorf("Rotate90(Rotate270(src)) = %q, want %q", got, want)
+	}
+	if got := labels(Rotate90(Rotate90(src))); !slices.Equal(got, labels(Rotate180(src))) {
+		t.Errorf("Rotate90 twice = %q, want Rotate180 %q", got, labels(Rotate180(src)))
+	}
+	if got := labels(Transpose(Transpose(src))); !slices.Equal(got, want) {
+		t.Errorf("Transpose twice = %q, want %q", got, want)
+	}
+	if got := labels(Transverse(src)); !slices.Equal(got, labels(Rotate180(Transpose(src)))) {
+		t.Errorf("Transverse = %q, want Transpose then Rotate180 %q", got, labels(Rotate180(Transpose(src))))
+	}
+}
@@ -0,0 +1,330 @@
+// Package mapthumb draws small maps of where photos were taken, for the
+// slideshow's map overlay, from the tiles of a slippy map server such as
+// OpenStreetMap's. Tiles and finished maps are kept under the state
+// directory, and tiles are fetched one at a time in the background, so the
+// tile server sees each tile once and never more than one request at a time.
+package mapthumb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // some tile servers send JPEG
+	"image/png"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTileURL is OpenStreetMap's standard tile server. Its usage
+	// policy asks for a descriptive User-Agent and for tiles to be cached,
+	// which Maker does.
+	DefaultTileURL = "https://tile.openstreetmap.org/{z}/{x}/{y}.png"
+	// DefaultZoom shows a region about 30 km across in a map.
+	DefaultZoom = 10
+	// MaxZoom is the deepest zoom tile servers commonly offer.
+	MaxZoom = 19
+
+	// Size is the width and height of a map.
+	Size = 200
+
+	dirName  = "maps"
+	tileSize = 256
+
+	// keyScale rounds coordinates to a hundredth of a degree, about a
+	// kilometre, so that photos taken near each other share a map. At the
+	// default zoom that moves the dot by a few pixels at most.
+	keyScale = 100
+
+	// keptMaps is how many decoded maps are held in memory; the rest are
+	// read back from disk when their place comes up again.
+	keptMaps = 8
+	// queueSize is how many places may wait for their map; more are
+	// dropped and asked for again by the next Map call.
+	queueSize = 16
+	// retryAfter is how long a place whose map could not be made waits
+	// before it is tried again.
+	retryAfter = 10 * time.Minute
+
+	requestTimeout = 30 * time.Second
+	maxTileBytes   = 1 << 20
+	userAgent      = "OpenFrame photo frame (+https://github.com/electronjoe/OpenFrame)"
+)
+
+// Options picks the tiles maps are drawn from.
+type Options struct {
+	// TileURL is the tile address with {z}, {x} and {y} in it; "" means
+	// DefaultTileURL.
+	TileURL string
+	// Zoom is the tile zoom level, 1 to MaxZoom; zero means DefaultZoom.
+	Zoom int
+}
+
+// Maker draws and caches maps. It is safe for concurrent use.
+type Maker struct {
+	tileURL string
+	zoom    int
+	dir     string
+	http    *http.Client
+	queue   chan key
+
+	mu      sync.Mutex
+	maps    map[key]image.Image
+	order   []key // of maps, oldest first
+	pending map[key]bool
+	failed  map[key]time.Time
+}
+
+// key is a place rounded to keyScale.
+type key struct{ lat, lon int }
+
+func keyFor(lat, lon float64) key {
+	return key{int(math.Round(lat * keyScale)), int(math.Round(lon * keyScale))}
+}
+
+// New returns a Maker caching under stateDir, drawing maps in a background
+// goroutine until ctx is cancelled. Maps from different tile servers or
+// zoom levels are cached apart.
+func New(ctx context.Context, stateDir string, opts Options) *Maker {
+	tileURL := opts.TileURL
+	if tileURL == "" {
+		tileURL = DefaultTileURL
+	}
+	zoom := opts.Zoom
+	if zoom <= 0 {
+		zoom = DefaultZoom
+	}
+	sum := sha1.Sum([]byte(tileURL))
+	m := &Maker{
+		tileURL: tileURL,
+		zoom:    zoom,
+		dir:     filepath.Join(stateDir, dirName, hex.EncodeToString(sum[:4])),
+		http:    &http.Client{Timeout: requestTimeout},
+		queue:   make(chan key, queueSize),
+		maps:    make(map[key]image.Image),
+		pending: make(map[key]bool),
+		failed:  make(map[key]time.Time),
+	}
+	go m.run(ctx)
+	return m
+}
+
+// Map returns the map of the place at lat, lon, with a dot on it, or nil
+// while it is being made in the background. It never waits on the disk or
+// the network.
+func (m *Maker) Map(lat, lon float64) image.Image {
+	k := keyFor(lat, lon)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if img, ok := m.maps[k]; ok {
+		return img
+	}
+	if m.pending[k] || time.Since(m.failed[k]) < retryAfter {
+		return nil
+	}
+	select {
+	case m.queue <- k:
+		m.pending[k] = true
+	default:
+	}
+	return nil
+}
+
+// run makes the maps asked for, one at a time, until ctx is cancelled.
+func (m *Maker) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case k := <-m.queue:
+			img, err := m.load(k)
+			m.mu.Lock()
+			delete(m.pending, k)
+			if err != nil {
+				log.Printf("Map overlay: %v", err)
+				m.failed[k] = time.Now()
+			} else {
+				m.keep(k, img)
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// keep holds img in memory, dropping the oldest map once keptMaps are held.
+// m.mu must be held.
+func (m *Maker) keep(k key, img image.Image) {
+	if len(m.order) == keptMaps {
+		delete(m.maps, m.order[0])
+		m.order = m.order[1:]
+	}
+	m.maps[k] = img
+	m.order = append(m.order, k)
+}
+
+// load reads the map of k from disk, drawing and saving it first if need be.
+func (m *Maker) load(k key) (image.Image, error) {
+	path := filepath.Join(m.dir, fmt.Sprintf("z%d", m.zoom), fmt.Sprintf("%d_%d.png", k.lat, k.lon))
+	if f, err := os.Open(path); err == nil {
+		defer f.Close()
+		if img, err := png.Decode(f); err == nil {
+			return img, nil
+		}
+	}
+	img, err := m.draw(float64(k.lat)/keyScale, float64(k.lon)/keyScale)
+	if err != nil {
+		return nil, err
+	}
+	if err := writePNG(path, img); err != nil {
+		log.Printf("Warning: could not cache map: %v", err)
+	}
+	return img, nil
+}
+
+// draw puts together the Size x Size map centred on lat, lon from the tiles
+// it covers, and marks the spot.
+func (m *Maker) draw(lat, lon float64) (image.Image, error) {
+	cx, cy := worldPixel(lat, lon, m.zoom)
+	x0, y0 := int(math.Round(cx))-Size/2, int(math.Round(cy))-Size/2
+	tiles := 1 << m.zoom
+
+	img := image.NewRGBA(image.Rect(0, 0, Size, Size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Gray{Y: 0xdd}), image.Point{}, draw.Src)
+	for ty := floorDiv(y0, tileSize); ty <= floorDiv(y0+Size-1, tileSize); ty++ {
+		if ty < 0 || ty >= tiles {
+			continue
+		}
+		for tx := floorDiv(x0, tileSize); tx <= floorDiv(x0+Size-1, tileSize); tx++ {
+			tile, err := m.tile(((tx%tiles)+tiles)%tiles, ty)
+			if err != nil {
+				return nil, err
+			}
+			at := image.Pt(tx*tileSize-x0, ty*tileSize-y0)
+			draw.Draw(img, tile.Bounds().Sub(tile.Bounds().Min).Add(at), tile, tile.Bounds().Min, draw.Src)
+		}
+	}
+	drawMarker(img, Size/2, Size/2)
+	drawBorder(img)
+	return img, nil
+}
+
+// tile returns tile x, y of the map's zoom level, fetching it unless it is
+// already cached.
+func (m *Maker) tile(x, y int) (image.Image, error) {
+	path := filepath.Join(m.dir, "tiles", strconv.Itoa(m.zoom), strconv.Itoa(x), strconv.Itoa(y)+".png")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		data, err = m.fetchTile(x, y)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFile(path, data); err != nil {
+			log.Printf("Warning: could not cache map tile: %v", err)
+		}
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode tile %d/%d/%d: %w", m.zoom, x, y, err)
+	}
+	return img, nil
+}
+
+func (m *Maker) fetchTile(x, y int) ([]byte, error) {
+	u := strings.NewReplacer("{z}", strconv.Itoa(m.zoom), "{x}", strconv.Itoa(x), "{y}", strconv.Itoa(y)).Replace(m.tileURL)
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxTileBytes))
+}
+
+// worldPixel returns where lat, lon lies, in pixels from the top-left corner
+// of the Web Mercator world map at zoom.
+func worldPixel(lat, lon float64, zoom int) (x, y float64) {
+	// The projection ends short of the poles.
+	lat = math.Max(-85.0511, math.Min(85.0511, lat))
+	n := math.Ldexp(tileSize, zoom)
+	x = (lon + 180) / 360 * n
+	y = (1 - math.Asinh(math.Tan(lat*math.Pi/180))/math.Pi) / 2 * n
+	return x, y
+}
+
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+// drawMarker draws a red dot ringed in white centred on x, y.
+func drawMarker(img *image.RGBA, x, y int) {
+	const outer, inner = 8, 6
+	for dy := -outer; dy <= outer; dy++ {
+		for dx := -outer; dx <= outer; dx++ {
+			switch d := dx*dx + dy*dy; {
+			case d <= inner*inner:
+				img.Set(x+dx, y+dy, color.RGBA{R: 0xd0, G: 0x20, B: 0x20, A: 0xff})
+			case d <= outer*outer:
+				img.Set(x+dx, y+dy, color.White)
+			}
+		}
+	}
+}
+
+// drawBorder frames img in white so the map stands apart from the photo.
+func drawBorder(img *image.RGBA) {
+	const width = 2
+	b := img.Bounds()
+	for _, r := range []image.Rectangle{
+		{b.Min, image.Pt(b.Max.X, b.Min.Y+width)},
+		{image.Pt(b.Min.X, b.Max.Y-width), b.Max},
+		{b.Min, image.Pt(b.Min.X+width, b.Max.Y)},
+		{image.Pt(b.Max.X-width, b.Min.Y), b.Max},
+	} {
+		draw.Draw(img, r, image.White, image.Point{}, draw.Src)
+	}
+}
+
+// writePNG saves img at path, replacing it in one step.
+func writePNG(path string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return writeFile(path, buf.Bytes())
+}
+
+// writeFile writes data to path, creating its directory, and renames it into
+// place so a crash never leaves half a file.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,87 @@
+package mapthumb
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorldPixel(t *testing.T) {
+	tests := []struct {
+		lat, lon float64
+		zoom     int
+		wantX    float64
+		wantY    float64
+	}{
+		{lat: 0, lon: 0, zoom: 0, wantX: 128, wantY: 128},
+		{lat: 0, lon: -180, zoom: 1, wantX: 0, wantY: 256},
+		{lat: 90, lon: 180, zoom: 1, wantX: 512, wantY: 0},
+		{lat: -90, lon: 0, zoom: 2, wantX: 512, wantY: 1024},
+	}
+	for _, tt := range tests {
+		x, y := worldPixel(tt.lat, tt.lon, tt.zoom)
+		if math.Abs(x-tt.wantX) > 0.01 || math.Abs(y-tt.wantY) > 0.01 {
+			t.Errorf("worldPixel(%g, %g, %d) = %.2f, %.2f, want %g, %g", tt.lat, tt.lon, tt.zoom, x, y, tt.wantX, tt.wantY)
+		}
+	}
+}
+
+func TestMapFetchesEachTileOnce(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("User-Agent") == "" {
+			t.Error("tile request without a User-Agent")
+		}
+		tile := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+		for i := range tile.Pix {
+			tile.Pix[i] = 0x40
+		}
+		var buf bytes.Buffer
+		png.Encode(&buf, tile)
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	opts := Options{TileURL: srv.URL + "/{z}/{x}/{y}.png", Zoom: 3}
+	img := waitForMap(t, New(ctx, dir, opts), 51.5, -0.12)
+	if b := img.Bounds(); b.Dx() != Size || b.Dy() != Size {
+		t.Fatalf("map is %v, want %dx%d", b, Size, Size)
+	}
+	if c := color.RGBAModel.Convert(img.At(Size/2, Size/2)).(color.RGBA); c.R < 0xc0 || c.G > 0x40 {
+		t.Errorf("centre = %v, want the red marker", c)
+	}
+	fetched := requests.Load()
+	if fetched == 0 || fetched > 4 {
+		t.Fatalf("fetched %d tiles, want 1 to 4", fetched)
+	}
+
+	// A place nearby shares the cached tiles, and a new Maker the saved map.
+	waitForMap(t, New(ctx, dir, opts), 51.6, -0.1)
+	waitForMap(t, New(ctx, dir, opts), 51.5, -0.12)
+	if got := requests.Load(); got != fetched {
+		t.Errorf("fetched %d tiles in all, want the first %d only", got, fetched)
+	}
+}
+
+func waitForMap(t *testing.T, m *Maker, lat, lon float64) image.Image {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if img := m.Map(lat, lon); img != nil {
+			return img
+		}
+	}
+	t.Fatalf("no map of %g, %g", lat, lon)
+	return nil
+}
@@ -0,0 +1,333 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/cec"
+	"github.com/electronjoe/OpenFrame/internal/config"
+)
+
+const (
+	keepAlive      = 30 * time.Second
+	dialTimeout    = 10 * time.Second
+	initialBackoff = time.Second
+	maxBackoff     = 2 * time.Minute
+
+	topicCurrent = "/current"
+	topicStatus  = "/status"
+	topicCommand = "/command"
+)
+
+// Bridge publishes slideshow state to an MQTT broker and forwards commands
+// received on <prefix>/command to the slideshow and the TV.
+//
+// Topics (relative to the configured prefix):
+//
+//	<prefix>/current  retained JSON describing the slide on screen
+//	<prefix>/status   retained "online"/"offline" (the latter set via last will)
+//	<prefix>/command  accepts "next", "prev", "pause", "on" and "off"
+type Bridge struct {
+	cfg          config.MQTT
+	remoteEvents chan<- cec.RemoteCommand
+	// haveCEC is false without cec-client, when "on" and "off" are ignored;
+	// warnedNoCEC, touched only by the reader, says so once.
+	haveCEC     bool
+	warnedNoCEC bool
+
+	mu      sync.Mutex
+	current []byte // latest slide state, republished after every reconnect
+	changed chan struct{}
+
+	done chan struct{} // closed once the bridge has disconnected for good
+}
+
+// slideState is the payload published on <prefix>/current.
+type slideState struct {
+	Index  int      `json:"index"`
+	Total  int      `json:"total"`
+	Photos []string `json:"photos"`
+}
+
+// Start connects to the configured broker in a background goroutine and keeps
+// reconnecting with backoff whenever the connection drops, until ctx is
+// cancelled. haveCEC says whether cec-client is there to carry out the TV
+// power commands. It returns nil when no broker is configured; all Bridge
+// methods are no-ops on a nil Bridge.
+func Start(ctx context.Context, cfg config.MQTT, remoteEvents chan<- cec.RemoteCommand, haveCEC bool) *Bridge {
+	if !cfg.Enabled() {
+		return nil
+	}
+	b := &Bridge{
+		cfg:          cfg,
+		remoteEvents: remoteEvents,
+		haveCEC:      haveCEC,
+		changed:      make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}
+	go b.run(ctx)
+	return b
+}
+
+// Done returns a channel closed once the bridge has stopped after its
+// context was cancelled. For a nil Bridge it is already closed.
+func (b *Bridge) Done() <-chan struct{} {
+	if b == nil {
+		return closedChan
+	}
+	return b.done
+}
+
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// PublishSlide records the slide now on screen and queues it for publishing.
+// It never blocks, so it is safe to call from the Ebiten update loop.
+func (b *Bridge) PublishSlide(index, total int, photos []string) {
+	if b == nil {
+		return
+	}
+	data, err := json.Marshal(slideState{Index: index, Total: total, Photos: photos})
+	if err != nil {
+		log.Printf("MQTT: could not encode slide state: %v", err)
+		return
+	}
+
+	b.mu.Lock()
+	b.current = data
+	b.mu.Unlock()
+	b.signalChanged()
+}
+
+// run owns the connection lifecycle.
+func (b *Bridge) run(ctx context.Context) {
+	defer close(b.done)
+	backoff := initialBackoff
+	for {
+		start := time.Now()
+		err := b.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > maxBackoff {
+			// The connection was healthy for a while; retry promptly.
+			backoff = initialBackoff
+		}
+		log.Printf("MQTT: connection to %s lost: %v (retrying in %s)", b.cfg.Broker, err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// session runs a single connection until it fails or ctx is cancelled.
+func (b *Bridge) session(ctx context.Context) error {
+	conn, err := dial(b.cfg.Broker)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	prefix := strings.TrimRight(b.cfg.TopicPrefix, "/")
+	statusTopic := prefix + topicStatus
+	commandTopic := prefix + topicCommand
+
+	if _, err := conn.Write(encodeConnect(connectOptions{
+		clientID:     b.cfg.ClientID,
+		username:     b.cfg.Username,
+		password:     b.cfg.Password,
+		keepAlive:    uint16(keepAlive / time.Second),
+		willTopic:    statusTopic,
+		willMessage:  "offline",
+		willRetained: true,
+	})); err != nil {
+		return fmt.Errorf("send CONNECT: %w", err)
+	}
+
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(dialTimeout))
+	ack, err := readPacket(r)
+	if err != nil {
+		return fmt.Errorf("read CONNACK: %w", err)
+	}
+	if err := connAckError(ack); err != nil {
+		return err
+	}
+
+	if _, err := conn.Write(encodeSubscribe(1, commandTopic)); err != nil {
+		return fmt.Errorf("subscribe to %s: %w", commandTopic, err)
+	}
+	if _, err := conn.Write(encodePublish(statusTopic, []byte("online"), true)); err != nil {
+		return fmt.Errorf("publish status: %w", err)
+	}
+	log.Printf("MQTT: connected to %s, listening on %s", b.cfg.Broker, commandTopic)
+
+	// All writes happen on this goroutine; the reader only reports back,
+	// until done tells it nobody is listening any more.
+	acks := make(chan uint16, 8)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		readErr <- b.readLoop(conn, r, commandTopic, acks, done)
+	}()
+
+	// Publish whatever is current right away so retained state survives
+	// broker restarts.
+	b.signalChanged()
+
+	ping := time.NewTicker(keepAlive / 2)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// A clean DISCONNECT suppresses the last will, so report
+			// offline ourselves.
+			conn.Write(encodePublish(statusTopic, []byte("offline"), true))
+			conn.Write(encodeDisconnect())
+			log.Printf("MQTT: disconnected from %s", b.cfg.Broker)
+			return nil
+		case err := <-readErr:
+			return err
+		case id := <-acks:
+			if _, err := conn.Write(encodePubAck(id)); err != nil {
+				return fmt.Errorf("send PUBACK: %w", err)
+			}
+		case <-ping.C:
+			if _, err := conn.Write(encodePingReq()); err != nil {
+				return fmt.Errorf("send PINGREQ: %w", err)
+			}
+		case <-b.changed:
+			b.mu.Lock()
+			current := b.current
+			b.mu.Unlock()
+			if current == nil {
+				continue
+			}
+			if _, err := conn.Write(encodePublish(prefix+topicCurrent, current, true)); err != nil {
+				return fmt.Errorf("publish slide state: %w", err)
+			}
+		}
+	}
+}
+
+// readLoop consumes packets until the connection fails or done is closed,
+// dispatching commands and asking the writer to acknowledge QoS 1 deliveries.
+func (b *Bridge) readLoop(conn net.Conn, r *bufio.Reader, commandTopic string, acks chan<- uint16, done <-chan struct{}) error {
+	for {
+		// The broker answers our pings, so silence means a dead connection.
+		conn.SetReadDeadline(time.Now().Add(2 * keepAlive))
+		p, err := readPacket(r)
+		if err != nil {
+			return err
+		}
+		if p.kind != packetPublish {
+			continue
+		}
+		topic, packetID, payload, err := parsePublish(p)
+		if err != nil {
+			return err
+		}
+		if packetID != 0 {
+			select {
+			case acks <- packetID:
+			case <-done:
+				return net.ErrClosed
+			}
+		}
+		if topic == commandTopic {
+			b.handleCommand(string(payload))
+		}
+	}
+}
+
+// handleCommand maps a command payload onto slideshow navigation or TV power.
+func (b *Bridge) handleCommand(payload string) {
+	name := strings.ToLower(strings.TrimSpace(payload))
+	switch name {
+	case "on", "off":
+		if !b.haveCEC {
+			if !b.warnedNoCEC {
+				log.Printf("MQTT: ignoring %q and any later power commands: cec-client is not installed", name)
+				b.warnedNoCEC = true
+			}
+			return
+		}
+		power := cec.PowerOnTV
+		if name == "off" {
+			power = cec.PowerOffTV
+		}
+		go setPower(power, name)
+	default:
+		cmd, ok := cec.ParseRemoteCommand(name)
+		if !ok {
+			log.Printf("MQTT: ignoring unknown command %q", payload)
+			return
+		}
+		// Blocking here would stall the reader, and with it the keepalive.
+		select {
+		case b.remoteEvents <- cmd:
+		default:
+			log.Printf("MQTT: dropping command %q: the slideshow has not caught up with earlier ones", name)
+		}
+	}
+}
+
+func (b *Bridge) signalChanged() {
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+}
+
+// setPower runs a (slow) cec-client power call off the reader goroutine.
+func setPower(fn func() error, state string) {
+	if err := fn(); err != nil {
+		log.Printf("MQTT: power %s failed: %v", state, err)
+	}
+}
+
+// dial opens a TCP or TLS connection based on the broker URL scheme.
+func dial(broker string) (net.Conn, error) {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return nil, fmt.Errorf("parse broker URL: %w", err)
+	}
+
+	useTLS := false
+	port := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		useTLS = true
+		port = "8883"
+	default:
+		return nil, fmt.Errorf("unsupported broker scheme %q", u.Scheme)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if useTLS {
+		return tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: u.Hostname()})
+	}
+	return dialer.Dial("tcp", addr)
+}
@@ -0,0 +1,212 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The bridge only needs a small subset of MQTT 3.1.1, so packets are encoded
+// by hand rather than pulling in a full client library.
+const (
+	packetConnect    = 1
+	packetConnAck    = 2
+	packetPublish    = 3
+	packetPubAck     = 4
+	packetSubscribe  = 8
+	packetSubAck     = 9
+	packetPingReq    = 12
+	packetPingResp   = 13
+	packetDisconnect = 14
+)
+
+// maxRemainingLength is the largest value the variable-length encoding allows.
+const maxRemainingLength = 268435455
+
+// packet is a decoded control packet: the fixed header plus its body.
+type packet struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+// connectOptions holds the fields of a CONNECT packet.
+type connectOptions struct {
+	clientID     string
+	username     string
+	password     string
+	keepAlive    uint16
+	willTopic    string
+	willMessage  string
+	willRetained bool
+}
+
+func encodeConnect(o connectOptions) []byte {
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, 4) // protocol level 3.1.1
+
+	flags := byte(0x02) // clean session
+	if o.willTopic != "" {
+		flags |= 0x04
+		if o.willRetained {
+			flags |= 0x20
+		}
+	}
+	if o.password != "" {
+		flags |= 0x40
+	}
+	if o.username != "" {
+		flags |= 0x80
+	}
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, o.keepAlive)
+
+	body = appendString(body, o.clientID)
+	if o.willTopic != "" {
+		body = appendString(body, o.willTopic)
+		body = appendString(body, o.willMessage)
+	}
+	if o.username != "" {
+		body = appendString(body, o.username)
+	}
+	if o.password != "" {
+		body = appendString(body, o.password)
+	}
+	return encodePacket(packetConnect, 0, body)
+}
+
+func encodePublish(topic string, payload []byte, retained bool) []byte {
+	var flags byte
+	if retained {
+		flags |= 0x01
+	}
+	body := appendString(nil, topic)
+	body = append(body, payload...)
+	return encodePacket(packetPublish, flags, body)
+}
+
+func encodeSubscribe(packetID uint16, topic string) []byte {
+	body := binary.BigEndian.AppendUint16(nil, packetID)
+	body = appendString(body, topic)
+	body = append(body, 0) // QoS 0
+	return encodePacket(packetSubscribe, 0x02, body)
+}
+
+func encodePubAck(packetID uint16) []byte {
+	return encodePacket(packetPubAck, 0, binary.BigEndian.AppendUint16(nil, packetID))
+}
+
+func encodePingReq() []byte {
+	return encodePacket(packetPingReq, 0, nil)
+}
+
+func encodeDisconnect() []byte {
+	return encodePacket(packetDisconnect, 0, nil)
+}
+
+func encodePacket(kind, flags byte, body []byte) []byte {
+	out := []byte{kind<<4 | flags}
+	out = appendRemainingLength(out, len(body))
+	return append(out, body...)
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func appendRemainingLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+// readPacket reads one control packet from r.
+func readPacket(r *bufio.Reader) (packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+
+	// The length takes one to four bytes, seven bits each, low bits first.
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return packet{}, errors.New("malformed remaining length")
+		}
+		digit, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+		length += int(digit&0x7f) * multiplier
+		if digit&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+	return packet{kind: header >> 4, flags: header & 0x0f, body: body}, nil
+}
+
+// parsePublish extracts the topic, packet ID (QoS > 0 only) and payload of an
+// incoming PUBLISH packet.
+func parsePublish(p packet) (topic string, packetID uint16, payload []byte, err error) {
+	body := p.body
+	if len(body) < 2 {
+		return "", 0, nil, errors.New("short PUBLISH packet")
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	body = body[2:]
+	if len(body) < n {
+		return "", 0, nil, errors.New("short PUBLISH topic")
+	}
+	topic = string(body[:n])
+	body = body[n:]
+
+	if qos := (p.flags >> 1) & 0x03; qos > 0 {
+		if len(body) < 2 {
+			return "", 0, nil, errors.New("short PUBLISH packet ID")
+		}
+		packetID = binary.BigEndian.Uint16(body)
+		body = body[2:]
+	}
+	return topic, packetID, body, nil
+}
+
+// connAckError translates a CONNACK return code into an error (nil on success).
+func connAckError(p packet) error {
+	if p.kind != packetConnAck || len(p.body) < 2 {
+		return fmt.Errorf("expected CONNACK, got packet type %d", p.kind)
+	}
+	switch code := p.body[1]; code {
+	case 0:
+		return nil
+	case 1:
+		return errors.New("broker refused connection: unacceptable protocol version")
+	case 2:
+		return errors.New("broker refused connection: client identifier rejected")
+	case 3:
+		return errors.New("broker refused connection: server unavailable")
+	case 4:
+		return errors.New("broker refused connection: bad username or password")
+	case 5:
+		return errors.New("broker refused connection: not authorized")
+	default:
+		return fmt.Errorf("broker refused connection: return code %d", code)
+	}
+}
@@ -0,0 +1,159 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRemainingLength(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []byte
+	}{
+		{n: 0, want: []byte{0x00}},
+		{n: 127, want: []byte{0x7f}},
+		{n: 128, want: []byte{0x80, 0x01}},
+		{n: 16383, want: []byte{0xff, 0x7f}},
+		{n: 16384, want: []byte{0x80, 0x80, 0x01}},
+		{n: 2097151, want: []byte{0xff, 0xff, 0x7f}},
+		{n: 2097152, want: []byte{0x80, 0x80, 0x80, 0x01}},
+		{n: maxRemainingLength, want: []byte{0xff, 0xff, 0xff, 0x7f}},
+	}
+	for _, tt := range tests {
+		if got := appendRemainingLength(nil, tt.n); !bytes.Equal(got, tt.want) {
+			t.Errorf("appendRemainingLength(%d) = % x, want % x", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestReadPacket(t *testing.T) {
+	body := bytes.Repeat([]byte{'x'}, 200)
+	tests := []struct {
+		name     string
+		data     []byte
+		wantKind byte
+		wantBody []byte
+		wantErr  string
+	}{
+		{name: "no body", data: encodePingReq(), wantKind: packetPingReq},
+		{name: "two-byte length", data: encodePacket(packetPublish, 0, body), wantKind: packetPublish, wantBody: body},
+		{name: "empty", data: nil, wantErr: io.EOF.Error()},
+		{name: "length cut short", data: []byte{0x30, 0x80}, wantErr: io.EOF.Error()},
+		{name: "body cut short", data: []byte{0x30, 0x05, 'x'}, wantErr: io.ErrUnexpectedEOF.Error()},
+		{name: "five-byte length", data: []byte{0x30, 0xff, 0xff, 0xff, 0xff, 0x01}, wantErr: "malformed remaining length"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := readPacket(bufio.NewReader(bytes.NewReader(tt.data)))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("readPacket() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.kind != tt.wantKind || !bytes.Equal(p.body, tt.wantBody) {
+				t.Errorf("readPacket() = type %d with %d bytes, want type %d with %d", p.kind, len(p.body), tt.wantKind, len(tt.wantBody))
+			}
+		})
+	}
+}
+
+func TestParsePublish(t *testing.T) {
+	tests := []struct {
+		name        string
+		p           packet
+		wantTopic   string
+		wantID      uint16
+		wantPayload string
+		wantErr     bool
+	}{
+		{
+			name:        "QoS 0",
+			p:           packet{kind: packetPublish, body: append(appendString(nil, "frame/command"), "next"...)},
+			wantTopic:   "frame/command",
+			wantPayload: "next",
+		},
+		{
+			name:        "QoS 1 has a packet ID",
+			p:           packet{kind: packetPublish, flags: 0x02, body: append(appendString(nil, "frame/command"), 0x12, 0x34, 'o', 'n')},
+			wantTopic:   "frame/command",
+			wantID:      0x1234,
+			wantPayload: "on",
+		},
+		{name: "no topic length", p: packet{kind: packetPublish, body: []byte{0x00}}, wantErr: true},
+		{name: "short topic", p: packet{kind: packetPublish, body: []byte{0x00, 0x05, 'f', 'r'}}, wantErr: true},
+		{name: "QoS 1 without packet ID", p: packet{kind: packetPublish, flags: 0x02, body: append(appendString(nil, "t"), 0x12)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topic, id, payload, err := parsePublish(tt.p)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePublish() error = %v, want error %t", err, tt.wantErr)
+			}
+			if topic != tt.wantTopic || id != tt.wantID || string(payload) != tt.wantPayload {
+				t.Errorf("parsePublish() = %q, %#x, %q; want %q, %#x, %q", topic, id, payload, tt.wantTopic, tt.wantID, tt.wantPayload)
+			}
+		})
+	}
+}
+
+func TestConnAckError(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       packet
+		wantErr string
+	}{
+		{name: "accepted", p: packet{kind: packetConnAck, body: []byte{0, 0}}},
+		{name: "session present", p: packet{kind: packetConnAck, body: []byte{1, 0}}},
+		{name: "bad credentials", p: packet{kind: packetConnAck, body: []byte{0, 4}}, wantErr: "bad username or password"},
+		{name: "not authorized", p: packet{kind: packetConnAck, body: []byte{0, 5}}, wantErr: "not authorized"},
+		{name: "unknown code", p: packet{kind: packetConnAck, body: []byte{0, 42}}, wantErr: "return code 42"},
+		{name: "not a CONNACK", p: packet{kind: packetPublish, body: []byte{0, 0}}, wantErr: "expected CONNACK"},
+		{name: "short", p: packet{kind: packetConnAck, body: []byte{0}}, wantErr: "expected CONNACK"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := connAckError(tt.p)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("connAckError() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("connAckError() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncodeConnectFlags(t *testing.T) {
+	p, err := readPacket(bufio.NewReader(bytes.NewReader(encodeConnect(connectOptions{
+		clientID:     "frame",
+		username:     "me",
+		password:     "secret",
+		willTopic:    "frame/status",
+		willMessage:  "offline",
+		willRetained: true,
+	}))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.kind != packetConnect {
+		t.Fatalf("packet type %d, want CONNECT", p.kind)
+	}
+	// "MQTT" with its length, then the protocol level, then the flags:
+	// username, password, will retain, will and clean session.
+	if got, want := p.body[7], byte(0x80|0x40|0x20|0x04|0x02); got != want {
+		t.Errorf("connect flags = %#x, want %#x", got, want)
+	}
+	if !bytes.HasSuffix(p.body, append(appendString(nil, "me"), appendString(nil, "secret")...)) {
+		t.Error("CONNECT does not end with the username and password")
+	}
+}
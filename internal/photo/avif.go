@@ -0,0 +1,423 @@
+package photo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// An AVIF file is a HEIF container (ISO BMFF boxes) whose primary item is
+// AV1-coded. The container is read here, in pure Go, for the size, rotation
+// and EXIF of the photo; decoding the pixels takes libavif, which is only
+// linked in when building with the avif tag (see avif_libavif.go).
+
+const (
+	// maxAVIFMetaSize bounds the boxes read into memory whole: the meta
+	// box, which only describes the items, and the EXIF payload.
+	maxAVIFMetaSize = 4 << 20
+
+	// maxAVIFBoxes bounds the walk over the top-level boxes.
+	maxAVIFBoxes = 64
+)
+
+var (
+	errNotAVIF = errors.New("not an AVIF file")
+	errBadAVIF = errors.New("malformed AVIF file")
+
+	// errAVIFUnsupported is returned when decoding AVIF in a build without
+	// libavif.
+	errAVIFUnsupported = errors.New("AVIF decoding needs a build with the avif tag")
+)
+
+func init() {
+	image.RegisterFormat("avif", "????ftypavif", decodeAVIF, decodeAVIFConfig)
+	image.RegisterFormat("avif", "????ftypavis", decodeAVIF, decodeAVIFConfig)
+}
+
+// isAVIF reports whether path has the AVIF extension.
+func isAVIF(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".avif"
+}
+
+// avifInfo is what the HEIF container says about the primary image.
+type avifInfo struct {
+	width, height int // as coded, before rotation
+	// turns is the irot angle: the anticlockwise quarter turns that bring
+	// the image upright.
+	turns int
+	exif  []byte // TIFF data of the EXIF item, or nil
+}
+
+// orientation returns the EXIF orientation equivalent to the rotation.
+// AVIF encoders carry a JPEG's orientation over into irot, often leaving the
+// EXIF tag as it was, so the EXIF tag is not consulted.
+func (a avifInfo) orientation() int {
+	return [4]int{1, 8, 3, 6}[a.turns]
+}
+
+// decodeAVIF decodes the AVIF in r. Like the other formats it leaves the
+// rotation to the caller.
+func decodeAVIF(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAVIFPixels(data)
+}
+
+func decodeAVIFConfig(r io.Reader) (image.Config, error) {
+	info, err := readAVIF(readerAt(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.NRGBAModel, Width: info.width, Height: info.height}, nil
+}
+
+// avifFileInfo reads the container of the AVIF file at path.
+func avifFileInfo(path string) (avifInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return avifInfo{}, err
+	}
+	defer f.Close()
+	return readAVIF(f)
+}
+
+// readerAt returns r as an io.ReaderAt, reading it into memory if need be.
+// A read error surfaces from the first ReadAt.
+func readerAt(r io.Reader) io.ReaderAt {
+	if ra, ok := r.(io.ReaderAt); ok {
+		return ra
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return errReaderAt{err}
+	}
+	return bytes.NewReader(data)
+}
+
+type errReaderAt struct{ err error }
+
+func (e errReaderAt) ReadAt([]byte, int64) (int, error) { return 0, e.err }
+
+// readAVIF reads the ftyp and meta boxes at the start of the AVIF in r.
+func readAVIF(r io.ReaderAt) (avifInfo, error) {
+	var ftyp, meta []byte
+	var off int64
+	for i := 0; i < maxAVIFBoxes && meta == nil; i++ {
+		var header [16]byte
+		n, err := r.ReadAt(header[:], off)
+		if n < 8 {
+			if err != nil && err != io.EOF {
+				return avifInfo{}, err
+			}
+			break
+		}
+		size, typ, headerLen := int64(binary.BigEndian.Uint32(header[:])), string(header[4:8]), int64(8)
+		if size == 1 {
+			if n < 16 {
+				return avifInfo{}, errBadAVIF
+			}
+			size, headerLen = int64(binary.BigEndian.Uint64(header[8:])), 16
+		}
+		if i == 0 && typ != "ftyp" {
+			return avifInfo{}, errNotAVIF
+		}
+		if size < headerLen {
+			// A size of 0, the box running to the end of the file,
+			// is only written for the media data.
+			break
+		}
+		if typ == "ftyp" || typ == "meta" {
+			if size-headerLen > maxAVIFMetaSize {
+				return avifInfo{}, errBadAVIF
+			}
+			payload := make([]byte, size-headerLen)
+			if _, err := r.ReadAt(payload, off+headerLen); err != nil {
+				return avifInfo{}, fmt.Errorf("read %s box: %w", typ, err)
+			}
+			if typ == "ftyp" {
+				ftyp = payload
+			} else {
+				meta = payload
+			}
+		}
+		off += size
+	}
+	if !avifBrand(ftyp) {
+		return avifInfo{}, errNotAVIF
+	}
+	if len(meta) < 4 {
+		return avifInfo{}, errBadAVIF
+	}
+	// meta is a full box: version and flags come before its children.
+	children, err := splitBoxes(meta[4:])
+	if err != nil {
+		return avifInfo{}, err
+	}
+	return parseAVIFMeta(r, children)
+}
+
+// avifBrand reports whether the ftyp payload names AVIF as the major or a
+// compatible brand.
+func avifBrand(ftyp []byte) bool {
+	if len(ftyp) < 8 {
+		return false
+	}
+	brands := append(ftyp[:4:4], ftyp[8:]...)
+	for i := 0; i+4 <= len(brands); i += 4 {
+		if b := string(brands[i : i+4]); b == "avif" || b == "avis" {
+			return true
+		}
+	}
+	return false
+}
+
+// bmffBox is one ISO BMFF box read into memory.
+type bmffBox struct {
+	typ  string
+	data []byte // the payload, after the header
+}
+
+// splitBoxes splits b into the boxes it holds.
+func splitBoxes(b []byte) ([]bmffBox, error) {
+	var boxes []bmffBox
+	for len(b) > 0 {
+		if len(b) < 8 {
+			return nil, errBadAVIF
+		}
+		size, headerLen := uint64(binary.BigEndian.Uint32(b)), uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(b))
+		case 1:
+			if len(b) < 16 {
+				return nil, errBadAVIF
+			}
+			size, headerLen = binary.BigEndian.Uint64(b[8:]), 16
+		}
+		if size < headerLen || size > uint64(len(b)) {
+			return nil, errBadAVIF
+		}
+		boxes = append(boxes, bmffBox{typ: string(b[4:8]), data: b[headerLen:size]})
+		b = b[size:]
+	}
+	return boxes, nil
+}
+
+func findBox(boxes []bmffBox, typ string) ([]byte, bool) {
+	for _, b := range boxes {
+		if b.typ == typ {
+			return b.data, true
+		}
+	}
+	return nil, false
+}
+
+// bmffReader reads big-endian fields off the front of a box, noting rather
+// than panicking when it runs out.
+type bmffReader struct {
+	b   []byte
+	bad bool
+}
+
+// uint reads an n-byte unsigned field; n may be 0, for fields a box leaves
+// out.
+func (r *bmffReader) uint(n int) uint64 {
+	if len(r.b) < n {
+		r.bad, r.b = true, nil
+		return 0
+	}
+	var v uint64
+	for _, c := range r.b[:n] {
+		v = v<<8 | uint64(c)
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+// uintOf reads a field that later box versions widened from 16 to 32 bits.
+func (r *bmffReader) uintOf(wide bool) uint64 {
+	if wide {
+		return r.uint(4)
+	}
+	return r.uint(2)
+}
+
+// fullBox reads the version and flags that start a full box.
+func (r *bmffReader) fullBox() (version int, flags uint32) {
+	v := r.uint(4)
+	return int(v >> 24), uint32(v & 0xffffff)
+}
+
+// parseAVIFMeta finds the primary item's size and rotation, and its EXIF,
+// among the children of the meta box.
+func parseAVIFMeta(r io.ReaderAt, meta []bmffBox) (avifInfo, error) {
+	var info avifInfo
+	pitm, ok := findBox(meta, "pitm")
+	if !ok {
+		return info, errBadAVIF
+	}
+	pr := &bmffReader{b: pitm}
+	version, _ := pr.fullBox()
+	primary := uint32(pr.uintOf(version > 0))
+	if pr.bad {
+		return info, errBadAVIF
+	}
+
+	iprp, _ := findBox(meta, "iprp")
+	for _, prop := range itemProperties(iprp, primary) {
+		pr := &bmffReader{b: prop.data}
+		switch prop.typ {
+		case "ispe":
+			pr.fullBox()
+			info.width, info.height = int(pr.uint(4)), int(pr.uint(4))
+		case "irot":
+			info.turns = int(pr.uint(1) & 3)
+		}
+		if pr.bad {
+			return info, errBadAVIF
+		}
+	}
+	if info.width <= 0 || info.height <= 0 {
+		return info, errBadAVIF
+	}
+
+	// A missing or unreadable EXIF item leaves the photo without EXIF, as
+	// for other formats.
+	if id, ok := exifItem(meta); ok {
+		if payload, err := itemData(r, meta, id); err == nil && len(payload) >= 4 {
+			// The payload starts with the offset of the TIFF header.
+			if skip := uint64(binary.BigEndian.Uint32(payload)); skip <= uint64(len(payload)-4) {
+				info.exif = payload[4+skip:]
+			}
+		}
+	}
+	return info, nil
+}
+
+// itemProperties returns the properties the iprp box associates with item.
+func itemProperties(iprp []byte, item uint32) []bmffBox {
+	boxes, err := splitBoxes(iprp)
+	if err != nil {
+		return nil
+	}
+	ipco, _ := findBox(boxes, "ipco")
+	props, err := splitBoxes(ipco)
+	if err != nil {
+		return nil
+	}
+	ipma, _ := findBox(boxes, "ipma")
+	r := &bmffReader{b: ipma}
+	version, flags := r.fullBox()
+	var found []bmffBox
+	for n := r.uint(4); n > 0 && !r.bad; n-- {
+		id := uint32(r.uintOf(version >= 1))
+		for m := r.uint(1); m > 0 && !r.bad; m-- {
+			// The top bit marks an essential property.
+			var index int
+			if flags&1 != 0 {
+				index = int(r.uint(2) & 0x7fff)
+			} else {
+				index = int(r.uint(1) & 0x7f)
+			}
+			if id == item && index >= 1 && index <= len(props) {
+				found = append(found, props[index-1])
+			}
+		}
+	}
+	return found
+}
+
+// exifItem returns the ID of the EXIF item listed in the iinf box.
+func exifItem(meta []bmffBox) (uint32, bool) {
+	iinf, _ := findBox(meta, "iinf")
+	r := &bmffReader{b: iinf}
+	version, _ := r.fullBox()
+	r.uintOf(version > 0)
+	if r.bad {
+		return 0, false
+	}
+	entries, err := splitBoxes(r.b)
+	if err != nil {
+		return 0, false
+	}
+	for _, e := range entries {
+		if e.typ != "infe" {
+			continue
+		}
+		er := &bmffReader{b: e.data}
+		version, _ := er.fullBox()
+		if version < 2 {
+			continue
+		}
+		id := uint32(er.uintOf(version > 2))
+		er.uint(2) // item_protection_index
+		if !er.bad && len(er.b) >= 4 && string(er.b[:4]) == "Exif" {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// itemData reads the extents of item, as the iloc box lays them out, from
+// the file or from the idat box.
+func itemData(r io.ReaderAt, meta []bmffBox, item uint32) ([]byte, error) {
+	iloc, _ := findBox(meta, "iloc")
+	lr := &bmffReader{b: iloc}
+	version, _ := lr.fullBox()
+	sizes := lr.uint(2)
+	offsetSize, lengthSize := int(sizes>>12), int(sizes>>8&0xf)
+	baseOffsetSize, indexSize := int(sizes>>4&0xf), int(sizes&0xf)
+	if version == 0 {
+		indexSize = 0
+	}
+	count := lr.uintOf(version >= 2)
+	for ; count > 0 && !lr.bad; count-- {
+		id := uint32(lr.uintOf(version >= 2))
+		method := 0
+		if version >= 1 {
+			method = int(lr.uint(2) & 0xf)
+		}
+		lr.uint(2) // data_reference_index
+		base := lr.uint(baseOffsetSize)
+		var data []byte
+		for extents := lr.uint(2); extents > 0 && !lr.bad; extents-- {
+			lr.uint(indexSize)
+			off, n := base+lr.uint(offsetSize), lr.uint(lengthSize)
+			if id != item {
+				continue
+			}
+			if uint64(len(data))+n > maxAVIFMetaSize {
+				return nil, errBadAVIF
+			}
+			extent := make([]byte, n)
+			switch method {
+			case 0:
+				if _, err := r.ReadAt(extent, int64(off)); err != nil {
+					return nil, err
+				}
+			case 1:
+				idat, _ := findBox(meta, "idat")
+				if off+n > uint64(len(idat)) {
+					return nil, errBadAVIF
+				}
+				copy(extent, idat[off:])
+			default:
+				return nil, fmt.Errorf("item construction method %d not supported", method)
+			}
+			data = append(data, extent...)
+		}
+		if id == item && !lr.bad {
+			return data, nil
+		}
+	}
+	return nil, errBadAVIF
+}
@@ -0,0 +1,77 @@
+//go:build avif && cgo
+
+package photo
+
+/*
+#cgo pkg-config: libavif
+#include <stdlib.h>
+#include <avif/avif.h>
+
+// decode_avif decodes the first image of the AVIF in data to 8-bit RGBA,
+// without applying its rotation. On success *pixels holds width*height*4
+// bytes that the caller must free.
+static avifResult decode_avif(const uint8_t *data, size_t size, uint8_t **pixels, uint32_t *width, uint32_t *height) {
+	avifDecoder *decoder = avifDecoderCreate();
+	if (decoder == NULL) {
+		return AVIF_RESULT_OUT_OF_MEMORY;
+	}
+	avifResult result = avifDecoderSetIOMemory(decoder, data, size);
+	if (result == AVIF_RESULT_OK) {
+		result = avifDecoderParse(decoder);
+	}
+	if (result == AVIF_RESULT_OK) {
+		result = avifDecoderNextImage(decoder);
+	}
+	if (result == AVIF_RESULT_OK) {
+		avifRGBImage rgb;
+		avifRGBImageSetDefaults(&rgb, decoder->image);
+		rgb.format = AVIF_RGB_FORMAT_RGBA;
+		rgb.depth = 8;
+		rgb.rowBytes = rgb.width * 4;
+		rgb.pixels = malloc((size_t)rgb.rowBytes * rgb.height);
+		if (rgb.pixels == NULL) {
+			result = AVIF_RESULT_OUT_OF_MEMORY;
+		} else {
+			result = avifImageYUVToRGB(decoder->image, &rgb);
+			if (result == AVIF_RESULT_OK) {
+				*pixels = rgb.pixels;
+				*width = rgb.width;
+				*height = rgb.height;
+			} else {
+				free(rgb.pixels);
+			}
+		}
+	}
+	avifDecoderDestroy(decoder);
+	return result;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"unsafe"
+)
+
+// avifSupported reports whether AVIF files can be decoded: this build links
+// libavif.
+const avifSupported = true
+
+// decodeAVIFPixels decodes data with libavif.
+func decodeAVIFPixels(data []byte) (image.Image, error) {
+	if len(data) == 0 {
+		return nil, errNotAVIF
+	}
+	var pixels *C.uint8_t
+	var width, height C.uint32_t
+	result := C.decode_avif((*C.uint8_t)(unsafe.Pointer(&data[0])), C.size_t(len(data)), &pixels, &width, &height)
+	if result != C.AVIF_RESULT_OK {
+		return nil, fmt.Errorf("libavif: %s", C.GoString(C.avifResultToString(result)))
+	}
+	defer C.free(unsafe.Pointer(pixels))
+
+	img := image.NewNRGBA(image.Rect(0, 0, int(width), int(height)))
+	copy(img.Pix, unsafe.Slice((*byte)(unsafe.Pointer(pixels)), len(img.Pix)))
+	return img, nil
+}
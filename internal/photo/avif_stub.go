@@ -0,0 +1,13 @@
+//go:build !avif || !cgo
+
+package photo
+
+import "image"
+
+// avifSupported reports whether AVIF files can be decoded; without libavif
+// they are left out of the slideshow.
+const avifSupported = false
+
+func decodeAVIFPixels([]byte) (image.Image, error) {
+	return nil, errAVIFUnsupported
+}
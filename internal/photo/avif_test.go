@@ -0,0 +1,117 @@
+package photo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExtractMetadataReadsAVIFContainer(t *testing.T) {
+	tiff := encodeTestTIFF(testEXIF{
+		// The container's rotation wins over the EXIF tag.
+		ifd0: []exifField{shortField(tagOrientation, 3)},
+		exif: []exifField{asciiField(tagDateTimeOriginal, "2023:07:08 09:10:11")},
+	})
+	tests := []struct {
+		name            string
+		turns           int
+		exif            []byte
+		wantOrientation int
+		wantW, wantH    int
+	}{
+		{name: "upright", exif: tiff, wantOrientation: 1, wantW: 40, wantH: 30},
+		{name: "quarter turn", turns: 1, exif: tiff, wantOrientation: 8, wantW: 30, wantH: 40},
+		{name: "three quarter turns", turns: 3, exif: tiff, wantOrientation: 6, wantW: 30, wantH: 40},
+		{name: "no EXIF", turns: 2, wantOrientation: 3, wantW: 40, wantH: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "a.avif")
+			if err := os.WriteFile(path, encodeTestAVIF(40, 30, tt.turns, tt.exif), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			p, err := extractMetadata(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Orientation != tt.wantOrientation || p.Width != tt.wantW || p.Height != tt.wantH {
+				t.Errorf("extractMetadata() = %dx%d orientation %d, want %dx%d orientation %d",
+					p.Width, p.Height, p.Orientation, tt.wantW, tt.wantH, tt.wantOrientation)
+			}
+			want := time.Date(2023, 7, 8, 9, 10, 11, 0, time.Local)
+			if tt.exif != nil && !p.TakenTime.Equal(want) {
+				t.Errorf("TakenTime = %v, want %v", p.TakenTime, want)
+			}
+		})
+	}
+}
+
+func TestAVIFDecodeConfigAndOrientation(t *testing.T) {
+	data := encodeTestAVIF(40, 30, 1, nil)
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "avif" || cfg.Width != 40 || cfg.Height != 30 {
+		t.Errorf("DecodeConfig() = %s %dx%d, want avif 40x30", format, cfg.Width, cfg.Height)
+	}
+	if got := ReadOrientation(bytes.NewReader(data)); got != 8 {
+		t.Errorf("ReadOrientation() = %d, want 8", got)
+	}
+	if _, err := readAVIF(bytes.NewReader(data[:len(data)/2])); err == nil {
+		t.Error("readAVIF() of a truncated file succeeded")
+	}
+}
+
+// encodeTestAVIF builds the HEIF container of a w x h AVIF turned by turns
+// anticlockwise quarter turns, with exif, if set, as its EXIF item. The
+// image item has no AV1 data.
+func encodeTestAVIF(w, h, turns int, exif []byte) []byte {
+	ftyp := testBox("ftyp", []byte("avif\x00\x00\x00\x00mif1avif"))
+
+	items := [][]byte{testFullBox("infe", 2, u16(1), u16(0), []byte("av01\x00"))}
+	if exif != nil {
+		items = append(items, testFullBox("infe", 2, u16(2), u16(0), []byte("Exif\x00")))
+	}
+	iprp := testBox("iprp",
+		testBox("ipco",
+			testFullBox("ispe", 0, u32(uint32(w)), u32(uint32(h))),
+			testBox("irot", []byte{byte(turns)})),
+		// Item 1 has properties 1 and 2, both marked essential.
+		testFullBox("ipma", 0, u32(1), u16(1), []byte{2, 0x81, 0x82}))
+	payload := append(u32(0), exif...)
+
+	// The EXIF item lies in mdat, just after meta, so meta is built twice:
+	// the second time with the offset its own length gives.
+	meta := func(exifOffset uint32) []byte {
+		iloc := testFullBox("iloc", 0, []byte{0x44, 0x00}, u16(0))
+		if exif != nil {
+			iloc = testFullBox("iloc", 0, []byte{0x44, 0x00}, u16(1),
+				u16(2), u16(0), u16(1), u32(exifOffset), u32(uint32(len(payload))))
+		}
+		return testFullBox("meta", 0,
+			testFullBox("pitm", 0, u16(1)),
+			testFullBox("iinf", 0, u16(uint16(len(items))), bytes.Join(items, nil)),
+			iloc,
+			iprp)
+	}
+	m := meta(uint32(len(ftyp) + len(meta(0)) + 8))
+	return bytes.Join([][]byte{ftyp, m, testBox("mdat", payload)}, nil)
+}
+
+func testBox(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	return append(append(u32(uint32(8+len(body))), typ...), body...)
+}
+
+func testFullBox(typ string, version byte, payload ...[]byte) []byte {
+	return testBox(typ, append([][]byte{{version, 0, 0, 0}}, payload...)...)
+}
+
+func u16(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+
+func u32(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
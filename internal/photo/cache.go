@@ -11,7 +11,10 @@ import (
 
 const (
 	metadataCacheFileName = "photo_metadata_cache.json"
-	metadataCacheVersion  = 1
+
+	// metadataCacheVersion is bumped whenever metadata extraction changes so
+	// that entries written by older builds are re-read.
+	metadataCacheVersion = 11
 )
 
 type metadataCache struct {
@@ -19,16 +22,28 @@ type metadataCache struct {
 	Entries map[string]metadataCacheEntry `json:"entries"`
 }
 
+// metadataCacheEntry mirrors Photo, so Width and Height are the display
+// dimensions with any 90° EXIF rotation already applied.
 type metadataCacheEntry struct {
-	ModTime     int64     `json:"modTime"`
-	TakenTime   time.Time `json:"takenTime"`
-	Width       int       `json:"width"`
-	Height      int       `json:"height"`
-	Orientation int       `json:"orientation"`
+	ModTime     int64      `json:"modTime"`
+	TakenTime   time.Time  `json:"takenTime"`
+	Width       int        `json:"width"`
+	Height      int        `json:"height"`
+	Orientation int        `json:"orientation"`
+	Latitude    float64    `json:"latitude,omitempty"`
+	Longitude   float64    `json:"longitude,omitempty"`
+	HasLocation bool       `json:"hasLocation,omitempty"`
+	Rating      int        `json:"rating,omitempty"`
+	Keywords    []string   `json:"keywords,omitempty"`
+	Caption     string     `json:"caption,omitempty"`
+	Shot        *Shot      `json:"shot,omitempty"`
+	Transform   *Transform `json:"transform,omitempty"`
+	// ContentKey is the file's contentKey, computed only when deduping.
+	ContentKey string `json:"contentKey,omitempty"`
 }
 
-func loadMetadataCache() (*metadataCache, error) {
-	path, err := metadataCachePath()
+func loadMetadataCache(stateDir string) (*metadataCache, error) {
+	path, err := metadataCachePath(stateDir)
 	if err != nil {
 		return nil, err
 	}
@@ -53,8 +68,8 @@ func loadMetadataCache() (*metadataCache, error) {
 	return cache, nil
 }
 
-func saveMetadataCache(cache *metadataCache) error {
-	path, err := metadataCachePath()
+func saveMetadataCache(stateDir string, cache *metadataCache) error {
+	path, err := metadataCachePath(stateDir)
 	if err != nil {
 		return err
 	}
@@ -80,12 +95,24 @@ func saveMetadataCache(cache *metadataCache) error {
 	return nil
 }
 
-func metadataCachePath() (string, error) {
+func metadataCachePath(stateDir string) (string, error) {
+	if stateDir == "" {
+		dir, err := defaultStateDir()
+		if err != nil {
+			return "", err
+		}
+		stateDir = dir
+	}
+	return filepath.Join(stateDir, metadataCacheFileName), nil
+}
+
+// defaultStateDir is ~/.openframe, used when LoadOptions.StateDir is unset.
+func defaultStateDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("determine user home: %w", err)
 	}
-	return filepath.Join(homeDir, configDirName, metadataCacheFileName), nil
+	return filepath.Join(homeDir, configDirName), nil
 }
 
 func newMetadataCache() *metadataCache {
@@ -109,6 +136,14 @@ func (c *metadataCache) get(path string, modTime time.Time) (Photo, bool) {
 		Width:       entry.Width,
 		Height:      entry.Height,
 		Orientation: entry.Orientation,
+		Latitude:    entry.Latitude,
+		Longitude:   entry.Longitude,
+		HasLocation: entry.HasLocation,
+		Rating:      entry.Rating,
+		Keywords:    entry.Keywords,
+		Caption:     entry.Caption,
+		Shot:        entry.Shot,
+		Transform:   entry.Transform,
 	}, true
 }
 
@@ -122,7 +157,43 @@ func (c *metadataCache) set(path string, modTime time.Time, photo Photo) {
 		Width:       photo.Width,
 		Height:      photo.Height,
 		Orientation: photo.Orientation,
+		Latitude:    photo.Latitude,
+		Longitude:   photo.Longitude,
+		HasLocation: photo.HasLocation,
+		Rating:      photo.Rating,
+		Keywords:    photo.Keywords,
+		Caption:     photo.Caption,
+		Shot:        photo.Shot,
+		Transform:   photo.Transform,
+	}
+}
+
+// contentKey returns the content key cached for path, if its entry has one.
+// Entries are replaced, without a key, when their file changes.
+func (c *metadataCache) contentKey(path string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	entry, ok := c.Entries[path]
+	if !ok || entry.ContentKey == "" {
+		return "", false
+	}
+	return entry.ContentKey, true
+}
+
+// setContentKey records key in the entry for path, reporting whether there
+// was one to record it in.
+func (c *metadataCache) setContentKey(path, key string) bool {
+	if c == nil {
+		return false
+	}
+	entry, ok := c.Entries[path]
+	if !ok {
+		return false
 	}
+	entry.ContentKey = key
+	c.Entries[path] = entry
+	return true
 }
 
 func (c *metadataCache) prune(validPaths map[string]struct{}) bool {
@@ -0,0 +1,58 @@
+package photo
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// xmpDescriptionRE matches the dc:description alternatives where XMP keeps
+// the caption; the first (default language) one is used.
+var xmpDescriptionRE = regexp.MustCompile(`(?s)<dc:description>\s*<rdf:Alt\s*>\s*<rdf:li[^>]*>(.*?)</rdf:li>`)
+
+// cameraDescriptions are placeholders some cameras write into
+// ImageDescription, which are no caption at all.
+var cameraDescriptions = []string{
+	"OLYMPUS DIGITAL CAMERA",
+	"SONY DSC",
+	"DIGITAL CAMERA",
+	"DCIM",
+}
+
+// readCaption finds the photo's caption in its XMP dc:description, IPTC
+// Caption/Abstract or EXIF ImageDescription, in that order. x may be nil if
+// the file has no EXIF.
+func readCaption(m embeddedMetadata, x *exif.Exif) string {
+	if d := xmpDescriptionRE.FindSubmatch(m.xmp); d != nil {
+		if c := strings.TrimSpace(html.UnescapeString(string(d[1]))); c != "" {
+			return c
+		}
+	}
+	for _, c := range iptcValues(m.iptc, iptcCaptionDataset) {
+		if c = strings.TrimSpace(c); c != "" {
+			return c
+		}
+	}
+	if x != nil {
+		if tag, err := x.Get(exif.ImageDescription); err == nil {
+			if c, err := tag.StringVal(); err == nil {
+				c = strings.TrimSpace(strings.TrimRight(c, "\x00"))
+				if c != "" && !isCameraDescription(c) {
+					return c
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func isCameraDescription(c string) bool {
+	for _, d := range cameraDescriptions {
+		if strings.EqualFold(c, d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package photo
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// dedupeSample is the size of each of the three pieces of a file, at its
+// start, middle and end, that contentKey hashes.
+const dedupeSample = 64 << 10
+
+// contentKey identifies the contents of the file at path, of size bytes,
+// without reading all of it: it hashes the size and three samples. Copies
+// of a photo share a key; an edited version, even one of the same size,
+// almost always changes a sample.
+func contentKey(path string, size int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	binary.Write(h, binary.BigEndian, size)
+	buf := make([]byte, dedupeSample)
+	for _, off := range []int64{0, size/2 - dedupeSample/2, size - dedupeSample} {
+		off = max(off, 0)
+		n, err := f.ReadAt(buf, off)
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("hash %s: %w", path, err)
+		}
+		h.Write(buf[:n])
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// cachedContentKey returns the contentKey of path, from the metadata cache
+// entry cachedMetadata has just checked if it has one. updated reports
+// whether the cache was modified.
+func cachedContentKey(cache *metadataCache, path string, info os.FileInfo) (key string, updated bool, err error) {
+	if key, ok := cache.contentKey(path); ok {
+		return key, false, nil
+	}
+	key, err = contentKey(path, info.Size())
+	if err != nil {
+		return "", false, err
+	}
+	return key, cache.setContentKey(path, key), nil
+}
+
+// dedupe keeps the first of the photos that share a key in keys, in scan
+// order, so that albums listed earlier win. The copies are the same file,
+// so nothing else tells them apart.
+func dedupe(photos []Photo, keys map[string]string) []Photo {
+	seen := make(map[string]bool, len(photos))
+	kept := photos[:0]
+	for _, p := range photos {
+		if key, ok := keys[p.FilePath]; ok {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
@@ -0,0 +1,71 @@
+package photo
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadDedupeKeepsFirstCopy(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "full_exif.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same size as the fixture, but different at its end.
+	edited := slices.Clone(fixture)
+	edited[len(edited)-3] ^= 0xff
+
+	dir := t.TempDir()
+	originals := filepath.Join(dir, "originals")
+	copies := filepath.Join(dir, "copies")
+	files := map[string][]byte{
+		filepath.Join(originals, "a.jpg"):      fixture,
+		filepath.Join(copies, "a copy.jpg"):    fixture,
+		filepath.Join(copies, "a edited.jpg"):  edited,
+		filepath.Join(copies, "sub", "a2.jpg"): fixture,
+	}
+	for path, data := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stateDir := t.TempDir()
+	tests := []struct {
+		name   string
+		dedupe bool
+		want   []string
+	}{
+		{"off", false, []string{"copies/a copy.jpg", "copies/a edited.jpg", "copies/sub/a2.jpg", "originals/a.jpg"}},
+		{"on", true, []string{"copies/a edited.jpg", "originals/a.jpg"}},
+		// The second scan takes the keys from the metadata cache.
+		{"on, cached", true, []string{"copies/a edited.jpg", "originals/a.jpg"}},
+	}
+	for _, tt := range tests {
+		photos, err := Load([]string{originals, copies}, LoadOptions{StateDir: stateDir, Dedupe: tt.dedupe})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, p := range photos {
+			rel, _ := filepath.Rel(dir, p.FilePath)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Load() found %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	cache, err := loadMetadataCache(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := cache.Entries[filepath.Join(copies, "a copy.jpg")].ContentKey; key == "" {
+		t.Error("content key was not cached")
+	}
+}
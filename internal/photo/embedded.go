@@ -0,0 +1,133 @@
+package photo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+const (
+	// tagXMP holds an XMP packet in TIFF-based files (TIFF, DNG, most RAW).
+	tagXMP = 0x02bc
+	// tagIPTC holds IPTC-IIM records in TIFF-based files.
+	tagIPTC = 0x83bb
+
+	jpegXMPHeader       = "http://ns.adobe.com/xap/1.0/\x00"
+	jpegPhotoshopHeader = "Photoshop 3.0\x00"
+
+	// photoshopIPTC is the Photoshop image resource holding IPTC-IIM.
+	photoshopIPTC = 0x0404
+)
+
+// embeddedMetadata holds the raw XMP and IPTC blocks describing a photo.
+// Either may be nil.
+type embeddedMetadata struct {
+	xmp  []byte
+	iptc []byte
+}
+
+// readEmbedded collects the XMP and IPTC metadata of the photo at path. A
+// RAW file's XMP sidecar, where Lightroom keeps edits, wins over XMP inside
+// the file. x may be nil if the file has no EXIF.
+func readEmbedded(path string, x *exif.Exif) embeddedMetadata {
+	var m embeddedMetadata
+	if x != nil && x.Tiff != nil && len(x.Tiff.Dirs) > 0 {
+		for _, tag := range x.Tiff.Dirs[0].Tags {
+			switch tag.Id {
+			case tagXMP:
+				m.xmp = tag.Val
+			case tagIPTC:
+				m.iptc = tag.Val
+			}
+		}
+	}
+	if m.xmp == nil && m.iptc == nil {
+		if f, err := os.Open(path); err == nil {
+			m = jpegMetadata(f)
+			f.Close()
+		}
+	}
+	if IsRawFile(path) {
+		if data, err := os.ReadFile(sidecarPath(path)); err == nil {
+			m.xmp = data
+		}
+	}
+	return m
+}
+
+// sidecarPath is where Lightroom writes the XMP sidecar for a RAW file:
+// the same name with an .xmp extension.
+func sidecarPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".xmp"
+}
+
+// jpegMetadata walks the JPEG header segments in r for the XMP packet and
+// the IPTC records. It stops at the image data, so only the start of the
+// file is read.
+func jpegMetadata(r io.ReadSeeker) embeddedMetadata {
+	var m embeddedMetadata
+	var marker [2]byte
+	if _, err := io.ReadFull(r, marker[:]); err != nil || marker != [2]byte{0xff, 0xd8} {
+		return m
+	}
+	for {
+		var head [4]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil || head[0] != 0xff {
+			return m
+		}
+		// Start of scan or end of image: no more metadata segments.
+		if head[1] == 0xda || head[1] == 0xd9 {
+			return m
+		}
+		size := int(binary.BigEndian.Uint16(head[2:])) - 2
+		if size < 0 {
+			return m
+		}
+		if head[1] != 0xe1 && head[1] != 0xed { // APP1, APP13
+			if _, err := r.Seek(int64(size), io.SeekCurrent); err != nil {
+				return m
+			}
+			continue
+		}
+		segment := make([]byte, size)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return m
+		}
+		if packet, ok := bytes.CutPrefix(segment, []byte(jpegXMPHeader)); ok && head[1] == 0xe1 {
+			m.xmp = packet
+		}
+		if resources, ok := bytes.CutPrefix(segment, []byte(jpegPhotoshopHeader)); ok && head[1] == 0xed {
+			m.iptc = photoshopResource(resources, photoshopIPTC)
+		}
+	}
+}
+
+// photoshopResource finds the image resource with the given ID among the
+// "8BIM" blocks of a Photoshop APP13 segment.
+func photoshopResource(b []byte, id uint16) []byte {
+	for len(b) >= 12 && string(b[:4]) == "8BIM" {
+		resID := binary.BigEndian.Uint16(b[4:])
+		// The name is a Pascal string padded to an even length.
+		nameLen := int(b[6]) + 1
+		nameLen += nameLen % 2
+		off := 6 + nameLen
+		if off+4 > len(b) {
+			return nil
+		}
+		size := int(binary.BigEndian.Uint32(b[off:]))
+		off += 4
+		if size < 0 || off+size > len(b) {
+			return nil
+		}
+		if resID == id {
+			return b[off : off+size]
+		}
+		b = b[min(off+size+size%2, len(b)):]
+	}
+	return nil
+}
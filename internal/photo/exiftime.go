@@ -0,0 +1,180 @@
+package photo
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+// exifTimeLayout is the fixed format of EXIF date/time strings.
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+// goexif predates EXIF 2.31 and does not know the UTC offset tags, so they
+// are loaded from the Exif sub-IFD under these names.
+const (
+	offsetTime          exif.FieldName = "OffsetTime"
+	offsetTimeOriginal  exif.FieldName = "OffsetTimeOriginal"
+	offsetTimeDigitized exif.FieldName = "OffsetTimeDigitized"
+)
+
+var offsetTimeFields = map[uint16]exif.FieldName{
+	0x9010: offsetTime,
+	0x9011: offsetTimeOriginal,
+	0x9012: offsetTimeDigitized,
+}
+
+// exifTimeField groups a timestamp tag with its sub-second and offset tags.
+type exifTimeField struct {
+	dateTime, subSec, offset exif.FieldName
+}
+
+// exifTimeFields lists the EXIF timestamps to try, best first: when the
+// shutter fired, when the image was digitized, then the last-modified stamp.
+var exifTimeFields = []exifTimeField{
+	{exif.DateTimeOriginal, exif.SubSecTimeOriginal, offsetTimeOriginal},
+	{exif.DateTimeDigitized, exif.SubSecTimeDigitized, offsetTimeDigitized},
+	{exif.DateTime, exif.SubSecTime, offsetTime},
+}
+
+// exifTakenTime returns the first parseable timestamp among exifTimeFields,
+// refined by its sub-second tag and placed in the zone given by its offset
+// tag. Without an offset the zone is worked out from the GPS timestamp of a
+// geotagged photo (see gpsZone), and otherwise the time is interpreted as
+// local, as goexif does. Blank or zeroed values ("0000:00:00 00:00:00") are
+// skipped.
+func exifTakenTime(x *exif.Exif) (time.Time, bool) {
+	loadOffsetTags(x)
+
+	for _, f := range exifTimeFields {
+		s, ok := exifString(x, f.dateTime)
+		if !ok {
+			continue
+		}
+		loc, zoned := time.Local, false
+		if off, ok := exifString(x, f.offset); ok {
+			loc, zoned = parseEXIFOffset(off)
+		}
+		if !zoned {
+			if loc, zoned = gpsZone(x, s); !zoned {
+				loc = time.Local
+			}
+		}
+		t, err := time.ParseInLocation(exifTimeLayout, s, loc)
+		if err != nil {
+			continue
+		}
+		if sub, ok := exifString(x, f.subSec); ok {
+			t = t.Add(parseEXIFSubSec(sub))
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// loadOffsetTags adds the offset tags from the Exif sub-IFD to x.
+func loadOffsetTags(x *exif.Exif) {
+	ptr, err := x.Get(exif.ExifIFDPointer)
+	if err != nil {
+		return
+	}
+	offset, err := ptr.Int64(0)
+	if err != nil || offset <= 0 || offset >= int64(len(x.Raw)) {
+		return
+	}
+	r := bytes.NewReader(x.Raw)
+	if _, err := r.Seek(offset, 0); err != nil {
+		return
+	}
+	dir, _, err := tiff.DecodeDir(r, x.Tiff.Order)
+	if err != nil {
+		return
+	}
+	x.LoadTags(dir, offsetTimeFields, false)
+}
+
+// exifString returns the trimmed value of an ASCII tag, if present and non-empty.
+func exifString(x *exif.Exif, name exif.FieldName) (string, bool) {
+	tag, err := x.Get(name)
+	if err != nil {
+		return "", false
+	}
+	s, err := tag.StringVal()
+	if err != nil {
+		return "", false
+	}
+	s = strings.TrimSpace(s)
+	return s, s != ""
+}
+
+// parseEXIFOffset parses an offset tag such as "+09:00" or "-05:30".
+func parseEXIFOffset(s string) (*time.Location, bool) {
+	t, err := time.Parse("-07:00", s)
+	if err != nil {
+		return nil, false
+	}
+	_, secs := t.Zone()
+	return time.FixedZone(s, secs), true
+}
+
+// gpsZone works out the zone of the camera-local time wall (in
+// exifTimeLayout) from the GPS timestamp, which is in UTC: the zone is the
+// difference between the two, to the nearest quarter hour, which allows for
+// a GPS fix a few minutes old. It reports false unless the photo has a GPS
+// position and timestamp a plausible offset apart.
+func gpsZone(x *exif.Exif, wall string) (*time.Location, bool) {
+	if lat, long, err := x.LatLong(); err != nil || !validLatLong(lat, long) {
+		return nil, false
+	}
+	date, ok := exifString(x, exif.GPSDateStamp)
+	if !ok {
+		return nil, false
+	}
+	utc, err := time.ParseInLocation("2006:01:02", date, time.UTC)
+	if err != nil {
+		return nil, false
+	}
+	stamp, err := x.Get(exif.GPSTimeStamp)
+	if err != nil {
+		return nil, false
+	}
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		num, den, err := stamp.Rat2(i)
+		if err != nil || den == 0 {
+			return nil, false
+		}
+		utc = utc.Add(time.Duration(float64(num) / float64(den) * float64(unit)))
+	}
+	local, err := time.ParseInLocation(exifTimeLayout, wall, time.UTC)
+	if err != nil {
+		return nil, false
+	}
+
+	// Zones run from UTC-12:00 to UTC+14:00.
+	offset := local.Sub(utc).Round(15 * time.Minute)
+	if offset < -12*time.Hour || offset > 14*time.Hour {
+		return nil, false
+	}
+	secs := int(offset.Seconds())
+	name := time.Unix(0, 0).In(time.FixedZone("", secs)).Format("-07:00")
+	return time.FixedZone(name, secs), true
+}
+
+// parseEXIFSubSec converts a sub-second tag, the decimal digits following the
+// seconds ("5" is 0.5s, "042" is 42ms), into a duration.
+func parseEXIFSubSec(s string) time.Duration {
+	if len(s) > 9 {
+		s = s[:9]
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0
+	}
+	for i := len(s); i < 9; i++ {
+		n *= 10
+	}
+	return time.Duration(n)
+}
@@ -0,0 +1,167 @@
+package photo
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestExtractTimeSubSecondBreaksTies(t *testing.T) {
+	dir := t.TempDir()
+	// Burst shots within the same second; names deliberately out of order.
+	subSecs := map[string]string{"c.jpg": "9", "a.jpg": "250", "b.jpg": "07"}
+	var paths []string
+	for name, sub := range subSecs {
+		paths = append(paths, writeTestJPEG(t, dir, name, testEXIF{
+			exif: []exifField{
+				asciiField(tagDateTimeOriginal, "2023:08:01 12:00:00"),
+				asciiField(tagSubSecTimeOrig, sub),
+			},
+		}))
+	}
+
+	var photos []Photo
+	for _, path := range paths {
+		meta, err := extractEXIF(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		taken := meta.takenTime
+		photos = append(photos, Photo{FilePath: path, TakenTime: taken})
+	}
+	sort.Slice(photos, func(i, j int) bool { return photos[i].TakenTime.Before(photos[j].TakenTime) })
+
+	wantNanos := []int{70_000_000, 250_000_000, 900_000_000}
+	for i, p := range photos {
+		if got := p.TakenTime.Nanosecond(); got != wantNanos[i] {
+			t.Errorf("photo %d (%s): nanoseconds = %d, want %d", i, p.FilePath, got, wantNanos[i])
+		}
+	}
+}
+
+func TestExtractTimeUsesOffsetTimeOriginal(t *testing.T) {
+	path := writeTestJPEG(t, t.TempDir(), "tokyo.jpg", testEXIF{
+		exif: []exifField{
+			asciiField(tagDateTimeOriginal, "2023:08:01 12:00:00"),
+			asciiField(tagOffsetTimeOrig, "+09:00"),
+			asciiField(tagSubSecTimeOrig, "5"),
+		},
+	})
+
+	meta, err := extractEXIF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := meta.takenTime
+	want := time.Date(2023, 8, 1, 3, 0, 0, 500_000_000, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("TakenTime = %v, want %v", got, want)
+	}
+	if _, offset := got.Zone(); offset != 9*60*60 {
+		t.Errorf("zone offset = %ds, want +09:00", offset)
+	}
+}
+
+// gpsFields places a photo near Tokyo, with its GPS fix at the given UTC
+// date and time.
+func gpsFields(date string, h, m, s uint32) []exifField {
+	return []exifField{
+		asciiField(tagGPSLatitudeRef, "N"),
+		ratField(tagGPSLatitude, 35, 40, 30),
+		asciiField(tagGPSLongitudeRef, "E"),
+		ratField(tagGPSLongitude, 139, 45, 10),
+		ratField(tagGPSTimeStamp, h, m, s),
+		asciiField(tagGPSDateStamp, date),
+	}
+}
+
+func TestExtractTimeTakesZoneFromGPS(t *testing.T) {
+	tests := []struct {
+		name       string
+		e          testEXIF
+		wantOffset int // seconds east of UTC; -1 for time.Local
+	}{
+		{
+			name: "GPS a few minutes behind",
+			e: testEXIF{
+				exif: []exifField{asciiField(tagDateTimeOriginal, "2023:08:01 12:00:00")},
+				gps:  gpsFields("2023:08:01", 2, 57, 40),
+			},
+			wantOffset: 9 * 60 * 60,
+		},
+		{
+			name: "UTC on the next day",
+			e: testEXIF{
+				exif: []exifField{asciiField(tagDateTimeOriginal, "2023:07:31 20:00:00")},
+				gps:  gpsFields("2023:08:01", 6, 0, 0),
+			},
+			wantOffset: -10 * 60 * 60,
+		},
+		{
+			name: "half-hour zone",
+			e: testEXIF{
+				exif: []exifField{asciiField(tagDateTimeOriginal, "2023:08:01 12:00:00")},
+				gps:  gpsFields("2023:08:01", 6, 30, 5),
+			},
+			wantOffset: 5*60*60 + 30*60,
+		},
+		{
+			name: "offset tag wins",
+			e: testEXIF{
+				exif: []exifField{
+					asciiField(tagDateTimeOriginal, "2023:08:01 12:00:00"),
+					asciiField(tagOffsetTimeOrig, "+02:00"),
+				},
+				gps: gpsFields("2023:08:01", 3, 0, 0),
+			},
+			wantOffset: 2 * 60 * 60,
+		},
+		{
+			name: "implausible offset",
+			e: testEXIF{
+				exif: []exifField{asciiField(tagDateTimeOriginal, "2023:08:01 12:00:00")},
+				gps:  gpsFields("2023:08:03", 3, 0, 0),
+			},
+			wantOffset: -1,
+		},
+		{
+			name:       "no GPS",
+			e:          testEXIF{exif: []exifField{asciiField(tagDateTimeOriginal, "2023:08:01 12:00:00")}},
+			wantOffset: -1,
+		},
+	}
+	for _, tt := range tests {
+		path := writeTestJPEG(t, t.TempDir(), "trip.jpg", tt.e)
+		meta, err := extractEXIF(path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := meta.takenTime
+		if tt.wantOffset == -1 {
+			if got.Location() != time.Local {
+				t.Errorf("%s: zone %v, want local time", tt.name, got.Location())
+			}
+			continue
+		}
+		if _, offset := got.Zone(); offset != tt.wantOffset {
+			t.Errorf("%s: zone offset %ds, want %ds", tt.name, offset, tt.wantOffset)
+		}
+	}
+}
+
+func TestParseEXIFSubSec(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"5", 500 * time.Millisecond},
+		{"042", 42 * time.Millisecond},
+		{"1234567891", 123456789},
+		{"abc", 0},
+	}
+	for _, tt := range tests {
+		if got := parseEXIFSubSec(tt.in); got != tt.want {
+			t.Errorf("parseEXIFSubSec(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package photo
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// The fixtures in testdata are tiny images:
+//
+//	full_exif.jpg     8x6, DateTimeOriginal 2021:05:30 18:45:12.25 at +02:00, orientation 1
+//	orientation6.jpg  8x6 as stored, DateTimeOriginal 2022:12:24 20:00:00, orientation 6
+//	no_exif.png       5x7, no metadata at all
+//	corrupt.jpg       a JPEG marker followed by junk
+//	bad_exif.jpg      full_exif.jpg with its EXIF cut off inside the first IFD
+
+// fixtureModTime is stamped on copies of the fixtures, since git does not
+// keep mod times.
+var fixtureModTime = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// copyFixture copies testdata/name into a temporary directory and sets its
+// mod time to fixtureModTime.
+func copyFixture(t *testing.T, name string) string {
+	t.Helper()
+	src, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	path := filepath.Join(t.TempDir(), name)
+	dst, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, fixtureModTime, fixtureModTime); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractEXIFFixtures(t *testing.T) {
+	tests := []struct {
+		file            string
+		wantTime        time.Time
+		wantOrientation int
+	}{
+		{
+			file:            "full_exif.jpg",
+			wantTime:        time.Date(2021, 5, 30, 18, 45, 12, 250_000_000, time.FixedZone("", 2*60*60)),
+			wantOrientation: 1,
+		},
+		{
+			file:            "orientation6.jpg",
+			wantTime:        time.Date(2022, 12, 24, 20, 0, 0, 0, time.Local),
+			wantOrientation: 6,
+		},
+		{
+			// Without EXIF the file's mod time stands in.
+			file:            "no_exif.png",
+			wantTime:        fixtureModTime,
+			wantOrientation: 1,
+		},
+		{
+			// Unreadable EXIF is not an error; the defaults apply.
+			file:            "corrupt.jpg",
+			wantTime:        fixtureModTime,
+			wantOrientation: 1,
+		},
+		{
+			file:            "bad_exif.jpg",
+			wantTime:        fixtureModTime,
+			wantOrientation: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			meta, err := extractEXIF(copyFixture(t, tt.file))
+			if err != nil {
+				t.Fatalf("extractEXIF() error = %v", err)
+			}
+			if !meta.takenTime.Equal(tt.wantTime) {
+				t.Errorf("takenTime = %v, want %v", meta.takenTime, tt.wantTime)
+			}
+			if meta.orientation != tt.wantOrientation {
+				t.Errorf("orientation = %d, want %d", meta.orientation, tt.wantOrientation)
+			}
+		})
+	}
+}
+
+func TestExtractDimensionsFixtures(t *testing.T) {
+	tests := []struct {
+		file         string
+		wantW, wantH int
+		wantErr      bool
+	}{
+		{file: "full_exif.jpg", wantW: 8, wantH: 6},
+		// Dimensions are as stored; orientation is applied by extractMetadata.
+		{file: "orientation6.jpg", wantW: 8, wantH: 6},
+		{file: "no_exif.png", wantW: 5, wantH: 7},
+		{file: "corrupt.jpg", wantErr: true},
+		{file: "bad_exif.jpg", wantW: 8, wantH: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			w, h, err := extractDimensions(filepath.Join("testdata", tt.file))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("extractDimensions() = %dx%d, want an error", w, h)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractDimensions() error = %v", err)
+			}
+			if w != tt.wantW || h != tt.wantH {
+				t.Errorf("extractDimensions() = %dx%d, want %dx%d", w, h, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestExtractMetadataFixtures(t *testing.T) {
+	tests := []struct {
+		file            string
+		wantW, wantH    int
+		wantOrientation int
+		wantErr         bool
+	}{
+		{file: "full_exif.jpg", wantW: 8, wantH: 6, wantOrientation: 1},
+		// Rotated 90°, so the display size is portrait.
+		{file: "orientation6.jpg", wantW: 6, wantH: 8, wantOrientation: 6},
+		{file: "no_exif.png", wantW: 5, wantH: 7, wantOrientation: 1},
+		{file: "corrupt.jpg", wantErr: true},
+		// Broken EXIF costs only the metadata, not the photo.
+		{file: "bad_exif.jpg", wantW: 8, wantH: 6, wantOrientation: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.file)
+			p, err := extractMetadata(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("extractMetadata() = %+v, want an error", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractMetadata() error = %v", err)
+			}
+			if p.FilePath != path {
+				t.Errorf("FilePath = %q, want %q", p.FilePath, path)
+			}
+			if p.Width != tt.wantW || p.Height != tt.wantH || p.Orientation != tt.wantOrientation {
+				t.Errorf("extractMetadata() = %dx%d orientation %d, want %dx%d orientation %d",
+					p.Width, p.Height, p.Orientation, tt.wantW, tt.wantH, tt.wantOrientation)
+			}
+		})
+	}
+}
+
+func TestLoadSkipsUnreadableFixtures(t *testing.T) {
+	photos, err := Load([]string{"testdata"}, LoadOptions{StateDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range photos {
+		got = append(got, filepath.Base(p.FilePath))
+	}
+	slices.Sort(got)
+	want := []string{"bad_exif.jpg", "full_exif.jpg", "no_exif.png", "orientation6.jpg"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Load() found %v, want %v", got, want)
+	}
+}
+
+func TestLoadLeavesOutSmallImages(t *testing.T) {
+	tests := []struct {
+		name string
+		opts LoadOptions
+		want []string
+	}{
+		{"no limits", LoadOptions{}, []string{"bad_exif.jpg", "full_exif.jpg", "no_exif.png", "orientation6.jpg"}},
+		{"min file size", LoadOptions{MinFileSize: 450}, []string{"full_exif.jpg", "orientation6.jpg"}},
+		{"min dimension", LoadOptions{MinDimension: 7}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.StateDir = t.TempDir()
+			photos, err := Load([]string{"testdata"}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range photos {
+				got = append(got, filepath.Base(p.FilePath))
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Load() found %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// panicReader panics when read, as goexif does on some malformed EXIF.
+type panicReader struct{}
+
+func (panicReader) Read([]byte) (int, error) { panic("index out of range") }
+
+func TestDecodeEXIFRecoversFromPanic(t *testing.T) {
+	x, err := decodeEXIF(panicReader{})
+	if !errors.Is(err, errEXIFPanic) {
+		t.Errorf("decodeEXIF() error = %v, want %v", err, errEXIFPanic)
+	}
+	if x != nil {
+		t.Errorf("decodeEXIF() = %v, want nil", x)
+	}
+	if got := ReadOrientation(panicReader{}); got != 1 {
+		t.Errorf("ReadOrientation() = %d, want 1", got)
+	}
+}
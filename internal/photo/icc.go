@@ -0,0 +1,156 @@
+package photo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/electronjoe/OpenFrame/internal/imgproc"
+)
+
+const (
+	jpegICCHeader = "ICC_PROFILE\x00"
+	pngSignature  = "\x89PNG\r\n\x1a\n"
+	// maxICCProfile bounds an embedded profile; most are a few KB, and a
+	// LUT-based printer profile a few hundred.
+	maxICCProfile = 4 << 20
+)
+
+// ReadICCProfile returns the ICC colour profile embedded in the JPEG or PNG
+// in r, or nil if it has none. A JPEG profile may be split over several APP2
+// segments, which are joined in sequence order; a PNG's is the iCCP chunk,
+// decompressed. Only the headers are read.
+func ReadICCProfile(r io.ReadSeeker) []byte {
+	var magic [8]byte
+	n, _ := io.ReadFull(r, magic[:])
+	if _, err := r.Seek(int64(-n), io.SeekCurrent); err != nil {
+		return nil
+	}
+	switch {
+	case n >= 2 && magic[0] == 0xff && magic[1] == 0xd8:
+		return jpegICCProfile(r)
+	case n == 8 && string(magic[:]) == pngSignature:
+		return pngICCProfile(r)
+	}
+	return nil
+}
+
+// ReadColorProfile returns the embedded colour profile of the image in r,
+// to convert it to sRGB with, or nil if converting would do nothing: there
+// is no profile, it is sRGB already, or it is of a kind imgproc cannot
+// convert from. Callers rewind r afterwards.
+func ReadColorProfile(r io.ReadSeeker) *imgproc.ColorProfile {
+	data := ReadICCProfile(r)
+	if data == nil {
+		return nil
+	}
+	profile, err := imgproc.ParseColorProfile(data)
+	if err != nil || profile.IsSRGB() {
+		return nil
+	}
+	return profile
+}
+
+// jpegICCProfile joins the ICC_PROFILE APP2 segments of the JPEG in r.
+func jpegICCProfile(r io.ReadSeeker) []byte {
+	if _, err := r.Seek(2, io.SeekCurrent); err != nil {
+		return nil
+	}
+	type chunk struct {
+		seq  byte
+		data []byte
+	}
+	var chunks []chunk
+	total := 0
+	for {
+		var head [4]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil || head[0] != 0xff {
+			break
+		}
+		// Start of scan or end of image: no more header segments.
+		if head[1] == 0xda || head[1] == 0xd9 {
+			break
+		}
+		size := int(binary.BigEndian.Uint16(head[2:])) - 2
+		if size < 0 {
+			break
+		}
+		if head[1] != 0xe2 { // APP2
+			if _, err := r.Seek(int64(size), io.SeekCurrent); err != nil {
+				break
+			}
+			continue
+		}
+		segment := make([]byte, size)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			break
+		}
+		// Each chunk is numbered from 1, after which comes the count.
+		body, ok := bytes.CutPrefix(segment, []byte(jpegICCHeader))
+		if !ok || len(body) < 2 {
+			continue
+		}
+		total += len(body) - 2
+		if total > maxICCProfile {
+			return nil
+		}
+		chunks = append(chunks, chunk{seq: body[0], data: body[2:]})
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].seq < chunks[j].seq })
+	profile := make([]byte, 0, total)
+	for _, c := range chunks {
+		profile = append(profile, c.data...)
+	}
+	return profile
+}
+
+// pngICCProfile decompresses the iCCP chunk of the PNG in r, which must come
+// before the image data.
+func pngICCProfile(r io.ReadSeeker) []byte {
+	if _, err := r.Seek(8, io.SeekCurrent); err != nil {
+		return nil
+	}
+	for {
+		var head [8]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			return nil
+		}
+		size := int64(binary.BigEndian.Uint32(head[:4]))
+		switch string(head[4:]) {
+		case "IDAT", "IEND":
+			return nil
+		case "iCCP":
+			if size > maxICCProfile {
+				return nil
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return nil
+			}
+			// A profile name of up to 79 bytes and its terminating NUL,
+			// then the compression method (0, zlib).
+			nul := bytes.IndexByte(chunk, 0)
+			if nul < 0 || nul+2 > len(chunk) || chunk[nul+1] != 0 {
+				return nil
+			}
+			z, err := zlib.NewReader(bytes.NewReader(chunk[nul+2:]))
+			if err != nil {
+				return nil
+			}
+			profile, err := io.ReadAll(io.LimitReader(z, maxICCProfile))
+			if err != nil {
+				return nil
+			}
+			return profile
+		}
+		// Skip the data and its CRC.
+		if _, err := r.Seek(size+4, io.SeekCurrent); err != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package photo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestReadICCProfile(t *testing.T) {
+	profile := bytes.Repeat([]byte("profile "), 100)
+	img := image.NewGray(image.Rect(0, 0, 8, 6))
+
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	// A profile in two APP2 chunks, stored out of order.
+	var segments []byte
+	for _, chunk := range []struct {
+		seq  byte
+		data []byte
+	}{{2, profile[500:]}, {1, profile[:500]}} {
+		payload := append([]byte(jpegICCHeader), chunk.seq, 2)
+		payload = append(payload, chunk.data...)
+		segments = append(segments, 0xff, 0xe2)
+		segments = binary.BigEndian.AppendUint16(segments, uint16(len(payload)+2))
+		segments = append(segments, payload...)
+	}
+	withJPEGProfile := append(append(append([]byte{}, jpg.Bytes()[:2]...), segments...), jpg.Bytes()[2:]...)
+
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, img); err != nil {
+		t.Fatal(err)
+	}
+	// The iCCP chunk goes after IHDR, which is 25 bytes from its start.
+	var compressed bytes.Buffer
+	z := zlib.NewWriter(&compressed)
+	z.Write(profile)
+	z.Close()
+	body := append([]byte("iCCP"), "Display P3\x00\x00"...)
+	body = append(body, compressed.Bytes()...)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(body)-4))
+	chunk = append(chunk, body...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(body))
+	ihdrEnd := len(pngSignature) + 25
+	withPNGProfile := append(append(append([]byte{}, pngData.Bytes()[:ihdrEnd]...), chunk...), pngData.Bytes()[ihdrEnd:]...)
+	if _, err := png.Decode(bytes.NewReader(withPNGProfile)); err != nil {
+		t.Fatalf("PNG with the iCCP chunk does not decode: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{name: "jpeg", data: withJPEGProfile, want: profile},
+		{name: "jpeg without a profile", data: jpg.Bytes()},
+		{name: "png", data: withPNGProfile, want: profile},
+		{name: "png without a profile", data: pngData.Bytes()},
+		{name: "neither", data: []byte("GIF89a")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReadICCProfile(bytes.NewReader(tt.data)); !bytes.Equal(got, tt.want) {
+				t.Errorf("ReadICCProfile() = %d bytes, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package photo
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultIgnoreExtensions are the extensions of files Load leaves out
+// without a word when LoadOptions.IgnoreExtensions is nil: videos, including
+// the motion half of live photos, and the sidecars and system files that
+// share folders with photos.
+var DefaultIgnoreExtensions = []string{
+	".mov", ".mp4", ".m4v", ".avi", ".mkv", ".webm", ".3gp", ".mts",
+	".aae", ".xmp", ".json", ".thm", ".db", ".ini", ".ds_store",
+}
+
+// livePhotoStills are the extensions, in both cases, of the still a live
+// photo's video may sit beside.
+var livePhotoStills = []string{".heic", ".jpg", ".jpeg", ".HEIC", ".JPG", ".JPEG"}
+
+// skippedFiles tallies the files a walk leaves out as unsupported, by
+// extension, so that each kind is warned about once rather than file by
+// file.
+type skippedFiles struct {
+	ignore []string
+	counts map[string]int
+}
+
+func newSkippedFiles(ignore []string) *skippedFiles {
+	if ignore == nil {
+		ignore = DefaultIgnoreExtensions
+	}
+	return &skippedFiles{ignore: ignore, counts: make(map[string]int)}
+}
+
+// add notes the unsupported file at path, unless its extension is one to
+// ignore or it is the video of a live photo.
+func (s *skippedFiles) add(path string) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if slices.ContainsFunc(s.ignore, func(e string) bool { return strings.EqualFold(e, ext) }) || isLivePhotoVideo(path) {
+		return
+	}
+	s.counts[ext]++
+}
+
+// log warns about each extension left out.
+func (s *skippedFiles) log() {
+	exts := make([]string, 0, len(s.counts))
+	for ext := range s.counts {
+		exts = append(exts, ext)
+	}
+	slices.Sort(exts)
+	for _, ext := range exts {
+		name := ext
+		if name == "" {
+			name = "extensionless"
+		}
+		log.Printf("Warning: left out %d %s files: not a supported image format (see ignoreExtensions)", s.counts[ext], name)
+	}
+}
+
+// isLivePhotoVideo reports whether path is the video half of a live photo: a
+// .mov or .mp4 beside a still of the same name, which is shown on its own.
+func isLivePhotoVideo(path string) bool {
+	ext := filepath.Ext(path)
+	switch strings.ToLower(ext) {
+	case ".mov", ".mp4":
+	default:
+		return false
+	}
+	base := strings.TrimSuffix(path, ext)
+	for _, still := range livePhotoStills {
+		if _, err := os.Stat(base + still); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package photo
+
+import (
+	"encoding/binary"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// IPTC-IIM application record datasets: one keyword each, and the caption.
+const (
+	iptcTagMarker       = 0x1c
+	iptcApplication     = 2
+	iptcKeywordsDataset = 25
+	iptcCaptionDataset  = 120
+)
+
+var (
+	// xmpSubjectRE matches the dc:subject bag where XMP keeps keywords.
+	xmpSubjectRE = regexp.MustCompile(`(?s)<dc:subject>\s*<rdf:Bag\s*>(.*?)</rdf:Bag>`)
+	xmpItemRE    = regexp.MustCompile(`(?s)<rdf:li[^>]*>(.*?)</rdf:li>`)
+)
+
+// FilterByKeywords drops photos tagged with any of exclude. When include is
+// non-empty only photos tagged with one of include are kept, plus untagged
+// photos if includeUntagged is set. Keywords compare case-insensitively.
+func FilterByKeywords(photos []Photo, include, exclude []string, includeUntagged bool) []Photo {
+	if len(include) == 0 && len(exclude) == 0 {
+		return photos
+	}
+	kept := make([]Photo, 0, len(photos))
+	for _, p := range photos {
+		if hasAnyKeyword(p, exclude) {
+			continue
+		}
+		if len(include) > 0 && !hasAnyKeyword(p, include) && (len(p.Keywords) > 0 || !includeUntagged) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+func hasAnyKeyword(p Photo, keywords []string) bool {
+	for _, k := range p.Keywords {
+		for _, want := range keywords {
+			if strings.EqualFold(k, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readKeywords merges the XMP and IPTC keywords of a photo, dropping
+// duplicates (ignoring case) and keeping the first spelling seen.
+func readKeywords(m embeddedMetadata) []string {
+	var keywords []string
+	for _, k := range append(xmpKeywords(m.xmp), iptcValues(m.iptc, iptcKeywordsDataset)...) {
+		k = strings.TrimSpace(k)
+		if k == "" || slices.ContainsFunc(keywords, func(seen string) bool { return strings.EqualFold(seen, k) }) {
+			continue
+		}
+		keywords = append(keywords, k)
+	}
+	return keywords
+}
+
+// xmpKeywords extracts the dc:subject entries of an XMP packet.
+func xmpKeywords(packet []byte) []string {
+	bag := xmpSubjectRE.FindSubmatch(packet)
+	if bag == nil {
+		return nil
+	}
+	var keywords []string
+	for _, item := range xmpItemRE.FindAllSubmatch(bag[1], -1) {
+		keywords = append(keywords, html.UnescapeString(string(item[1])))
+	}
+	return keywords
+}
+
+// iptcValues extracts the values of one application dataset from IPTC-IIM
+// records, stopping at anything malformed. Extended-length datasets are
+// never keywords or captions, so they end the scan too.
+func iptcValues(b []byte, dataset byte) []string {
+	var values []string
+	for len(b) >= 5 && b[0] == iptcTagMarker {
+		record, ds := b[1], b[2]
+		size := int(binary.BigEndian.Uint16(b[3:]))
+		if size&0x8000 != 0 || 5+size > len(b) {
+			break
+		}
+		if record == iptcApplication && ds == dataset {
+			values = append(values, string(b[5:5+size]))
+		}
+		b = b[5+size:]
+	}
+	return values
+}
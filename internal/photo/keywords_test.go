@@ -0,0 +1,131 @@
+package photo
+
+import (
+	"slices"
+	"testing"
+)
+
+// iptcRecord encodes one IPTC-IIM dataset.
+func iptcRecord(record, dataset byte, value string) []byte {
+	return append([]byte{iptcTagMarker, record, dataset, byte(len(value) >> 8), byte(len(value))}, value...)
+}
+
+func TestXMPKeywords(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet string
+		want   []string
+	}{
+		{
+			name: "subject bag",
+			packet: xmpPacket(` xmlns:dc="http://purl.org/dc/elements/1.1/">
+   <dc:subject>
+    <rdf:Bag>
+     <rdf:li>Beach</rdf:li>
+     <rdf:li xml:lang="en">Tom &amp; Jo</rdf:li>
+    </rdf:Bag>
+   </dc:subject>
+  </rdf:Description>`),
+			want: []string{"Beach", "Tom & Jo"},
+		},
+		{
+			name: "other bags are not keywords",
+			packet: xmpPacket(` xmlns:dc="http://purl.org/dc/elements/1.1/">
+   <dc:creator><rdf:Seq><rdf:li>Jo</rdf:li></rdf:Seq></dc:creator>
+   <lr:hierarchicalSubject><rdf:Bag><rdf:li>Places|Beach</rdf:li></rdf:Bag></lr:hierarchicalSubject>
+  </rdf:Description>`),
+		},
+		{name: "empty bag", packet: xmpPacket(`><dc:subject><rdf:Bag/></dc:subject></rdf:Description>`)},
+		{name: "no packet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := xmpKeywords([]byte(tt.packet)); !slices.Equal(got, tt.want) {
+				t.Errorf("xmpKeywords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPTCValues(t *testing.T) {
+	join := func(parts ...[]byte) []byte { return slices.Concat(parts...) }
+	beach := iptcRecord(iptcApplication, iptcKeywordsDataset, "Beach")
+	tests := []struct {
+		name string
+		iptc []byte
+		want []string
+	}{
+		{
+			name: "keywords among other datasets",
+			iptc: join(
+				iptcRecord(1, 90, "\x1b%G"), // envelope: coded character set
+				beach,
+				iptcRecord(iptcApplication, iptcCaptionDataset, "Summer"),
+				iptcRecord(iptcApplication, iptcKeywordsDataset, "Tom & Jo"),
+			),
+			want: []string{"Beach", "Tom & Jo"},
+		},
+		{name: "empty"},
+		{name: "length past the end", iptc: join(beach, []byte{iptcTagMarker, 2, 25, 0, 9, 'S', 'e', 'a'}), want: []string{"Beach"}},
+		{name: "header cut short", iptc: join(beach, []byte{iptcTagMarker, 2, 25, 0}), want: []string{"Beach"}},
+		{name: "extended length", iptc: join(beach, []byte{iptcTagMarker, 2, 25, 0x80, 4, 0, 0, 0, 3, 'S', 'e', 'a'}, beach), want: []string{"Beach"}},
+		{name: "no tag marker", iptc: join(beach, []byte{0, 2, 25, 0, 3, 'S', 'e', 'a'}, beach), want: []string{"Beach"}},
+		{name: "empty keyword", iptc: iptcRecord(iptcApplication, iptcKeywordsDataset, ""), want: []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := iptcValues(tt.iptc, iptcKeywordsDataset); !slices.Equal(got, tt.want) {
+				t.Errorf("iptcValues() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadKeywordsMergesXMPAndIPTC(t *testing.T) {
+	m := embeddedMetadata{
+		xmp: []byte(`<dc:subject><rdf:Bag><rdf:li>Beach</rdf:li><rdf:li> </rdf:li></rdf:Bag></dc:subject>`),
+		iptc: slices.Concat(
+			iptcRecord(iptcApplication, iptcKeywordsDataset, "BEACH"),
+			iptcRecord(iptcApplication, iptcKeywordsDataset, " Family "),
+		),
+	}
+	if got, want := readKeywords(m), []string{"Beach", "Family"}; !slices.Equal(got, want) {
+		t.Errorf("readKeywords() = %q, want %q", got, want)
+	}
+}
+
+func TestFilterByKeywords(t *testing.T) {
+	photos := []Photo{
+		{FilePath: "beach.jpg", Keywords: []string{"Beach", "Family"}},
+		{FilePath: "work.jpg", Keywords: []string{"work"}},
+		{FilePath: "private.jpg", Keywords: []string{"Family", "Private"}},
+		{FilePath: "untagged.jpg"},
+	}
+	tests := []struct {
+		name            string
+		include         []string
+		exclude         []string
+		includeUntagged bool
+		want            []string
+	}{
+		{name: "no filter", want: []string{"beach.jpg", "work.jpg", "private.jpg", "untagged.jpg"}},
+		{name: "include", include: []string{"family"}, want: []string{"beach.jpg", "private.jpg"}},
+		{name: "include with untagged", include: []string{"FAMILY"}, includeUntagged: true, want: []string{"beach.jpg", "private.jpg", "untagged.jpg"}},
+		{name: "exclude", exclude: []string{"private", "WORK"}, want: []string{"beach.jpg", "untagged.jpg"}},
+		{name: "exclude wins over include", include: []string{"Family"}, exclude: []string{"Private"}, want: []string{"beach.jpg"}},
+		{name: "untagged flag alone keeps everything", includeUntagged: true, want: []string{"beach.jpg", "work.jpg", "private.jpg", "untagged.jpg"}},
+		{name: "exclude keeps untagged either way", exclude: []string{"work"}, includeUntagged: false, want: []string{"beach.jpg", "private.jpg", "untagged.jpg"}},
+		{name: "no match", include: []string{"Mountains"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, p := range FilterByKeywords(photos, tt.include, tt.exclude, tt.includeUntagged) {
+				got = append(got, p.FilePath)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FilterByKeywords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package photo
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"image"
 	_ "image/gif"
 	_ "image/jpeg"
 	_ "image/png"
+	"io"
 	"io/fs"
 	"log"
 	"os"
@@ -14,78 +17,139 @@ import (
 	"time"
 
 	"github.com/rwcarlsen/goexif/exif"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
 )
 
 // Photo represents a single photo's metadata (including orientation).
 type Photo struct {
 	FilePath    string
+	Album       string // the album directory or URL it was found in; empty for playlist files
 	TakenTime   time.Time
-	Width       int
-	Height      int
+	Width       int // display width, i.e. after applying Orientation
+	Height      int // display height, i.e. after applying Orientation
 	Orientation int // EXIF orientation value, 1–8
+
+	// Latitude and Longitude are the EXIF GPS position in decimal degrees,
+	// valid only when HasLocation is set.
+	Latitude    float64
+	Longitude   float64
+	HasLocation bool
+
+	// Rating is the star rating from XMP or EXIF: 1–5, 0 if unrated, -1 if
+	// rejected.
+	Rating int
+
+	// Keywords are the photo's XMP (dc:subject) and IPTC keywords.
+	Keywords []string
+
+	// Caption is the photo's description from XMP, IPTC or EXIF.
+	Caption string
+
+	// Shot is the camera, lens and exposure settings from EXIF, or nil if
+	// it records none.
+	Shot *Shot
+
+	// Place is the friendly place name cmd/geocode recorded for the photo
+	// in its folder's metadata.json, if any.
+	Place string
+
+	// RotatedToFill marks a landscape the slide builder turned 90°
+	// clockwise to stand beside a portrait; Width and Height are then its
+	// turned size. The renderer turns the image to match.
+	RotatedToFill bool
+
+	// Transform is the reframing from the photo's transform sidecar, if it
+	// has one; Width and Height are the reframed size.
+	Transform *Transform
+}
+
+// LoadOptions tunes how Load scans albums.
+type LoadOptions struct {
+	// StateDir holds the metadata cache; it defaults to ~/.openframe so
+	// separate instances can keep separate caches.
+	StateDir string
+
+	// MinDimension and MinFileSize make Load leave out images narrower or
+	// shorter than MinDimension pixels, or smaller than MinFileSize bytes,
+	// such as stickers and icons among exported photos. Zero keeps all.
+	MinDimension int
+	MinFileSize  int64
+
+	// FollowSymlinks makes Load walk symlinked directories, an album that
+	// is itself a symlink included; see walkAlbum.
+	FollowSymlinks bool
+
+	// Dedupe makes Load keep one of each set of identical files, such as a
+	// photo copied into two albums; see contentKey.
+	Dedupe bool
+
+	// IgnoreExtensions, e.g. ".mov", are of files Load leaves out without a
+	// word; other files that are not supported images get one warning per
+	// extension. Nil means DefaultIgnoreExtensions.
+	IgnoreExtensions []string
 }
 
 // Load walks each album directory, gathering metadata for each image file.
-func Load(albumDirs []string) ([]Photo, error) {
-	cache, err := loadMetadataCache()
-	if err != nil {
-		log.Printf("Warning: could not load metadata cache: %v", err)
-		cache = newMetadataCache()
-	}
+func Load(albumDirs []string, opts LoadOptions) ([]Photo, error) {
+	cache := openMetadataCache(opts.StateDir)
 
 	var photos []Photo
 	cacheUpdated := false
 	seenPaths := make(map[string]struct{})
+	tooSmall := 0
+	keys := make(map[string]string)
+	skipped := newSkippedFiles(opts.IgnoreExtensions)
 
 	for _, albumDir := range albumDirs {
-		err := filepath.WalkDir(albumDir, func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				log.Printf("Error accessing %s: %v", path, err)
-				// Skip this file/dir but keep walking
-				return nil
-			}
-			if d.IsDir() {
-				return nil
-			}
-			if !isImageFile(path) {
-				return nil
+		err := walkAlbum(albumDir, opts.FollowSymlinks, func(path string, d fs.DirEntry) {
+			if !IsImageFile(path) {
+				// A symlink may be to a directory left unwalked.
+				if d.Type().IsRegular() {
+					skipped.add(path)
+				}
+				return
 			}
 
 			seenPaths[path] = struct{}{}
 
+			// A symlinked photo's size and mod time are its target's.
 			info, infoErr := d.Info()
-			if infoErr != nil {
+			if infoErr != nil || d.Type()&fs.ModeSymlink != 0 {
 				info, infoErr = os.Stat(path)
 			}
 			if infoErr != nil {
 				log.Printf("Warning: could not stat %s: %v", path, infoErr)
-				return nil
+				return
 			}
-			modTime := info.ModTime()
-
-			if cached, ok := cache.get(path, modTime); ok {
-				photos = append(photos, cached)
-				return nil
+			if info.Size() < opts.MinFileSize {
+				tooSmall++
+				return
 			}
 
-			takenTime, width, height, orientation, err := extractMetadata(path)
+			p, updated, err := cachedMetadata(cache, path, info.ModTime())
 			if err != nil {
 				// Not critical; just log a warning and skip this file
 				log.Printf("Warning: could not extract metadata for %s: %v", path, err)
-				return nil
+				return
 			}
-
-			p := Photo{
-				FilePath:    path,
-				TakenTime:   takenTime,
-				Width:       width,
-				Height:      height,
-				Orientation: orientation,
+			cacheUpdated = cacheUpdated || updated
+			if p.Width < opts.MinDimension || p.Height < opts.MinDimension {
+				tooSmall++
+				return
+			}
+			if opts.Dedupe {
+				key, updated, err := cachedContentKey(cache, path, info)
+				if err != nil {
+					// Shown, but never taken for a copy.
+					log.Printf("Warning: could not hash %s: %v", path, err)
+				} else {
+					keys[path] = key
+					cacheUpdated = cacheUpdated || updated
+				}
 			}
+			p.Album = albumDir
 			photos = append(photos, p)
-			cache.set(path, modTime, p)
-			cacheUpdated = true
-			return nil
 		})
 		if err != nil {
 			// Log but continue; one bad directory shouldn’t break the entire load
@@ -93,96 +157,412 @@ func Load(albumDirs []string) ([]Photo, error) {
 		}
 	}
 
+	skipped.log()
+	if tooSmall > 0 {
+		log.Printf("Left out %d images under %dpx or %d bytes", tooSmall, opts.MinDimension, opts.MinFileSize)
+	}
+
+	if opts.Dedupe {
+		n := len(photos)
+		photos = dedupe(photos, keys)
+		if dups := n - len(photos); dups > 0 {
+			log.Printf("Left out %d duplicate photos", dups)
+		}
+	}
+
 	if cache.prune(seenPaths) {
 		cacheUpdated = true
 	}
 
 	if cacheUpdated {
-		if err := saveMetadataCache(cache); err != nil {
+		if err := saveMetadataCache(opts.StateDir, cache); err != nil {
 			log.Printf("Warning: could not save metadata cache: %v", err)
 		}
 	}
 
+	attachPlaces(photos)
 	return photos, nil
 }
 
-// isImageFile checks for common image file extensions.
-func isImageFile(path string) bool {
+// walkAlbum calls fn for each file under album, skipping (and logging)
+// entries it cannot read. Symlinks are reported as files and not followed
+// unless followSymlinks is set; then symlinked directories, album itself
+// included, are walked too, with their files reported under the path
+// through the link.
+func walkAlbum(album string, followSymlinks bool, fn func(path string, d fs.DirEntry)) error {
+	if followSymlinks {
+		return walkFollowingSymlinks(album, make(map[string]bool), fn)
+	}
+	return filepath.WalkDir(album, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.Printf("Error accessing %s: %v", path, err)
+			// Skip this file/dir but keep walking
+			return nil
+		}
+		if !d.IsDir() {
+			fn(path, d)
+		}
+		return nil
+	})
+}
+
+// walkFollowingSymlinks walks the directory dir resolves to for walkAlbum,
+// following symlinks to directories. visited holds the real paths of the
+// directories walked so far; a link to one of them, such as a link back up
+// the tree that would otherwise loop forever, is logged and skipped.
+func walkFollowingSymlinks(dir string, visited map[string]bool, fn func(path string, d fs.DirEntry)) error {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	return filepath.WalkDir(real, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.Printf("Error accessing %s: %v", path, err)
+			return nil
+		}
+		// Report the path as reached through dir, not where it really is.
+		rel, err := filepath.Rel(real, path)
+		if err != nil {
+			return nil
+		}
+		linked := filepath.Join(dir, rel)
+
+		switch {
+		case d.IsDir():
+			if visited[path] {
+				log.Printf("Skipping %s: it leads back to %s, which is already being scanned", linked, path)
+				return filepath.SkipDir
+			}
+			visited[path] = true
+		case d.Type()&fs.ModeSymlink != 0:
+			info, err := os.Stat(path)
+			if err != nil {
+				log.Printf("Error accessing %s: %v", linked, err)
+				return nil
+			}
+			if !info.IsDir() {
+				fn(linked, d)
+				return nil
+			}
+			if err := walkFollowingSymlinks(linked, visited, fn); err != nil {
+				log.Printf("Error walking directory %s: %v", linked, err)
+			}
+		default:
+			fn(linked, d)
+		}
+		return nil
+	})
+}
+
+// LoadFiles gathers metadata for the listed image files, keeping their order
+// (and any repeats). Files that are missing, unsupported or unreadable are
+// logged and skipped. Unlike Load it never prunes the metadata cache, since
+// the list says nothing about photos elsewhere in the albums.
+func LoadFiles(paths []string, opts LoadOptions) ([]Photo, error) {
+	cache := openMetadataCache(opts.StateDir)
+
+	var photos []Photo
+	cacheUpdated := false
+	for _, path := range paths {
+		if !IsImageFile(path) {
+			log.Printf("Skipping %s: not a supported image file", path)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			log.Printf("Skipping %s: %v", path, err)
+			continue
+		}
+		if info.IsDir() {
+			log.Printf("Skipping %s: is a directory", path)
+			continue
+		}
+
+		p, updated, err := cachedMetadata(cache, path, info.ModTime())
+		if err != nil {
+			log.Printf("Skipping %s: could not extract metadata: %v", path, err)
+			continue
+		}
+		photos = append(photos, p)
+		cacheUpdated = cacheUpdated || updated
+	}
+
+	if cacheUpdated {
+		if err := saveMetadataCache(opts.StateDir, cache); err != nil {
+			log.Printf("Warning: could not save metadata cache: %v", err)
+		}
+	}
+	attachPlaces(photos)
+	return photos, nil
+}
+
+// ReadMetadata extracts the metadata of the image file at path without
+// consulting the metadata cache, for callers that keep their own.
+func ReadMetadata(path string) (Photo, error) {
+	return extractMetadata(path)
+}
+
+// openMetadataCache loads the metadata cache, starting afresh if it cannot
+// be read.
+func openMetadataCache(stateDir string) *metadataCache {
+	cache, err := loadMetadataCache(stateDir)
+	if err != nil {
+		log.Printf("Warning: could not load metadata cache: %v", err)
+		return newMetadataCache()
+	}
+	return cache
+}
+
+// cachedMetadata returns the metadata for path from the cache, extracting and
+// caching it if the file, its transform sidecar or a RAW file's XMP sidecar
+// has changed. updated
+// reports whether the cache was modified.
+func cachedMetadata(cache *metadataCache, path string, modTime time.Time) (p Photo, updated bool, err error) {
+	if IsRawFile(path) {
+		// Re-rating in Lightroom only touches the sidecar.
+		if info, err := os.Stat(sidecarPath(path)); err == nil && info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	if info, err := os.Stat(TransformPath(path)); err == nil && info.ModTime().After(modTime) {
+		modTime = info.ModTime()
+	}
+	if cached, ok := cache.get(path, modTime); ok {
+		return cached, false, nil
+	}
+	p, err = extractMetadata(path)
+	if err != nil {
+		return Photo{}, false, err
+	}
+	cache.set(path, modTime, p)
+	return p, true, nil
+}
+
+// IsImageFile reports whether path has the extension of a format Load picks
+// up, including camera RAW.
+func IsImageFile(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
 	switch ext {
-	case ".jpg", ".jpeg", ".png", ".gif":
+	case ".jpg", ".jpeg", ".png", ".gif", ".tif", ".tiff", ".bmp":
 		return true
+	case ".avif":
+		return avifSupported
 	}
-	return false
+	return IsRawFile(path)
 }
 
 // extractMetadata obtains the photo's timestamp (from EXIF or file mod time),
-// the image dimensions, and the EXIF orientation (1–8).
-func extractMetadata(path string) (time.Time, int, int, int, error) {
-	takenTime, orientation, err := extractTimeAndOrientation(path)
+// the image dimensions, the EXIF orientation (1–8), any GPS position, the
+// star rating, keywords and caption, and the transform from its sidecar.
+func extractMetadata(path string) (Photo, error) {
+	meta, err := extractEXIF(path)
 	if err != nil {
-		return time.Time{}, 0, 0, 0, err
+		return Photo{}, err
 	}
 
 	width, height, err := extractDimensions(path)
 	if err != nil {
-		return time.Time{}, 0, 0, 0, err
+		return Photo{}, err
 	}
 
 	// If orientation is 5,6,7,8, swap width and height
 	// so that Photo.Width, Photo.Height reflect the final (rotated) dimensions.
-	switch orientation {
+	switch meta.orientation {
 	case 5, 6, 7, 8:
 		width, height = height, width
 	}
 
-	return takenTime, width, height, orientation, nil
+	// A broken sidecar is not worth leaving the photo out for.
+	transform, err := readTransform(path)
+	if err != nil {
+		log.Printf("Warning: ignoring transform: %v", err)
+	}
+	width, height = transform.Size(width, height)
+
+	return Photo{
+		FilePath:    path,
+		TakenTime:   meta.takenTime,
+		Width:       width,
+		Height:      height,
+		Orientation: meta.orientation,
+		Latitude:    meta.latitude,
+		Longitude:   meta.longitude,
+		HasLocation: meta.hasLocation,
+		Rating:      meta.rating,
+		Keywords:    meta.keywords,
+		Caption:     meta.caption,
+		Shot:        meta.shot,
+		Transform:   transform,
+	}, nil
+}
+
+// exifMetadata is what extractEXIF reads in its single pass over the file.
+type exifMetadata struct {
+	takenTime   time.Time
+	orientation int
+	latitude    float64
+	longitude   float64
+	hasLocation bool
+	rating      int
+	keywords    []string
+	caption     string
+	shot        *Shot
 }
 
-// extractTimeAndOrientation reads EXIF data to get date/time and orientation.
-// If not found, orientation defaults to 1 (no transform).
-func extractTimeAndOrientation(path string) (time.Time, int, error) {
+// extractEXIF reads EXIF data to get date/time, orientation, GPS position and
+// camera settings, and the star rating, keywords and caption from XMP, IPTC or EXIF. If not
+// found, orientation defaults to 1 (no transform), the time to the file's mod
+// time, hasLocation is false and the photo is unrated, untagged and
+// uncaptioned.
+func extractEXIF(path string) (exifMetadata, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return time.Time{}, 1, fmt.Errorf("open file: %w", err)
+		return exifMetadata{}, fmt.Errorf("open file: %w", err)
 	}
 	defer f.Close()
 
-	var takenTime time.Time
-	var orientation = 1 // default if tag missing or invalid
+	meta := exifMetadata{orientation: 1} // default if tag missing or invalid
 
-	x, errDecode := exif.Decode(f)
-	if errDecode == nil && x != nil {
-		// Attempt to read EXIF DateTime
-		if t, errDate := x.DateTime(); errDate == nil {
-			takenTime = t
+	// AVIF keeps its EXIF in an item of its own, and its rotation outside
+	// the EXIF.
+	var src io.Reader = f
+	var avif avifInfo
+	if isAVIF(path) {
+		if avif, err = readAVIF(f); err != nil {
+			return exifMetadata{}, fmt.Errorf("read AVIF container: %w", err)
 		}
-		// Attempt to read Orientation tag
-		tagOrient, errOrient := x.Get(exif.Orientation)
-		if errOrient == nil && tagOrient != nil {
-			if orientVal, errConv := tagOrient.Int(0); errConv == nil {
-				orientation = orientVal
+		src = bytes.NewReader(avif.exif)
+	}
+
+	// goexif reads TIFF files natively, so scanner output keeps its DateTime.
+	x, errDecode := decodeEXIF(src)
+	if errDecode != nil {
+		if errors.Is(errDecode, errEXIFPanic) {
+			log.Printf("Warning: malformed EXIF in %s: %v", path, errDecode)
+		}
+		x = nil
+	}
+	if x != nil {
+		errTags := guardEXIF(func() {
+			if t, ok := exifTakenTime(x); ok {
+				meta.takenTime = t
 			}
+			meta.orientation = exifOrientation(x)
+			// Most photos carry no GPS; that is not an error.
+			if lat, long, errGPS := x.LatLong(); errGPS == nil && validLatLong(lat, long) {
+				meta.latitude, meta.longitude, meta.hasLocation = lat, long, true
+			}
+			meta.shot = readShot(x)
+		})
+		if errTags != nil {
+			log.Printf("Warning: malformed EXIF in %s: %v", path, errTags)
+			x = nil
 		}
 	}
+	if isAVIF(path) {
+		meta.orientation = avif.orientation()
+	}
+	embedded := readEmbedded(path, x)
+	meta.rating = readRating(embedded, x)
+	meta.keywords = readKeywords(embedded)
+	meta.caption = readCaption(embedded, x)
 
 	// Fallback to file mod time if EXIF time was not available
-	if takenTime.IsZero() {
+	if meta.takenTime.IsZero() {
 		info, errStat := os.Stat(path)
 		if errStat == nil {
-			takenTime = info.ModTime()
+			meta.takenTime = info.ModTime()
 		} else {
 			// If we somehow can't get mod time, just pick epoch
-			takenTime = time.Unix(0, 0)
+			meta.takenTime = time.Unix(0, 0)
 		}
 	}
 
-	return takenTime, orientation, nil
+	return meta, nil
+}
+
+// ReadOrientation returns the EXIF orientation (1–8) of the image in r, or 1
+// if it has none, for images not loaded through Load. For an AVIF read
+// through an io.ReaderAt it is the orientation of the container's rotation.
+func ReadOrientation(r io.Reader) int {
+	if ra, ok := r.(io.ReaderAt); ok {
+		if info, err := readAVIF(ra); err == nil {
+			return info.orientation()
+		}
+	}
+	orientation := 1
+	x, err := decodeEXIF(r)
+	if err != nil {
+		return orientation
+	}
+	guardEXIF(func() { orientation = exifOrientation(x) })
+	return orientation
+}
+
+// errEXIFPanic marks EXIF that goexif panicked on rather than rejected.
+var errEXIFPanic = errors.New("panic decoding EXIF")
+
+// decodeEXIF is exif.Decode, except that goexif panicking on a malformed
+// APP1 segment is returned as an error wrapping errEXIFPanic, so that one bad
+// file cannot bring down loading.
+func decodeEXIF(r io.Reader) (x *exif.Exif, err error) {
+	if errPanic := guardEXIF(func() { x, err = exif.Decode(r) }); errPanic != nil {
+		return nil, errPanic
+	}
+	return x, err
+}
+
+// guardEXIF runs f, which reads EXIF through goexif, and returns any panic
+// it raises as an error wrapping errEXIFPanic. goexif's tag accessors panic
+// on out-of-range values as readily as its decoder does.
+func guardEXIF(f func()) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("%w: %v", errEXIFPanic, v)
+		}
+	}()
+	f()
+	return nil
+}
+
+// exifOrientation reads the Orientation tag, defaulting to 1 (no transform)
+// if it is missing or invalid.
+func exifOrientation(x *exif.Exif) int {
+	tag, err := x.Get(exif.Orientation)
+	if err != nil {
+		return 1
+	}
+	if v, err := tag.Int(0); err == nil && v >= 1 && v <= 8 {
+		return v
+	}
+	return 1
+}
+
+// validLatLong rejects positions goexif can produce from malformed tags
+// (NaN from zero denominators, out-of-range degrees).
+func validLatLong(lat, long float64) bool {
+	return lat >= -90 && lat <= 90 && long >= -180 && long <= 180
 }
 
 // extractDimensions uses image.DecodeConfig to get width and height
-// without decoding the full image.
+// without decoding the full image. RAW files report the size of the
+// embedded preview, since that is what gets displayed.
 func extractDimensions(path string) (int, int, error) {
+	if IsRawFile(path) {
+		cfg, err := rawPreviewConfig(path)
+		if err != nil {
+			return 0, 0, fmt.Errorf("read preview of %s: %w", path, err)
+		}
+		return cfg.Width, cfg.Height, nil
+	}
+	if isAVIF(path) {
+		info, err := avifFileInfo(path)
+		if err != nil {
+			return 0, 0, fmt.Errorf("read AVIF container of %s: %w", path, err)
+		}
+		return info.width, info.height, nil
+	}
+
 	f, err := os.Open(path)
 	if err != nil {
 		return 0, 0, fmt.Errorf("open file for dimensions: %w", err)
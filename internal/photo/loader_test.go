@@ -0,0 +1,384 @@
+package photo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExtractTimePrefersDateTimeOriginal(t *testing.T) {
+	path := writeTestJPEG(t, t.TempDir(), "a.jpg", testEXIF{
+		ifd0: []exifField{asciiField(tagDateTime, "2024:06:01 09:00:00")},
+		exif: []exifField{
+			asciiField(tagDateTimeOriginal, "2019:03:04 05:06:07"),
+			asciiField(tagDateTimeDigitized, "2020:01:01 00:00:00"),
+		},
+	})
+
+	meta, err := extractEXIF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := meta.takenTime
+	want := time.Date(2019, 3, 4, 5, 6, 7, 0, time.Local)
+	if !got.Equal(want) {
+		t.Errorf("TakenTime = %v, want DateTimeOriginal %v", got, want)
+	}
+}
+
+func TestExtractTimeFallsBackThroughEXIFTags(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		exif testEXIF
+		want time.Time
+	}{
+		{
+			name: "digitized",
+			exif: testEXIF{
+				ifd0: []exifField{asciiField(tagDateTime, "2024:06:01 09:00:00")},
+				exif: []exifField{
+					asciiField(tagDateTimeOriginal, "0000:00:00 00:00:00"),
+					asciiField(tagDateTimeDigitized, "2020:01:02 03:04:05"),
+				},
+			},
+			want: time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local),
+		},
+		{
+			name: "datetime",
+			exif: testEXIF{
+				ifd0: []exifField{asciiField(tagDateTime, "2024:06:01 09:00:00")},
+			},
+			want: time.Date(2024, 6, 1, 9, 0, 0, 0, time.Local),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestJPEG(t, dir, tt.name+".jpg", tt.exif)
+			meta, err := extractEXIF(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := meta.takenTime
+			if !got.Equal(tt.want) {
+				t.Errorf("TakenTime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractTimeFallsBackToModTime(t *testing.T) {
+	path := writeTestJPEG(t, t.TempDir(), "plain.jpg", testEXIF{})
+	modTime := time.Date(2015, 7, 8, 10, 11, 12, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := extractEXIF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := meta.takenTime
+	if !got.Equal(modTime) {
+		t.Errorf("TakenTime = %v, want mod time %v", got, modTime)
+	}
+}
+
+func TestExtractMetadataAppliesOrientationToDimensions(t *testing.T) {
+	dir := t.TempDir()
+	// The test JPEG is stored 8x6 (landscape).
+	tests := []struct {
+		orientation     int
+		wantOrientation int
+		wantW, wantH    int
+	}{
+		{orientation: 1, wantOrientation: 1, wantW: 8, wantH: 6},
+		{orientation: 2, wantOrientation: 2, wantW: 8, wantH: 6},
+		{orientation: 3, wantOrientation: 3, wantW: 8, wantH: 6},
+		{orientation: 4, wantOrientation: 4, wantW: 8, wantH: 6},
+		{orientation: 5, wantOrientation: 5, wantW: 6, wantH: 8},
+		{orientation: 6, wantOrientation: 6, wantW: 6, wantH: 8},
+		{orientation: 7, wantOrientation: 7, wantW: 6, wantH: 8},
+		{orientation: 8, wantOrientation: 8, wantW: 6, wantH: 8},
+		{orientation: 0, wantOrientation: 1, wantW: 8, wantH: 6},
+		{orientation: 9, wantOrientation: 1, wantW: 8, wantH: 6},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("orientation %d", tt.orientation), func(t *testing.T) {
+			path := writeTestJPEG(t, dir, fmt.Sprintf("o%d.jpg", tt.orientation), testEXIF{
+				ifd0: []exifField{shortField(tagOrientation, uint16(tt.orientation))},
+			})
+			p, err := extractMetadata(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, h, orientation := p.Width, p.Height, p.Orientation
+			if orientation != tt.wantOrientation || w != tt.wantW || h != tt.wantH {
+				t.Errorf("extractMetadata() = %dx%d orientation %d, want %dx%d orientation %d",
+					w, h, orientation, tt.wantW, tt.wantH, tt.wantOrientation)
+			}
+		})
+	}
+}
+
+// EXIF tag IDs used by the tests.
+const (
+	tagOrientation       = 0x0112
+	tagDateTime          = 0x0132
+	tagExifIFDPointer    = 0x8769
+	tagGPSIFDPointer     = 0x8825
+	tagDateTimeOriginal  = 0x9003
+	tagDateTimeDigitized = 0x9004
+	tagOffsetTimeOrig    = 0x9011
+	tagSubSecTimeOrig    = 0x9291
+
+	tagGPSLatitudeRef  = 0x1
+	tagGPSLatitude     = 0x2
+	tagGPSLongitudeRef = 0x3
+	tagGPSLongitude    = 0x4
+	tagGPSTimeStamp    = 0x7
+	tagGPSDateStamp    = 0x1d
+)
+
+const (
+	exifTypeASCII = 2
+	exifTypeShort = 3
+	exifTypeLong  = 4
+	exifTypeRat   = 5
+)
+
+// exifField is one IFD entry; value holds the raw little-endian bytes.
+type exifField struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+func asciiField(tag uint16, s string) exifField {
+	v := append([]byte(s), 0)
+	return exifField{tag: tag, typ: exifTypeASCII, count: uint32(len(v)), value: v}
+}
+
+func shortField(tag, v uint16) exifField {
+	return exifField{tag: tag, typ: exifTypeShort, count: 1, value: binary.LittleEndian.AppendUint16(nil, v)}
+}
+
+// ratField holds whole-numbered rationals, such as the degrees, minutes and
+// seconds of a GPS coordinate.
+func ratField(tag uint16, vals ...uint32) exifField {
+	var v []byte
+	for _, n := range vals {
+		v = binary.LittleEndian.AppendUint32(v, n)
+		v = binary.LittleEndian.AppendUint32(v, 1)
+	}
+	return exifField{tag: tag, typ: exifTypeRat, count: uint32(len(vals)), value: v}
+}
+
+// testEXIF describes the tags to embed: ifd0 holds image tags such as
+// DateTime, exif holds the Exif sub-IFD (DateTimeOriginal...), gps the GPS
+// sub-IFD, and xmp is an XMP packet for an APP1 segment of its own.
+type testEXIF struct {
+	ifd0 []exifField
+	exif []exifField
+	gps  []exifField
+	xmp  string
+}
+
+// writeTestJPEG writes a small JPEG carrying the given EXIF tags to dir/name.
+// An empty testEXIF produces a JPEG without an EXIF or XMP segment.
+func writeTestJPEG(t *testing.T, dir, name string, e testEXIF) string {
+	t.Helper()
+
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 8, 6)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := img.Bytes()
+
+	// APP1 segments go straight after the SOI marker, EXIF first as goexif
+	// only looks at the first one.
+	var segments []byte
+	if len(e.ifd0) > 0 || len(e.exif) > 0 || len(e.gps) > 0 {
+		segments = appendAPP1(segments, append([]byte("Exif\x00\x00"), encodeTestTIFF(e)...))
+	}
+	if e.xmp != "" {
+		segments = appendAPP1(segments, append([]byte(jpegXMPHeader), e.xmp...))
+	}
+	data = append(append(append([]byte{}, data[:2]...), segments...), data[2:]...)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func appendAPP1(b, payload []byte) []byte {
+	b = append(b, 0xff, 0xe1)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(payload)+2))
+	return append(b, payload...)
+}
+
+// encodeTestTIFF lays out a little-endian TIFF header, IFD0 and (optionally)
+// the Exif and GPS sub-IFDs it points to.
+func encodeTestTIFF(e testEXIF) []byte {
+	ifd0 := append([]exifField{}, e.ifd0...)
+	var subIFDs [][]exifField
+	for _, sub := range []struct {
+		pointer uint16
+		fields  []exifField
+	}{{tagExifIFDPointer, e.exif}, {tagGPSIFDPointer, e.gps}} {
+		if len(sub.fields) > 0 {
+			// Placeholder; the real offset is known once IFD0's size is.
+			ifd0 = append(ifd0, exifField{tag: sub.pointer, typ: exifTypeLong, count: 1, value: make([]byte, 4)})
+			subIFDs = append(subIFDs, sub.fields)
+		}
+	}
+	offset := 8 + ifdSize(ifd0)
+	for i, fields := range subIFDs {
+		ifd0[len(ifd0)-len(subIFDs)+i].value = binary.LittleEndian.AppendUint32(nil, uint32(offset))
+		offset += ifdSize(fields)
+	}
+
+	out := []byte("II*\x00")
+	out = binary.LittleEndian.AppendUint32(out, 8)
+	out = appendIFD(out, ifd0)
+	for _, fields := range subIFDs {
+		out = appendIFD(out, fields)
+	}
+	return out
+}
+
+// ifdSize is the encoded size of an IFD including its out-of-line values.
+func ifdSize(fields []exifField) int {
+	n := 2 + 12*len(fields) + 4
+	for _, f := range fields {
+		if len(f.value) > 4 {
+			n += len(f.value)
+		}
+	}
+	return n
+}
+
+// appendIFD encodes fields as an IFD starting at len(out), with values longer
+// than four bytes stored after the entry table.
+func appendIFD(out []byte, fields []exifField) []byte {
+	dataOffset := len(out) + 2 + 12*len(fields) + 4
+	var data []byte
+
+	out = binary.LittleEndian.AppendUint16(out, uint16(len(fields)))
+	for _, f := range fields {
+		out = binary.LittleEndian.AppendUint16(out, f.tag)
+		out = binary.LittleEndian.AppendUint16(out, f.typ)
+		out = binary.LittleEndian.AppendUint32(out, f.count)
+		if len(f.value) <= 4 {
+			inline := make([]byte, 4)
+			copy(inline, f.value)
+			out = append(out, inline...)
+			continue
+		}
+		out = binary.LittleEndian.AppendUint32(out, uint32(dataOffset+len(data)))
+		data = append(data, f.value...)
+	}
+	out = binary.LittleEndian.AppendUint32(out, 0) // no next IFD
+	return append(out, data...)
+}
+
+func TestLoadFollowsSymlinksWithoutLooping(t *testing.T) {
+	fixture, err := os.ReadFile(filepath.Join("testdata", "full_exif.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	album := filepath.Join(dir, "album")
+	other := filepath.Join(dir, "other")
+	for _, d := range []string{album, other} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, path := range []string{filepath.Join(album, "a.jpg"), filepath.Join(other, "b.jpg")} {
+		if err := os.WriteFile(path, fixture, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(album, "loop"):  album, // back up the tree
+		filepath.Join(album, "other"): other,
+		filepath.Join(dir, "linked"):  album, // an album that is a symlink
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks unsupported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		albums []string
+		follow bool
+		want   []string
+	}{
+		{"not following", []string{album}, false, []string{"album/a.jpg"}},
+		{"following", []string{album}, true, []string{"album/a.jpg", "album/other/b.jpg"}},
+		{"symlinked album", []string{filepath.Join(dir, "linked")}, true, []string{"linked/a.jpg", "linked/other/b.jpg"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			photos, err := Load(tt.albums, LoadOptions{StateDir: t.TempDir(), FollowSymlinks: tt.follow})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range photos {
+				rel, _ := filepath.Rel(dir, p.FilePath)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Load() found %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkippedFilesCountsOnlyUnexpectedExtensions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"IMG_1.HEIC", "IMG_1.MOV", "IMG_2.jpg", "IMG_2.mp4", "clip.mov", "clip2.mkv", "notes.txt", "IMG_3.heic", "README"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unsupported := []string{"IMG_1.HEIC", "IMG_1.MOV", "IMG_2.mp4", "clip.mov", "clip2.mkv", "notes.txt", "IMG_3.heic", "README"}
+
+	tests := []struct {
+		name   string
+		ignore []string
+		want   map[string]int
+	}{
+		{name: "default", want: map[string]int{".heic": 2, ".txt": 1, "": 1}},
+		// Live photo videos stay quiet even when videos are not ignored.
+		{name: "none ignored", ignore: []string{}, want: map[string]int{".heic": 2, ".txt": 1, "": 1, ".mov": 1, ".mkv": 1}},
+		{name: "own list", ignore: []string{".HEIC", ".txt"}, want: map[string]int{"": 1, ".mov": 1, ".mkv": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSkippedFiles(tt.ignore)
+			for _, name := range unsupported {
+				s.add(filepath.Join(dir, name))
+			}
+			if !maps.Equal(s.counts, tt.want) {
+				t.Errorf("counts = %v, want %v", s.counts, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package photo
+
+import "time"
+
+// FilterOnThisDay keeps the photos taken on day's month and day, in any
+// year. Outside leap years, photos from February 29 are kept on February 28.
+func FilterOnThisDay(photos []Photo, day time.Time) []Photo {
+	_, month, date := day.Date()
+	leapDay := month == time.February && date == 28 && !isLeapYear(day.Year())
+	var kept []Photo
+	for _, p := range photos {
+		_, m, d := p.TakenTime.Date()
+		if m == month && (d == date || leapDay && d == 29) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
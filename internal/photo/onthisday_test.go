@@ -0,0 +1,43 @@
+package photo
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFilterOnThisDay(t *testing.T) {
+	taken := func(year int, month time.Month, day int) Photo {
+		return Photo{
+			FilePath:  time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Format("2006-01-02"),
+			TakenTime: time.Date(year, month, day, 9, 30, 0, 0, time.UTC),
+		}
+	}
+	photos := []Photo{
+		taken(2019, time.June, 14),
+		taken(2021, time.June, 15),
+		taken(2023, time.June, 14),
+		taken(2020, time.February, 28),
+		taken(2020, time.February, 29),
+	}
+	tests := []struct {
+		day  time.Time
+		want []string
+	}{
+		{time.Date(2025, time.June, 14, 23, 0, 0, 0, time.Local), []string{"2019-06-14", "2023-06-14"}},
+		{time.Date(2025, time.June, 16, 0, 0, 0, 0, time.Local), nil},
+		// February 29 photos turn up on the 28th outside leap years.
+		{time.Date(2025, time.February, 28, 12, 0, 0, 0, time.Local), []string{"2020-02-28", "2020-02-29"}},
+		{time.Date(2024, time.February, 28, 12, 0, 0, 0, time.Local), []string{"2020-02-28"}},
+		{time.Date(2024, time.February, 29, 12, 0, 0, 0, time.Local), []string{"2020-02-29"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range FilterOnThisDay(photos, tt.day) {
+			got = append(got, p.FilePath)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("FilterOnThisDay(%s) = %v, want %v", tt.day.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
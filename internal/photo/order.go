@@ -0,0 +1,97 @@
+package photo
+
+import (
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SortOrder selects how photos are ordered before slides are built.
+type SortOrder string
+
+const (
+	SortRandom SortOrder = "random" // shuffle on every run
+	SortTime   SortOrder = "time"   // oldest TakenTime first, ties in path order
+	SortName   SortOrder = "name"   // natural order of file names, ignoring directories
+	SortPath   SortOrder = "path"   // natural order of full paths, grouping by album/folder
+)
+
+// Order sorts photos in place according to by; unknown orders shuffle.
+func Order(photos []Photo, by SortOrder) {
+	switch by {
+	case SortTime:
+		// Bursts, and photos dated by the same modification time, share a
+		// TakenTime; the path settles them the same way on every run.
+		sort.SliceStable(photos, func(i, j int) bool {
+			a, b := photos[i].TakenTime, photos[j].TakenTime
+			if !a.Equal(b) {
+				return a.Before(b)
+			}
+			return naturalLess(photos[i].FilePath, photos[j].FilePath)
+		})
+	case SortName:
+		sort.Slice(photos, func(i, j int) bool {
+			a, b := filepath.Base(photos[i].FilePath), filepath.Base(photos[j].FilePath)
+			if a != b {
+				return naturalLess(a, b)
+			}
+			return naturalLess(photos[i].FilePath, photos[j].FilePath)
+		})
+	case SortPath:
+		sort.Slice(photos, func(i, j int) bool {
+			return naturalLess(photos[i].FilePath, photos[j].FilePath)
+		})
+	default:
+		rand.Shuffle(len(photos), func(i, j int) {
+			photos[i], photos[j] = photos[j], photos[i]
+		})
+	}
+}
+
+// naturalLess compares strings so that embedded numbers sort by value
+// ("IMG_2" before "IMG_10"). Text runs compare case-insensitively, with the
+// raw strings as a final tiebreak.
+func naturalLess(a, b string) bool {
+	x, y := a, b
+	for x != "" && y != "" {
+		xs, xDigits := leadingRun(x)
+		ys, yDigits := leadingRun(y)
+		x, y = x[len(xs):], y[len(ys):]
+
+		switch {
+		case xDigits && yDigits:
+			xn, yn := strings.TrimLeft(xs, "0"), strings.TrimLeft(ys, "0")
+			if len(xn) != len(yn) {
+				return len(xn) < len(yn)
+			}
+			if xn != yn {
+				return xn < yn
+			}
+		default:
+			xl, yl := strings.ToLower(xs), strings.ToLower(ys)
+			if xl != yl {
+				return xl < yl
+			}
+		}
+	}
+	if len(x) != len(y) {
+		return len(x) < len(y)
+	}
+	return a < b
+}
+
+// leadingRun returns the maximal prefix of s made only of digits or only of
+// non-digits, and whether it is numeric.
+func leadingRun(s string) (string, bool) {
+	digits := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digits {
+		i++
+	}
+	return s[:i], digits
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
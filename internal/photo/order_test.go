@@ -0,0 +1,44 @@
+package photo
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestOrderByTimeBreaksTiesByPath(t *testing.T) {
+	burst := time.Date(2024, 7, 14, 16, 2, 5, 0, time.UTC)
+	// The same instant, as read from a photo with an offset in its EXIF.
+	burstInParis := burst.In(time.FixedZone("CEST", 2*60*60))
+	photos := []Photo{
+		{FilePath: "/albums/b/IMG_10.jpg", TakenTime: burst},
+		{FilePath: "/albums/b/IMG_9.jpg", TakenTime: burstInParis},
+		{FilePath: "/albums/a/IMG_11.jpg", TakenTime: burst},
+		{FilePath: "/albums/b/IMG_2.jpg", TakenTime: burst.Add(-time.Hour)},
+		{FilePath: "/albums/b/IMG_12.jpg", TakenTime: burst.Add(time.Second)},
+		{FilePath: "/albums/b/IMG_1.jpg", TakenTime: burst},
+	}
+	want := []string{
+		"/albums/b/IMG_2.jpg",
+		"/albums/a/IMG_11.jpg",
+		"/albums/b/IMG_1.jpg",
+		"/albums/b/IMG_9.jpg",
+		"/albums/b/IMG_10.jpg",
+		"/albums/b/IMG_12.jpg",
+	}
+	// Whatever order the scan found them in, they come out the same.
+	r := rand.New(rand.NewSource(1))
+	for run := range 20 {
+		shuffled := slices.Clone(photos)
+		r.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		Order(shuffled, SortTime)
+		var got []string
+		for _, p := range shuffled {
+			got = append(got, p.FilePath)
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("run %d: Order() = %q, want %q", run, got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package photo
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// placesFileName is the file cmd/geocode writes into each album folder,
+// mapping image file names to friendly place names.
+const placesFileName = "metadata.json"
+
+// attachPlaces fills in Place for the photos whose folder has a places file
+// naming them. Each folder's file is read once.
+func attachPlaces(photos []Photo) {
+	byDir := make(map[string]map[string]string)
+	for i := range photos {
+		dir := filepath.Dir(photos[i].FilePath)
+		places, ok := byDir[dir]
+		if !ok {
+			places = readPlaces(dir)
+			byDir[dir] = places
+		}
+		photos[i].Place = places[filepath.Base(photos[i].FilePath)]
+	}
+}
+
+// readPlaces reads the places file in dir, if there is one.
+func readPlaces(dir string) map[string]string {
+	path := filepath.Join(dir, placesFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entries map[string]struct {
+		FriendlyLocation string `json:"friendly_location"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		log.Printf("Warning: could not parse %s: %v", path, err)
+		return nil
+	}
+	places := make(map[string]string, len(entries))
+	for name, e := range entries {
+		if e.FriendlyLocation != "" {
+			places[name] = e.FriendlyLocation
+		}
+	}
+	return places
+}
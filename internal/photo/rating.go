@@ -0,0 +1,77 @@
+package photo
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// Ratings follow Lightroom: 1–5 stars, 0 for unrated and -1 for rejected.
+const (
+	minStars = -1
+	maxStars = 5
+)
+
+// tagRating is the Windows/Microsoft star rating in IFD0, which goexif does
+// not name.
+const tagRating = 0x4746
+
+// xmpRatingRE matches xmp:Rating written as an attribute or as an element.
+var xmpRatingRE = regexp.MustCompile(`xmp:Rating\s*=\s*["'](-?\d+)["']|<xmp:Rating>\s*(-?\d+)\s*</xmp:Rating>`)
+
+// FilterByRating keeps the photos rated at least minRating stars. Unrated
+// photos are kept only if includeUnrated is set; rejected ones never are. A
+// minRating of 0 or less keeps everything.
+func FilterByRating(photos []Photo, minRating int, includeUnrated bool) []Photo {
+	if minRating <= 0 {
+		return photos
+	}
+	kept := make([]Photo, 0, len(photos))
+	for _, p := range photos {
+		if p.Rating >= minRating || (p.Rating == 0 && includeUnrated) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// readRating finds the star rating in the photo's XMP, falling back to the
+// EXIF Rating tag. x may be nil if the file has no EXIF.
+func readRating(m embeddedMetadata, x *exif.Exif) int {
+	if r, ok := parseXMPRating(m.xmp); ok {
+		return r
+	}
+	if x != nil && x.Tiff != nil && len(x.Tiff.Dirs) > 0 {
+		for _, tag := range x.Tiff.Dirs[0].Tags {
+			if tag.Id != tagRating {
+				continue
+			}
+			if r, err := tag.Int(0); err == nil && validRating(r) {
+				return r
+			}
+		}
+	}
+	return 0
+}
+
+// parseXMPRating extracts xmp:Rating from an XMP packet.
+func parseXMPRating(packet []byte) (int, bool) {
+	m := xmpRatingRE.FindSubmatch(packet)
+	if m == nil {
+		return 0, false
+	}
+	v := m[1]
+	if v == nil {
+		v = m[2]
+	}
+	r, err := strconv.Atoi(string(v))
+	if err != nil || !validRating(r) {
+		return 0, false
+	}
+	return r, true
+}
+
+func validRating(r int) bool {
+	return r >= minStars && r <= maxStars
+}
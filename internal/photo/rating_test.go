@@ -0,0 +1,100 @@
+package photo
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// xmpPacket wraps an rdf:Description body the way Lightroom writes it.
+func xmpPacket(description string) string {
+	return `<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/"` + description + `
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`
+}
+
+func TestExtractMetadataReadsRating(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		e    testEXIF
+		want int
+	}{
+		{"unrated.jpg", testEXIF{}, 0},
+		{"xmp-attribute.jpg", testEXIF{xmp: xmpPacket(` xmp:Rating="4"/>`)}, 4},
+		{"xmp-element.jpg", testEXIF{xmp: xmpPacket(`>
+   <xmp:Rating>5</xmp:Rating>
+  </rdf:Description>`)}, 5},
+		{"rejected.jpg", testEXIF{xmp: xmpPacket(` xmp:Rating="-1"/>`)}, -1},
+		{"exif.jpg", testEXIF{ifd0: []exifField{shortField(tagRating, 3)}}, 3},
+		{"xmp-over-exif.jpg", testEXIF{
+			ifd0: []exifField{shortField(tagRating, 1)},
+			exif: []exifField{asciiField(tagDateTimeOriginal, "2023:08:01 12:00:00")},
+			xmp:  xmpPacket(` xmp:Rating="4"/>`),
+		}, 4},
+		{"out-of-range.jpg", testEXIF{xmp: xmpPacket(` xmp:Rating="9"/>`)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := extractMetadata(writeTestJPEG(t, dir, tt.name, tt.e))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Rating != tt.want {
+				t.Errorf("Rating = %d, want %d", p.Rating, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRatingPrefersRAWSidecar(t *testing.T) {
+	dir := t.TempDir()
+	raw := filepath.Join(dir, "IMG_0001.dng")
+	if err := os.WriteFile(raw, []byte("II*\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := readRating(readEmbedded(raw, nil), nil); got != 0 {
+		t.Errorf("without sidecar: rating = %d, want 0", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "IMG_0001.xmp"), []byte(xmpPacket(` xmp:Rating="2"/>`)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := readRating(readEmbedded(raw, nil), nil); got != 2 {
+		t.Errorf("with sidecar: rating = %d, want 2", got)
+	}
+}
+
+func TestFilterByRating(t *testing.T) {
+	photos := []Photo{
+		{FilePath: "rejected", Rating: -1},
+		{FilePath: "unrated", Rating: 0},
+		{FilePath: "three", Rating: 3},
+		{FilePath: "four", Rating: 4},
+		{FilePath: "five", Rating: 5},
+	}
+	tests := []struct {
+		minRating      int
+		includeUnrated bool
+		want           []string
+	}{
+		{0, false, []string{"rejected", "unrated", "three", "four", "five"}},
+		{4, false, []string{"four", "five"}},
+		{4, true, []string{"unrated", "four", "five"}},
+		{1, true, []string{"unrated", "three", "four", "five"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range FilterByRating(photos, tt.minRating, tt.includeUnrated) {
+			got = append(got, p.FilePath)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("FilterByRating(%d, %v) = %v, want %v", tt.minRating, tt.includeUnrated, got, tt.want)
+		}
+	}
+}
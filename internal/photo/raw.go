@@ -0,0 +1,299 @@
+package photo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TIFF tags that locate embedded previews in RAW files.
+const (
+	tiffTagCompression       = 0x0103
+	tiffTagStripOffsets      = 0x0111
+	tiffTagStripByteCounts   = 0x0117
+	tiffTagSubIFDs           = 0x014a
+	tiffTagJPEGInterchange   = 0x0201
+	tiffTagJPEGInterchangeLn = 0x0202
+
+	// Compression values whose strips may hold a baseline JPEG. RAW data
+	// itself is often also tagged 7 (lossless JPEG), which DecodeConfig
+	// rejects, so every candidate is checked before use.
+	tiffCompressionOldJPEG = 6
+	tiffCompressionJPEG    = 7
+
+	// maxIFDs bounds the walk so a corrupt file cannot loop forever.
+	maxIFDs = 64
+)
+
+// ErrNoPreview is returned by RawPreview when a RAW file carries no JPEG
+// preview that can be decoded.
+var ErrNoPreview = errors.New("no usable embedded JPEG preview")
+
+// IsRawFile reports whether path is a camera RAW file. These are shown using
+// the JPEG preview embedded by the camera rather than decoded.
+func IsRawFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cr2", ".nef", ".arw", ".dng":
+		return true
+	}
+	return false
+}
+
+// Decode decodes the image at path. RAW files decode to their embedded
+// preview; EXIF orientation is not applied.
+func Decode(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file %s: %w", path, err)
+	}
+	defer file.Close()
+	return DecodeReader(file, path)
+}
+
+// DecodeFS decodes the image called name in fsys, e.g. an embed.FS, as
+// Decode does.
+func DecodeFS(fsys fs.FS, name string) (image.Image, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file %s: %w", name, err)
+	}
+	defer file.Close()
+	return DecodeReader(file, name)
+}
+
+// DecodeReader decodes an image from r as Decode does. name is used for
+// messages and to recognise RAW and AVIF files by extension; a RAW file is read into
+// memory first unless r is an io.ReaderAt.
+func DecodeReader(r io.Reader, name string) (image.Image, error) {
+	if IsRawFile(name) {
+		ra, ok := r.(io.ReaderAt)
+		if !ok {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read %s: %w", name, err)
+			}
+			ra = bytes.NewReader(data)
+		}
+		preview, _, err := largestPreview(ra)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read preview of %s: %w", name, err)
+		}
+		img, err := jpeg.Decode(preview)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode preview of %s: %w", name, err)
+		}
+		return img, nil
+	}
+
+	// image.Decode only recognises AVIF files whose major brand is avif.
+	if isAVIF(name) {
+		img, err := decodeAVIF(r)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode image %s: %w", name, err)
+		}
+		return img, nil
+	}
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode image %s: %w", name, err)
+	}
+	return img, nil
+}
+
+// RawPreview returns the largest decodable JPEG preview embedded in the
+// TIFF-based RAW file (CR2, NEF, ARW, DNG) at path.
+func RawPreview(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	preview, _, err := largestPreview(f)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(preview)
+}
+
+// rawPreviewConfig returns the dimensions of the preview RawPreview would
+// return, without reading all of it.
+func rawPreviewConfig(path string) (image.Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer f.Close()
+
+	_, cfg, err := largestPreview(f)
+	return cfg, err
+}
+
+// largestPreview walks every IFD of the TIFF in r, including SubIFDs, and
+// returns the biggest embedded JPEG that image/jpeg can decode.
+func largestPreview(r io.ReaderAt) (*io.SectionReader, image.Config, error) {
+	order, first, err := readTIFFHeader(r)
+	if err != nil {
+		return nil, image.Config{}, err
+	}
+
+	var best *io.SectionReader
+	var bestCfg image.Config
+	visited := make(map[uint32]bool)
+	queue := []uint32{first}
+	for len(queue) > 0 && len(visited) < maxIFDs {
+		offset := queue[0]
+		queue = queue[1:]
+		if offset == 0 || visited[offset] {
+			continue
+		}
+		visited[offset] = true
+
+		dir, next, err := readIFD(r, order, offset)
+		if err != nil {
+			continue
+		}
+		queue = append(queue, next)
+		if subIFDs, ok := dir.uints(r, order, tiffTagSubIFDs); ok {
+			queue = append(queue, subIFDs...)
+		}
+
+		for _, c := range dir.previewCandidates(r, order) {
+			section := io.NewSectionReader(r, int64(c[0]), int64(c[1]))
+			cfg, err := jpeg.DecodeConfig(section)
+			if err != nil {
+				continue
+			}
+			if best == nil || cfg.Width*cfg.Height > bestCfg.Width*bestCfg.Height {
+				best = io.NewSectionReader(r, int64(c[0]), int64(c[1]))
+				bestCfg = cfg
+			}
+		}
+	}
+	if best == nil {
+		return nil, image.Config{}, ErrNoPreview
+	}
+	return best, bestCfg, nil
+}
+
+func readTIFFHeader(r io.ReaderAt) (binary.ByteOrder, uint32, error) {
+	var header [8]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return nil, 0, fmt.Errorf("read TIFF header: %w", err)
+	}
+	var order binary.ByteOrder
+	switch string(header[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, 0, errors.New("not a TIFF-based RAW file")
+	}
+	// Only the byte order is checked: some RAW formats replace TIFF's 42
+	// magic number with their own.
+	return order, order.Uint32(header[4:]), nil
+}
+
+// ifdEntry is one raw 12-byte IFD entry.
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value [4]byte
+}
+
+type ifd map[uint16]ifdEntry
+
+func readIFD(r io.ReaderAt, order binary.ByteOrder, offset uint32) (ifd, uint32, error) {
+	var countBuf [2]byte
+	if _, err := r.ReadAt(countBuf[:], int64(offset)); err != nil {
+		return nil, 0, err
+	}
+	n := int(order.Uint16(countBuf[:]))
+	buf := make([]byte, 12*n+4)
+	if _, err := r.ReadAt(buf, int64(offset)+2); err != nil {
+		return nil, 0, err
+	}
+
+	entries := make(ifd, n)
+	for i := 0; i < n; i++ {
+		e := buf[12*i:]
+		var entry ifdEntry
+		entry.typ = order.Uint16(e[2:])
+		entry.count = order.Uint32(e[4:])
+		copy(entry.value[:], e[8:12])
+		entries[order.Uint16(e[0:])] = entry
+	}
+	return entries, order.Uint32(buf[12*n:]), nil
+}
+
+// uints reads an unsigned SHORT, LONG or IFD-typed tag.
+func (d ifd) uints(r io.ReaderAt, order binary.ByteOrder, tag uint16) ([]uint32, bool) {
+	e, ok := d[tag]
+	if !ok || e.count == 0 || e.count > 1024 {
+		return nil, false
+	}
+	var size int
+	switch e.typ {
+	case 3: // SHORT
+		size = 2
+	case 4, 13: // LONG, IFD
+		size = 4
+	default:
+		return nil, false
+	}
+
+	raw := e.value[:]
+	if n := size * int(e.count); n > 4 {
+		raw = make([]byte, n)
+		if _, err := r.ReadAt(raw, int64(order.Uint32(e.value[:]))); err != nil {
+			return nil, false
+		}
+	}
+	vals := make([]uint32, e.count)
+	for i := range vals {
+		if size == 2 {
+			vals[i] = uint32(order.Uint16(raw[2*i:]))
+		} else {
+			vals[i] = order.Uint32(raw[4*i:])
+		}
+	}
+	return vals, true
+}
+
+func (d ifd) single(r io.ReaderAt, order binary.ByteOrder, tag uint16) (uint32, bool) {
+	vals, ok := d.uints(r, order, tag)
+	if !ok || len(vals) != 1 {
+		return 0, false
+	}
+	return vals[0], true
+}
+
+// previewCandidates returns the (offset, length) of every byte range in d
+// that may hold a JPEG.
+func (d ifd) previewCandidates(r io.ReaderAt, order binary.ByteOrder) [][2]uint32 {
+	var candidates [][2]uint32
+	if off, ok := d.single(r, order, tiffTagJPEGInterchange); ok {
+		if n, ok := d.single(r, order, tiffTagJPEGInterchangeLn); ok && n > 0 {
+			candidates = append(candidates, [2]uint32{off, n})
+		}
+	}
+	// CR2 and DNG keep previews as a single JPEG-compressed strip.
+	if c, ok := d.single(r, order, tiffTagCompression); ok && (c == tiffCompressionOldJPEG || c == tiffCompressionJPEG) {
+		off, okOff := d.single(r, order, tiffTagStripOffsets)
+		n, okLen := d.single(r, order, tiffTagStripByteCounts)
+		if okOff && okLen && n > 0 {
+			candidates = append(candidates, [2]uint32{off, n})
+		}
+	}
+	return candidates
+}
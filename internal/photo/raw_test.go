@@ -0,0 +1,230 @@
+package photo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+	"testing"
+)
+
+// tiffEntry is an IFD entry for tiffBuilder: SHORT (3) or LONG (4) values.
+type tiffEntry struct {
+	tag    uint16
+	typ    uint16
+	values []uint32
+	count  uint32 // overrides len(values) when set, to forge a bad count
+}
+
+// tiffBuilder lays out a little-endian TIFF bottom-up: data first, then the
+// IFDs that point at it, then the header's pointer to the first IFD.
+type tiffBuilder struct {
+	buf []byte
+}
+
+func newTIFF() *tiffBuilder {
+	return &tiffBuilder{buf: []byte{'I', 'I', 42, 0, 0, 0, 0, 0}}
+}
+
+// blob appends data and returns its offset.
+func (b *tiffBuilder) blob(data []byte) uint32 {
+	off := uint32(len(b.buf))
+	b.buf = append(b.buf, data...)
+	return off
+}
+
+// ifd appends an IFD and returns its offset.
+func (b *tiffBuilder) ifd(next uint32, entries ...tiffEntry) uint32 {
+	le := binary.LittleEndian
+	type pending struct {
+		at   int
+		data []byte
+	}
+	var out []pending
+	d := le.AppendUint16(nil, uint16(len(entries)))
+	for _, e := range entries {
+		count := e.count
+		if count == 0 {
+			count = uint32(len(e.values))
+		}
+		var raw []byte
+		for _, v := range e.values {
+			if e.typ == 3 {
+				raw = le.AppendUint16(raw, uint16(v))
+			} else {
+				raw = le.AppendUint32(raw, v)
+			}
+		}
+		d = le.AppendUint16(d, e.tag)
+		d = le.AppendUint16(d, e.typ)
+		d = le.AppendUint32(d, count)
+		if len(raw) > 4 {
+			out = append(out, pending{at: len(d), data: raw})
+			raw = []byte{0, 0, 0, 0}
+		}
+		d = append(d, append(raw, make([]byte, 4-len(raw))...)...)
+	}
+	d = le.AppendUint32(d, next)
+	off := b.blob(d)
+	for _, p := range out {
+		le.PutUint32(b.buf[int(off)+p.at:], b.blob(p.data))
+	}
+	return off
+}
+
+// bytes sets the first IFD and returns the file.
+func (b *tiffBuilder) bytes(first uint32) []byte {
+	binary.LittleEndian.PutUint32(b.buf[4:], first)
+	return b.buf
+}
+
+func testJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLargestPreview(t *testing.T) {
+	thumb, large := testJPEG(t, 16, 12), testJPEG(t, 64, 48)
+
+	// IFD0 holds a thumbnail; of its two SubIFDs one holds the large
+	// preview as a JPEG strip and the other the lossless JPEG raw data.
+	withSubIFDs := func() []byte {
+		b := newTIFF()
+		thumbOff, largeOff, rawOff := b.blob(thumb), b.blob(large), b.blob([]byte("\xff\xd8\xff\xc3 lossless raw data"))
+		preview := b.ifd(0,
+			tiffEntry{tag: tiffTagCompression, typ: 3, values: []uint32{tiffCompressionOldJPEG}},
+			tiffEntry{tag: tiffTagStripOffsets, typ: 4, values: []uint32{largeOff}},
+			tiffEntry{tag: tiffTagStripByteCounts, typ: 4, values: []uint32{uint32(len(large))}},
+		)
+		raw := b.ifd(0,
+			tiffEntry{tag: tiffTagCompression, typ: 3, values: []uint32{tiffCompressionJPEG}},
+			tiffEntry{tag: tiffTagStripOffsets, typ: 4, values: []uint32{rawOff}},
+			tiffEntry{tag: tiffTagStripByteCounts, typ: 4, values: []uint32{22}},
+		)
+		return b.bytes(b.ifd(0,
+			tiffEntry{tag: tiffTagSubIFDs, typ: 4, values: []uint32{raw, preview}},
+			tiffEntry{tag: tiffTagJPEGInterchange, typ: 4, values: []uint32{thumbOff}},
+			tiffEntry{tag: tiffTagJPEGInterchangeLn, typ: 4, values: []uint32{uint32(len(thumb))}},
+		))
+	}
+
+	tests := []struct {
+		name    string
+		data    func() []byte
+		want    []byte
+		wantErr error
+	}{
+		{name: "largest of the thumbnail and SubIFD previews", data: withSubIFDs, want: large},
+		{
+			name: "preview in the next IFD",
+			data: func() []byte {
+				b := newTIFF()
+				thumbOff := b.blob(thumb)
+				ifd1 := b.ifd(0,
+					tiffEntry{tag: tiffTagJPEGInterchange, typ: 4, values: []uint32{thumbOff}},
+					tiffEntry{tag: tiffTagJPEGInterchangeLn, typ: 4, values: []uint32{uint32(len(thumb))}},
+				)
+				return b.bytes(b.ifd(ifd1, tiffEntry{tag: tiffTagCompression, typ: 3, values: []uint32{1}}))
+			},
+			want: thumb,
+		},
+		{
+			name: "no preview",
+			data: func() []byte {
+				b := newTIFF()
+				return b.bytes(b.ifd(0, tiffEntry{tag: tiffTagCompression, typ: 3, values: []uint32{1}}))
+			},
+			wantErr: ErrNoPreview,
+		},
+		{
+			name: "offsets past the end",
+			data: func() []byte {
+				b := newTIFF()
+				return b.bytes(b.ifd(0xfffffff0,
+					tiffEntry{tag: tiffTagSubIFDs, typ: 4, values: []uint32{0xffffff00, 0x7fffffff}},
+					tiffEntry{tag: tiffTagJPEGInterchange, typ: 4, values: []uint32{0xffffff00}},
+					tiffEntry{tag: tiffTagJPEGInterchangeLn, typ: 4, values: []uint32{0xffffffff}},
+					tiffEntry{tag: tiffTagCompression, typ: 3, values: []uint32{tiffCompressionJPEG}},
+					tiffEntry{tag: tiffTagStripOffsets, typ: 4, values: []uint32{8}},
+					tiffEntry{tag: tiffTagStripByteCounts, typ: 4, values: []uint32{0xffffffff}},
+				))
+			},
+			wantErr: ErrNoPreview,
+		},
+		{
+			name: "forged counts",
+			data: func() []byte {
+				b := newTIFF()
+				return b.bytes(b.ifd(0,
+					tiffEntry{tag: tiffTagSubIFDs, typ: 4, values: []uint32{8}, count: 0xffffffff},
+					tiffEntry{tag: tiffTagJPEGInterchange, typ: 4, values: []uint32{8, 8}, count: 1024},
+					tiffEntry{tag: tiffTagJPEGInterchangeLn, typ: 4, values: []uint32{100}},
+				))
+			},
+			wantErr: ErrNoPreview,
+		},
+		{
+			name: "IFD entries past the end",
+			data: func() []byte {
+				return []byte{'I', 'I', 42, 0, 8, 0, 0, 0, 0xff, 0xff, 1, 2, 3}
+			},
+			wantErr: ErrNoPreview,
+		},
+		{
+			name: "IFDs that loop",
+			data: func() []byte {
+				b := newTIFF()
+				// The IFD is written at offset 8 and names itself as both
+				// next IFD and SubIFD.
+				return b.bytes(b.ifd(8, tiffEntry{tag: tiffTagSubIFDs, typ: 4, values: []uint32{8}}))
+			},
+			wantErr: ErrNoPreview,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data()
+			preview, cfg, err := largestPreview(bytes.NewReader(data))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("largestPreview() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(preview)
+			if err != nil || !bytes.Equal(got, tt.want) {
+				t.Errorf("largestPreview() returned %d bytes (error %v), want the %d-byte preview", len(got), err, len(tt.want))
+			}
+			want, _ := jpeg.DecodeConfig(bytes.NewReader(tt.want))
+			if cfg.Width != want.Width || cfg.Height != want.Height {
+				t.Errorf("largestPreview() config = %dx%d, want %dx%d", cfg.Width, cfg.Height, want.Width, want.Height)
+			}
+		})
+	}
+
+	// DecodeReader shows a RAW file as its largest preview.
+	img, err := DecodeReader(bytes.NewReader(withSubIFDs()), "IMG_0001.DNG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 64 || b.Dy() != 48 {
+		t.Errorf("DecodeReader() = %dx%d, want the 64x48 preview", b.Dx(), b.Dy())
+	}
+}
+
+func TestLargestPreviewRejectsNonTIFF(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("II*"), []byte("\xff\xd8\xff\xe0 a JPEG")} {
+		if _, _, err := largestPreview(bytes.NewReader(data)); err == nil || errors.Is(err, ErrNoPreview) {
+			t.Errorf("largestPreview(%q) error = %v, want a header error", data, err)
+		}
+	}
+}
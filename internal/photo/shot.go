@@ -0,0 +1,118 @@
+package photo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// Shot is how a photo was taken, from its EXIF. Fields the camera did not
+// record are left zero.
+type Shot struct {
+	Camera      string  `json:"camera,omitempty"`      // make and model, e.g. "Canon EOS R5"
+	Lens        string  `json:"lens,omitempty"`        // lens model
+	FocalLength float64 `json:"focalLength,omitempty"` // millimetres
+	FNumber     float64 `json:"fNumber,omitempty"`
+	Exposure    float64 `json:"exposure,omitempty"` // seconds
+	ISO         int     `json:"iso,omitempty"`
+}
+
+// String describes the shot on one line, e.g. "Canon EOS R5  RF24-70mm
+// F2.8 L IS USM  50mm  f/2.8  1/250s  ISO 100", leaving out what is not
+// known.
+func (s *Shot) String() string {
+	if s == nil {
+		return ""
+	}
+	var parts []string
+	if s.Camera != "" {
+		parts = append(parts, s.Camera)
+	}
+	if s.Lens != "" {
+		parts = append(parts, s.Lens)
+	}
+	if s.FocalLength > 0 {
+		parts = append(parts, formatDecimal(s.FocalLength)+"mm")
+	}
+	if s.FNumber > 0 {
+		parts = append(parts, "f/"+formatDecimal(s.FNumber))
+	}
+	if s.Exposure > 0 {
+		parts = append(parts, formatExposure(s.Exposure))
+	}
+	if s.ISO > 0 {
+		parts = append(parts, fmt.Sprintf("ISO %d", s.ISO))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// formatDecimal formats v to at most one decimal place: "50", "2.8".
+func formatDecimal(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
+// formatExposure formats an exposure time the way cameras show it: a
+// fraction of a second below half a second, e.g. "1/250s", else seconds.
+func formatExposure(secs float64) string {
+	if secs < 0.5 {
+		return fmt.Sprintf("1/%.0fs", math.Round(1/secs))
+	}
+	return formatDecimal(secs) + "s"
+}
+
+// readShot reads the camera, lens and exposure settings from x, or returns
+// nil if it records none of them. Like goexif's other accessors it may
+// panic on malformed tags, so it is called under guardEXIF.
+func readShot(x *exif.Exif) *Shot {
+	var s Shot
+	maker, _ := exifString(x, exif.Make)
+	model, _ := exifString(x, exif.Model)
+	s.Camera = cameraName(maker, model)
+	s.Lens, _ = exifString(x, exif.LensModel)
+	s.FocalLength = exifRational(x, exif.FocalLength)
+	s.FNumber = exifRational(x, exif.FNumber)
+	s.Exposure = exifRational(x, exif.ExposureTime)
+	if tag, err := x.Get(exif.ISOSpeedRatings); err == nil {
+		if iso, err := tag.Int(0); err == nil && iso > 0 {
+			s.ISO = iso
+		}
+	}
+	if s == (Shot{}) {
+		return nil
+	}
+	return &s
+}
+
+// cameraName joins maker and model, leaving the maker out when the model
+// already starts with its first word, as in "Canon" and "Canon EOS R5" or
+// "NIKON CORPORATION" and "NIKON D750".
+func cameraName(maker, model string) string {
+	if model == "" {
+		return maker
+	}
+	if first, _, _ := strings.Cut(maker, " "); first == "" || hasPrefixFold(model, first) {
+		return model
+	}
+	return maker + " " + model
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// exifRational returns the value of a rational tag, or 0 if it is missing
+// or not a positive number.
+func exifRational(x *exif.Exif, name exif.FieldName) float64 {
+	tag, err := x.Get(name)
+	if err != nil {
+		return 0
+	}
+	num, den, err := tag.Rat2(0)
+	if err != nil || num <= 0 || den <= 0 {
+		return 0
+	}
+	return float64(num) / float64(den)
+}
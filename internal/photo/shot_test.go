@@ -0,0 +1,74 @@
+package photo
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+const (
+	tagMake         = 0x010f
+	tagModel        = 0x0110
+	tagExposureTime = 0x829a
+	tagFNumber      = 0x829d
+	tagISO          = 0x8827
+	tagFocalLength  = 0x920a
+	tagLensModel    = 0xa434
+)
+
+// fracField holds one rational num/den, such as an f-number of 28/10.
+func fracField(tag uint16, num, den uint32) exifField {
+	v := binary.LittleEndian.AppendUint32(nil, num)
+	v = binary.LittleEndian.AppendUint32(v, den)
+	return exifField{tag: tag, typ: exifTypeRat, count: 1, value: v}
+}
+
+func TestExtractEXIFReadsShot(t *testing.T) {
+	tests := []struct {
+		name string
+		exif testEXIF
+		want string
+	}{
+		{
+			name: "everything",
+			exif: testEXIF{
+				ifd0: []exifField{asciiField(tagMake, "Canon"), asciiField(tagModel, "Canon EOS R5")},
+				exif: []exifField{
+					fracField(tagExposureTime, 1, 250),
+					fracField(tagFNumber, 28, 10),
+					shortField(tagISO, 100),
+					fracField(tagFocalLength, 50, 1),
+					asciiField(tagLensModel, "RF24-70mm F2.8 L IS USM"),
+				},
+			},
+			want: "Canon EOS R5  RF24-70mm F2.8 L IS USM  50mm  f/2.8  1/250s  ISO 100",
+		},
+		{
+			name: "make not in model, long exposure",
+			exif: testEXIF{
+				ifd0: []exifField{asciiField(tagMake, "SONY"), asciiField(tagModel, "ILCE-7M3")},
+				exif: []exifField{fracField(tagExposureTime, 25, 10)},
+			},
+			want: "SONY ILCE-7M3  2.5s",
+		},
+		{
+			name: "settings only",
+			exif: testEXIF{exif: []exifField{fracField(tagFNumber, 8, 1), fracField(tagFocalLength, 0, 1)}},
+			want: "f/8",
+		},
+		{name: "none", exif: testEXIF{ifd0: []exifField{asciiField(tagDateTime, "2024:06:01 09:00:00")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, err := extractEXIF(writeTestJPEG(t, t.TempDir(), "a.jpg", tt.exif))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := meta.shot.String(); got != tt.want {
+				t.Errorf("shot = %q, want %q", got, tt.want)
+			}
+			if (meta.shot == nil) != (tt.want == "") {
+				t.Errorf("shot = %+v, want nil only without settings", meta.shot)
+			}
+		})
+	}
+}
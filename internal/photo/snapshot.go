@@ -0,0 +1,118 @@
+package photo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const snapshotFileName = "photo_list_snapshot.json"
+
+// snapshot is the photo list of the last scan, saved so the next start can
+// show it without walking the albums.
+type snapshot struct {
+	// Version is the metadataCacheVersion the photos were read with.
+	Version int          `json:"version"`
+	Albums  []albumStamp `json:"albums"`
+	Photos  []Photo      `json:"photos"`
+}
+
+// albumStamp is what a snapshot checks of each album to tell whether it has
+// changed: the mod time and entry count of its top directory. Changes deeper
+// down go unnoticed, which the rescan after a snapshot start catches.
+type albumStamp struct {
+	Path    string `json:"path"`
+	ModTime int64  `json:"modTime"`
+	Entries int    `json:"entries"`
+}
+
+// SaveSnapshot records photos, as scanned from albums, in stateDir for
+// LoadSnapshot. The file is replaced in one step, so a crash mid-write
+// leaves the previous snapshot in place.
+func SaveSnapshot(stateDir string, albums []string, photos []Photo) error {
+	stamps, err := stampAlbums(albums)
+	if err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	path, err := snapshotPath(stateDir)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(snapshot{Version: metadataCacheVersion, Albums: stamps, Photos: photos})
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create snapshot directory: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot returns the photos SaveSnapshot last recorded in stateDir,
+// provided they were scanned from the same albums and none of those has
+// changed since. ok is false otherwise.
+func LoadSnapshot(stateDir string, albums []string) (photos []Photo, ok bool) {
+	path, err := snapshotPath(stateDir)
+	if err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Warning: could not read photo list snapshot: %v", err)
+		}
+		return nil, false
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		log.Printf("Warning: could not parse photo list snapshot: %v", err)
+		return nil, false
+	}
+	if snap.Version != metadataCacheVersion {
+		return nil, false
+	}
+	stamps, err := stampAlbums(albums)
+	if err != nil || !slices.Equal(stamps, snap.Albums) {
+		return nil, false
+	}
+	return snap.Photos, true
+}
+
+// stampAlbums stamps each album directory.
+func stampAlbums(albums []string) ([]albumStamp, error) {
+	stamps := make([]albumStamp, 0, len(albums))
+	for _, album := range albums {
+		info, err := os.Stat(album)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(album)
+		if err != nil {
+			return nil, err
+		}
+		stamps = append(stamps, albumStamp{Path: album, ModTime: info.ModTime().UnixNano(), Entries: len(entries)})
+	}
+	return stamps, nil
+}
+
+func snapshotPath(stateDir string) (string, error) {
+	if stateDir == "" {
+		dir, err := defaultStateDir()
+		if err != nil {
+			return "", err
+		}
+		stateDir = dir
+	}
+	return filepath.Join(stateDir, snapshotFileName), nil
+}
@@ -0,0 +1,61 @@
+package photo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotInvalidatedByAlbumChanges(t *testing.T) {
+	stateDir := t.TempDir()
+	album := t.TempDir()
+	path := writeTestJPEG(t, album, "a.jpg", testEXIF{})
+	photos := []Photo{{FilePath: path, Album: album, TakenTime: time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC), Width: 8, Height: 6}}
+
+	if _, ok := LoadSnapshot(stateDir, []string{album}); ok {
+		t.Fatal("LoadSnapshot found a snapshot before one was saved")
+	}
+	if err := SaveSnapshot(stateDir, []string{album}, photos); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := LoadSnapshot(stateDir, []string{album})
+	if !ok || len(got) != 1 || got[0].FilePath != path || !got[0].TakenTime.Equal(photos[0].TakenTime) {
+		t.Fatalf("LoadSnapshot = %v, %v; want the saved photos", got, ok)
+	}
+
+	if _, ok := LoadSnapshot(stateDir, []string{album, t.TempDir()}); ok {
+		t.Error("snapshot accepted for a different album list")
+	}
+
+	// A removed photo changes the album's entry count, whatever its mod
+	// time.
+	writeTestJPEG(t, album, "b.jpg", testEXIF{})
+	if err := os.Chtimes(album, fixtureModTime, fixtureModTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveSnapshot(stateDir, []string{album}, photos); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(album, "b.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(album, fixtureModTime, fixtureModTime); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := LoadSnapshot(stateDir, []string{album}); ok {
+		t.Error("snapshot accepted after a photo was removed")
+	}
+
+	// So does a touched album directory.
+	if err := SaveSnapshot(stateDir, []string{album}, photos); err != nil {
+		t.Fatal(err)
+	}
+	later := fixtureModTime.Add(time.Minute)
+	if err := os.Chtimes(album, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := LoadSnapshot(stateDir, []string{album}); ok {
+		t.Error("snapshot accepted after the album's mod time changed")
+	}
+}
@@ -0,0 +1,36 @@
+package photo
+
+import (
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FilenameTitle makes a title to show from the file name in path: without
+// its extension, with underscores and dashes as spaces, and each word
+// starting with a capital, so "beach_day-at-nans.jpg" is "Beach Day At
+// Nans". A name that ignore matches, such as a camera's "IMG_1234", has no
+// title and gives "". ignore sees the name without its extension; nil
+// ignores nothing.
+func FilenameTitle(path string, ignore *regexp.Regexp) string {
+	name := filepath.Base(filepath.FromSlash(path))
+	// Remote photos are named by their URL.
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if ignore != nil && ignore.MatchString(name) {
+		return ""
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || unicode.IsSpace(r)
+	})
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+	return strings.Join(words, " ")
+}
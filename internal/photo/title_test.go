@@ -0,0 +1,30 @@
+package photo
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFilenameTitle(t *testing.T) {
+	ignore := regexp.MustCompile(`(?i)^(img|dsc)?[\d_-]*$`)
+	tests := []struct {
+		path   string
+		ignore *regexp.Regexp
+		want   string
+	}{
+		{path: "/albums/2023/beach_day-at-nans.jpg", ignore: ignore, want: "Beach Day At Nans"},
+		{path: "/albums/Summer  in  NYC.jpeg", ignore: ignore, want: "Summer In NYC"},
+		{path: "/albums/__éte--2019__.png", ignore: ignore, want: "Éte 2019"},
+		{path: "https://nas.local/photos/Grand%20Canyon.jpg", ignore: ignore, want: "Grand Canyon"},
+		{path: "/albums/IMG_1234.JPG", ignore: ignore, want: ""},
+		{path: "/albums/DSC01234.jpg", ignore: ignore, want: ""},
+		{path: "/albums/20240101_123456.jpg", ignore: ignore, want: ""},
+		{path: "/albums/IMG_1234.JPG", want: "IMG 1234"},
+		{path: "/albums/.jpg", want: ""},
+	}
+	for _, tt := range tests {
+		if got := FilenameTitle(tt.path, tt.ignore); got != tt.want {
+			t.Errorf("FilenameTitle(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
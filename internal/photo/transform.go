@@ -0,0 +1,118 @@
+package photo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+
+	"github.com/electronjoe/OpenFrame/internal/imgproc"
+)
+
+// TransformSuffix is added to a photo's file name to name its transform
+// sidecar, e.g. beach.jpg.openframe.json.
+const TransformSuffix = ".openframe.json"
+
+// Transform reframes one photo for display without touching the file: a crop
+// and then a turn, both applied after the EXIF orientation. It is read from
+// the photo's transform sidecar.
+type Transform struct {
+	// Crop keeps part of the upright photo; nil keeps all of it.
+	Crop *Crop `json:"crop,omitempty"`
+	// Rotate turns the cropped photo clockwise by 0, 90, 180 or 270
+	// degrees.
+	Rotate int `json:"rotate,omitempty"`
+}
+
+// Crop is a rectangle in fractions of the photo's width and height, so that
+// it fits a thumbnail as well as the original.
+type Crop struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// TransformPath returns where the transform sidecar of the photo at path is.
+func TransformPath(path string) string {
+	return path + TransformSuffix
+}
+
+// readTransform reads the transform sidecar of the photo at path. It returns
+// nil, and no error, when there is none.
+func readTransform(path string) (*Transform, error) {
+	data, err := os.ReadFile(TransformPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var t Transform
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", TransformPath(path), err)
+	}
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", TransformPath(path), err)
+	}
+	return &t, nil
+}
+
+func (t Transform) validate() error {
+	if t.Rotate%90 != 0 || t.Rotate < 0 || t.Rotate >= 360 {
+		return fmt.Errorf("rotate: must be 0, 90, 180 or 270, got %d", t.Rotate)
+	}
+	if c := t.Crop; c != nil {
+		// A little slack lets a crop computed in floating point end at 1.
+		const slack = 1e-6
+		if c.X < 0 || c.Y < 0 || c.Width <= 0 || c.Height <= 0 || c.X+c.Width > 1+slack || c.Y+c.Height > 1+slack {
+			return fmt.Errorf("crop: must lie within 0 and 1 with a positive width and height, got %+v", *c)
+		}
+	}
+	return nil
+}
+
+// cropRect returns the crop, in pixels, of a w x h image.
+func (t *Transform) cropRect(w, h int) image.Rectangle {
+	if t == nil || t.Crop == nil {
+		return image.Rect(0, 0, w, h)
+	}
+	c := t.Crop
+	x0, y0 := int(math.Round(c.X*float64(w))), int(math.Round(c.Y*float64(h)))
+	x1, y1 := int(math.Round((c.X+c.Width)*float64(w))), int(math.Round((c.Y+c.Height)*float64(h)))
+	// Never crop away the whole photo.
+	x1, y1 = max(x1, x0+1), max(y1, y0+1)
+	return image.Rect(x0, y0, x1, y1).Intersect(image.Rect(0, 0, w, h))
+}
+
+// Size returns the size a w x h upright photo has once transformed. A nil
+// Transform leaves it as it is.
+func (t *Transform) Size(w, h int) (int, int) {
+	r := t.cropRect(w, h)
+	w, h = r.Dx(), r.Dy()
+	if t != nil && t.Rotate%180 != 0 {
+		return h, w
+	}
+	return w, h
+}
+
+// Apply crops and turns the upright img. A nil Transform returns img.
+func (t *Transform) Apply(img image.Image) image.Image {
+	if t == nil {
+		return img
+	}
+	if t.Crop != nil {
+		img = imgproc.Crop(img, t.cropRect(img.Bounds().Dx(), img.Bounds().Dy()))
+	}
+	switch t.Rotate {
+	case 90:
+		img = imgproc.Rotate90(img)
+	case 180:
+		img = imgproc.Rotate180(img)
+	case 270:
+		img = imgproc.Rotate270(img)
+	}
+	return img
+}
@@ -0,0 +1,79 @@
+package photo
+
+import (
+	"image"
+	"image/color"
+	"os"
+	"testing"
+)
+
+func TestExtractMetadataAppliesTransformSidecar(t *testing.T) {
+	// The test JPEG is stored 8x6 (landscape).
+	tests := []struct {
+		name         string
+		sidecar      string
+		wantW, wantH int
+		wantErr      bool
+	}{
+		{name: "none", wantW: 8, wantH: 6},
+		{name: "crop", sidecar: `{"crop": {"x": 0.25, "y": 0, "width": 0.5, "height": 1}}`, wantW: 4, wantH: 6},
+		{name: "rotate", sidecar: `{"rotate": 90}`, wantW: 6, wantH: 8},
+		{name: "crop then rotate", sidecar: `{"crop": {"x": 0, "y": 0.5, "width": 1, "height": 0.5}, "rotate": 270}`, wantW: 3, wantH: 8},
+		{name: "bad rotate", sidecar: `{"rotate": 45}`, wantW: 8, wantH: 6, wantErr: true},
+		{name: "crop out of range", sidecar: `{"crop": {"x": 0.5, "y": 0, "width": 0.75, "height": 1}}`, wantW: 8, wantH: 6, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestJPEG(t, t.TempDir(), "a.jpg", testEXIF{})
+			if tt.sidecar != "" {
+				if err := os.WriteFile(TransformPath(path), []byte(tt.sidecar), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p, err := extractMetadata(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Width != tt.wantW || p.Height != tt.wantH {
+				t.Errorf("extractMetadata() = %dx%d, want %dx%d", p.Width, p.Height, tt.wantW, tt.wantH)
+			}
+			// A sidecar that cannot be used is ignored.
+			if (p.Transform == nil) != (tt.sidecar == "" || tt.wantErr) {
+				t.Errorf("Transform = %+v, want it set only for a valid sidecar", p.Transform)
+			}
+		})
+	}
+}
+
+func TestTransformApplyCropsBeforeTurning(t *testing.T) {
+	// A 4x2 image, red on the left half and blue on the right.
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			src.Set(x, y, red)
+			if x >= 2 {
+				src.Set(x, y, blue)
+			}
+		}
+	}
+	tr := &Transform{Crop: &Crop{X: 0.25, Y: 0, Width: 0.75, Height: 1}, Rotate: 90}
+	got := tr.Apply(src)
+	if b := got.Bounds(); b.Dx() != 2 || b.Dy() != 3 {
+		t.Fatalf("Apply() bounds = %v, want 2x3", b)
+	}
+	// Turned clockwise, what was the left edge is now the top row.
+	if c := color.RGBAModel.Convert(got.At(0, 0)); c != red {
+		t.Errorf("top left = %v, want red", c)
+	}
+	if c := color.RGBAModel.Convert(got.At(1, 2)); c != blue {
+		t.Errorf("bottom right = %v, want blue", c)
+	}
+	if w, h := tr.Size(4, 2); w != 2 || h != 3 {
+		t.Errorf("Size(4, 2) = %dx%d, want 2x3", w, h)
+	}
+	var none *Transform
+	if none.Apply(src) != image.Image(src) {
+		t.Error("nil Transform changed the image")
+	}
+}
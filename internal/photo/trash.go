@@ -0,0 +1,74 @@
+package photo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const trashDirName = "trash"
+
+// MoveToTrash moves the photo at path into the trash folder under stateDir
+// (~/.openframe by default) rather than deleting it, and returns its new
+// path. A name already taken in the trash gets a numeric suffix.
+func MoveToTrash(stateDir, path string) (string, error) {
+	if stateDir == "" {
+		dir, err := defaultStateDir()
+		if err != nil {
+			return "", err
+		}
+		stateDir = dir
+	}
+	trashDir := filepath.Join(stateDir, trashDirName)
+	if err := os.MkdirAll(trashDir, 0o755); err != nil {
+		return "", fmt.Errorf("create trash directory: %w", err)
+	}
+
+	dest := filepath.Join(trashDir, filepath.Base(path))
+	ext := filepath.Ext(dest)
+	stem := strings.TrimSuffix(dest, ext)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(dest); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		dest = stem + "-" + strconv.Itoa(i) + ext
+	}
+
+	err := os.Rename(path, dest)
+	if errors.Is(err, syscall.EXDEV) {
+		// Albums often live on another filesystem (a NAS mount).
+		err = moveAcrossDevices(path, dest)
+	}
+	if err != nil {
+		return "", fmt.Errorf("move %s to trash: %w", path, err)
+	}
+	return dest, nil
+}
+
+// moveAcrossDevices copies src to dest, then removes src.
+func moveAcrossDevices(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dest)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,37 @@
+package player
+
+import "time"
+
+// Durations stretches or shortens the interval for particular kinds of
+// slide. Each field multiplies the interval; zero means 1 (no change). A
+// slide's orientation factor and its favorite factor combine.
+type Durations struct {
+	Landscape    float64 // a single landscape (or square) photo
+	Portrait     float64 // a single portrait photo
+	PortraitPair float64 // two portraits side by side, or two landscapes stacked
+	Favorite     float64 // any photo on the slide is a favorite
+}
+
+// slideDuration is how long slide stays on screen given the base interval.
+func (d Durations) slideDuration(interval time.Duration, slide Slide, favorite bool) time.Duration {
+	factor := 1.0
+	switch {
+	case len(slide.Photos) == 2:
+		factor = multiplier(d.PortraitPair)
+	case len(slide.Photos) == 1 && isPortrait(slide.Photos[0]):
+		factor = multiplier(d.Portrait)
+	case len(slide.Photos) == 1:
+		factor = multiplier(d.Landscape)
+	}
+	if favorite {
+		factor *= multiplier(d.Favorite)
+	}
+	return time.Duration(float64(interval) * factor)
+}
+
+func multiplier(m float64) float64 {
+	if m <= 0 {
+		return 1
+	}
+	return m
+}
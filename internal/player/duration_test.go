@@ -0,0 +1,94 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/cec"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+func TestSlideDuration(t *testing.T) {
+	landscape := Slide{Photos: []photo.Photo{{Width: 3, Height: 2}}}
+	portrait := Slide{Photos: []photo.Photo{{Width: 2, Height: 3}}}
+	pair := Slide{Photos: []photo.Photo{{Width: 2, Height: 3}, {Width: 2, Height: 3}}}
+	d := Durations{Landscape: 1.5, Portrait: 1.2, PortraitPair: 0.5, Favorite: 2}
+
+	tests := []struct {
+		name      string
+		durations Durations
+		slide     Slide
+		favorite  bool
+		want      time.Duration
+	}{
+		{name: "unset multipliers keep the interval", slide: pair, favorite: true, want: 10 * time.Second},
+		{name: "landscape", durations: d, slide: landscape, want: 15 * time.Second},
+		{name: "portrait", durations: d, slide: portrait, want: 12 * time.Second},
+		{name: "portrait pair", durations: d, slide: pair, want: 5 * time.Second},
+		{name: "favorite combines with orientation", durations: d, slide: landscape, favorite: true, want: 30 * time.Second},
+		{name: "favorite alone", durations: Durations{Favorite: 2}, slide: pair, favorite: true, want: 20 * time.Second},
+		{name: "negative means unset", durations: Durations{Landscape: -1}, slide: landscape, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.durations.slideDuration(10*time.Second, tt.slide, tt.favorite); got != tt.want {
+				t.Errorf("slideDuration() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeFavorites map[string]bool
+
+func (f fakeFavorites) Has(path string) bool { return f[path] }
+
+func (f fakeFavorites) Set(path string, favorite bool) error {
+	f[path] = favorite
+	return nil
+}
+
+func TestUpdateUsesPerSlideDuration(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	p := New(landscapeSlides("a.jpg", "b.jpg"), Options{
+		Interval:  10 * time.Second,
+		LoadImage: loadFake,
+		Clock:     clock,
+		Durations: Durations{Favorite: 3},
+		Favorites: fakeFavorites{"a.jpg": true},
+	})
+	p.LoadDisplayableSlide()
+
+	clock.now = clock.now.Add(20 * time.Second)
+	p.Update()
+	if got := currentPath(t, p); got != "a.jpg" {
+		t.Fatalf("favorite advanced after 20s, showing %s", got)
+	}
+	clock.now = clock.now.Add(11 * time.Second)
+	p.Update()
+	if got := currentPath(t, p); got != "b.jpg" {
+		t.Fatalf("favorite still showing after 31s: %s", got)
+	}
+	if _, interval := p.SlideTiming(); interval != 10*time.Second {
+		t.Errorf("SlideTiming() interval = %s for a plain slide, want 10s", interval)
+	}
+}
+
+func TestWakingKeepsSlideDuration(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	p := New(landscapeSlides("a.jpg", "b.jpg"), Options{
+		Interval:    10 * time.Second,
+		IdleTimeout: time.Minute,
+		LoadImage:   loadFake,
+		Clock:       clock,
+		Durations:   Durations{Favorite: 3},
+		Favorites:   fakeFavorites{"a.jpg": true, "b.jpg": true},
+	})
+	p.LoadDisplayableSlide()
+
+	clock.now = clock.now.Add(61 * time.Second)
+	p.Update()
+	p.Command(cec.RemoteUnknown)
+	if _, interval := p.SlideTiming(); interval != 30*time.Second {
+		t.Errorf("SlideTiming() interval = %s after waking on a favorite, want 30s", interval)
+	}
+}
@@ -0,0 +1,48 @@
+package player
+
+import (
+	"log"
+	"time"
+)
+
+// SetIdleHandler registers fn to be called from the update loop when the
+// slideshow goes idle (no remote activity for Options.IdleTimeout, including
+// time spent paused) and again when remote activity wakes it. fn is called
+// once per transition and must not block.
+func (p *Player) SetIdleHandler(fn func(idle bool)) {
+	p.onIdleChange = fn
+}
+
+// noteActivity records remote input, waking the slideshow if it was idle.
+func (p *Player) noteActivity(now time.Time) {
+	p.lastActivity = now
+	if p.standby {
+		p.setStandby(false)
+	}
+}
+
+// updateIdle puts the slideshow into standby once the idle timeout elapses.
+func (p *Player) updateIdle(now time.Time) {
+	if p.idleTimeout <= 0 || p.standby {
+		return
+	}
+	if now.Sub(p.lastActivity) >= p.idleTimeout {
+		p.setStandby(true)
+	}
+}
+
+func (p *Player) setStandby(standby bool) {
+	p.standby = standby
+	if standby {
+		log.Printf("No remote activity for %s; going idle", p.idleTimeout)
+	} else {
+		log.Printf("Remote activity; waking up")
+		// Start the current slide's time afresh now that it is visible.
+		p.slideStart = p.clock.Now()
+		p.switchTime = p.slideStart.Add(p.slideDuration())
+		p.markAdvanced()
+	}
+	if p.onIdleChange != nil {
+		p.onIdleChange(standby)
+	}
+}
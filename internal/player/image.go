@@ -0,0 +1,42 @@
+package player
+
+import (
+	"io"
+
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+// Image is one photo ready for display. The renderer that produced it owns
+// the pixels; the player only releases them when the slide changes.
+type Image interface {
+	Dispose()
+}
+
+// ImageLoader prepares one photo for display.
+type ImageLoader func(photo.Photo) (Image, error)
+
+// StreamLoader prepares one photo for display from r rather than from its
+// file, for photos that are not on disk, such as those in remote albums.
+type StreamLoader func(r io.Reader, p photo.Photo) (Image, error)
+
+// DecodeImage is an ImageLoader for running without a renderer. It fully
+// decodes the photo, so corrupt files are skipped exactly as they would be on
+// screen, then discards the pixels.
+func DecodeImage(p photo.Photo) (Image, error) {
+	if _, err := photo.Decode(p.FilePath); err != nil {
+		return nil, err
+	}
+	return decodedImage{}, nil
+}
+
+// DecodeImageFrom is the StreamLoader counterpart of DecodeImage.
+func DecodeImageFrom(r io.Reader, p photo.Photo) (Image, error) {
+	if _, err := photo.DecodeReader(r, p.FilePath); err != nil {
+		return nil, err
+	}
+	return decodedImage{}, nil
+}
+
+type decodedImage struct{}
+
+func (decodedImage) Dispose() {}
@@ -0,0 +1,888 @@
+// Package player holds the slideshow's state machine: the slide list,
+// navigation, timing, rescans, idling and the watchdog. It has no rendering
+// or windowing dependencies, so it can be driven headless and tested without
+// a display; the slideshow package draws it with Ebiten.
+//
+// A Player belongs to the goroutine that calls Update, Ebiten's game loop
+// when it is drawn. Other goroutines, such as the CEC listener, the MQTT
+// bridge and the HTTP API, never touch its state: they send remote commands
+// on the channel given to SetRemoteCommandChan, which Update applies between
+// frames, call Rescan, whose result Update swaps in, and read Status, a
+// snapshot Update takes under a lock after every frame. The watchdog
+// started by StartWatchdog likewise only reads atomics and kicks Update.
+package player
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math/rand"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/cec"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+// Clock supplies the current time; tests substitute a fake one to step
+// through slide timing deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Player holds the state of our slideshow, including the slides, indexes, etc.
+// Its methods must be called from the Update goroutine, except where noted.
+type Player struct {
+	slides        []Slide
+	currentIndex  int
+	currentImages []Image
+	loadingError  error
+
+	// The slide on screen, and the one it replaced. previousImages is only
+	// kept (until the next change) when keepPrevious is set, so a renderer
+	// can animate between the two; direction is +1 for a move forward and
+	// -1 for a move back.
+	shown          Slide
+	hasShown       bool
+	previous       Slide
+	previousImages []Image
+	hasPrevious    bool
+	direction      int
+	keepPrevious   bool
+	// memoryBudget caps the decoded bytes of the two slides held for a
+	// transition; zero means no cap. overBudget is set once that has been
+	// logged.
+	memoryBudget int64
+	overBudget   bool
+
+	clock         Clock
+	interval      time.Duration
+	titleDuration time.Duration
+	durations     Durations
+	slideStart    time.Time
+	switchTime    time.Time
+	paused        bool
+	// held keeps the current slide up until the hold is released or the
+	// slide is changed by hand; unlike paused it lasts for one slide only.
+	held bool
+	// blanked shows black in place of the slides until the next command;
+	// the slideshow carries on underneath.
+	blanked bool
+
+	standbyMessage string
+	scan           ScanFunc
+	rescanInterval time.Duration
+	nextRescan     time.Time
+	scanning       atomic.Bool
+	rescanQueued   atomic.Bool
+	rescanResults  chan rescanResult
+	// rescanAsked makes the next rescan result report the photo count, for
+	// a rescan asked for by command.
+	rescanAsked bool
+
+	// Idle tracking: after idleTimeout without remote input the slideshow
+	// stops advancing and onIdleChange is told so the TV can be turned off.
+	idleTimeout  time.Duration
+	lastActivity time.Time
+	standby      bool
+	onIdleChange func(idle bool)
+
+	// Watchdog state, shared with the goroutine started by StartWatchdog.
+	due          atomic.Int64 // UnixNano of the scheduled slide change
+	idle         atomic.Bool  // paused, held, or nothing to show
+	watchdogKick chan struct{}
+
+	remoteCommandChan chan cec.RemoteCommand
+	onSlideChange     func(index, total int, slide Slide)
+	onNotify          func(msg string)
+	onVolume          func(cmd cec.RemoteCommand)
+
+	loadImage    ImageLoader
+	preloader    *Preloader
+	preloadAhead int
+	placeholder  string
+
+	// trash moves a photo out of the albums; a first delete press arms
+	// deletion of the current slide until deleteArmedUntil.
+	trash            func(path string) (string, error)
+	deleteArmedUntil time.Time
+
+	favorites Favorites
+	shuffler  Shuffler
+
+	// status is what Status reports, taken at the end of every Update.
+	statusMu sync.Mutex
+	status   Status
+}
+
+// Favorites persists which photos have been flagged from the remote.
+type Favorites interface {
+	Has(path string) bool
+	Set(path string, favorite bool) error
+}
+
+// Each faster or slower command changes the interval by intervalStep,
+// within minInterval and maxInterval.
+const (
+	intervalStep = 2 * time.Second
+	minInterval  = 2 * time.Second
+	maxInterval  = 10 * time.Minute
+)
+
+// deleteConfirmWindow is how long a first delete press waits for the second.
+const deleteConfirmWindow = 5 * time.Second
+
+// Options configures a Player.
+type Options struct {
+	Interval time.Duration
+	// TitleDuration is how long title cards stay up; zero means Interval.
+	TitleDuration time.Duration
+	// Durations scales Interval for each photo slide.
+	Durations Durations
+	// KeepPrevious holds on to the outgoing slide's images until the next
+	// change, for renderers that animate transitions. See PreviousSlide.
+	KeepPrevious bool
+	// MemoryBudget, when positive, is the most decoded image memory (in
+	// bytes, estimated at four a pixel) to hold. A slide change that would
+	// keep the outgoing slide past it drops that slide first, so the change
+	// is a cut.
+	MemoryBudget int64
+
+	// LoadImage prepares each photo for display; nil means DecodeImage.
+	LoadImage ImageLoader
+	// Preloader, if set, decodes the photos of the next PreloadAhead
+	// slides, in the direction of travel, while the current one is up. What
+	// it holds counts toward MemoryBudget. The Player closes it.
+	Preloader    *Preloader
+	PreloadAhead int
+	// Placeholder is the path of an image shown in place of a photo whose
+	// file cannot be read, as when the network share it is on is down. The
+	// slide stays in the slideshow, so the photo is tried again when it
+	// next comes up; without a Placeholder, or for a photo that reads but
+	// does not decode, the slide is skipped and dropped.
+	Placeholder string
+	// Clock defaults to the system clock.
+	Clock Clock
+
+	// StandbyMessage is shown while there are no slides to display.
+	StandbyMessage string
+	// Scan rebuilds the slide list for Rescan. While there is nothing to
+	// show it is retried every RescanInterval.
+	Scan           ScanFunc
+	RescanInterval time.Duration
+
+	// IdleTimeout is how long without remote input (paused or not) before
+	// the slideshow goes idle; zero disables idling. See SetIdleHandler.
+	IdleTimeout time.Duration
+
+	// Trash moves a deleted photo out of the albums and returns where it
+	// went. Nil disables the delete command.
+	Trash func(path string) (string, error)
+
+	// Favorites records the favorite command; nil disables it.
+	Favorites Favorites
+
+	// Shuffler reorders the slides before the first pass and again at the
+	// start of every pass; nil keeps the order given.
+	Shuffler Shuffler
+
+	// StartRandom opens on a random slide rather than the first.
+	StartRandom bool
+	// ResumeAt, if set, opens on the slide showing the photo at that path,
+	// e.g. the one last shown before a restart. A photo that has gone
+	// falls back to StartRandom or the first slide.
+	ResumeAt string
+}
+
+// New creates a Player for slides. Call LoadDisplayableSlide to load the
+// first slide, then Update on every frame.
+func New(slides []Slide, opts Options) *Player {
+	clock := opts.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+	loadImage := opts.LoadImage
+	if loadImage == nil {
+		loadImage = DecodeImage
+	}
+
+	now := clock.Now()
+	p := &Player{
+		slides:        slides,
+		clock:         clock,
+		interval:      opts.Interval,
+		titleDuration: opts.TitleDuration,
+		durations:     opts.Durations,
+		keepPrevious:  opts.KeepPrevious,
+		memoryBudget:  opts.MemoryBudget,
+		slideStart:    now,
+		switchTime:    now.Add(opts.Interval),
+
+		standbyMessage: opts.StandbyMessage,
+		scan:           opts.Scan,
+		rescanInterval: opts.RescanInterval,
+		nextRescan:     now.Add(opts.RescanInterval),
+		rescanResults:  make(chan rescanResult, 1),
+
+		idleTimeout:  opts.IdleTimeout,
+		lastActivity: now,
+
+		watchdogKick: make(chan struct{}, 1),
+
+		loadImage:    loadImage,
+		preloader:    opts.Preloader,
+		preloadAhead: opts.PreloadAhead,
+		placeholder:  opts.Placeholder,
+
+		trash:     opts.Trash,
+		favorites: opts.Favorites,
+		shuffler:  opts.Shuffler,
+	}
+	if p.shuffler != nil {
+		p.shuffler.Shuffle(p.slides[p.pinned():], nil)
+	}
+	p.currentIndex = startIndex(p.slides, opts)
+	p.markAdvanced()
+	return p
+}
+
+// startIndex is the index of the slide opts says to open on.
+func startIndex(slides []Slide, opts Options) int {
+	if len(slides) == 0 || slides[0].Pinned {
+		return 0
+	}
+	if opts.ResumeAt != "" {
+		for i, s := range slides {
+			if slices.Contains(s.Paths(), opts.ResumeAt) {
+				return i
+			}
+		}
+	}
+	if opts.StartRandom {
+		return rand.Intn(len(slides))
+	}
+	return 0
+}
+
+// pinned is the number of slides at the start that stay put: one if the
+// first is Pinned, or else none.
+func (p *Player) pinned() int {
+	if len(p.slides) > 0 && p.slides[0].Pinned {
+		return 1
+	}
+	return 0
+}
+
+// SetRemoteCommandChan allows us to inject the remote events channel.
+func (p *Player) SetRemoteCommandChan(ch chan cec.RemoteCommand) {
+	p.remoteCommandChan = ch
+}
+
+// SetSlideChangeHandler registers fn to be called from the update loop each
+// time a slide has been loaded for display. fn must not block.
+func (p *Player) SetSlideChangeHandler(fn func(index, total int, slide Slide)) {
+	p.onSlideChange = fn
+}
+
+// SetNotifyHandler registers fn to be called from the update loop with a
+// short message for the viewer each time a command takes effect, e.g.
+// "Added to favorites". fn must not block.
+func (p *Player) SetNotifyHandler(fn func(msg string)) {
+	p.onNotify = fn
+}
+
+// SetVolumeHandler registers fn to be called from the update loop with each
+// volume key pressed (cec.RemoteVolumeUp, cec.RemoteVolumeDown or
+// cec.RemoteMute), e.g. to pass it on to the TV. Volume keys do nothing else
+// to the slideshow. fn must not block.
+func (p *Player) SetVolumeHandler(fn func(cmd cec.RemoteCommand)) {
+	p.onVolume = fn
+}
+
+// notify passes a message for the viewer to the notify handler, if any.
+func (p *Player) notify(format string, args ...any) {
+	if p.onNotify != nil {
+		p.onNotify(fmt.Sprintf(format, args...))
+	}
+}
+
+// Update advances the slideshow by one frame: it reads remote commands,
+// handles them, and also auto-advances slides if not paused.
+func (p *Player) Update() {
+	// Non-blocking read of remote commands
+readLoop:
+	for {
+		select {
+		case cmd := <-p.remoteCommandChan:
+			p.Command(cmd)
+		default:
+			break readLoop
+		}
+	}
+
+	// Swap in a finished rescan, or start one if there is nothing to show
+	select {
+	case r := <-p.rescanResults:
+		p.applyRescan(r)
+	default:
+	}
+	p.maybeRescan(p.clock.Now())
+
+	p.updateIdle(p.clock.Now())
+
+	// If not paused, auto-advance slides on interval (or when the watchdog
+	// decided the slideshow had stalled). Nobody is watching while idle.
+	running := !p.paused && !p.held && !p.standby
+	select {
+	case <-p.watchdogKick:
+		if running {
+			p.advanceSlide()
+		}
+	default:
+	}
+	if running && p.clock.Now().After(p.switchTime) {
+		p.advanceSlide()
+	}
+	p.idle.Store(!running || len(p.slides) == 0)
+	p.takeStatus()
+}
+
+// Command applies one remote command, e.g. from a keyboard. It must be called
+// from the same goroutine as Update.
+func (p *Player) Command(cmd cec.RemoteCommand) {
+	p.noteActivity(p.clock.Now())
+	p.handleRemoteCommand(cmd)
+}
+
+// handleRemoteCommand adjusts the slideshow based on remote input.
+func (p *Player) handleRemoteCommand(cmd cec.RemoteCommand) {
+	switch cmd {
+	case cec.RemoteVolumeUp, cec.RemoteVolumeDown, cec.RemoteMute:
+		if p.onVolume != nil {
+			p.onVolume(cmd)
+		}
+		return
+	}
+	if p.blanked {
+		// Any command unblanks, and that is all it does.
+		p.blanked = false
+		return
+	}
+	if cmd != cec.RemoteDelete {
+		p.deleteArmedUntil = time.Time{}
+	}
+	switch cmd {
+	case cec.RemoteLeft:
+		p.previousSlide()
+	case cec.RemoteRight:
+		p.advanceSlide()
+	case cec.RemoteSelect:
+		p.paused = !p.paused
+		if p.paused {
+			p.notify("Paused")
+		} else {
+			p.notify("Resumed")
+		}
+		// Don't count paused time against the watchdog.
+		p.markAdvanced()
+	case cec.RemoteDelete:
+		p.requestDelete()
+	case cec.RemoteFavorite:
+		p.toggleFavorite()
+	case cec.RemoteHold:
+		p.toggleHold()
+	case cec.RemoteFaster:
+		p.changeInterval(-intervalStep)
+	case cec.RemoteSlower:
+		p.changeInterval(intervalStep)
+	case cec.RemoteBlank:
+		p.blanked = true
+	case cec.RemoteRescan:
+		p.requestRescan()
+	default:
+		// Unknown or unhandled
+	}
+}
+
+// requestDelete arms deletion of the current slide, or carries it out if
+// this is the confirming second press.
+func (p *Player) requestDelete() {
+	if p.trash == nil || len(p.slides) == 0 || p.loadingError != nil || p.slides[p.currentIndex].IsTitleCard() {
+		return
+	}
+	now := p.clock.Now()
+	if now.After(p.deleteArmedUntil) {
+		p.deleteArmedUntil = now.Add(deleteConfirmWindow)
+		log.Printf("Delete requested for %v; press again to confirm", p.slides[p.currentIndex].Paths())
+		return
+	}
+	p.deleteArmedUntil = time.Time{}
+	p.deleteCurrentSlide()
+}
+
+// deleteCurrentSlide moves every photo of the current slide to the trash,
+// drops the slide and shows the one that took its place. A photo that could
+// not be moved stays on screen as a slide of its own.
+func (p *Player) deleteCurrentSlide() {
+	var kept []photo.Photo
+	moved := 0
+	for _, ph := range p.slides[p.currentIndex].Photos {
+		dest, err := p.trash(ph.FilePath)
+		if err != nil {
+			log.Printf("Delete failed: %v", err)
+			kept = append(kept, ph)
+			continue
+		}
+		log.Printf("Moved %s to %s", ph.FilePath, dest)
+		moved++
+	}
+	switch moved {
+	case 0:
+		p.notify("Delete failed")
+		return
+	case 1:
+		p.notify("Moved to trash")
+	default:
+		p.notify("Moved %d photos to trash", moved)
+	}
+
+	if len(kept) > 0 {
+		// One photo of a pair is still in the albums: it stays on as a
+		// slide of its own.
+		p.slides[p.currentIndex].Photos = kept
+		p.slides[p.currentIndex].Stacked = false
+	} else {
+		p.slides = slices.Delete(p.slides, p.currentIndex, p.currentIndex+1)
+		if p.currentIndex >= len(p.slides) {
+			p.currentIndex = 0
+		}
+	}
+	p.LoadDisplayableSlide()
+}
+
+// toggleFavorite flips the favorite flag of the current slide. Both photos of
+// a side-by-side slide follow the first, so one press can't leave them split.
+func (p *Player) toggleFavorite() {
+	if p.favorites == nil || len(p.slides) == 0 || p.loadingError != nil || p.slides[p.currentIndex].IsTitleCard() {
+		return
+	}
+	photos := p.slides[p.currentIndex].Photos
+	favorite := !p.favorites.Has(photos[0].FilePath)
+	for _, ph := range photos {
+		if err := p.favorites.Set(ph.FilePath, favorite); err != nil {
+			log.Printf("Saving favorite failed: %v", err)
+			p.notify("Saving favorite failed")
+			return
+		}
+	}
+	log.Printf("Favorite %t: %v", favorite, p.slides[p.currentIndex].Paths())
+	if favorite {
+		p.notify("Added to favorites")
+	} else {
+		p.notify("Removed from favorites")
+	}
+	// The slide's remaining time follows its new favorite multiplier.
+	p.switchTime = p.slideStart.Add(p.slideDuration())
+	p.markAdvanced()
+}
+
+// toggleHold holds the current slide on screen, or releases it to stay up
+// for a fresh interval before the slideshow moves on.
+func (p *Player) toggleHold() {
+	if len(p.slides) == 0 || p.loadingError != nil {
+		return
+	}
+	p.held = !p.held
+	if !p.held {
+		p.slideStart = p.clock.Now()
+		p.switchTime = p.slideStart.Add(p.slideDuration())
+	}
+	// Don't count held time against the watchdog.
+	p.markAdvanced()
+}
+
+// changeInterval lengthens or shortens the interval by delta until the
+// slideshow is restarted, unless that would take it out of bounds. The slide
+// on screen gets its new duration from when it went up.
+func (p *Player) changeInterval(delta time.Duration) {
+	interval := p.interval + delta
+	if interval < minInterval || interval > maxInterval {
+		return
+	}
+	p.interval = interval
+	log.Printf("Interval set to %v", interval)
+	p.notify("Interval: %v", interval)
+	p.switchTime = p.slideStart.Add(p.slideDuration())
+	p.markAdvanced()
+}
+
+// IsFavorite reports whether the photo at path has been flagged as a
+// favorite.
+func (p *Player) IsFavorite(path string) bool {
+	return p.favorites != nil && p.favorites.Has(path)
+}
+
+// DeletePending reports whether a delete press is waiting to be confirmed.
+func (p *Player) DeletePending() bool {
+	return p.clock.Now().Before(p.deleteArmedUntil)
+}
+
+// CurrentSlide returns the slide on screen and its loaded images. ok is false
+// while there is nothing to show.
+func (p *Player) CurrentSlide() (slide Slide, images []Image, ok bool) {
+	if len(p.slides) == 0 || p.loadingError != nil {
+		return Slide{}, nil, false
+	}
+	return p.slides[p.currentIndex], p.currentImages, true
+}
+
+// SlidePosition returns the index of the slide on screen and how many slides
+// there are, title cards included. total is zero while there is nothing to
+// show.
+func (p *Player) SlidePosition() (index, total int) {
+	if len(p.slides) == 0 || p.loadingError != nil {
+		return 0, 0
+	}
+	return p.currentIndex, len(p.slides)
+}
+
+// PreviousSlide returns the slide that the one on screen replaced, its images
+// and the direction of the move (+1 forward, -1 back). ok is false unless
+// Options.KeepPrevious is set and a slide was replaced by another.
+func (p *Player) PreviousSlide() (slide Slide, images []Image, direction int, ok bool) {
+	if !p.hasPrevious || p.loadingError != nil || len(p.slides) == 0 {
+		return Slide{}, nil, 0, false
+	}
+	return p.previous, p.previousImages, p.direction, true
+}
+
+// LoadingError returns the error that left nothing displayable, if any.
+func (p *Player) LoadingError() error {
+	return p.loadingError
+}
+
+// StandbyMessage returns the text to show while there are no slides.
+func (p *Player) StandbyMessage() string {
+	return p.standbyMessage
+}
+
+// Paused reports whether auto-advance has been paused from the remote.
+func (p *Player) Paused() bool {
+	return p.paused
+}
+
+// Held reports whether the current slide is being held on screen.
+func (p *Player) Held() bool {
+	return p.held
+}
+
+// Blanked reports whether the screen has been blanked by a blank command.
+func (p *Player) Blanked() bool {
+	return p.blanked
+}
+
+// Interval returns how long a photo slide stays up before any per-slide
+// scaling, as changed by faster and slower commands.
+func (p *Player) Interval() time.Duration {
+	return p.interval
+}
+
+// SlideTiming returns how long the current slide has been on screen and how
+// long it stays up in total.
+func (p *Player) SlideTiming() (elapsed, interval time.Duration) {
+	return p.clock.Now().Sub(p.slideStart), p.switchTime.Sub(p.slideStart)
+}
+
+// SlideStart returns when the slide on screen was put up. It changes with
+// every slide change.
+func (p *Player) SlideStart() time.Time {
+	return p.slideStart
+}
+
+// slideDuration is how long the current slide stays on screen.
+func (p *Player) slideDuration() time.Duration {
+	if len(p.slides) == 0 {
+		return p.interval
+	}
+	slide := p.slides[p.currentIndex]
+	if slide.Duration > 0 {
+		return slide.Duration
+	}
+	if slide.IsTitleCard() {
+		if p.titleDuration > 0 {
+			return p.titleDuration
+		}
+		return p.interval
+	}
+	favorite := false
+	for _, ph := range slide.Photos {
+		favorite = favorite || p.IsFavorite(ph.FilePath)
+	}
+	return p.durations.slideDuration(p.interval, slide, favorite)
+}
+
+// LoadCurrentSlide loads the images for the current index's slide.
+func (p *Player) LoadCurrentSlide() error {
+	if p.currentIndex < 0 || p.currentIndex >= len(p.slides) {
+		return nil
+	}
+	p.freeSlideImages()
+
+	slide := p.slides[p.currentIndex]
+	var newImages []Image
+	for _, ph := range slide.Photos {
+		img, err := p.loadPhoto(ph)
+		if err != nil {
+			img, err = p.loadPlaceholder(ph, err)
+		}
+		if err != nil {
+			// Don't leak the textures of photos that did load.
+			for _, loaded := range newImages {
+				loaded.Dispose()
+			}
+			return err
+		}
+		newImages = append(newImages, img)
+	}
+
+	p.currentImages = newImages
+	p.shown, p.hasShown = slide, true
+	if tracker, ok := p.shuffler.(drawTracker); ok {
+		tracker.Drawn(slide)
+	}
+	if p.onSlideChange != nil {
+		p.onSlideChange(p.currentIndex, len(p.slides), slide)
+	}
+	return nil
+}
+
+// advanceSlide increments currentIndex (with wraparound, reshuffling if a
+// Shuffler is set) and loads that slide.
+func (p *Player) advanceSlide() {
+	if len(p.slides) == 0 {
+		return
+	}
+	if p.onlySlideShown() {
+		p.keepSlide()
+		return
+	}
+	next := (p.currentIndex + 1) % len(p.slides)
+	tracker, tracked := p.shuffler.(drawTracker)
+	if tracked && tracker.Spent(p.slides[next]) {
+		// The rest of the pass has been shown already.
+		next = 0
+	}
+	if next == 0 && p.shuffler != nil {
+		// A new pass: reshuffle, keeping clear of the slide just shown.
+		last := p.slides[p.currentIndex]
+		p.shuffler.Shuffle(p.slides[p.pinned():], &last)
+	}
+	p.currentIndex = next
+	p.LoadDisplayableSlide()
+}
+
+// previousSlide decrements currentIndex (with wraparound) and loads that slide.
+func (p *Player) previousSlide() {
+	if len(p.slides) == 0 {
+		return
+	}
+	if p.onlySlideShown() {
+		p.keepSlide()
+		return
+	}
+	p.currentIndex = (p.currentIndex - 1 + len(p.slides)) % len(p.slides)
+	p.loadSlideSkippingFailures(-1)
+}
+
+// onlySlideShown reports whether the slideshow is down to one slide and it
+// is on screen, so that moving on would only decode it again.
+func (p *Player) onlySlideShown() bool {
+	return len(p.slides) == 1 && p.hasShown && p.loadingError == nil && sameSlide(p.shown, p.slides[0])
+}
+
+// keepSlide starts the slide on screen over in place of reloading it: its
+// timer restarts and any previous slide is dropped, so there is nothing to
+// animate from.
+func (p *Player) keepSlide() {
+	p.freePreviousImages()
+	p.slideStart = p.clock.Now()
+	p.switchTime = p.slideStart.Add(p.slideDuration())
+	p.markAdvanced()
+}
+
+// LoadDisplayableSlide loads the current slide, skipping forward past any
+// that cannot be decoded.
+func (p *Player) LoadDisplayableSlide() {
+	p.loadSlideSkippingFailures(1)
+}
+
+// loadSlideSkippingFailures loads the current slide, resets the slide timer
+// and releases any hold. A slide that fails to load, even with the
+// placeholder, is logged and dropped, and the next one
+// in the direction of travel (step is +1 or -1) is tried instead. Each
+// failure shrinks the list, so a run of corrupt files cannot loop forever;
+// the error screen appears only once nothing displayable is left.
+func (p *Player) loadSlideSkippingFailures(step int) {
+	p.deleteArmedUntil = time.Time{}
+	p.held = false
+	p.retireSlide(step)
+	p.loadingError = nil
+	for len(p.slides) > 0 {
+		err := p.LoadCurrentSlide()
+		if err == nil {
+			break
+		}
+		log.Printf("Skipping unreadable slide %v: %v", p.slides[p.currentIndex].Paths(), err)
+		p.slides = slices.Delete(p.slides, p.currentIndex, p.currentIndex+1)
+		if len(p.slides) == 0 {
+			p.loadingError = fmt.Errorf("no displayable photos left; last error: %w", err)
+			p.freePreviousImages()
+			break
+		}
+		if step < 0 {
+			p.currentIndex--
+		}
+		p.currentIndex = (p.currentIndex + len(p.slides)) % len(p.slides)
+	}
+	p.slideStart = p.clock.Now()
+	p.switchTime = p.slideStart.Add(p.slideDuration())
+	p.markAdvanced()
+	p.preloadNext(step)
+}
+
+// loadPhoto prepares ph for display, from the preloader if it has it.
+func (p *Player) loadPhoto(ph photo.Photo) (Image, error) {
+	if p.preloader != nil {
+		if img, ok := p.preloader.take(ph); ok {
+			return img, nil
+		}
+	}
+	return p.loadImage(ph)
+}
+
+// loadPlaceholder prepares the placeholder image to stand in for ph, which
+// failed to load with err, if ph's file could not be read. Otherwise, or if
+// the placeholder fails too, it returns err.
+func (p *Player) loadPlaceholder(ph photo.Photo, err error) (Image, error) {
+	var pathErr *fs.PathError
+	if p.placeholder == "" || !errors.As(err, &pathErr) {
+		return nil, err
+	}
+	img, placeholderErr := p.loadImage(photo.Photo{FilePath: p.placeholder})
+	if placeholderErr != nil {
+		log.Printf("Loading placeholder image: %v", placeholderErr)
+		return nil, err
+	}
+	log.Printf("Showing the placeholder for unavailable %s: %v", ph.FilePath, err)
+	return img, nil
+}
+
+// preloadNext tells the preloader the photos of the slides coming up in
+// direction step (+1 or -1), nearest first: up to preloadAhead of them,
+// short of the end of a pass that is to be reshuffled and of the memory
+// budget.
+func (p *Player) preloadNext(step int) {
+	if p.preloader == nil {
+		return
+	}
+	var photos []photo.Photo
+	used := p.shown.decodedBytes()
+	if p.hasPrevious {
+		used += p.previous.decodedBytes()
+	}
+	i := p.currentIndex
+	for n := 0; n < p.preloadAhead && p.loadingError == nil && len(p.slides) > 1; n++ {
+		next := i + step
+		if next < 0 || next >= len(p.slides) {
+			if step > 0 && p.shuffler != nil {
+				break
+			}
+			next = (next + len(p.slides)) % len(p.slides)
+		}
+		if next == p.currentIndex {
+			break
+		}
+		i = next
+		used += p.slides[i].decodedBytes()
+		if p.memoryBudget > 0 && used > p.memoryBudget {
+			break
+		}
+		photos = append(photos, p.slides[i].Photos...)
+	}
+	p.preloader.Preload(photos)
+}
+
+// retireSlide takes the slide on screen down before a move in direction step,
+// keeping it as the previous slide if asked to and the memory budget allows.
+func (p *Player) retireSlide(step int) {
+	p.freePreviousImages()
+	if !p.keepPrevious || !p.hasShown || !p.withinBudget() {
+		p.freeSlideImages()
+		return
+	}
+	p.previous, p.previousImages, p.hasPrevious = p.shown, p.currentImages, true
+	p.direction = step
+	p.currentImages = nil
+	p.hasShown = false
+}
+
+// withinBudget reports whether the slide on screen and the one about to load
+// fit in the memory budget together.
+func (p *Player) withinBudget() bool {
+	if p.memoryBudget <= 0 || len(p.slides) == 0 {
+		return true
+	}
+	need := p.shown.decodedBytes() + p.slides[p.currentIndex].decodedBytes()
+	if need <= p.memoryBudget {
+		return true
+	}
+	if !p.overBudget {
+		log.Printf("Slides need %d MB together, over the %d MB memory budget; cutting instead of keeping the outgoing slide",
+			need>>20, p.memoryBudget>>20)
+		p.overBudget = true
+	}
+	return false
+}
+
+// freePreviousImages disposes the images of the previous slide (if any).
+func (p *Player) freePreviousImages() {
+	for _, img := range p.previousImages {
+		img.Dispose()
+	}
+	p.previousImages = nil
+	p.hasPrevious = false
+}
+
+// Close releases the images of the slides on screen when the slideshow
+// shuts down. The Player must not be used afterwards.
+func (p *Player) Close() {
+	p.freeSlideImages()
+	p.freePreviousImages()
+	if p.preloader != nil {
+		p.preloader.Close()
+	}
+}
+
+// freeSlideImages disposes the images of the current slide (if any).
+func (p *Player) freeSlideImages() {
+	p.hasShown = false
+	if len(p.currentImages) == 0 {
+		return
+	}
+	for _, img := range p.currentImages {
+		img.Dispose()
+	}
+	p.currentImages = nil
+}
+
+// SetLoadingError puts err on screen in place of the slideshow.
+func (p *Player) SetLoadingError(err error) {
+	p.loadingError = err
+}
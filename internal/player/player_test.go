@@ -0,0 +1,632 @@
+package player
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/cec"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+type fakeImage struct{ disposed bool }
+
+func (f *fakeImage) Dispose() { f.disposed = true }
+
+func loadFake(photo.Photo) (Image, error) { return &fakeImage{}, nil }
+
+func landscapeSlides(paths ...string) []Slide {
+	var slides []Slide
+	for _, p := range paths {
+		slides = append(slides, Slide{Photos: []photo.Photo{{FilePath: p, Width: 800, Height: 600}}})
+	}
+	return slides
+}
+
+func currentPath(t *testing.T, p *Player) string {
+	t.Helper()
+	slide, _, ok := p.CurrentSlide()
+	if !ok {
+		t.Fatalf("no slide on screen (error %v)", p.LoadingError())
+	}
+	return slide.Photos[0].FilePath
+}
+
+func TestLoadCurrentSlideDisposesPartialImagesOnError(t *testing.T) {
+	slides := []Slide{{Photos: []photo.Photo{
+		{FilePath: "good.jpg", Width: 600, Height: 800},
+		{FilePath: "corrupt.jpg", Width: 600, Height: 800},
+	}}}
+
+	var loaded []*fakeImage
+	decodeErr := errors.New("unexpected EOF")
+	p := New(slides, Options{LoadImage: func(ph photo.Photo) (Image, error) {
+		if ph.FilePath == "corrupt.jpg" {
+			return nil, decodeErr
+		}
+		img := &fakeImage{}
+		loaded = append(loaded, img)
+		return img, nil
+	}})
+
+	if err := p.LoadCurrentSlide(); !errors.Is(err, decodeErr) {
+		t.Fatalf("LoadCurrentSlide() error = %v, want %v", err, decodeErr)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("loaded %d images, want 1", len(loaded))
+	}
+	if !loaded[0].disposed {
+		t.Error("first image of the slide was not disposed after the second failed")
+	}
+	if len(p.currentImages) != 0 {
+		t.Errorf("currentImages = %d images, want none", len(p.currentImages))
+	}
+}
+
+func TestPlaceholderStandsInForUnreadablePhoto(t *testing.T) {
+	unreachable := &fs.PathError{Op: "open", Path: "b.jpg", Err: syscall.EHOSTDOWN}
+	var loaded []string
+	nasDown := true
+	p := New(landscapeSlides("a.jpg", "b.jpg", "c.jpg", "corrupt.jpg"), Options{
+		Interval: time.Hour,
+		LoadImage: func(ph photo.Photo) (Image, error) {
+			switch {
+			case ph.FilePath == "b.jpg" && nasDown:
+				return nil, unreachable
+			case ph.FilePath == "corrupt.jpg":
+				return nil, errors.New("unexpected EOF")
+			}
+			loaded = append(loaded, ph.FilePath)
+			return &fakeImage{}, nil
+		},
+		Placeholder: "unavailable.png",
+	})
+	p.LoadDisplayableSlide()
+
+	p.Command(cec.RemoteRight)
+	if got := currentPath(t, p); got != "b.jpg" {
+		t.Fatalf("showing %s, want b.jpg", got)
+	}
+	if want := []string{"a.jpg", "unavailable.png"}; !slices.Equal(loaded, want) {
+		t.Errorf("loaded %q, want %q", loaded, want)
+	}
+
+	// A file that does not decode is still dropped.
+	p.Command(cec.RemoteRight)
+	p.Command(cec.RemoteRight)
+	if got := currentPath(t, p); got != "a.jpg" {
+		t.Fatalf("showing %s after corrupt.jpg, want a.jpg", got)
+	}
+
+	// Once the share is back, the photo itself is shown.
+	nasDown = false
+	loaded = nil
+	p.Command(cec.RemoteRight)
+	if want := []string{"b.jpg"}; !slices.Equal(loaded, want) {
+		t.Errorf("loaded %q on the next pass, want %q", loaded, want)
+	}
+	if n := len(p.slides); n != 3 {
+		t.Errorf("%d slides left, want 3", n)
+	}
+}
+
+func TestUpdateAdvancesOnIntervalAndRemote(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	p := New(landscapeSlides("a.jpg", "b.jpg", "c.jpg"), Options{
+		Interval:  10 * time.Second,
+		LoadImage: loadFake,
+		Clock:     clock,
+	})
+	remote := make(chan cec.RemoteCommand, 1)
+	p.SetRemoteCommandChan(remote)
+	p.LoadDisplayableSlide()
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		cmd     cec.RemoteCommand
+		want    string
+	}{
+		{name: "before interval", advance: 9 * time.Second, want: "a.jpg"},
+		{name: "interval elapsed", advance: 2 * time.Second, want: "b.jpg"},
+		{name: "next", cmd: cec.RemoteRight, want: "c.jpg"},
+		{name: "next wraps", cmd: cec.RemoteRight, want: "a.jpg"},
+		{name: "prev wraps", cmd: cec.RemoteLeft, want: "c.jpg"},
+		{name: "pause", cmd: cec.RemoteSelect, want: "c.jpg"},
+		{name: "paused past interval", advance: time.Minute, want: "c.jpg"},
+	}
+	for _, s := range steps {
+		clock.now = clock.now.Add(s.advance)
+		if s.cmd != cec.RemoteUnknown {
+			remote <- s.cmd
+		}
+		p.Update()
+		if got := currentPath(t, p); got != s.want {
+			t.Fatalf("%s: showing %s, want %s", s.name, got, s.want)
+		}
+	}
+}
+
+func TestFasterAndSlowerChangeInterval(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	p := New(landscapeSlides("a.jpg", "b.jpg", "c.jpg"), Options{
+		Interval:  6 * time.Second,
+		LoadImage: loadFake,
+		Clock:     clock,
+	})
+	remote := make(chan cec.RemoteCommand, 1)
+	p.SetRemoteCommandChan(remote)
+	p.LoadDisplayableSlide()
+
+	steps := []struct {
+		name         string
+		advance      time.Duration
+		cmd          cec.RemoteCommand
+		want         string
+		wantInterval time.Duration
+	}{
+		{name: "faster", advance: time.Second, cmd: cec.RemoteFaster, want: "a.jpg", wantInterval: 4 * time.Second},
+		// The slide on screen is cut short to the new interval.
+		{name: "new interval elapsed", advance: 3*time.Second + time.Millisecond, want: "b.jpg", wantInterval: 4 * time.Second},
+		{name: "faster again", cmd: cec.RemoteFaster, want: "b.jpg", wantInterval: 2 * time.Second},
+		{name: "not below the minimum", cmd: cec.RemoteFaster, want: "b.jpg", wantInterval: 2 * time.Second},
+		{name: "slower", cmd: cec.RemoteSlower, want: "b.jpg", wantInterval: 4 * time.Second},
+		{name: "before new interval", advance: 3 * time.Second, want: "b.jpg", wantInterval: 4 * time.Second},
+		{name: "slower interval elapsed", advance: time.Second + time.Millisecond, want: "c.jpg", wantInterval: 4 * time.Second},
+	}
+	for _, s := range steps {
+		clock.now = clock.now.Add(s.advance)
+		if s.cmd != cec.RemoteUnknown {
+			remote <- s.cmd
+		}
+		p.Update()
+		if got := currentPath(t, p); got != s.want {
+			t.Fatalf("%s: showing %s, want %s", s.name, got, s.want)
+		}
+		if got := p.Interval(); got != s.wantInterval {
+			t.Fatalf("%s: Interval() = %v, want %v", s.name, got, s.wantInterval)
+		}
+	}
+}
+
+func TestCommandsNotify(t *testing.T) {
+	p := New(landscapeSlides("a.jpg", "b.jpg"), Options{
+		Interval:  10 * time.Second,
+		LoadImage: loadFake,
+		Clock:     &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		Favorites: fakeFavorites{},
+	})
+	var got []string
+	p.SetNotifyHandler(func(msg string) { got = append(got, msg) })
+	p.LoadDisplayableSlide()
+
+	for _, cmd := range []cec.RemoteCommand{cec.RemoteSelect, cec.RemoteSelect, cec.RemoteFavorite, cec.RemoteFavorite, cec.RemoteSlower, cec.RemoteRight} {
+		p.Command(cmd)
+	}
+	want := []string{"Paused", "Resumed", "Added to favorites", "Removed from favorites", "Interval: 12s"}
+	if !slices.Equal(got, want) {
+		t.Errorf("notified %q, want %q", got, want)
+	}
+}
+
+func TestVolumeKeysPassThrough(t *testing.T) {
+	p := New(landscapeSlides("a.jpg", "b.jpg"), Options{
+		Interval:  10 * time.Second,
+		LoadImage: loadFake,
+		Clock:     &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+	})
+	var got []cec.RemoteCommand
+	p.SetVolumeHandler(func(cmd cec.RemoteCommand) { got = append(got, cmd) })
+	p.LoadDisplayableSlide()
+
+	keys := []cec.RemoteCommand{cec.RemoteVolumeUp, cec.RemoteVolumeDown, cec.RemoteMute}
+	for _, cmd := range keys {
+		p.Command(cmd)
+	}
+	if !slices.Equal(got, keys) {
+		t.Errorf("passed through %v, want %v", got, keys)
+	}
+	if path := currentPath(t, p); path != "a.jpg" || p.Paused() {
+		t.Errorf("volume keys moved to %s (paused %t), want a.jpg still playing", path, p.Paused())
+	}
+}
+
+func TestHoldKeepsOneSlide(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	p := New(landscapeSlides("a.jpg", "b.jpg", "c.jpg"), Options{
+		Interval:  10 * time.Second,
+		LoadImage: loadFake,
+		Clock:     clock,
+	})
+	remote := make(chan cec.RemoteCommand, 1)
+	p.SetRemoteCommandChan(remote)
+	p.LoadDisplayableSlide()
+
+	steps := []struct {
+		name     string
+		advance  time.Duration
+		cmd      cec.RemoteCommand
+		want     string
+		wantHeld bool
+	}{
+		{name: "hold", cmd: cec.RemoteHold, want: "a.jpg", wantHeld: true},
+		{name: "held past interval", advance: time.Minute, want: "a.jpg", wantHeld: true},
+		{name: "release", cmd: cec.RemoteHold, want: "a.jpg"},
+		// Released, the slide gets a fresh interval.
+		{name: "before fresh interval", advance: 9 * time.Second, want: "a.jpg"},
+		{name: "fresh interval elapsed", advance: 2 * time.Second, want: "b.jpg"},
+		{name: "hold again", cmd: cec.RemoteHold, want: "b.jpg", wantHeld: true},
+		{name: "next releases", cmd: cec.RemoteRight, want: "c.jpg"},
+		{name: "next slide not held", advance: 11 * time.Second, want: "a.jpg"},
+	}
+	for _, s := range steps {
+		clock.now = clock.now.Add(s.advance)
+		if s.cmd != cec.RemoteUnknown {
+			remote <- s.cmd
+		}
+		p.Update()
+		if got := currentPath(t, p); got != s.want {
+			t.Fatalf("%s: showing %s, want %s", s.name, got, s.want)
+		}
+		if p.Held() != s.wantHeld {
+			t.Fatalf("%s: Held() = %t, want %t", s.name, p.Held(), s.wantHeld)
+		}
+		if p.Paused() {
+			t.Fatalf("%s: holding paused the slideshow", s.name)
+		}
+	}
+}
+
+func TestBlankKeepsTimerRunning(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	p := New(landscapeSlides("a.jpg", "b.jpg", "c.jpg"), Options{
+		Interval:  10 * time.Second,
+		LoadImage: loadFake,
+		Clock:     clock,
+	})
+	remote := make(chan cec.RemoteCommand, 1)
+	p.SetRemoteCommandChan(remote)
+	p.LoadDisplayableSlide()
+
+	steps := []struct {
+		name        string
+		advance     time.Duration
+		cmd         cec.RemoteCommand
+		want        string
+		wantBlanked bool
+	}{
+		{name: "blank", cmd: cec.RemoteBlank, want: "a.jpg", wantBlanked: true},
+		{name: "advances while blank", advance: 11 * time.Second, want: "b.jpg", wantBlanked: true},
+		// The unblanking command is not carried out as well.
+		{name: "next unblanks", cmd: cec.RemoteRight, want: "b.jpg"},
+		{name: "next", cmd: cec.RemoteRight, want: "c.jpg"},
+		{name: "blank again", cmd: cec.RemoteBlank, want: "c.jpg", wantBlanked: true},
+		{name: "volume leaves it blank", cmd: cec.RemoteMute, want: "c.jpg", wantBlanked: true},
+		{name: "blank unblanks", cmd: cec.RemoteBlank, want: "c.jpg"},
+	}
+	for _, s := range steps {
+		clock.now = clock.now.Add(s.advance)
+		if s.cmd != cec.RemoteUnknown {
+			remote <- s.cmd
+		}
+		p.Update()
+		if got := currentPath(t, p); got != s.want {
+			t.Fatalf("%s: showing %s, want %s", s.name, got, s.want)
+		}
+		if p.Blanked() != s.wantBlanked {
+			t.Fatalf("%s: Blanked() = %t, want %t", s.name, p.Blanked(), s.wantBlanked)
+		}
+	}
+}
+
+func TestMemoryBudgetDropsPreviousSlide(t *testing.T) {
+	slides := landscapeSlides("a.jpg", "b.jpg", "c.jpg")
+	// c is big enough that it and b do not fit the budget together.
+	slides[2].Photos[0].Width, slides[2].Photos[0].Height = 4000, 3000
+	p := New(slides, Options{
+		Interval:     time.Hour,
+		LoadImage:    loadFake,
+		Clock:        &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		KeepPrevious: true,
+		MemoryBudget: 40 << 20,
+	})
+	remote := make(chan cec.RemoteCommand, 1)
+	p.SetRemoteCommandChan(remote)
+	p.LoadDisplayableSlide()
+
+	remote <- cec.RemoteRight
+	p.Update()
+	if prev, _, _, ok := p.PreviousSlide(); !ok || prev.Paths()[0] != "a.jpg" {
+		t.Fatalf("within budget: previous slide %v (%v), want a.jpg", prev.Paths(), ok)
+	}
+
+	_, images, _ := p.CurrentSlide()
+	remote <- cec.RemoteRight
+	p.Update()
+	if _, _, _, ok := p.PreviousSlide(); ok {
+		t.Errorf("over budget: previous slide kept, want it dropped")
+	}
+	if !images[0].(*fakeImage).disposed {
+		t.Errorf("over budget: b.jpg's image not disposed")
+	}
+}
+
+func TestSlidePositionFollowsNavigation(t *testing.T) {
+	p := New(landscapeSlides("a.jpg", "b.jpg", "c.jpg"), Options{
+		Interval:  time.Hour,
+		LoadImage: loadFake,
+		Clock:     &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+	})
+	remote := make(chan cec.RemoteCommand, 1)
+	p.SetRemoteCommandChan(remote)
+	p.LoadDisplayableSlide()
+
+	for _, step := range []struct {
+		cmd  cec.RemoteCommand
+		want int
+	}{
+		{cec.RemoteUnknown, 0},
+		{cec.RemoteRight, 1},
+		{cec.RemoteLeft, 0},
+		{cec.RemoteLeft, 2},
+	} {
+		if step.cmd != cec.RemoteUnknown {
+			remote <- step.cmd
+		}
+		p.Update()
+		if index, total := p.SlidePosition(); index != step.want || total != 3 {
+			t.Fatalf("after %v: position %d / %d, want %d / 3", step.cmd, index, total, step.want)
+		}
+	}
+}
+
+func TestSingleSlideIsDecodedOnce(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	loads := 0
+	p := New(landscapeSlides("a.jpg"), Options{
+		Interval: 10 * time.Second,
+		LoadImage: func(ph photo.Photo) (Image, error) {
+			loads++
+			return &fakeImage{}, nil
+		},
+		Clock:        clock,
+		KeepPrevious: true,
+	})
+	remote := make(chan cec.RemoteCommand, 1)
+	p.SetRemoteCommandChan(remote)
+	p.LoadDisplayableSlide()
+
+	for _, cmd := range []cec.RemoteCommand{cec.RemoteUnknown, cec.RemoteRight, cec.RemoteLeft, cec.RemoteUnknown} {
+		start := p.SlideStart()
+		clock.now = clock.now.Add(11 * time.Second)
+		if cmd != cec.RemoteUnknown {
+			remote <- cmd
+		}
+		p.Update()
+		if got := currentPath(t, p); got != "a.jpg" {
+			t.Fatalf("after %v: showing %s, want a.jpg", cmd, got)
+		}
+		if !p.SlideStart().After(start) {
+			t.Errorf("after %v: slide timer not restarted", cmd)
+		}
+		if _, _, _, ok := p.PreviousSlide(); ok {
+			t.Errorf("after %v: a previous slide to animate from, want none", cmd)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("the only photo was decoded %d times, want once", loads)
+	}
+}
+
+func TestStartAt(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{name: "first", want: "a.jpg"},
+		{name: "resume", opts: Options{ResumeAt: "c.jpg"}, want: "c.jpg"},
+		{name: "resume a photo that has gone", opts: Options{ResumeAt: "gone.jpg"}, want: "a.jpg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.LoadImage = loadFake
+			p := New(landscapeSlides("a.jpg", "b.jpg", "c.jpg"), tt.opts)
+			p.LoadDisplayableSlide()
+			if got := currentPath(t, p); got != tt.want {
+				t.Errorf("opened on %s, want %s", got, tt.want)
+			}
+		})
+	}
+	pinned := landscapeSlides("cover.jpg", "a.jpg", "b.jpg")
+	pinned[0].Pinned = true
+	p := New(pinned, Options{LoadImage: loadFake, StartRandom: true, ResumeAt: "b.jpg", Shuffler: NewSmartShuffle(1)})
+	p.LoadDisplayableSlide()
+	if got := currentPath(t, p); got != "cover.jpg" {
+		t.Errorf("with a pinned slide, opened on %s, want cover.jpg", got)
+	}
+	for pass := 0; pass < 5; pass++ {
+		for range 3 {
+			p.Command(cec.RemoteRight)
+		}
+		if got := currentPath(t, p); got != "cover.jpg" {
+			t.Fatalf("pass %d opened on %s, want the pinned cover.jpg", pass+2, got)
+		}
+	}
+
+	// A random start can land anywhere, but lands on a slide.
+	p = New(landscapeSlides("a.jpg", "b.jpg", "c.jpg"), Options{LoadImage: loadFake, StartRandom: true})
+	p.LoadDisplayableSlide()
+	currentPath(t, p)
+}
+
+// TestStatusIsSafeDuringUpdates is meant for go test -race: other goroutines
+// read the status and send commands while Update advances the slideshow.
+func TestStatusIsSafeDuringUpdates(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	p := New(landscapeSlides("a.jpg", "b.jpg", "c.jpg"), Options{
+		Interval:  10 * time.Second,
+		LoadImage: loadFake,
+		Clock:     clock,
+		Scan: func() ([]Slide, error) {
+			return landscapeSlides("a.jpg", "b.jpg", "c.jpg", "d.jpg"), nil
+		},
+	})
+	remote := make(chan cec.RemoteCommand, 10)
+	p.SetRemoteCommandChan(remote)
+	p.LoadDisplayableSlide()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if st := p.Status(); st.Total > 0 && len(st.Photos) != 1 {
+					t.Errorf("Status() = %+v, want one photo on screen", st)
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, cmd := range []cec.RemoteCommand{cec.RemoteRight, cec.RemoteSelect, cec.RemoteLeft, cec.RemoteSelect} {
+			remote <- cmd
+		}
+		p.Rescan()
+	}()
+
+	for i := 0; i < 200; i++ {
+		clock.now = clock.now.Add(time.Second)
+		p.Update()
+	}
+	close(done)
+	wg.Wait()
+
+	// Let the rescan land, then check the status caught up with it.
+	for deadline := time.Now().Add(5 * time.Second); p.Status().Total != 4; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Status().Total = %d after the rescan, want 4", p.Status().Total)
+		}
+		time.Sleep(time.Millisecond)
+		p.Update()
+	}
+	if st := p.Status(); st.Paused || st.Interval != 10*time.Second {
+		t.Errorf("Status() = %+v, want playing at 10s", st)
+	}
+}
+
+func TestRescanCommandKeepsPhotoOnScreen(t *testing.T) {
+	portrait := func(path string) photo.Photo { return photo.Photo{FilePath: path, Width: 600, Height: 800} }
+	scanned := make(chan []Slide, 1)
+	p := New([]Slide{{Photos: []photo.Photo{portrait("a.jpg")}}, {Photos: []photo.Photo{portrait("b.jpg")}}}, Options{
+		Interval:  10 * time.Second,
+		LoadImage: loadFake,
+		Clock:     &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		Scan:      func() ([]Slide, error) { return <-scanned, nil },
+	})
+	var got []string
+	p.SetNotifyHandler(func(msg string) { got = append(got, msg) })
+	p.LoadDisplayableSlide()
+	p.Command(cec.RemoteRight)
+
+	// b.jpg now pairs with a new portrait.
+	scanned <- []Slide{
+		{Photos: []photo.Photo{portrait("a.jpg")}},
+		{Photos: []photo.Photo{portrait("c.jpg"), portrait("b.jpg")}},
+		{Photos: []photo.Photo{portrait("d.jpg")}},
+	}
+	p.Command(cec.RemoteRescan)
+	for deadline := time.Now().Add(5 * time.Second); len(got) < 2 && time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		p.Update()
+	}
+	if want := []string{"Rescanning", "Found 4 photos"}; !slices.Equal(got, want) {
+		t.Fatalf("notified %q, want %q", got, want)
+	}
+	slide, images, ok := p.CurrentSlide()
+	if !ok || !slices.Equal(slide.Paths(), []string{"c.jpg", "b.jpg"}) || len(images) != 2 {
+		t.Errorf("after the rescan showing %v with %d images, want c.jpg and b.jpg", slide.Paths(), len(images))
+	}
+}
+
+func TestDeleteKeepsPhotosThatDidNotMove(t *testing.T) {
+	portrait := func(path string) photo.Photo { return photo.Photo{FilePath: path, Width: 600, Height: 800} }
+	tests := []struct {
+		name       string
+		locked     []string // photos the trash fails to move
+		wantSlides [][]string
+		wantNotes  []string
+	}{
+		{
+			name:       "both moved",
+			wantSlides: [][]string{{"c.jpg"}},
+			wantNotes:  []string{"Moved 2 photos to trash"},
+		},
+		{
+			name:       "one of the pair moved",
+			locked:     []string{"b.jpg"},
+			wantSlides: [][]string{{"b.jpg"}, {"c.jpg"}},
+			wantNotes:  []string{"Moved to trash"},
+		},
+		{
+			name:       "neither moved",
+			locked:     []string{"a.jpg", "b.jpg"},
+			wantSlides: [][]string{{"a.jpg", "b.jpg"}, {"c.jpg"}},
+			wantNotes:  []string{"Delete failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New([]Slide{
+				{Photos: []photo.Photo{portrait("a.jpg"), portrait("b.jpg")}},
+				{Photos: []photo.Photo{portrait("c.jpg")}},
+			}, Options{
+				Interval:  10 * time.Second,
+				LoadImage: loadFake,
+				Clock:     &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+				Trash: func(path string) (string, error) {
+					if slices.Contains(tt.locked, path) {
+						return "", errors.New("permission denied")
+					}
+					return "/trash/" + path, nil
+				},
+			})
+			var notes []string
+			p.SetNotifyHandler(func(msg string) { notes = append(notes, msg) })
+			p.LoadDisplayableSlide()
+			p.Command(cec.RemoteDelete)
+			p.Command(cec.RemoteDelete)
+
+			var got [][]string
+			for _, s := range p.slides {
+				got = append(got, s.Paths())
+			}
+			if !slices.EqualFunc(got, tt.wantSlides, slices.Equal) {
+				t.Errorf("slides = %q, want %q", got, tt.wantSlides)
+			}
+			if !slices.Equal(notes, tt.wantNotes) {
+				t.Errorf("notified %q, want %q", notes, tt.wantNotes)
+			}
+			slide, images, ok := p.CurrentSlide()
+			if !ok || !slices.Equal(slide.Paths(), tt.wantSlides[0]) || len(images) != len(tt.wantSlides[0]) {
+				t.Errorf("showing %v with %d images, want %v", slide.Paths(), len(images), tt.wantSlides[0])
+			}
+		})
+	}
+}
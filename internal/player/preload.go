@@ -0,0 +1,158 @@
+package player
+
+import (
+	"image"
+	"slices"
+	"sync"
+
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+// Decoder prepares one photo's pixels for display without touching the GPU,
+// so that it can run off the Update goroutine.
+type Decoder func(photo.Photo) (image.Image, error)
+
+// Uploader turns pixels from a Decoder into an Image for display. It runs on
+// the Update goroutine.
+type Uploader func(image.Image) Image
+
+// Preloader decodes the photos of upcoming slides on a goroutine of its own,
+// so that moving on only has to upload them. The Player tells it what is
+// coming after every slide change and takes the pixels from it when a slide
+// it preloaded comes up; photos it has not finished, or could not decode,
+// are loaded as usual.
+type Preloader struct {
+	decode Decoder
+	upload Uploader
+
+	mu     sync.Mutex
+	wanted []photo.Photo
+	ready  map[string]image.Image
+	failed map[string]bool
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPreloader starts a Preloader. Close stops it.
+func NewPreloader(decode Decoder, upload Uploader) *Preloader {
+	l := &Preloader{
+		decode: decode,
+		upload: upload,
+		ready:  make(map[string]image.Image),
+		failed: make(map[string]bool),
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Preload asks for photos to be decoded, nearest first, and drops whatever
+// has been decoded for any other photo, such as those behind after a change
+// of direction.
+func (l *Preloader) Preload(photos []photo.Photo) {
+	l.mu.Lock()
+	l.wanted = photos
+	keep := make(map[string]bool, len(photos))
+	for _, ph := range photos {
+		keep[ph.FilePath] = true
+	}
+	for path := range l.ready {
+		if !keep[path] {
+			delete(l.ready, path)
+		}
+	}
+	for path := range l.failed {
+		if !keep[path] {
+			delete(l.failed, path)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the preloaded photo uploaded for display, and forgets it.
+// It must be called from the Update goroutine.
+func (l *Preloader) take(ph photo.Photo) (Image, bool) {
+	l.mu.Lock()
+	src, ok := l.ready[ph.FilePath]
+	delete(l.ready, ph.FilePath)
+	l.wanted = slices.DeleteFunc(slices.Clone(l.wanted), func(w photo.Photo) bool { return w.FilePath == ph.FilePath })
+	l.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	return l.upload(src), true
+}
+
+// Close stops the preloading goroutine, waiting for any decode under way,
+// and drops what it holds.
+func (l *Preloader) Close() {
+	close(l.stop)
+	<-l.done
+	l.mu.Lock()
+	l.wanted, l.ready = nil, nil
+	l.mu.Unlock()
+}
+
+// run decodes wanted photos one at a time until Close.
+func (l *Preloader) run() {
+	defer close(l.done)
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-l.wake:
+		}
+		for {
+			ph, ok := l.next()
+			if !ok {
+				break
+			}
+			src, err := l.decode(ph)
+			l.mu.Lock()
+			switch {
+			case err != nil:
+				l.failed[ph.FilePath] = true
+			case l.isWanted(ph.FilePath):
+				l.ready[ph.FilePath] = src
+			}
+			l.mu.Unlock()
+			select {
+			case <-l.stop:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// next returns the nearest wanted photo not yet decoded or tried.
+func (l *Preloader) next() (photo.Photo, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, ph := range l.wanted {
+		if _, done := l.ready[ph.FilePath]; !done && !l.failed[ph.FilePath] {
+			return ph, true
+		}
+	}
+	return photo.Photo{}, false
+}
+
+// isWanted reports whether the photo at path is still to be preloaded. l.mu
+// must be held.
+func (l *Preloader) isWanted(path string) bool {
+	for _, ph := range l.wanted {
+		if ph.FilePath == path {
+			return true
+		}
+	}
+	return false
+}
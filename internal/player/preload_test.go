@@ -0,0 +1,136 @@
+package player
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+	"slices"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/cec"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+// readyPaths waits for the preloader to settle on want, the paths it holds
+// decoded, and returns what it holds.
+func readyPaths(t *testing.T, l *Preloader, want ...string) []string {
+	t.Helper()
+	sort.Strings(want)
+	var got []string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		l.mu.Lock()
+		got = got[:0]
+		for path := range l.ready {
+			got = append(got, path)
+		}
+		l.mu.Unlock()
+		sort.Strings(got)
+		if slices.Equal(got, want) {
+			break
+		}
+	}
+	return got
+}
+
+func TestPreloadAheadInDirectionOfTravel(t *testing.T) {
+	var loads atomic.Int32
+	preloader := NewPreloader(func(photo.Photo) (image.Image, error) {
+		return image.NewGray(image.Rect(0, 0, 1, 1)), nil
+	}, func(image.Image) Image { return &fakeImage{} })
+	p := New(landscapeSlides("a.jpg", "b.jpg", "c.jpg", "d.jpg", "e.jpg"), Options{
+		Interval: time.Hour,
+		LoadImage: func(photo.Photo) (Image, error) {
+			loads.Add(1)
+			return &fakeImage{}, nil
+		},
+		Preloader:    preloader,
+		PreloadAhead: 2,
+	})
+	defer p.Close()
+	p.LoadDisplayableSlide()
+	if got := readyPaths(t, preloader, "b.jpg", "c.jpg"); !slices.Equal(got, []string{"b.jpg", "c.jpg"}) {
+		t.Fatalf("preloaded %v at a.jpg, want b.jpg and c.jpg", got)
+	}
+
+	p.Command(cec.RemoteRight)
+	if got := currentPath(t, p); got != "b.jpg" {
+		t.Fatalf("showing %s, want b.jpg", got)
+	}
+	if n := loads.Load(); n != 1 {
+		t.Errorf("b.jpg loaded without the preloader; %d loads, want 1", n)
+	}
+	if got := readyPaths(t, preloader, "c.jpg", "d.jpg"); !slices.Equal(got, []string{"c.jpg", "d.jpg"}) {
+		t.Errorf("preloaded %v at b.jpg, want c.jpg and d.jpg", got)
+	}
+
+	// Turning back drops what lay ahead and wraps round behind the start.
+	p.Command(cec.RemoteLeft)
+	if got := readyPaths(t, preloader, "d.jpg", "e.jpg"); !slices.Equal(got, []string{"d.jpg", "e.jpg"}) {
+		t.Errorf("preloaded %v back at a.jpg, want d.jpg and e.jpg", got)
+	}
+	p.Command(cec.RemoteLeft)
+	if got := currentPath(t, p); got != "e.jpg" {
+		t.Fatalf("showing %s, want e.jpg", got)
+	}
+	if n := loads.Load(); n != 2 {
+		t.Errorf("%d loads after going back, want 2 (a.jpg again, then e.jpg preloaded)", n)
+	}
+}
+
+func TestPreloadWithinMemoryBudget(t *testing.T) {
+	preloader := NewPreloader(func(photo.Photo) (image.Image, error) {
+		return image.NewGray(image.Rect(0, 0, 1, 1)), nil
+	}, func(image.Image) Image { return &fakeImage{} })
+	// Each slide decodes to 800 x 600 x 4 bytes; two fit, not three.
+	p := New(landscapeSlides("a.jpg", "b.jpg", "c.jpg", "d.jpg"), Options{
+		LoadImage:    loadFake,
+		MemoryBudget: 2*800*600*4 + 1,
+		Preloader:    preloader,
+		PreloadAhead: 3,
+	})
+	defer p.Close()
+	p.LoadDisplayableSlide()
+	if got := readyPaths(t, preloader, "b.jpg"); !slices.Equal(got, []string{"b.jpg"}) {
+		t.Errorf("preloaded %v, want only b.jpg within the budget", got)
+	}
+}
+
+func TestPreloaderDoesNotLeakGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	preloader := NewPreloader(func(photo.Photo) (image.Image, error) {
+		time.Sleep(100 * time.Microsecond)
+		return image.NewGray(image.Rect(0, 0, 1, 1)), nil
+	}, func(image.Image) Image { return &fakeImage{} })
+	var paths []string
+	for i := range 50 {
+		paths = append(paths, fmt.Sprintf("%02d.jpg", i))
+	}
+	p := New(landscapeSlides(paths...), Options{
+		Interval:     time.Hour,
+		LoadImage:    loadFake,
+		KeepPrevious: true,
+		Preloader:    preloader,
+		PreloadAhead: 3,
+	})
+	p.LoadDisplayableSlide()
+	for i := range 500 {
+		if i%7 < 4 {
+			p.Command(cec.RemoteRight)
+		} else {
+			p.Command(cec.RemoteLeft)
+		}
+	}
+	if n := runtime.NumGoroutine(); n > before+1 {
+		t.Errorf("%d goroutines while preloading, want at most %d", n, before+1)
+	}
+	p.Close()
+	for deadline := time.Now().Add(5 * time.Second); runtime.NumGoroutine() > before && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after Close, want %d", n, before)
+	}
+}
@@ -0,0 +1,149 @@
+package player
+
+import (
+	"log"
+	"slices"
+	"time"
+)
+
+// ScanFunc produces a fresh slide list, typically by re-reading the albums.
+type ScanFunc func() ([]Slide, error)
+
+type rescanResult struct {
+	slides []Slide
+	err    error
+}
+
+// Rescan rebuilds the slide list in the background using Options.Scan. It is
+// safe to call from any goroutine. A call made while a scan is running queues
+// one more scan, so files added mid-scan are still picked up. The new slides
+// are swapped in by Update.
+func (p *Player) Rescan() {
+	if p.scan == nil {
+		return
+	}
+	p.rescanQueued.Store(true)
+	if !p.scanning.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		for p.rescanQueued.Swap(false) {
+			slides, err := p.scan()
+			p.rescanResults <- rescanResult{slides: slides, err: err}
+		}
+		p.scanning.Store(false)
+		// Catch a request that arrived after the last check above.
+		if p.rescanQueued.Load() {
+			p.Rescan()
+		}
+	}()
+}
+
+// requestRescan starts a rescan for the rescan command, whose outcome is
+// reported through the notify handler once it lands.
+func (p *Player) requestRescan() {
+	if p.scan == nil {
+		return
+	}
+	p.rescanAsked = true
+	p.notify("Rescanning")
+	p.Rescan()
+}
+
+// maybeRescan kicks off a periodic rescan while there is nothing to show,
+// either because no photos were found or because the current slide failed to
+// load (e.g. an album's network mount went away).
+func (p *Player) maybeRescan(now time.Time) {
+	if p.rescanInterval <= 0 || now.Before(p.nextRescan) {
+		return
+	}
+	if len(p.slides) > 0 && p.loadingError == nil {
+		return
+	}
+	p.nextRescan = now.Add(p.rescanInterval)
+	p.Rescan()
+}
+
+// applyRescan swaps in the result of a finished scan. The slide on screen is
+// kept if it is still present, or else the slide now showing one of its
+// photos, e.g. a portrait that has gained a partner; otherwise the
+// slideshow restarts from the top. With a Shuffler the new slides are
+// shuffled, starting a fresh pass.
+func (p *Player) applyRescan(r rescanResult) {
+	asked := p.rescanAsked
+	p.rescanAsked = false
+	if r.err != nil {
+		log.Printf("Rescan failed: %v", r.err)
+		if asked {
+			p.notify("Rescan failed")
+		}
+		return
+	}
+	if asked {
+		p.notify("Found %d photos", countPhotos(r.slides))
+	}
+
+	var current *Slide
+	if len(p.slides) > 0 && p.loadingError == nil {
+		current = &p.slides[p.currentIndex]
+	}
+
+	p.slides = r.slides
+	p.currentIndex = 0
+	if p.shuffler != nil {
+		p.shuffler.Shuffle(p.slides[p.pinned():], current)
+	}
+	if current != nil {
+		if i, same := findSlide(p.slides, *current); i >= 0 {
+			p.currentIndex = i
+			if first := p.pinned(); p.shuffler != nil && i >= first {
+				// Start the fresh pass from the slide on screen, after
+				// any pinned one.
+				s := p.slides[i]
+				copy(p.slides[first+1:i+1], p.slides[first:i])
+				p.slides[first] = s
+				p.currentIndex = first
+			}
+			if same {
+				return
+			}
+		}
+	}
+
+	if len(p.slides) == 0 {
+		p.freeSlideImages()
+		p.freePreviousImages()
+		p.loadingError = nil
+		return
+	}
+	log.Printf("Rescan found %d slides", len(p.slides))
+	p.LoadDisplayableSlide()
+}
+
+// findSlide returns the index of the slide in slides that is s, with same
+// set, or failing that of the first that shares a photo with it; -1 if
+// none does.
+func findSlide(slides []Slide, s Slide) (index int, same bool) {
+	for i, t := range slides {
+		if sameSlide(t, s) {
+			return i, true
+		}
+	}
+	for i, t := range slides {
+		for _, path := range t.Paths() {
+			if slices.Contains(s.Paths(), path) {
+				return i, false
+			}
+		}
+	}
+	return -1, false
+}
+
+// countPhotos is the number of photos on slides, title cards aside.
+func countPhotos(slides []Slide) int {
+	n := 0
+	for _, s := range slides {
+		n += len(s.Photos)
+	}
+	return n
+}
@@ -0,0 +1,137 @@
+package player
+
+import (
+	"math/rand"
+	"path/filepath"
+	"strings"
+)
+
+// Shuffler picks the slide order for each pass through the slideshow, so a
+// random slideshow does not repeat itself identically.
+type Shuffler interface {
+	// Shuffle reorders slides in place for a new pass. last is the slide
+	// shown just before the pass starts, or nil.
+	Shuffle(slides []Slide, last *Slide)
+}
+
+// smartLookahead is how far ahead SmartShuffle looks for a slide unlike the
+// one before it. It keeps a pass linear in the number of slides; albums
+// bigger than that simply cluster a little.
+const smartLookahead = 64
+
+// SmartShuffle reshuffles every pass and then spreads out photos from the
+// same album or day, so they are not shown back-to-back wherever there is
+// something else to show in between.
+type SmartShuffle struct {
+	rng *rand.Rand
+}
+
+// NewSmartShuffle returns a SmartShuffle whose passes are determined by seed.
+func NewSmartShuffle(seed int64) *SmartShuffle {
+	return &SmartShuffle{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Shuffle implements Shuffler.
+func (s *SmartShuffle) Shuffle(slides []Slide, last *Slide) {
+	s.rng.Shuffle(len(slides), func(i, j int) {
+		slides[i], slides[j] = slides[j], slides[i]
+	})
+	prev := last
+	for i := range slides {
+		if prev != nil {
+			// Bring forward the first of the next few slides that is
+			// unlike the one before; if there is none, leave it be.
+			for j := i; j < min(len(slides), i+smartLookahead); j++ {
+				if !alike(slides[j], *prev) {
+					slides[i], slides[j] = slides[j], slides[i]
+					break
+				}
+			}
+		}
+		prev = &slides[i]
+	}
+}
+
+// drawTracker is a Shuffler that is told which slides are shown, and can end
+// a pass early once the rest of it has been shown already.
+type drawTracker interface {
+	// Drawn records that s has been shown.
+	Drawn(s Slide)
+	// Spent reports whether s has been shown since the pass began.
+	Spent(s Slide) bool
+}
+
+// ShuffleBag shows every slide once, in random order, before any is shown
+// again: a rescan part way through keeps the slides already shown for the
+// end of the pass, which starts over once it reaches them.
+type ShuffleBag struct {
+	rng   *rand.Rand
+	drawn map[string]bool
+}
+
+// NewShuffleBag returns a ShuffleBag whose passes are determined by seed.
+func NewShuffleBag(seed int64) *ShuffleBag {
+	return &ShuffleBag{rng: rand.New(rand.NewSource(seed)), drawn: make(map[string]bool)}
+}
+
+// Shuffle implements Shuffler. The slides not yet drawn come first, in
+// random order, and the rest after them. Once every slide has been drawn
+// the bag is refilled, keeping last off the front.
+func (b *ShuffleBag) Shuffle(slides []Slide, last *Slide) {
+	present := make(map[string]bool, len(slides))
+	undrawn := 0
+	for _, s := range slides {
+		key := bagKey(s)
+		present[key] = true
+		if !b.drawn[key] {
+			undrawn++
+		}
+	}
+	for key := range b.drawn {
+		if !present[key] {
+			delete(b.drawn, key)
+		}
+	}
+	if undrawn == 0 {
+		clear(b.drawn)
+	}
+
+	b.rng.Shuffle(len(slides), func(i, j int) {
+		slides[i], slides[j] = slides[j], slides[i]
+	})
+	n := 0
+	for i := range slides {
+		if !b.drawn[bagKey(slides[i])] {
+			slides[n], slides[i] = slides[i], slides[n]
+			n++
+		}
+	}
+	if last != nil && n > 1 && sameSlide(slides[0], *last) {
+		slides[0], slides[n-1] = slides[n-1], slides[0]
+	}
+}
+
+// Drawn implements drawTracker.
+func (b *ShuffleBag) Drawn(s Slide) {
+	b.drawn[bagKey(s)] = true
+}
+
+// Spent implements drawTracker.
+func (b *ShuffleBag) Spent(s Slide) bool {
+	return b.drawn[bagKey(s)]
+}
+
+// bagKey identifies a slide by its photos.
+func bagKey(s Slide) string {
+	return strings.Join(s.Paths(), "\x00")
+}
+
+// alike reports whether the first photos of a and b come from the same album
+// directory or were taken on the same day.
+func alike(a, b Slide) bool {
+	if len(a.Photos) == 0 || len(b.Photos) == 0 {
+		return false
+	}
+	pa, pb := a.Photos[0], b.Photos[0]
+	return filepath.Dir(pa.FilePath) == filepath.Dir(pb.FilePath) || sameDay(pa, pb)
+}
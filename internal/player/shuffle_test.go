@@ -0,0 +1,138 @@
+package player
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/cec"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+// albumSlides returns perAlbum slides from each of albums, with every album's
+// photos taken on its own day.
+func albumSlides(albums []string, perAlbum int) []Slide {
+	var slides []Slide
+	for a, album := range albums {
+		day := time.Date(2024, 1, 1+a, 12, 0, 0, 0, time.UTC)
+		for i := 0; i < perAlbum; i++ {
+			slides = append(slides, Slide{Photos: []photo.Photo{{
+				FilePath:  filepath.Join(album, fmt.Sprintf("%d.jpg", i)),
+				TakenTime: day,
+				Width:     800,
+				Height:    600,
+			}}})
+		}
+	}
+	return slides
+}
+
+func slidePaths(slides []Slide) []string {
+	var paths []string
+	for _, s := range slides {
+		paths = append(paths, s.Paths()...)
+	}
+	return paths
+}
+
+func TestSmartShuffleIsDeterministic(t *testing.T) {
+	a := albumSlides([]string{"x", "y", "z"}, 5)
+	b := albumSlides([]string{"x", "y", "z"}, 5)
+	NewSmartShuffle(42).Shuffle(a, nil)
+	NewSmartShuffle(42).Shuffle(b, nil)
+	if !slices.Equal(slidePaths(a), slidePaths(b)) {
+		t.Errorf("same seed gave %v and %v", slidePaths(a), slidePaths(b))
+	}
+
+	got := slidePaths(a)
+	want := slidePaths(albumSlides([]string{"x", "y", "z"}, 5))
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("shuffle changed the slides: got %v, want %v", got, want)
+	}
+}
+
+func TestSmartShuffleSeparatesAlbums(t *testing.T) {
+	// With equal-sized albums a pass can always alternate.
+	for seed := int64(0); seed < 20; seed++ {
+		slides := albumSlides([]string{"x", "y"}, 4)
+		last := slides[0]
+		NewSmartShuffle(seed).Shuffle(slides, &last)
+		prev := last
+		for i, s := range slides {
+			if alike(s, prev) {
+				t.Errorf("seed %d: slide %d (%v) follows %v from the same album", seed, i, s.Paths(), prev.Paths())
+			}
+			prev = s
+		}
+	}
+}
+
+func TestPlayerReshufflesEachPass(t *testing.T) {
+	p := New(landscapeSlides("a.jpg", "b.jpg", "c.jpg", "d.jpg", "e.jpg", "f.jpg"), Options{
+		Interval:  time.Hour,
+		LoadImage: loadFake,
+		Clock:     &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		Shuffler:  NewSmartShuffle(1),
+	})
+	remote := make(chan cec.RemoteCommand, 1)
+	p.SetRemoteCommandChan(remote)
+	p.LoadDisplayableSlide()
+
+	var passes [][]string
+	for pass := 0; pass < 3; pass++ {
+		var shown []string
+		for i := 0; i < 6; i++ {
+			shown = append(shown, currentPath(t, p))
+			remote <- cec.RemoteRight
+			p.Update()
+		}
+		sorted := slices.Clone(shown)
+		slices.Sort(sorted)
+		if want := []string{"a.jpg", "b.jpg", "c.jpg", "d.jpg", "e.jpg", "f.jpg"}; !slices.Equal(sorted, want) {
+			t.Fatalf("pass %d showed %v, want each photo once", pass, shown)
+		}
+		passes = append(passes, shown)
+	}
+	if slices.Equal(passes[0], passes[1]) && slices.Equal(passes[1], passes[2]) {
+		t.Errorf("every pass showed %v; want a fresh order each pass", passes[0])
+	}
+}
+
+func TestShuffleBagShowsEverySlideBeforeRepeating(t *testing.T) {
+	paths := []string{"a.jpg", "b.jpg", "c.jpg", "d.jpg", "e.jpg", "f.jpg", "g.jpg"}
+	for seed := int64(0); seed < 10; seed++ {
+		p := New(landscapeSlides(paths...), Options{
+			Interval:  time.Hour,
+			LoadImage: loadFake,
+			Clock:     &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+			Shuffler:  NewShuffleBag(seed),
+		})
+		remote := make(chan cec.RemoteCommand, 1)
+		p.SetRemoteCommandChan(remote)
+		p.LoadDisplayableSlide()
+
+		var shown []string
+		for draw := 0; draw < 4*len(paths); draw++ {
+			shown = append(shown, currentPath(t, p))
+			if draw == 3 || draw == 12 {
+				// A rescan mid-cycle starts a new pass without putting
+				// the slides already shown back in the bag.
+				p.applyRescan(rescanResult{slides: landscapeSlides(paths...)})
+			}
+			remote <- cec.RemoteRight
+			p.Update()
+		}
+		for start := 0; start < len(shown); start += len(paths) {
+			cycle := slices.Clone(shown[start : start+len(paths)])
+			slices.Sort(cycle)
+			if !slices.Equal(cycle, paths) {
+				t.Fatalf("seed %d: draws %d-%d were %v, want each photo once (all draws %v)",
+					seed, start, start+len(paths)-1, shown[start:start+len(paths)], shown)
+			}
+		}
+	}
+}
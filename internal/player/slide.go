@@ -0,0 +1,348 @@
+package player
+
+import (
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+// Slide holds up to two photos to be displayed side-by-side if both are
+// portrait, or one above the other if Stacked.
+type Slide struct {
+	Photos []photo.Photo // either 1 or 2 Photos, or none for a title card
+	// Stacked puts the first of two landscapes above the second.
+	Stacked bool
+	// Pinned keeps the first slide first, such as a welcome photo: it is
+	// left out of every shuffle and opens the slideshow, whatever
+	// Options.StartRandom and ResumeAt say.
+	Pinned bool
+
+	// Title is set on the title card that introduces a day's photos when
+	// slides are grouped by date.
+	Title *TitleCard
+
+	// Duration, when positive, is how long the slide stays up, overriding
+	// the interval and slideDurations (e.g. from a playlist).
+	Duration time.Duration
+}
+
+// TitleCard announces the photos taken on one day.
+type TitleCard struct {
+	Date   time.Time // the day, at midnight in the photos' time zone
+	Photos int       // how many photos follow
+}
+
+// Paths returns the file paths of the slide's photos.
+func (s Slide) Paths() []string {
+	paths := make([]string, len(s.Photos))
+	for i, p := range s.Photos {
+		paths[i] = p.FilePath
+	}
+	return paths
+}
+
+// IsTitleCard reports whether s is a title card rather than photos.
+func (s Slide) IsTitleCard() bool {
+	return s.Title != nil
+}
+
+// decodedBytes estimates the memory the slide's decoded images take: four
+// bytes a pixel at each photo's full size.
+func (s Slide) decodedBytes() int64 {
+	var n int64
+	for _, p := range s.Photos {
+		n += int64(p.Width) * int64(p.Height) * 4
+	}
+	return n
+}
+
+// sameSlide reports whether a and b show the same thing, so a rescan can keep
+// the slideshow's place.
+func sameSlide(a, b Slide) bool {
+	if a.IsTitleCard() || b.IsTitleCard() {
+		return a.IsTitleCard() && b.IsTitleCard() && a.Title.Date.Equal(b.Title.Date)
+	}
+	return slices.Equal(a.Paths(), b.Paths())
+}
+
+// The logical screen size the slideshow has always drawn at.
+const (
+	DefaultDisplayWidth  = 1920
+	DefaultDisplayHeight = 1080
+)
+
+// RotatedSize returns the size of a width x height screen turned by degrees,
+// one of 0, 90, 180 or 270: swapped by a quarter turn, unchanged otherwise.
+func RotatedSize(width, height, degrees int) (int, int) {
+	if degrees%180 != 0 {
+		return height, width
+	}
+	return width, height
+}
+
+// SlideOptions controls how photos are turned into slides.
+type SlideOptions struct {
+	// GroupByDate gathers photos taken on the same day together (days in
+	// the order their first photo appears, photos keeping their relative
+	// order) and starts each day with a title card.
+	GroupByDate bool
+	// Interleave takes slides from each album in turn (albums in the order
+	// their first photo appears, each album's photos keeping their relative
+	// order), so that no one album's photos run together. With GroupByDate
+	// the albums take turns within each day. Portraits are only paired with
+	// photos from their own album.
+	Interleave bool
+	// DisplayWidth and DisplayHeight are the logical screen size, which
+	// decides whether two portraits fit side by side; zero means 1920x1080.
+	DisplayWidth, DisplayHeight int
+	// PairTolerance, when positive, only puts two portraits side by side if
+	// their aspect ratios differ by at most this percentage of the taller
+	// one's; zero pairs any two.
+	PairTolerance float64
+	// AutoRotateToFill pairs a portrait that would be shown alone with a
+	// neighbouring landscape taken the same day, turning the landscape 90°
+	// clockwise (see photo.Photo.RotatedToFill). Animated GIFs are never
+	// turned.
+	AutoRotateToFill bool
+	// StackLandscapes puts two consecutive landscapes of similar shape
+	// (see PairTolerance) one above the other, when together they fill
+	// more of the display than either would alone: panoramas on a wide
+	// screen, or most landscapes on a portrait-mounted one.
+	StackLandscapes bool
+}
+
+// BuildSlidesFromPhotos takes a set of photos and merges consecutive portraits
+// into one Slide if the display is wide enough for side-by-side.
+func BuildSlidesFromPhotos(photos []photo.Photo, opts SlideOptions) []Slide {
+	if !opts.GroupByDate {
+		return buildSlides(photos, opts)
+	}
+
+	var slides []Slide
+	for _, day := range groupByDay(photos) {
+		y, m, d := day[0].TakenTime.Date()
+		slides = append(slides, Slide{Title: &TitleCard{
+			Date:   time.Date(y, m, d, 0, 0, 0, 0, day[0].TakenTime.Location()),
+			Photos: len(day),
+		}})
+		slides = append(slides, buildSlides(day, opts)...)
+	}
+	return slides
+}
+
+// BuildSlidesFromPlaylist builds slides for photos in exactly the given
+// order, pairing consecutive photos as usual; opts.GroupByDate and
+// opts.Interleave are ignored. durations[i] is photo i's own duration, or
+// zero; a side-by-side slide stays up for the longer of its two photos.
+func BuildSlidesFromPlaylist(photos []photo.Photo, durations []time.Duration, opts SlideOptions) []Slide {
+	slides := pairPhotos(photos, opts)
+	i := 0
+	for s := range slides {
+		for range slides[s].Photos {
+			if i < len(durations) {
+				slides[s].Duration = max(slides[s].Duration, durations[i])
+			}
+			i++
+		}
+	}
+	return slides
+}
+
+// buildSlides turns photos into slides, pairing photos and interleaving
+// albums as opts asks.
+func buildSlides(photos []photo.Photo, opts SlideOptions) []Slide {
+	if !opts.Interleave {
+		return pairPhotos(photos, opts)
+	}
+	var albums [][]Slide
+	for _, album := range groupByAlbum(photos) {
+		albums = append(albums, pairPhotos(album, opts))
+	}
+	var slides []Slide
+	for i := 0; ; i++ {
+		added := false
+		for _, album := range albums {
+			if i < len(album) {
+				slides = append(slides, album[i])
+				added = true
+			}
+		}
+		if !added {
+			return slides
+		}
+	}
+}
+
+// groupByAlbum splits photos by their Album.
+func groupByAlbum(photos []photo.Photo) [][]photo.Photo {
+	var albums [][]photo.Photo
+	index := make(map[string]int)
+	for _, p := range photos {
+		i, ok := index[p.Album]
+		if !ok {
+			i = len(albums)
+			index[p.Album] = i
+			albums = append(albums, nil)
+		}
+		albums[i] = append(albums[i], p)
+	}
+	return albums
+}
+
+// groupByDay splits photos by the calendar day of their TakenTime.
+func groupByDay(photos []photo.Photo) [][]photo.Photo {
+	type day struct {
+		y int
+		m time.Month
+		d int
+	}
+	var days [][]photo.Photo
+	index := make(map[day]int)
+	for _, p := range photos {
+		y, m, d := p.TakenTime.Date()
+		key := day{y, m, d}
+		i, ok := index[key]
+		if !ok {
+			i = len(days)
+			index[key] = i
+			days = append(days, nil)
+		}
+		days[i] = append(days[i], p)
+	}
+	return days
+}
+
+// pairPhotos turns photos into slides, putting consecutive portraits of
+// similar shape side by side if the display is wide enough. With
+// opts.AutoRotateToFill a portrait left over pairs with a landscape next to
+// it, turned to stand upright, and with opts.StackLandscapes consecutive
+// landscapes may be stacked.
+func pairPhotos(photos []photo.Photo, opts SlideOptions) []Slide {
+	sideBySide := displayAllowsSideBySide(opts.DisplayWidth, opts.DisplayHeight)
+	pairable := func(a, b photo.Photo) bool {
+		return sideBySide && isPortrait(a) && isPortrait(b) && similarAspect(a, b, opts.PairTolerance)
+	}
+	stackable := func(a, b photo.Photo) bool {
+		return opts.StackLandscapes && isLandscape(a) && isLandscape(b) && similarAspect(a, b, opts.PairTolerance) &&
+			stackingFillsMore(a, b, opts.DisplayWidth, opts.DisplayHeight)
+	}
+	var slides []Slide
+	i := 0
+	for i < len(photos) {
+		current := photos[i]
+		// Attempt to pair with next if it exists, both are portrait, etc.
+		if i+1 < len(photos) {
+			next := photos[i+1]
+			if pairable(current, next) {
+				slides = append(slides, Slide{Photos: []photo.Photo{current, next}})
+				i += 2
+				continue
+			}
+			if stackable(current, next) {
+				slides = append(slides, Slide{Photos: []photo.Photo{current, next}, Stacked: true})
+				i += 2
+				continue
+			}
+			// Turn a landscape only for a portrait that would otherwise
+			// be alone, not one the next photo could pair with.
+			if opts.AutoRotateToFill && sameDay(current, next) &&
+				!(i+2 < len(photos) && pairable(next, photos[i+2])) {
+				if pair, ok := turnToFill(current, next); ok && pairable(pair[0], pair[1]) {
+					slides = append(slides, Slide{Photos: pair})
+					i += 2
+					continue
+				}
+			}
+		}
+		slides = append(slides, Slide{Photos: []photo.Photo{current}})
+		i++
+	}
+	return slides
+}
+
+// turnToFill returns a and b, in that order, with whichever is a landscape
+// turned upright, if the other is a portrait.
+func turnToFill(a, b photo.Photo) ([]photo.Photo, bool) {
+	switch {
+	case isPortrait(a) && isTurnable(b):
+		return []photo.Photo{a, rotatedToFill(b)}, true
+	case isTurnable(a) && isPortrait(b):
+		return []photo.Photo{rotatedToFill(a), b}, true
+	}
+	return nil, false
+}
+
+// isTurnable reports whether p is a landscape that may be turned to fill.
+func isTurnable(p photo.Photo) bool {
+	return p.Width > p.Height && !strings.EqualFold(filepath.Ext(p.FilePath), ".gif")
+}
+
+// rotatedToFill returns p turned 90° clockwise.
+func rotatedToFill(p photo.Photo) photo.Photo {
+	p.Width, p.Height = p.Height, p.Width
+	p.RotatedToFill = true
+	return p
+}
+
+// sameDay reports whether a and b were taken on the same calendar day.
+func sameDay(a, b photo.Photo) bool {
+	ya, ma, da := a.TakenTime.Date()
+	yb, mb, db := b.TakenTime.Date()
+	return ya == yb && ma == mb && da == db
+}
+
+// isPortrait is a simple check: height > width (assuming it's stored in photo.Photo).
+func isPortrait(p photo.Photo) bool {
+	return p.Height > p.Width
+}
+
+// isLandscape reports whether p is wider than it is tall.
+func isLandscape(p photo.Photo) bool {
+	return p.Width > p.Height
+}
+
+// stackingFillsMore reports whether landscapes a and b, each fitted into
+// half the height of a width x height display, one above the other, cover
+// more of it together than either does fitted to the whole display.
+func stackingFillsMore(a, b photo.Photo, width, height int) bool {
+	if width <= 0 || height <= 0 {
+		width, height = DefaultDisplayWidth, DefaultDisplayHeight
+	}
+	stacked := fittedArea(a, width, height/2) + fittedArea(b, width, height/2)
+	return stacked > max(fittedArea(a, width, height), fittedArea(b, width, height))
+}
+
+// fittedArea is the area p covers scaled to fit a w x h box.
+func fittedArea(p photo.Photo, w, h int) float64 {
+	if p.Width <= 0 || p.Height <= 0 {
+		return 0
+	}
+	scale := min(float64(w)/float64(p.Width), float64(h)/float64(p.Height))
+	return float64(p.Width) * float64(p.Height) * scale * scale
+}
+
+// similarAspect reports whether the width-to-height ratios of a and b differ
+// by at most tolerance percent of the smaller (taller) one. A tolerance of
+// zero or less accepts any pair.
+func similarAspect(a, b photo.Photo, tolerance float64) bool {
+	if tolerance <= 0 {
+		return true
+	}
+	ra := float64(a.Width) / float64(a.Height)
+	rb := float64(b.Width) / float64(b.Height)
+	return math.Abs(ra-rb) <= tolerance/100*min(ra, rb)
+}
+
+// displayAllowsSideBySide reports whether a display of width x height is
+// wide enough for a pair of portraits: at least 4:3, where each still fills
+// most of the height. Narrower or portrait-mounted screens show them singly.
+func displayAllowsSideBySide(width, height int) bool {
+	if width <= 0 || height <= 0 {
+		width, height = DefaultDisplayWidth, DefaultDisplayHeight
+	}
+	return 3*width >= 4*height
+}
@@ -0,0 +1,245 @@
+package player
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+// orientedPhotos returns a photo for each of orientations, "L" for landscape
+// and "P" for portrait, named by its position.
+func orientedPhotos(orientations ...string) []photo.Photo {
+	var photos []photo.Photo
+	for i, o := range orientations {
+		p := photo.Photo{FilePath: fmt.Sprintf("%d.jpg", i), Width: 800, Height: 600}
+		if o == "P" {
+			p.Width, p.Height = 600, 800
+		}
+		photos = append(photos, p)
+	}
+	return photos
+}
+
+func TestRotatedSize(t *testing.T) {
+	tests := []struct {
+		degrees    int
+		wantWidth  int
+		wantHeight int
+	}{
+		{0, 1920, 1080},
+		{90, 1080, 1920},
+		{180, 1920, 1080},
+		{270, 1080, 1920},
+	}
+	for _, tt := range tests {
+		w, h := RotatedSize(1920, 1080, tt.degrees)
+		if w != tt.wantWidth || h != tt.wantHeight {
+			t.Errorf("RotatedSize(1920, 1080, %d) = %dx%d, want %dx%d", tt.degrees, w, h, tt.wantWidth, tt.wantHeight)
+		}
+		// Turning the result back gives the original size.
+		if w, h := RotatedSize(w, h, tt.degrees); w != 1920 || h != 1080 {
+			t.Errorf("RotatedSize twice by %d = %dx%d, want 1920x1080", tt.degrees, w, h)
+		}
+	}
+}
+
+func TestBuildSlidesFromPhotosPairsPortraits(t *testing.T) {
+	tests := []struct {
+		name         string
+		orientations []string
+		opts         SlideOptions
+		want         [][]string
+	}{
+		{
+			name: "empty",
+			want: nil,
+		},
+		{
+			name:         "all landscapes",
+			orientations: []string{"L", "L", "L"},
+			want:         [][]string{{"0.jpg"}, {"1.jpg"}, {"2.jpg"}},
+		},
+		{
+			name:         "exactly two portraits",
+			orientations: []string{"P", "P"},
+			want:         [][]string{{"0.jpg", "1.jpg"}},
+		},
+		{
+			name:         "odd trailing portrait",
+			orientations: []string{"P", "P", "P", "P", "P"},
+			want:         [][]string{{"0.jpg", "1.jpg"}, {"2.jpg", "3.jpg"}, {"4.jpg"}},
+		},
+		{
+			name:         "alternating",
+			orientations: []string{"P", "L", "P", "L", "P"},
+			want:         [][]string{{"0.jpg"}, {"1.jpg"}, {"2.jpg"}, {"3.jpg"}, {"4.jpg"}},
+		},
+		{
+			name:         "landscape in the middle",
+			orientations: []string{"P", "P", "L", "P", "P"},
+			want:         [][]string{{"0.jpg", "1.jpg"}, {"2.jpg"}, {"3.jpg", "4.jpg"}},
+		},
+		{
+			name:         "portrait display shows portraits singly",
+			orientations: []string{"P", "P"},
+			opts:         SlideOptions{DisplayWidth: 1080, DisplayHeight: 1920},
+			want:         [][]string{{"0.jpg"}, {"1.jpg"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]string
+			for _, s := range BuildSlidesFromPhotos(orientedPhotos(tt.orientations...), tt.opts) {
+				got = append(got, s.Paths())
+			}
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("BuildSlidesFromPhotos() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSlidesPairsSimilarAspectRatios(t *testing.T) {
+	portrait := func(w, h int) photo.Photo {
+		return photo.Photo{FilePath: fmt.Sprintf("%dx%d.jpg", w, h), Width: w, Height: h}
+	}
+	tests := []struct {
+		name      string
+		a, b      photo.Photo
+		tolerance float64
+		wantPair  bool
+	}{
+		{name: "no tolerance pairs anything", a: portrait(900, 1600), b: portrait(800, 1000), wantPair: true},
+		{name: "phone shot and 4:5", a: portrait(900, 1600), b: portrait(800, 1000), tolerance: 20},
+		{name: "same ratio", a: portrait(600, 800), b: portrait(1200, 1600), tolerance: 1, wantPair: true},
+		{name: "just inside", a: portrait(800, 1000), b: portrait(879, 1000), tolerance: 10, wantPair: true},
+		{name: "just outside", a: portrait(800, 1000), b: portrait(881, 1000), tolerance: 10},
+		{name: "order does not matter", a: portrait(881, 1000), b: portrait(800, 1000), tolerance: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slides := BuildSlidesFromPhotos([]photo.Photo{tt.a, tt.b}, SlideOptions{PairTolerance: tt.tolerance})
+			if gotPair := len(slides) == 1; gotPair != tt.wantPair {
+				t.Errorf("BuildSlidesFromPhotos() made %d slides, want pair = %t", len(slides), tt.wantPair)
+			}
+		})
+	}
+}
+
+func TestBuildSlidesRotatesLandscapeToFill(t *testing.T) {
+	// describe lists each slide's photos by name, a turned one marked "r".
+	describe := func(slides []Slide) []string {
+		var got []string
+		for _, s := range slides {
+			var names []string
+			for _, p := range s.Photos {
+				name := strings.TrimSuffix(p.FilePath, ".jpg")
+				if p.RotatedToFill {
+					name += "r"
+				}
+				names = append(names, name)
+			}
+			got = append(got, strings.Join(names, "+"))
+		}
+		return got
+	}
+	tests := []struct {
+		name         string
+		orientations []string
+		nextDay      int // index of the first photo taken a day later, if > 0
+		off          bool
+		want         []string
+	}{
+		{name: "portrait then landscape", orientations: []string{"P", "L"}, want: []string{"0+1r"}},
+		{name: "landscape then portrait", orientations: []string{"L", "P"}, want: []string{"0r+1"}},
+		{name: "portraits still pair", orientations: []string{"L", "P", "P"}, want: []string{"0", "1+2"}},
+		{name: "one landscape per portrait", orientations: []string{"P", "L", "L"}, want: []string{"0+1r", "2"}},
+		{name: "landscapes alone", orientations: []string{"L", "L"}, want: []string{"0", "1"}},
+		{name: "different days", orientations: []string{"P", "L"}, nextDay: 1, want: []string{"0", "1"}},
+		{name: "off", orientations: []string{"P", "L"}, off: true, want: []string{"0", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			photos := orientedPhotos(tt.orientations...)
+			for i := range photos {
+				photos[i].TakenTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+				if tt.nextDay > 0 && i >= tt.nextDay {
+					photos[i].TakenTime = photos[i].TakenTime.AddDate(0, 0, 1)
+				}
+			}
+			slides := BuildSlidesFromPhotos(photos, SlideOptions{AutoRotateToFill: !tt.off})
+			if got := describe(slides); !slices.Equal(got, tt.want) {
+				t.Errorf("slides %v, want %v", got, tt.want)
+			}
+			for _, s := range slides {
+				for _, p := range s.Photos {
+					if p.RotatedToFill && !isPortrait(p) {
+						t.Errorf("%s turned but still %dx%d", p.FilePath, p.Width, p.Height)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBuildSlidesStacksLandscapesThatFillMore(t *testing.T) {
+	landscape := func(name string, w, h int) photo.Photo {
+		return photo.Photo{FilePath: name, Width: w, Height: h}
+	}
+	tests := []struct {
+		name          string
+		a, b          photo.Photo
+		width, height int
+		off           bool
+		wantStacked   bool
+	}{
+		{name: "panoramas", a: landscape("a", 3000, 1000), b: landscape("b", 3300, 1100), wantStacked: true},
+		{name: "3:2 on a wide screen", a: landscape("a", 1500, 1000), b: landscape("b", 1500, 1000)},
+		{name: "3:2 on a portrait screen", a: landscape("a", 1500, 1000), b: landscape("b", 1500, 1000), width: 1080, height: 1920, wantStacked: true},
+		{name: "off", a: landscape("a", 3000, 1000), b: landscape("b", 3000, 1000), off: true},
+		{name: "portraits are not stacked", a: landscape("a", 1000, 3000), b: landscape("b", 1000, 3000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slides := BuildSlidesFromPhotos([]photo.Photo{tt.a, tt.b}, SlideOptions{
+				DisplayWidth:    tt.width,
+				DisplayHeight:   tt.height,
+				StackLandscapes: !tt.off,
+			})
+			gotStacked := len(slides) == 1 && slides[0].Stacked
+			if gotStacked != tt.wantStacked {
+				t.Errorf("BuildSlidesFromPhotos() = %+v, want stacked = %t", slides, tt.wantStacked)
+			}
+		})
+	}
+}
+
+func TestBuildSlidesInterleavesAlbums(t *testing.T) {
+	landscape := func(album, path string) photo.Photo {
+		return photo.Photo{FilePath: path, Album: album, Width: 800, Height: 600}
+	}
+	portrait := func(album, path string) photo.Photo {
+		return photo.Photo{FilePath: path, Album: album, Width: 600, Height: 800}
+	}
+	photos := []photo.Photo{
+		landscape("a", "a1"), landscape("a", "a2"), landscape("a", "a3"),
+		portrait("b", "b1"), portrait("b", "b2"),
+		landscape("c", "c1"),
+		// Portraits only pair within their album.
+		portrait("a", "a4"),
+	}
+
+	slides := BuildSlidesFromPhotos(photos, SlideOptions{Interleave: true})
+	var got [][]string
+	for _, s := range slides {
+		got = append(got, s.Paths())
+	}
+	want := [][]string{{"a1"}, {"b1", "b2"}, {"c1"}, {"a2"}, {"a3"}, {"a4"}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("BuildSlidesFromPhotos() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package player
+
+import "time"
+
+// Status is a snapshot of the slideshow for readers on other goroutines,
+// such as the HTTP API. Update takes a fresh one at the end of every frame.
+type Status struct {
+	// Index and Total place the slide on screen in the slideshow, title
+	// cards included; Total is zero while there is nothing to show.
+	Index, Total int
+	// Photos are the paths of the slide on screen; none for a title card.
+	Photos []string
+	Paused bool
+	Held   bool
+	// Blanked is set while a blank command has the screen black.
+	Blanked bool
+	// Interval is how long a photo slide stays up, before any per-slide
+	// scaling.
+	Interval time.Duration
+	// Error is what left nothing displayable, if anything did.
+	Error string
+}
+
+// Status returns the slideshow as of the end of the last Update. Unlike the
+// other methods, it is safe to call from any goroutine.
+func (p *Player) Status() Status {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+	return p.status
+}
+
+// takeStatus records the state Status reports. It runs on the Update
+// goroutine, the only one to change that state.
+func (p *Player) takeStatus() {
+	s := Status{
+		Paused:   p.paused,
+		Held:     p.held,
+		Blanked:  p.blanked,
+		Interval: p.interval,
+	}
+	s.Index, s.Total = p.SlidePosition()
+	if slide, _, ok := p.CurrentSlide(); ok {
+		s.Photos = slide.Paths()
+	}
+	if p.loadingError != nil {
+		s.Error = p.loadingError.Error()
+	}
+	p.statusMu.Lock()
+	p.status = s
+	p.statusMu.Unlock()
+}
@@ -0,0 +1,64 @@
+package player
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// StartWatchdog launches a goroutine that forces an advance when the slide
+// on screen has overrun its scheduled change by more than threshold while the
+// slideshow is running, e.g. after a stalled decode. Measuring from the
+// scheduled change rather than from when the slide went up keeps long slides
+// (favorites, playlist durations, a slowed interval) from being cut short.
+// It stays quiet while paused or when there is nothing to show. A
+// non-positive threshold disables the watchdog. It stops when ctx is
+// cancelled.
+func (p *Player) StartWatchdog(ctx context.Context, threshold time.Duration) {
+	if threshold <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(threshold / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			now := p.clock.Now()
+			if overdue, ok := p.stalled(now, threshold); ok {
+				log.Printf("Watchdog: slide change overdue by %s (threshold %s); forcing an advance", overdue.Round(time.Second), threshold)
+				// Restart the clock so a still-wedged loop isn't nagged every tick.
+				p.due.Store(now.UnixNano())
+				select {
+				case p.watchdogKick <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+}
+
+// stalled reports how long past its scheduled change the slide on screen is
+// at now, and whether that is more than threshold. It is safe to call from
+// any goroutine.
+func (p *Player) stalled(now time.Time, threshold time.Duration) (time.Duration, bool) {
+	if p.idle.Load() {
+		return 0, false
+	}
+	overdue := now.Sub(time.Unix(0, p.due.Load()))
+	return overdue, overdue > threshold
+}
+
+// markAdvanced publishes the current slide's scheduled change for the
+// watchdog. It is called whenever switchTime is set, and when a pause or hold
+// ends, so time spent stopped is not counted as overrun.
+func (p *Player) markAdvanced() {
+	due := p.switchTime
+	if now := p.clock.Now(); now.After(due) {
+		due = now
+	}
+	p.due.Store(due.UnixNano())
+}
@@ -0,0 +1,72 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/cec"
+)
+
+func TestWatchdogWaitsForLongSlides(t *testing.T) {
+	// Thirty seconds was the default threshold for a 10s interval, and each
+	// slide below is meant to stay up longer than that.
+	const threshold = 30 * time.Second
+	tests := []struct {
+		name   string
+		slides []Slide
+		opts   Options
+		cmds   []cec.RemoteCommand
+		// The slide is not stalled at quiet, but is at stalled.
+		quiet, stalled time.Duration
+	}{
+		{
+			name:    "favorite",
+			slides:  landscapeSlides("a.jpg", "b.jpg"),
+			opts:    Options{Durations: Durations{Favorite: 5}, Favorites: fakeFavorites{"a.jpg": true}},
+			quiet:   45 * time.Second,
+			stalled: 81 * time.Second,
+		},
+		{
+			name:   "slowed by the remote",
+			slides: landscapeSlides("a.jpg", "b.jpg"),
+			// Fifteen presses take the interval from 10s to 40s.
+			cmds: []cec.RemoteCommand{
+				cec.RemoteSlower, cec.RemoteSlower, cec.RemoteSlower, cec.RemoteSlower, cec.RemoteSlower,
+				cec.RemoteSlower, cec.RemoteSlower, cec.RemoteSlower, cec.RemoteSlower, cec.RemoteSlower,
+				cec.RemoteSlower, cec.RemoteSlower, cec.RemoteSlower, cec.RemoteSlower, cec.RemoteSlower,
+			},
+			quiet:   35 * time.Second,
+			stalled: 71 * time.Second,
+		},
+		{
+			name: "playlist duration",
+			slides: []Slide{
+				{Photos: landscapeSlides("a.jpg")[0].Photos, Duration: time.Minute},
+				landscapeSlides("b.jpg")[0],
+			},
+			quiet:   55 * time.Second,
+			stalled: 91 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			tt.opts.Interval = 10 * time.Second
+			tt.opts.LoadImage = loadFake
+			tt.opts.Clock = &fakeClock{now: start}
+			p := New(tt.slides, tt.opts)
+			p.LoadDisplayableSlide()
+			for _, cmd := range tt.cmds {
+				p.Command(cmd)
+			}
+			p.Update()
+
+			if overdue, ok := p.stalled(start.Add(tt.quiet), threshold); ok {
+				t.Errorf("stalled at %s, overdue by %s; want the slide left to run", tt.quiet, overdue)
+			}
+			if _, ok := p.stalled(start.Add(tt.stalled), threshold); !ok {
+				t.Errorf("not stalled at %s, want a forced advance", tt.stalled)
+			}
+		})
+	}
+}
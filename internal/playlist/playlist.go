@@ -0,0 +1,136 @@
+// Package playlist reads a hand-written list of photos to show in a fixed
+// order, as an alternative to walking album directories.
+//
+// A file ending in .json holds an array whose entries are either a path or
+// an object with a path and an optional duration in seconds:
+//
+//	["/photos/a.jpg", {"path": "/photos/b.jpg", "duration": 20}]
+//
+// Any other file is plain text with one path per line, optionally followed by
+// "|" and a duration in seconds. Blank lines and lines starting with # are
+// ignored:
+//
+//	# Summer
+//	/photos/a.jpg
+//	/photos/b.jpg | 20
+//
+// Relative paths are resolved against the playlist's own directory.
+package playlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is one photo of the playlist.
+type Entry struct {
+	Path string
+	// Duration overrides how long the photo is shown; zero means the usual
+	// interval.
+	Duration time.Duration
+}
+
+// jsonEntry decodes either form of a JSON playlist entry.
+type jsonEntry struct {
+	Path     string   `json:"path"`
+	Duration *float64 `json:"duration"`
+}
+
+func (e *jsonEntry) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &e.Path)
+	}
+	type plain jsonEntry // drops this method, avoiding recursion
+	return json.Unmarshal(data, (*plain)(e))
+}
+
+// Read parses the playlist at path. An entry that cannot be parsed fails the
+// whole playlist; whether the listed files exist is left to the caller.
+func Read(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read playlist: %w", err)
+	}
+	var entries []Entry
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		entries, err = parseJSON(data)
+	} else {
+		entries, err = parseText(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse playlist %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for i, e := range entries {
+		if !filepath.IsAbs(e.Path) {
+			entries[i].Path = filepath.Join(dir, e.Path)
+		}
+	}
+	return entries, nil
+}
+
+func parseJSON(data []byte) ([]Entry, error) {
+	var raw []jsonEntry
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(raw))
+	for i, r := range raw {
+		if r.Path == "" {
+			return nil, fmt.Errorf("entry %d: missing path", i+1)
+		}
+		e := Entry{Path: r.Path}
+		if r.Duration != nil {
+			d, err := seconds(*r.Duration)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: %w", i+1, err)
+			}
+			e.Duration = d
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func parseText(data []byte) ([]Entry, error) {
+	var entries []Entry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		e := Entry{Path: text}
+		if i := strings.LastIndex(text, "|"); i >= 0 {
+			e.Path = strings.TrimSpace(text[:i])
+			v, err := strconv.ParseFloat(strings.TrimSpace(text[i+1:]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: duration %q is not a number", line, strings.TrimSpace(text[i+1:]))
+			}
+			if e.Duration, err = seconds(v); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if e.Path == "" {
+			return nil, fmt.Errorf("line %d: missing path", line)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+func seconds(v float64) (time.Duration, error) {
+	if v <= 0 {
+		return 0, errors.New("duration must be a positive number of seconds")
+	}
+	return time.Duration(v * float64(time.Second)), nil
+}
@@ -0,0 +1,110 @@
+package playlist
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []Entry // relative paths are under the playlist's directory
+		wantErr string
+	}{
+		{
+			name: "text",
+			file: "list.txt",
+			content: `# Summer
+/photos/a.jpg
+
+  beach/b.jpg | 20
+/photos/c|d.jpg | 1.5
+`,
+			want: []Entry{
+				{Path: "/photos/a.jpg"},
+				{Path: "beach/b.jpg", Duration: 20 * time.Second},
+				{Path: "/photos/c|d.jpg", Duration: 1500 * time.Millisecond},
+			},
+		},
+		{
+			name:    "text duration not a number",
+			file:    "list.txt",
+			content: "# Summer\n/photos/a.jpg\n/photos/b.jpg | long\n",
+			wantErr: `line 3: duration "long" is not a number`,
+		},
+		{
+			name:    "text duration not positive",
+			file:    "list.txt",
+			content: "/photos/a.jpg | 0\n",
+			wantErr: "line 1: duration must be a positive number",
+		},
+		{
+			name:    "text missing path",
+			file:    "list.txt",
+			content: "/photos/a.jpg\n\n | 5\n",
+			wantErr: "line 3: missing path",
+		},
+		{
+			name:    "JSON",
+			file:    "list.JSON",
+			content: `["/photos/a.jpg", {"path": "beach/b.jpg", "duration": 20}, {"path": "c.jpg"}]`,
+			want: []Entry{
+				{Path: "/photos/a.jpg"},
+				{Path: "beach/b.jpg", Duration: 20 * time.Second},
+				{Path: "c.jpg"},
+			},
+		},
+		{
+			name:    "JSON missing path",
+			file:    "list.json",
+			content: `["/photos/a.jpg", {"duration": 20}]`,
+			wantErr: "entry 2: missing path",
+		},
+		{
+			name:    "JSON negative duration",
+			file:    "list.json",
+			content: `[{"path": "a.jpg", "duration": -1}]`,
+			wantErr: "entry 1: duration must be a positive number",
+		},
+		{
+			name:    "JSON not an array",
+			file:    "list.json",
+			content: `{"path": "a.jpg"}`,
+			wantErr: "cannot unmarshal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Read(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Read() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := slices.Clone(tt.want)
+			for i, e := range want {
+				if !filepath.IsAbs(e.Path) {
+					want[i].Path = filepath.Join(dir, e.Path)
+				}
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("Read() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package remote
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+const (
+	// maxListingBytes caps how much of an index page or manifest is read.
+	maxListingBytes = 16 << 20
+
+	// maxListingDepth limits how far a directory listing is followed into
+	// subdirectories.
+	maxListingDepth = 8
+)
+
+// hrefRE matches the links of an HTML directory listing, as served by
+// Apache, nginx, lighttpd or a NAS's web share.
+var hrefRE = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+
+// propfindBody asks a WebDAV server for just the type of each resource.
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop><resourcetype/></prop></propfind>`
+
+// list returns the URLs of the photos in the album at rawURL, sorted. A URL
+// ending in .json is a manifest: a JSON array of photo paths, relative to the
+// manifest. Any other URL is a directory, listed by WebDAV PROPFIND where the
+// server supports it and from its HTML index page otherwise, subdirectories
+// included.
+func (c *Client) list(rawURL string) ([]string, error) {
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	base.User = nil
+
+	var urls []string
+	if strings.EqualFold(path.Ext(base.Path), ".json") {
+		urls, err = c.listManifest(base)
+	} else {
+		if !strings.HasSuffix(base.Path, "/") {
+			base.Path += "/"
+		}
+		seen := make(map[string]bool)
+		err = c.listDirectory(base, base, 0, seen, &urls)
+	}
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(urls)
+	return slices.Compact(urls), nil
+}
+
+// listManifest reads the photo paths from the manifest at u.
+func (c *Client) listManifest(u *url.URL) ([]string, error) {
+	data, err := c.fetchListing(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	var urls []string
+	for _, p := range paths {
+		ref, err := url.Parse(p)
+		if err != nil {
+			continue
+		}
+		if photoURL := u.ResolveReference(ref); photo.IsImageFile(photoURL.Path) {
+			urls = append(urls, photoURL.String())
+		}
+	}
+	return urls, nil
+}
+
+// listDirectory adds the photos in the directory at dir, and in its
+// subdirectories below root, to urls.
+func (c *Client) listDirectory(root, dir *url.URL, depth int, seen map[string]bool, urls *[]string) error {
+	if seen[dir.String()] {
+		return nil
+	}
+	seen[dir.String()] = true
+
+	links, err := c.propfind(dir)
+	if err != nil {
+		if links, err = c.indexLinks(dir); err != nil {
+			return err
+		}
+	}
+	for _, link := range links {
+		u := dir.ResolveReference(link)
+		u.Fragment = ""
+		// Only follow links within the album, which leaves out the parent
+		// directory and the sort links of an index page.
+		if u.Scheme != root.Scheme || u.Host != root.Host || u.RawQuery != "" ||
+			!strings.HasPrefix(u.Path, root.Path) || u.Path == dir.Path {
+			continue
+		}
+		switch {
+		case strings.HasSuffix(u.Path, "/"):
+			if depth+1 < maxListingDepth {
+				if err := c.listDirectory(root, u, depth+1, seen, urls); err != nil {
+					// One unreadable subdirectory should not cost the
+					// rest of the album.
+					continue
+				}
+			}
+		case photo.IsImageFile(u.Path):
+			*urls = append(*urls, u.String())
+		}
+	}
+	return nil
+}
+
+// indexLinks returns the links on the HTML index page at dir.
+func (c *Client) indexLinks(dir *url.URL) ([]*url.URL, error) {
+	data, err := c.fetchListing(http.MethodGet, dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	var links []*url.URL
+	for _, m := range hrefRE.FindAllSubmatch(data, -1) {
+		if u, err := url.Parse(html.UnescapeString(string(m[1]))); err == nil {
+			links = append(links, u)
+		}
+	}
+	return links, nil
+}
+
+// multistatus is the part of a WebDAV PROPFIND response that list needs.
+type multistatus struct {
+	Responses []struct {
+		Href       string    `xml:"href"`
+		Collection *struct{} `xml:"propstat>prop>resourcetype>collection"`
+	} `xml:"response"`
+}
+
+// propfind lists the members of the WebDAV collection at dir, with
+// subcollections given a trailing slash. It fails on servers without WebDAV.
+func (c *Client) propfind(dir *url.URL) ([]*url.URL, error) {
+	data, err := c.fetchListing("PROPFIND", dir, http.Header{
+		"Depth":        {"1"},
+		"Content-Type": {`application/xml; charset="utf-8"`},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ms multistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("parse PROPFIND response: %w", err)
+	}
+	var links []*url.URL
+	for _, r := range ms.Responses {
+		u, err := url.Parse(r.Href)
+		if err != nil {
+			continue
+		}
+		if r.Collection != nil && !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+			u.RawPath = ""
+		}
+		links = append(links, u)
+	}
+	return links, nil
+}
+
+// fetchListing fetches an index page, manifest or PROPFIND response. Only
+// a 200 OK answer, or 207 Multi-Status for PROPFIND, is accepted.
+func (c *Client) fetchListing(method string, u *url.URL, header http.Header) ([]byte, error) {
+	var body io.Reader
+	want := http.StatusOK
+	if method == "PROPFIND" {
+		body = strings.NewReader(propfindBody)
+		want = http.StatusMultiStatus
+	}
+	resp, err := c.do(method, u.String(), header, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != want {
+		return nil, fmt.Errorf("%s %s: %s", method, u, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxListingBytes))
+}
@@ -0,0 +1,482 @@
+// Package remote shows albums served over HTTP, such as a NAS share or a
+// WebDAV folder, without mounting them. Scans read only the start of each
+// photo for its metadata; the full image is streamed when the photo comes up
+// and kept in a cache under the state directory, revalidated by ETag or
+// Last-Modified on later scans.
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/photo"
+	"github.com/electronjoe/OpenFrame/internal/player"
+)
+
+const (
+	cacheDirName  = "remote"
+	indexFileName = "index.json"
+
+	// indexVersion is bumped whenever the index layout or the metadata it
+	// keeps changes, so that stale entries are fetched again.
+	indexVersion = 2
+
+	// headerBytes is how much of each photo a scan fetches. EXIF and the
+	// image dimensions sit at the start of a JPEG, so this is usually
+	// enough; when it is not, the whole photo is downloaded instead.
+	headerBytes = 256 << 10
+
+	// scanWorkers is how many photos a scan checks at once.
+	scanWorkers = 4
+
+	requestTimeout = 2 * time.Minute
+)
+
+// IsURL reports whether an album entry names a remote album rather than a
+// directory.
+func IsURL(album string) bool {
+	lower := strings.ToLower(album)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// Client loads the photos of remote albums and opens them for display. It is
+// safe for concurrent use.
+type Client struct {
+	albums []string
+	dir    string
+	http   *http.Client
+
+	// auth holds the credentials given in album URLs, by scheme and host,
+	// so the photo URLs handed out (and logged, and listed by the HTTP API)
+	// never carry a password.
+	auth map[string]*url.Userinfo
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// entry is what the index remembers about one remote photo.
+type entry struct {
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"lastModified,omitempty"`
+	Photo        photo.Photo `json:"photo"`
+}
+
+// indexFile is the on-disk layout of the index.
+type indexFile struct {
+	Version int               `json:"version"`
+	Entries map[string]*entry `json:"entries"`
+}
+
+// New returns a Client for the given album URLs, caching under stateDir. An
+// unreadable index only means the photos are fetched again.
+func New(stateDir string, albums []string) *Client {
+	c := &Client{
+		albums:  albums,
+		dir:     filepath.Join(stateDir, cacheDirName),
+		http:    &http.Client{Timeout: requestTimeout},
+		auth:    make(map[string]*url.Userinfo),
+		entries: make(map[string]*entry),
+	}
+	for _, album := range albums {
+		if u, err := url.Parse(album); err == nil && u.User != nil {
+			c.auth[u.Scheme+"://"+u.Host] = u.User
+		}
+	}
+	if err := c.loadIndex(); err != nil {
+		log.Printf("Warning: could not load remote album index: %v", err)
+	}
+	return c
+}
+
+// Load lists every remote album and returns its photos, with FilePath set to
+// the photo's URL. Albums that cannot be listed and photos that cannot be
+// read are logged and skipped.
+func (c *Client) Load() []photo.Photo {
+	var urls, albums []string
+	complete := true
+	for _, album := range c.albums {
+		listed, err := c.list(album)
+		if err != nil {
+			log.Printf("Skipping remote album %s: %v", redact(album), err)
+			complete = false
+			continue
+		}
+		urls = append(urls, listed...)
+		for range listed {
+			albums = append(albums, redact(album))
+		}
+	}
+
+	results := make([]*photo.Photo, len(urls))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for range scanWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				p, err := c.metadata(urls[i])
+				if err != nil {
+					log.Printf("Skipping %s: %v", urls[i], err)
+					continue
+				}
+				p.Album = albums[i]
+				results[i] = &p
+			}
+		}()
+	}
+	for i := range urls {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	var photos []photo.Photo
+	for _, p := range results {
+		if p != nil {
+			photos = append(photos, *p)
+		}
+	}
+
+	// Forget photos that have gone from their album, unless an album could
+	// not be listed and its photos might only be out of reach for now.
+	if complete {
+		c.prune(urls)
+	}
+	if err := c.saveIndex(); err != nil {
+		log.Printf("Warning: could not save remote album index: %v", err)
+	}
+	return photos
+}
+
+// ImageLoader returns a player.ImageLoader that streams remote photos through
+// the cache into decode and hands every other photo to local.
+func (c *Client) ImageLoader(local player.ImageLoader, decode player.StreamLoader) player.ImageLoader {
+	return func(p photo.Photo) (player.Image, error) {
+		if !IsURL(p.FilePath) {
+			return local(p)
+		}
+		r, err := c.Open(p.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		img, err := decode(r, p)
+		if err != nil {
+			return nil, err
+		}
+		// Finish the download so the photo is cached even if the decoder
+		// stopped short of the end.
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			log.Printf("Warning: could not cache %s: %v", p.FilePath, err)
+		}
+		return img, nil
+	}
+}
+
+// Open returns the photo at rawURL, from the cache when it holds a current
+// copy. Otherwise the photo is streamed from the server and cached once it
+// has been read to the end.
+func (c *Client) Open(rawURL string) (io.ReadCloser, error) {
+	if f, err := os.Open(c.cachePath(rawURL)); err == nil {
+		return f, nil
+	}
+
+	resp, err := c.get(rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetch %s: %s", rawURL, resp.Status)
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("create remote cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(c.dir, "download-*")
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("create remote cache: %w", err)
+	}
+	return &cachingReader{body: resp.Body, tmp: tmp, dest: c.cachePath(rawURL)}, nil
+}
+
+// metadata returns the metadata of the photo at rawURL, reusing the index
+// when the server reports the photo unchanged.
+func (c *Client) metadata(rawURL string) (photo.Photo, error) {
+	c.mu.Lock()
+	cached := c.entries[rawURL]
+	c.mu.Unlock()
+
+	header := http.Header{"Range": {fmt.Sprintf("bytes=0-%d", headerBytes-1)}}
+	if cached != nil {
+		if cached.ETag != "" {
+			header.Set("If-None-Match", cached.ETag)
+		} else if cached.LastModified != "" {
+			header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := c.get(rawURL, header)
+	if err != nil {
+		return photo.Photo{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if cached != nil {
+			return cached.Photo, nil
+		}
+		return photo.Photo{}, fmt.Errorf("fetch: unexpected %s", resp.Status)
+	case http.StatusOK, http.StatusPartialContent:
+	default:
+		return photo.Photo{}, fmt.Errorf("fetch: %s", resp.Status)
+	}
+
+	// The photo is new or has changed, so any cached copy is stale.
+	os.Remove(c.cachePath(rawURL))
+	modTime, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		modTime = time.Now()
+	}
+
+	var p photo.Photo
+	if resp.StatusCode == http.StatusOK {
+		// The server ignored the range and is sending the whole photo,
+		// so keep it.
+		p, err = c.metadataFromDownload(rawURL, resp, modTime)
+	} else {
+		p, err = c.metadataFromHeader(rawURL, resp.Body, modTime)
+		if err != nil {
+			// The metadata lies beyond the header, as in some RAW files.
+			resp.Body.Close()
+			p, err = c.metadataFromDownload(rawURL, nil, modTime)
+		}
+	}
+	if err != nil {
+		return photo.Photo{}, err
+	}
+	p.FilePath = rawURL
+
+	c.mu.Lock()
+	c.entries[rawURL] = &entry{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Photo:        p,
+	}
+	c.mu.Unlock()
+	return p, nil
+}
+
+// metadataFromHeader reads the metadata of rawURL from the start of the
+// photo in r.
+func (c *Client) metadataFromHeader(rawURL string, r io.Reader, modTime time.Time) (photo.Photo, error) {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return photo.Photo{}, fmt.Errorf("create remote cache: %w", err)
+	}
+	// The extension tells the decoders the format.
+	tmp, err := os.CreateTemp(c.dir, "header-*"+extension(rawURL))
+	if err != nil {
+		return photo.Photo{}, fmt.Errorf("create remote cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, io.LimitReader(r, headerBytes))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return photo.Photo{}, fmt.Errorf("fetch: %w", err)
+	}
+	// Photos without EXIF fall back to the file time, which should be the
+	// server's.
+	if err := os.Chtimes(tmp.Name(), modTime, modTime); err != nil {
+		return photo.Photo{}, err
+	}
+	return photo.ReadMetadata(tmp.Name())
+}
+
+// metadataFromDownload caches the whole photo at rawURL, reading it from
+// resp if given or else fetching it, and reads its metadata.
+func (c *Client) metadataFromDownload(rawURL string, resp *http.Response, modTime time.Time) (photo.Photo, error) {
+	var r io.ReadCloser
+	if resp != nil {
+		if err := os.MkdirAll(c.dir, 0o755); err != nil {
+			return photo.Photo{}, fmt.Errorf("create remote cache: %w", err)
+		}
+		tmp, err := os.CreateTemp(c.dir, "download-*")
+		if err != nil {
+			return photo.Photo{}, fmt.Errorf("create remote cache: %w", err)
+		}
+		r = &cachingReader{body: resp.Body, tmp: tmp, dest: c.cachePath(rawURL)}
+	} else {
+		var err error
+		if r, err = c.Open(rawURL); err != nil {
+			return photo.Photo{}, err
+		}
+	}
+	_, err := io.Copy(io.Discard, r)
+	r.Close()
+	if err != nil {
+		return photo.Photo{}, fmt.Errorf("fetch: %w", err)
+	}
+	cached := c.cachePath(rawURL)
+	if err := os.Chtimes(cached, modTime, modTime); err != nil {
+		return photo.Photo{}, err
+	}
+	return photo.ReadMetadata(cached)
+}
+
+// get fetches rawURL with the extra header fields, adding the album's
+// credentials.
+func (c *Client) get(rawURL string, header http.Header) (*http.Response, error) {
+	return c.do(http.MethodGet, rawURL, header, nil)
+}
+
+func (c *Client) do(method, rawURL string, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if user := c.auth[req.URL.Scheme+"://"+req.URL.Host]; user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+	}
+	return c.http.Do(req)
+}
+
+// prune drops the index entries and cached copies of photos not in urls.
+func (c *Client) prune(urls []string) {
+	listed := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		listed[u] = true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for u := range c.entries {
+		if !listed[u] {
+			delete(c.entries, u)
+			os.Remove(c.cachePath(u))
+		}
+	}
+}
+
+// cachePath is where the cached copy of the photo at rawURL is kept: a hash
+// of the URL, keeping the extension.
+func (c *Client) cachePath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+extension(rawURL))
+}
+
+func (c *Client) loadIndex() error {
+	data, err := os.ReadFile(filepath.Join(c.dir, indexFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var f indexFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	if f.Version != indexVersion || f.Entries == nil {
+		return nil
+	}
+	c.entries = f.Entries
+	return nil
+}
+
+// saveIndex writes the index atomically.
+func (c *Client) saveIndex() error {
+	c.mu.Lock()
+	data, err := json.Marshal(indexFile{Version: indexVersion, Entries: c.entries})
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(c.dir, indexFileName)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// cachingReader copies a download into a temporary file as it is read, and
+// moves it into the cache once the end is reached. A download closed early
+// is thrown away.
+type cachingReader struct {
+	body io.ReadCloser
+	tmp  *os.File
+	dest string
+	done bool
+}
+
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if n > 0 {
+		if _, werr := r.tmp.Write(p[:n]); werr != nil {
+			return n, werr
+		}
+	}
+	if err == io.EOF && !r.done {
+		r.done = true
+		cerr := r.tmp.Close()
+		if cerr == nil {
+			cerr = os.Rename(r.tmp.Name(), r.dest)
+		}
+		if cerr != nil {
+			os.Remove(r.tmp.Name())
+			return n, cerr
+		}
+	}
+	return n, err
+}
+
+func (r *cachingReader) Close() error {
+	err := r.body.Close()
+	if !r.done {
+		r.tmp.Close()
+		os.Remove(r.tmp.Name())
+	}
+	return err
+}
+
+// extension returns the lower-cased file extension of the path in rawURL.
+func extension(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(path.Ext(u.Path))
+}
+
+// redact hides any password in rawURL for logging.
+func redact(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
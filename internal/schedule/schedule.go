@@ -0,0 +1,107 @@
+// Package schedule works out from the config's schedule when the frame is
+// on, week by week, in local time.
+package schedule
+
+import (
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/config"
+)
+
+// Hours is one day's on hours, from On to Off, both offsets from local
+// midnight. Off earlier than On runs past midnight into the next day, and
+// equal times (the zero Hours) leave the frame off all day.
+type Hours struct {
+	On, Off time.Duration
+}
+
+// Week holds the on hours of each day, indexed by time.Weekday.
+type Week [7]Hours
+
+// FromConfig converts the validated schedule. Days it does not list take
+// the daily onTime and offTime, or stay off without them. ok is false if
+// no hours are set at all.
+func FromConfig(s config.Schedule) (w Week, ok bool) {
+	daily := s.OnTime != "" && s.OffTime != ""
+	if !daily && len(s.Days) == 0 {
+		return Week{}, false
+	}
+	for day := range w {
+		times := config.DaySchedule{OnTime: s.OnTime, OffTime: s.OffTime}
+		if d, listed := s.Days[config.Weekdays[day]]; listed {
+			times = d
+		}
+		if times.OnTime == "" || times.OffTime == "" {
+			continue
+		}
+		on, _ := config.ParseTimeOfDay(times.OnTime)
+		off, _ := config.ParseTimeOfDay(times.OffTime)
+		w[day] = Hours{On: on, Off: off}
+	}
+	return w, true
+}
+
+// IsOn reports whether t falls within the on hours: today's, or
+// yesterday's where they run past midnight. Times are compared on the
+// wall clock, so a DST change moves no boundary.
+func (w Week) IsOn(t time.Time) bool {
+	tod := timeOfDay(t)
+	today, yesterday := w[t.Weekday()], w[(t.Weekday()+6)%7]
+	switch {
+	case today.On < today.Off && tod >= today.On && tod < today.Off:
+		return true
+	case today.On > today.Off && tod >= today.On:
+		return true
+	}
+	return yesterday.On > yesterday.Off && tod < yesterday.Off
+}
+
+// Next returns the first time after t at which the frame turns on or off,
+// or false if it never does. A boundary that a DST change skips, such as
+// 02:30 on the night clocks go forward, comes as the clock jumps past it.
+func (w Week) Next(t time.Time) (time.Time, bool) {
+	on := w.IsOn(t)
+	y, m, d := t.Date()
+	var next time.Time
+	// Yesterday's hours may still be running; a week on, every boundary
+	// has come round once.
+	for i := -1; i <= 7; i++ {
+		hours := w[time.Date(y, m, d+i, 0, 0, 0, 0, t.Location()).Weekday()]
+		offDay := d + i
+		if hours.Off < hours.On {
+			offDay++
+		}
+		for _, at := range []time.Time{
+			wallTime(y, m, d+i, hours.On, t.Location()),
+			wallTime(y, m, offDay, hours.Off, t.Location()),
+		} {
+			if at.After(t) && w.IsOn(at) != on && (next.IsZero() || at.Before(next)) {
+				next = at
+			}
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// wallTime returns when the clock in loc reads tod on the given day or, if
+// a DST change skips that reading, when the clock jumps past it.
+func wallTime(y int, m time.Month, d int, tod time.Duration, loc *time.Location) time.Time {
+	h, minute := int(tod/time.Hour), int(tod%time.Hour/time.Minute)
+	t := time.Date(y, m, d, h, minute, 0, 0, loc)
+	// time.Date moves a skipped reading by the size of the gap, one way
+	// or the other; the gap's edge is where the reading would have been.
+	want := time.Date(y, m, d, h, minute, 0, 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	switch start, end := t.ZoneBounds(); {
+	case got.Before(want):
+		return end
+	case got.After(want):
+		return start
+	}
+	return t
+}
+
+// timeOfDay is t's wall-clock offset from midnight.
+func timeOfDay(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
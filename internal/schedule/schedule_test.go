@@ -0,0 +1,111 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/config"
+)
+
+// eveningsAndWeekends is on weekday evenings, until 1am on Friday night,
+// and all day at weekends.
+var eveningsAndWeekends = config.Schedule{
+	OnTime:  "17:00",
+	OffTime: "23:00",
+	Days: map[string]config.DaySchedule{
+		"friday":   {OnTime: "17:00", OffTime: "01:00"},
+		"saturday": {OnTime: "08:00", OffTime: "23:00"},
+		"sunday":   {OnTime: "08:00", OffTime: "22:00"},
+	},
+}
+
+func TestWeekIsOn(t *testing.T) {
+	week, ok := FromConfig(eveningsAndWeekends)
+	if !ok {
+		t.Fatal("FromConfig() = false, want a schedule")
+	}
+	// 2024-06-03 is a Monday.
+	tests := []struct {
+		at   string
+		want bool
+	}{
+		{at: "2024-06-03 12:00", want: false},
+		{at: "2024-06-03 17:00", want: true},
+		{at: "2024-06-03 22:59", want: true},
+		{at: "2024-06-03 23:00", want: false},
+		{at: "2024-06-08 00:30", want: true}, // Friday night
+		{at: "2024-06-08 01:00", want: false},
+		{at: "2024-06-08 09:00", want: true}, // Saturday
+		{at: "2024-06-09 22:30", want: false},
+		{at: "2024-06-10 09:00", want: false}, // Monday morning
+	}
+	for _, tt := range tests {
+		at, err := time.ParseInLocation("2006-01-02 15:04", tt.at, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := week.IsOn(at); got != tt.want {
+			t.Errorf("IsOn(%s %s) = %t, want %t", at.Weekday(), tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestWeekNext(t *testing.T) {
+	week, _ := FromConfig(eveningsAndWeekends)
+	tests := []struct {
+		from, want string
+	}{
+		{from: "2024-06-03 12:00", want: "2024-06-03 17:00"},
+		{from: "2024-06-03 17:00", want: "2024-06-03 23:00"},
+		{from: "2024-06-07 20:00", want: "2024-06-08 01:00"}, // Friday runs late
+		{from: "2024-06-08 02:00", want: "2024-06-08 08:00"},
+		{from: "2024-06-09 23:00", want: "2024-06-10 17:00"},
+	}
+	for _, tt := range tests {
+		from, _ := time.ParseInLocation("2006-01-02 15:04", tt.from, time.UTC)
+		next, ok := week.Next(from)
+		if got := next.Format("2006-01-02 15:04"); !ok || got != tt.want {
+			t.Errorf("Next(%s) = %s, %t, want %s", tt.from, got, ok, tt.want)
+		}
+	}
+}
+
+func TestWeekNextKeepsWallClockAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+	week, _ := FromConfig(config.Schedule{OnTime: "02:30", OffTime: "07:00"})
+	// Clocks go forward from 02:00 to 03:00 on 2024-03-10, skipping 02:30.
+	from := time.Date(2024, 3, 9, 12, 0, 0, 0, loc)
+	next, _ := week.Next(from)
+	if want := time.Date(2024, 3, 10, 3, 0, 0, 0, loc); !next.Equal(want) {
+		t.Errorf("Next() over the gap = %v, want %v", next, want)
+	}
+	// The off time stays at 07:00 local, 4 hours later.
+	if off, _ := week.Next(next); off.Sub(next) != 4*time.Hour {
+		t.Errorf("Next() after the gap = %v, want 07:00", off)
+	}
+	// Clocks go back on 2024-11-03; 07:00 is still 07:00.
+	from = time.Date(2024, 11, 3, 3, 0, 0, 0, loc)
+	if off, _ := week.Next(from); off.Hour() != 7 || off.Minute() != 0 {
+		t.Errorf("Next() after fall back = %v, want 07:00", off)
+	}
+}
+
+func TestFromConfigWithoutHours(t *testing.T) {
+	if _, ok := FromConfig(config.Schedule{OnTime: "06:00"}); ok {
+		t.Error("FromConfig() with only onTime = true, want false")
+	}
+	// A day listed without times stays off, even with daily times.
+	week, ok := FromConfig(config.Schedule{OnTime: "06:00", OffTime: "22:00", Days: map[string]config.DaySchedule{"monday": {}}})
+	if !ok {
+		t.Fatal("FromConfig() = false, want a schedule")
+	}
+	if week[time.Monday] != (Hours{}) || week[time.Tuesday] != (Hours{On: 6 * time.Hour, Off: 22 * time.Hour}) {
+		t.Errorf("FromConfig() = %v, want Monday off and Tuesday 06:00 to 22:00", week)
+	}
+	if _, ok := week.Next(time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)); !ok {
+		t.Error("Next() = false, want Tuesday's on time")
+	}
+}
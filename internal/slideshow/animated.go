@@ -0,0 +1,118 @@
+package slideshow
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// maxAnimationPixels caps the pixels of all frames of a GIF together
+	// (256 MB of textures); bigger animations show only their first frame.
+	maxAnimationPixels = 64 << 20
+
+	// defaultFrameDelay stands in for the near-zero delays some GIFs carry,
+	// as browsers do.
+	defaultFrameDelay = 100 * time.Millisecond
+)
+
+// animationFrame is one composed frame of an animated GIF.
+type animationFrame struct {
+	tiles []*ebiten.Image
+	delay time.Duration
+}
+
+func isGIF(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".gif")
+}
+
+// loadAnimatedGIF decodes every frame of the GIF in r; name is used in
+// messages. It returns nil without error for a GIF that should be shown as a
+// still: one with a single frame, or one too large to keep every frame in
+// memory.
+func loadAnimatedGIF(r io.Reader, name string) (*TiledImage, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode image %s: %w", name, err)
+	}
+	if len(g.Image) <= 1 {
+		return nil, nil
+	}
+	w, h := g.Config.Width, g.Config.Height
+	if w*h*len(g.Image) > maxAnimationPixels {
+		log.Printf("%s: %d frames of %dx%d is too large to animate; showing the first frame", name, len(g.Image), w, h)
+		return nil, nil
+	}
+
+	// Frames only carry the pixels that changed, so compose each onto a
+	// canvas, honouring the disposal method before drawing the next.
+	canvas := image.NewRGBA(image.Rect(0, 0, w, h))
+	frames := make([]animationFrame, 0, len(g.Image))
+	var edge color.Color
+	for i, frame := range g.Image {
+		disposal := byte(gif.DisposalNone)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(canvas.Rect)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		delay := defaultFrameDelay
+		if i < len(g.Delay) && g.Delay[i] > 1 {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		frames = append(frames, animationFrame{tiles: tileImage(canvas), delay: delay})
+		if i == 0 {
+			edge = sampleEdgeColor(canvas)
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return &TiledImage{
+		tiles:       frames[0].tiles,
+		totalWidth:  w,
+		totalHeight: h,
+		frames:      frames,
+		start:       time.Now(),
+		edgeColor:   edge,
+	}, nil
+}
+
+// animate selects the frame of an animated image due at now, looping for as
+// long as the slide stays up. Stills are left alone.
+func (t *TiledImage) animate(now time.Time) {
+	if len(t.frames) == 0 {
+		return
+	}
+	var total time.Duration
+	for _, f := range t.frames {
+		total += f.delay
+	}
+	elapsed := now.Sub(t.start) % total
+	for _, f := range t.frames {
+		if elapsed < f.delay {
+			t.tiles = f.tiles
+			return
+		}
+		elapsed -= f.delay
+	}
+}
@@ -0,0 +1,36 @@
+package slideshow
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ClockOptions configures the live clock overlay.
+type ClockOptions struct {
+	Enabled   bool
+	Position  Position
+	Use12Hour bool
+	ShowDate  bool // add a second line with the weekday and date
+}
+
+// clockDateFormat is the date the clock shows below the time.
+const clockDateFormat = "Mon Jan 2"
+
+// drawClockOverlay renders now at the clock's position, or wherever layout
+// moves it.
+func drawClockOverlay(screen *ebiten.Image, layout *overlayLayout, now time.Time, opts ClockOptions) {
+	msg := formatClock(now, opts.Use12Hour)
+	if opts.ShowDate {
+		msg += "\n" + now.Format(clockDateFormat)
+	}
+	drawOverlay(screen, layout, msg, OverlayOptions{Enabled: opts.Enabled, Position: opts.Position})
+}
+
+// formatClock returns the time of day of now as the clock shows it.
+func formatClock(now time.Time, use12Hour bool) string {
+	if use12Hour {
+		return now.Format("3:04 PM")
+	}
+	return now.Format("15:04")
+}
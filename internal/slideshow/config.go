@@ -0,0 +1,89 @@
+package slideshow
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/config"
+	"github.com/electronjoe/OpenFrame/internal/player"
+	"github.com/electronjoe/OpenFrame/internal/schedule"
+)
+
+// OptionsFromConfig returns the Options cfg, already validated, asks for.
+// The QR code's Link, the map overlay's Map and Stop are left for the caller
+// to set.
+func OptionsFromConfig(cfg config.Config) Options {
+	// Validate has already checked the colors.
+	backgroundColor, _ := config.ParseColor(cfg.BackgroundColor)
+	progressColor, _ := config.ParseColor(cfg.ProgressColor)
+	titleIgnore, _ := regexp.Compile(cfg.FilenameTitleIgnore)
+	// Slides are laid out for the screen as it is mounted.
+	width, height := player.RotatedSize(cfg.DisplayWidth, cfg.DisplayHeight, cfg.Rotate)
+	return Options{
+		Width:    width,
+		Height:   height,
+		Date:     overlay(cfg, "date"),
+		Location: overlay(cfg, "location"),
+		Caption:  overlay(cfg, "caption"),
+		Title:    TitleOptions{OverlayOptions: overlay(cfg, "title"), Ignore: titleIgnore},
+		Camera:   overlay(cfg, "camera"),
+		Counter:  overlay(cfg, "counter"),
+		QR:       QROptions{OverlayOptions: overlay(cfg, "qr")},
+		Map:      MapOptions{OverlayOptions: overlay(cfg, "map")},
+
+		Background:       backgroundColor,
+		EdgeFill:         cfg.BackgroundFill == "edge",
+		MatchPairHeights: cfg.PairAlign == "height",
+		Clock: ClockOptions{
+			Enabled:   cfg.Overlays["clock"].Enabled,
+			Position:  Position(cfg.Overlays["clock"].Position),
+			Use12Hour: cfg.ClockOverlay.Format == "12h",
+			ShowDate:  cfg.ClockOverlay.ShowDate,
+		},
+		Progress: ProgressOptions{
+			Enabled:  cfg.Overlays["progress"].Enabled,
+			Color:    progressColor,
+			Height:   cfg.ProgressHeight,
+			Position: Position(cfg.Overlays["progress"].Position),
+		},
+		NightDim: nightDim(cfg.DimSchedule),
+		Standby:  standby(cfg),
+		Transition: TransitionOptions{
+			Name:     cfg.Transition,
+			Duration: time.Duration(cfg.TransitionMs) * time.Millisecond,
+		},
+		FadeIn: time.Duration(max(cfg.FadeInMs, 0)) * time.Millisecond,
+		Drift:  cfg.SinglePhotoKenBurns,
+		MaxFPS: cfg.MaxFPS,
+		Mirror: Mirror(cfg.Mirror),
+		Rotate: cfg.Rotate,
+		Pause: PauseOptions{
+			Dim:           float64(cfg.PauseDim) / 100,
+			HideIndicator: cfg.HidePauseIndicator,
+		},
+	}
+}
+
+// overlay converts the named entry of the config's overlays map.
+func overlay(cfg config.Config, name string) OverlayOptions {
+	o := cfg.Overlays[name]
+	return OverlayOptions{Enabled: o.Enabled, Position: Position(o.Position)}
+}
+
+// standby converts the standby mode, with the schedule's off hours when it
+// sets any on hours.
+func standby(cfg config.Config) StandbyOptions {
+	opts := StandbyOptions{Mode: StandbyMode(cfg.StandbyMode)}
+	opts.Hours, opts.OffHours = schedule.FromConfig(cfg.Schedule)
+	return opts
+}
+
+// nightDim converts the validated dimSchedule for the slideshow.
+func nightDim(d config.DimSchedule) NightDimOptions {
+	if !d.Enabled() {
+		return NightDimOptions{}
+	}
+	start, _ := config.ParseTimeOfDay(d.Start)
+	end, _ := config.ParseTimeOfDay(d.End)
+	return NightDimOptions{Enabled: true, Start: start, End: end, Brightness: d.Brightness}
+}
@@ -1,6 +1,7 @@
 package slideshow
 
 import (
+    "fmt"
     "image/color"
     "math"
     "time"
@@ -8,36 +9,113 @@ import (
     "github.com/hajimehoshi/ebiten/v2"
     "github.com/hajimehoshi/ebiten/v2/ebitenutil"
     "github.com/hajimehoshi/ebiten/v2/text"
+    "github.com/hajimehoshi/ebiten/v2/vector"
     "golang.org/x/image/font/basicfont"
+
+    "github.com/electronjoe/OpenFrame/internal/player"
 )
 
 // drawDebugString prints text in the top-left corner of the screen.
 // Used for errors and debug messages.
-func drawDebugString(screen *ebiten.Image, msg string) {
-    screen.Fill(color.RGBA{0, 0, 0, 255}) // Clear to black
+func drawDebugString(screen *ebiten.Image, msg string, background color.Color) {
+    screen.Fill(background)
     ebitenutil.DebugPrint(screen, msg)
 }
 
+// drawStandbyMessage shows msg centered on a black screen, enlarged so it can
+// be read from across the room.
+func drawStandbyMessage(screen *ebiten.Image, msg string, background color.Color) {
+    screen.Fill(background)
+    _, sh := screen.Size()
+    drawCenteredText(screen, msg, 3, float64(sh)/2)
+}
+
+// drawTitleCard introduces a day's photos with its date and photo count.
+func drawTitleCard(screen *ebiten.Image, card *player.TitleCard, background color.Color) {
+    screen.Fill(background)
+    _, sh := screen.Size()
+    drawCenteredText(screen, card.Date.Format("Monday, 2 January 2006"), 6, float64(sh)/2-60)
+
+    count := fmt.Sprintf("%d photos", card.Photos)
+    if card.Photos == 1 {
+        count = "1 photo"
+    }
+    drawCenteredText(screen, count, 3, float64(sh)/2+60)
+}
+
+// drawCenteredText draws msg in white, scaled up from the basic font and
+// centered horizontally with its middle at y.
+func drawCenteredText(screen *ebiten.Image, msg string, scale, y float64) {
+    face := basicfont.Face7x13
+    bounds := text.BoundString(face, msg)
+    if bounds.Empty() {
+        return
+    }
+
+    textImg := ebiten.NewImage(bounds.Dx(), bounds.Dy())
+    defer textImg.Dispose()
+    text.Draw(textImg, msg, face, -bounds.Min.X, -bounds.Min.Y, color.White)
+
+    sw, _ := screen.Size()
+    op := &ebiten.DrawImageOptions{}
+    op.GeoM.Scale(scale, scale)
+    op.GeoM.Translate(
+        (float64(sw)-float64(bounds.Dx())*scale)/2,
+        y-float64(bounds.Dy())*scale/2,
+    )
+    screen.DrawImage(textImg, op)
+}
+
 // drawSlide is the main function for rendering the current slide,
 // which may have 1 or 2 photos (represented by up to 2 TiledImages).
-func drawSlide(screen *ebiten.Image, slide Slide, tiledImages []*TiledImage, dateOverlay bool) {
-    screen.Fill(color.RGBA{0, 0, 0, 255}) // Clear to black
+// With edgeFill each photo's letterbox takes its edge colour instead of
+// background, and with matchHeights a side-by-side pair is drawn at one
+// common height. A stacked pair is drawn one above the other instead.
+func drawSlide(screen *ebiten.Image, tiledImages []*TiledImage, background color.Color, edgeFill, matchHeights, stacked bool) {
+    fillLetterbox(screen, tiledImages, background, edgeFill, stacked)
 
     if len(tiledImages) == 1 {
         // Single-photo slide
         drawSingleImage(screen, tiledImages[0])
-        if dateOverlay && len(slide.Photos) == 1 {
-            drawDateOverlayLeft(screen, slide.Photos[0].TakenTime)
-        }
+    } else if len(tiledImages) == 2 && stacked {
+        drawTwoLandscapesStacked(screen, tiledImages[0], tiledImages[1])
     } else if len(tiledImages) == 2 {
         // Two-photo slide
-        drawTwoPortraitsSideBySide(screen, tiledImages[0], tiledImages[1])
+        drawTwoPortraitsSideBySide(screen, tiledImages[0], tiledImages[1], matchHeights)
+    }
+}
 
-        // Draw date overlays bottom-left and bottom-right
-        if dateOverlay && len(slide.Photos) == 2 {
-            drawDateOverlayLeft(screen, slide.Photos[0].TakenTime)
-            drawDateOverlayRight(screen, slide.Photos[1].TakenTime)
+// fillLetterbox paints the screen behind the photos: background, or with
+// edgeFill each photo's sampled edge colour (each half of the screen for a
+// pair, left and right or top and bottom if stacked). A photo without one
+// falls back to background.
+func fillLetterbox(screen *ebiten.Image, tiledImages []*TiledImage, background color.Color, edgeFill, stacked bool) {
+    screen.Fill(background)
+    if !edgeFill {
+        return
+    }
+    sw, sh := screen.Size()
+    for i, t := range tiledImages {
+        if t.edgeColor == nil {
+            continue
+        }
+        if len(tiledImages) == 1 {
+            screen.Fill(t.edgeColor)
+            return
         }
+        if stacked {
+            y, h := 0, sh/2
+            if i == 1 {
+                y, h = sh/2, sh-sh/2
+            }
+            vector.DrawFilledRect(screen, 0, float32(y), float32(sw), float32(h), t.edgeColor, false)
+            continue
+        }
+        x, w := 0, sw/2
+        if i == 1 {
+            x, w = sw/2, sw-sw/2
+        }
+        vector.DrawFilledRect(screen, float32(x), 0, float32(w), float32(sh), t.edgeColor, false)
     }
 }
 
@@ -78,22 +156,19 @@ func drawSingleImage(screen *ebiten.Image, t *TiledImage) {
 // drawTwoPortraitsSideBySide draws two portrait TiledImages (leftImg and rightImg)
 // side by side on the given Ebiten screen. Each image is scaled independently
 // so that it fits within half the screen’s width (and the full screen height)
-// while retaining its aspect ratio. The left image is centered in the left half,
-// and the right image is centered in the right half, maximizing each image’s size
-// without overflowing their respective half of the screen.
-func drawTwoPortraitsSideBySide(screen *ebiten.Image, leftImg, rightImg *TiledImage) {
+// while retaining its aspect ratio, or with matchHeights both are scaled to one
+// common height, the largest at which neither overflows its half. The left image
+// is centered in the left half, and the right image is centered in the right half.
+func drawTwoPortraitsSideBySide(screen *ebiten.Image, leftImg, rightImg *TiledImage, matchHeights bool) {
     sw, sh := screen.Size()
 
     // Original dimensions
     lw, lh := leftImg.totalWidth, leftImg.totalHeight
     rw, rh := rightImg.totalWidth, rightImg.totalHeight
 
-    // Separate scale factors: each must fit in sw/2 x sh
-    leftScale := computeScale(lw, lh, sw/2, sh)
+    leftScale, rightScale := pairScales(lw, lh, rw, rh, sw, sh, matchHeights)
     scaledLW := float64(lw) * leftScale
     scaledLH := float64(lh) * leftScale
-
-    rightScale := computeScale(rw, rh, sw/2, sh)
     scaledRW := float64(rw) * rightScale
     scaledRH := float64(rh) * rightScale
 
@@ -109,6 +184,33 @@ func drawTwoPortraitsSideBySide(screen *ebiten.Image, leftImg, rightImg *TiledIm
     drawTiledImage(screen, rightImg, rightScale, rightX, rightY)
 }
 
+// drawTwoLandscapesStacked draws two landscape TiledImages one above the
+// other, each scaled to fit the full width and half the height of the screen
+// and centered in its half.
+func drawTwoLandscapesStacked(screen *ebiten.Image, topImg, bottomImg *TiledImage) {
+    sw, sh := screen.Size()
+    for i, t := range []*TiledImage{topImg, bottomImg} {
+        scale := computeScale(t.totalWidth, t.totalHeight, sw, sh/2)
+        x := (float64(sw) - float64(t.totalWidth)*scale) / 2
+        y := float64(i*sh)/2 + (float64(sh)/2-float64(t.totalHeight)*scale)/2
+        drawTiledImage(screen, t, scale, x, y)
+    }
+}
+
+// pairScales returns the scale factors for a side-by-side pair of lw x lh and
+// rw x rh images on an sw x sh screen. Each must fit in sw/2 x sh; with
+// matchHeights they also share one displayed height.
+func pairScales(lw, lh, rw, rh, sw, sh int, matchHeights bool) (leftScale, rightScale float64) {
+    if !matchHeights || lw == 0 || lh == 0 || rw == 0 || rh == 0 {
+        return computeScale(lw, lh, sw/2, sh), computeScale(rw, rh, sw/2, sh)
+    }
+    // The tallest height at which both widths still fit their half.
+    height := float64(sh)
+    height = math.Min(height, float64(sw/2)*float64(lh)/float64(lw))
+    height = math.Min(height, float64(sw/2)*float64(rh)/float64(rw))
+    return height / float64(lh), height / float64(rh)
+}
+
 // Helper that draws a TiledImage at (offsetX, offsetY) using the given scale.
 func drawTiledImage(screen *ebiten.Image, t *TiledImage, scale, offsetX, offsetY float64) {
     tileIndex := 0
@@ -175,75 +277,71 @@ func drawTiledImageWithOffset(screen *ebiten.Image, t *TiledImage, scale float64
     }
 }
 
-// drawPauseIndicator places Pause notification text at top left of the screen.
-func drawPauseIndicator(screen *ebiten.Image) {
-    text.Draw(screen, "Slideshow Paused", basicfont.Face7x13, 20, 30, color.White)
-}
+// drawProgressBar fills a strip along the bottom (or top) edge in proportion to
+// how much of the slide interval has elapsed.
+func drawProgressBar(screen *ebiten.Image, elapsed, interval time.Duration, opts ProgressOptions) {
+    if interval <= 0 || opts.Height <= 0 {
+        return
+    }
+    fraction := math.Min(math.Max(float64(elapsed)/float64(interval), 0), 1)
 
-// drawDateOverlayLeft rotates the date 90° CCW and places it near the bottom-left edge.
-func drawDateOverlayLeft(screen *ebiten.Image, takenTime time.Time) {
-    dateStr := takenTime.Format("2006-01-02")
-    drawVerticalText(screen, dateStr, true)
+    sw, sh := screen.Size()
+    width := float32(fraction * float64(sw))
+    height := float32(opts.Height)
+    y := float32(sh) - height
+    if opts.Position == Top {
+        y = 0
+    }
+    vector.DrawFilledRect(screen, 0, y, width, height, opts.Color, false)
 }
 
-// drawDateOverlayRight rotates the date 90° CCW and places it near the bottom-right edge.
-func drawDateOverlayRight(screen *ebiten.Image, takenTime time.Time) {
-    dateStr := takenTime.Format("2006-01-02")
-    drawVerticalText(screen, dateStr, false)
+// drawDimmer darkens the whole screen by amount, from 0 (unchanged) to 1
+// (black).
+func drawDimmer(screen *ebiten.Image, amount float64) {
+    if amount <= 0 {
+        return
+    }
+    sw, sh := screen.Size()
+    shade := color.NRGBA{A: uint8(math.Min(amount, 1) * 255)}
+    vector.DrawFilledRect(screen, 0, 0, float32(sw), float32(sh), shade, false)
 }
 
-// drawVerticalText creates a small offscreen image of the date text, then rotates it 90° CCW
-// and draws it at the screen edge (left if `isLeftEdge`, right otherwise).
-func drawVerticalText(screen *ebiten.Image, textStr string, isLeftEdge bool) {
-    face := basicfont.Face7x13
-
-    // Measure the text in its normal orientation.
-    bounds := text.BoundString(face, textStr)
-    textWidth := bounds.Dx()
-    textHeight := bounds.Dy()
+// drawDeletePrompt asks for the second delete press in a band across the
+// middle of the screen.
+func drawDeletePrompt(screen *ebiten.Image, photos int) {
+    msg := "Press delete again to move this photo to the trash"
+    if photos > 1 {
+        msg = "Press delete again to move both photos to the trash"
+    }
 
-    // Create an offscreen image big enough for the text.
-    textImg := ebiten.NewImage(textWidth, textHeight)
-    // Optional: fill a semi-transparent background if desired:
-    // textImg.Fill(color.RGBA{0, 0, 0, 128})
+    const scale = 2
+    face := basicfont.Face7x13
+    bounds := text.BoundString(face, msg)
+    textImg := ebiten.NewImage(bounds.Dx(), bounds.Dy())
+    defer textImg.Dispose()
+    text.Draw(textImg, msg, face, -bounds.Min.X, -bounds.Min.Y, color.White)
 
-    // Draw the text in normal (horizontal) orientation at top-left of the offscreen.
-    // We typically draw so the text baseline is near the bottom of that offscreen rect:
-    text.Draw(textImg, textStr, face, 0, textHeight-2, color.White)
+    sw, sh := screen.Size()
+    bandHeight := float32(bounds.Dy()*scale + 40)
+    vector.DrawFilledRect(screen, 0, (float32(sh)-bandHeight)/2, float32(sw), bandHeight, color.NRGBA{R: 120, A: 220}, false)
 
-    // Now we set up our transformation to rotate 90° CCW.
-    // 90° CCW is -π/2 radians.
     op := &ebiten.DrawImageOptions{}
+    op.GeoM.Scale(scale, scale)
+    op.GeoM.Translate(
+        (float64(sw)-float64(bounds.Dx())*scale)/2,
+        (float64(sh)-float64(bounds.Dy())*scale)/2,
+    )
+    screen.DrawImage(textImg, op)
+}
 
-    // First, translate so the image center is at the origin (0,0).
-    op.GeoM.Translate(-float64(textWidth)/2, -float64(textHeight)/2)
-
-    // Rotate 90° counter-clockwise.
-    op.GeoM.Rotate(-math.Pi / 2)
-
-    // We’ll place the resulting, rotated image along the appropriate screen edge.
-    screenW, screenH := screen.Size()
-    margin := 20.0
-
-    // After rotation:
-    // - The "width" of the text (in the new orientation) will be textHeight.
-    // - The "height" of the text (in the new orientation) will be textWidth.
-
-    if isLeftEdge {
-        // For the left edge, x is margin + half of new image width,
-        // so that the left side lines up near margin. We want the bottom of text near screen bottom.
-        finalX := margin + float64(textHeight)/2
-        finalY := float64(screenH) - margin - float64(textWidth)/2
-
-        op.GeoM.Translate(finalX, finalY)
-    } else {
-        // For the right edge, x is (screenW - margin - half of new image width).
-        finalX := float64(screenW) - margin - float64(textHeight)/2
-        finalY := float64(screenH) - margin - float64(textWidth)/2
-
-        op.GeoM.Translate(finalX, finalY)
-    }
+// drawPauseIndicator places Pause notification text at top left of the screen,
+// or wherever layout moves it.
+func drawPauseIndicator(screen *ebiten.Image, layout *overlayLayout) {
+    drawOverlay(screen, layout, "Slideshow Paused", OverlayOptions{Enabled: true, Position: TopLeft})
+}
 
-    // Finally, draw the rotated text onto the main screen.
-    screen.DrawImage(textImg, op)
+// drawHoldIndicator shows that the slide on screen is held, at top left or
+// the next free position.
+func drawHoldIndicator(screen *ebiten.Image, layout *overlayLayout) {
+    drawOverlay(screen, layout, "Photo Held", OverlayOptions{Enabled: true, Position: TopLeft})
 }
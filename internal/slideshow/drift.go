@@ -0,0 +1,49 @@
+package slideshow
+
+import (
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// driftPeriod is how long a drifting slide takes to zoom in, pan across and
+// come back out, slow enough to look still at a glance.
+const driftPeriod = 2 * time.Minute
+
+// drifting reports whether the slide on screen should drift: Drift is on and
+// it is the only slide.
+func (g *SlideshowGame) drifting() bool {
+	if !g.drift {
+		return false
+	}
+	_, total := g.SlidePosition()
+	return total == 1
+}
+
+// makeDriftTarget creates the offscreen target a drifting slide is drawn
+// into, the size of screen, on first use.
+func (g *SlideshowGame) makeDriftTarget(screen *ebiten.Image) *ebiten.Image {
+	if g.driftTarget == nil {
+		g.driftTarget = ebiten.NewImage(screen.Bounds().Dx(), screen.Bounds().Dy())
+	}
+	return g.driftTarget
+}
+
+// drawDrift draws slide zoomed in by up to kenBurnsZoom and panned within
+// what the zoom crops, so the edges never show, as far through the cycle as
+// now is. The cycle follows the wall clock, so it carries on smoothly when
+// the slide's timer restarts.
+func drawDrift(screen, slide *ebiten.Image, now time.Time) {
+	phase := 2 * math.Pi * float64(now.UnixNano()%int64(driftPeriod)) / float64(driftPeriod)
+	w, h := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
+	scale := 1 + kenBurnsZoom*(1-math.Cos(phase))/2
+	slack := (scale - 1) * w / 2
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-w/2, -h/2)
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(w/2+slack*math.Sin(phase), h/2)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(slide, op)
+}
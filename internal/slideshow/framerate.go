@@ -0,0 +1,84 @@
+package slideshow
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// setTickRate drops Ebiten to maxFPS ticks a second while nothing on screen
+// moves and restores the full rate for animations. Remote commands are read
+// on every tick, so they are still handled at the lower rate.
+func (g *SlideshowGame) setTickRate() {
+	if g.maxFPS <= 0 {
+		return
+	}
+	tps := g.maxFPS
+	if g.animating() {
+		tps = ebiten.DefaultTPS
+	}
+	if ebiten.TPS() != tps {
+		ebiten.SetTPS(tps)
+	}
+}
+
+// animating reports whether the screen is about to change without a slide
+// change: a transition or pause fade is under way or due within a tick, the
+// first slide is fading in, a message is fading out, or an animated GIF is
+// up.
+func (g *SlideshowGame) animating() bool {
+	target := 0.0
+	if g.Paused() {
+		target = 1
+	}
+	if g.dimLevel != target {
+		return true
+	}
+
+	if g.transition.Duration > 0 && (g.transition.Name == "random" || transitions[g.transition.Name] != nil) {
+		elapsed, interval := g.SlideTiming()
+		if _, _, _, ok := g.PreviousSlide(); ok && elapsed < g.transition.Duration {
+			return true
+		}
+		// Speed up ahead of an automatic change, so that its transition
+		// starts smoothly.
+		tick := time.Second / time.Duration(g.maxFPS)
+		if !g.Paused() && !g.Held() && interval-elapsed < tick {
+			return true
+		}
+	}
+
+	if g.fadingIn(time.Now()) {
+		return true
+	}
+
+	if g.toastsFading(time.Now()) {
+		return true
+	}
+
+	_, images, ok := g.CurrentSlide()
+	if !ok {
+		return false
+	}
+	for _, img := range images {
+		if t, ok := img.(*TiledImage); ok && len(t.frames) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// frameDue reports whether Draw should draw a frame. With a frame rate cap
+// the screen is kept from one frame to the next and only redrawn after an
+// Update, so that Ebiten skips presenting the frames in between.
+func (g *SlideshowGame) frameDue(screen *ebiten.Image) bool {
+	if g.maxFPS <= 0 {
+		return true
+	}
+	if !g.updated {
+		return false
+	}
+	g.updated = false
+	screen.Clear()
+	return true
+}
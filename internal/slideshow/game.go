@@ -1,7 +1,11 @@
 package slideshow
 
 import (
-    "errors"
+    "fmt"
+    "image"
+    "image/color"
+    "io"
+    "math"
     "time"
 
     "github.com/hajimehoshi/ebiten/v2"
@@ -9,208 +13,477 @@ import (
 
     "github.com/electronjoe/OpenFrame/internal/cec"
     "github.com/electronjoe/OpenFrame/internal/photo"
+    "github.com/electronjoe/OpenFrame/internal/player"
+    "github.com/electronjoe/OpenFrame/internal/thumbnail"
 )
 
-// Slide holds up to two photos to be displayed side-by-side if both are portrait.
-type Slide struct {
-    Photos []photo.Photo // either 1 or 2 Photos
-}
-
-// BuildSlidesFromPhotos takes a set of photos and merges consecutive portraits
-// into one Slide if side-by-side is desired.
-func BuildSlidesFromPhotos(photos []photo.Photo) []Slide {
-    var slides []Slide
-    i := 0
-    for i < len(photos) {
-        current := photos[i]
-        // Attempt to pair with next if it exists, both are portrait, etc.
-        if i+1 < len(photos) {
-            next := photos[i+1]
-            if isPortrait(current) && isPortrait(next) && displayAllowsSideBySide() {
-                slides = append(slides, Slide{Photos: []photo.Photo{current, next}})
-                i += 2
-                continue
-            }
-        }
-        slides = append(slides, Slide{Photos: []photo.Photo{current}})
-        i++
-    }
-    return slides
+// SlideshowGame renders a player.Player with Ebiten. The player owns the
+// slides, navigation and timing; this type only adds drawing. Both belong to
+// Ebiten's game loop: other goroutines go through the player's command
+// channel and Status, as the player package describes.
+type SlideshowGame struct {
+    *player.Player
+
+    width      int
+    height     int
+    date       OverlayOptions
+    location   OverlayOptions
+    caption    OverlayOptions
+    title      TitleOptions
+    camera     OverlayOptions
+    counter    OverlayOptions
+    qr         QROptions
+    mapOverlay MapOptions
+    background color.Color
+    edgeFill   bool
+    matchPairs bool
+    clock      ClockOptions
+    progress   ProgressOptions
+    pause      PauseOptions
+    nightDim   NightDimOptions
+    standby    StandbyOptions
+    transition TransitionOptions
+    fadeIn     time.Duration
+    drift      bool
+    maxFPS     int
+    mirror     Mirror
+    rotate     int
+    stop       <-chan struct{}
+
+    // Offscreen targets for compositing the two slides of a transition,
+    // created on first use.
+    transitionFrom, transitionTo *ebiten.Image
+    // The effect picked for the change at transitionStart, for "random".
+    transitionStart time.Time
+    transitionName  string
+    // driftTarget holds a lone slide for drawDrift to zoom, created on
+    // first use.
+    driftTarget *ebiten.Image
+
+    // fadeInPending is set until a slide is drawn, and again by the error
+    // and standby screens. The next slide drawn, the one put up at
+    // fadeInSlide, then fades in from when it was first drawn, fadeInStart;
+    // the window may take a while to open after the first slide is loaded.
+    fadeInPending bool
+    fadeInSlide   time.Time
+    fadeInStart   time.Time
+
+    // dimLevel fades between 0 (playing) and 1 (fully dimmed for pause).
+    dimLevel float64
+
+    // The QR code for the slide at qrPaths; qrMade is set once it has
+    // been made, even if the slide has no code.
+    qrImage *ebiten.Image
+    qrPaths []string
+    qrMade  bool
+
+    // Likewise the map for the slide at mapPaths.
+    mapImg   *ebiten.Image
+    mapPaths []string
+    mapMade  bool
+
+    // The offscreen frame a mirrored or rotated screen is drawn into,
+    // created on first use.
+    frame *ebiten.Image
+
+    // Messages from Notify still on screen, oldest first.
+    toasts []toast
+
+    // updated is set by Update and cleared by the Draw after it, when the
+    // frame rate is capped.
+    updated bool
 }
 
-// isPortrait is a simple check: height > width (assuming it's stored in photo.Photo).
-func isPortrait(p photo.Photo) bool {
-    return p.Height > p.Width
+// Options configures how a SlideshowGame draws its slides.
+type Options struct {
+    // Width and Height are the logical screen size everything is drawn at
+    // before it is rotated and Ebiten scales it to the display; zero means
+    // 1920x1080.
+    Width, Height int
+    // Date, Location and Caption show the photo's date, where it was taken
+    // and its caption. Overlays asking for a position already taken that
+    // frame move to the next free one clockwise.
+    Date     OverlayOptions
+    Location OverlayOptions
+    Caption  OverlayOptions
+    // Title shows a title made from the file name of each photo without a
+    // caption.
+    Title TitleOptions
+    // Camera shows the camera, lens and exposure settings from EXIF.
+    Camera OverlayOptions
+    // Counter shows the slide's place in the slideshow, e.g. "42 / 1200".
+    Counter OverlayOptions
+    // QR shows a QR code linking to the photo on screen.
+    QR QROptions
+    // Map shows a small map of where the photo on screen was taken.
+    Map MapOptions
+    // Background fills the screen around photos; nil means black.
+    Background color.Color
+    // EdgeFill fills the bars around each photo with the average colour of
+    // its border instead, falling back to Background.
+    EdgeFill bool
+    // MatchPairHeights draws the two photos of a side-by-side slide at the
+    // same height rather than each as large as its half allows.
+    MatchPairHeights bool
+    Clock            ClockOptions
+    Progress         ProgressOptions
+    Pause            PauseOptions
+    NightDim         NightDimOptions
+    Standby          StandbyOptions
+    // Transition animates slide changes; the player must be created with
+    // player.Options.KeepPrevious for it to take effect.
+    Transition TransitionOptions
+    // FadeIn fades the first slide, and the first after the error or
+    // standby screen, in from black over that long; zero turns it off.
+    FadeIn time.Duration
+    // Drift slowly zooms and pans across the photo when the slideshow has
+    // only one slide, so that it is not a still.
+    Drift bool
+    // MaxFPS caps how many times a second the game updates and redraws
+    // while nothing is animating; zero leaves Ebiten at its default.
+    MaxFPS int
+    // Mirror flips everything drawn, overlays included; "" is MirrorNone.
+    Mirror Mirror
+    // Rotate turns everything drawn clockwise by 0, 90, 180 or 270
+    // degrees. A quarter turn makes the screen Height x Width.
+    Rotate int
+    // Stop ends the game loop when closed, as ESC does.
+    Stop <-chan struct{}
 }
 
-// For simplicity, assume we generally allow side-by-side (e.g. 16:9 display).
-func displayAllowsSideBySide() bool {
-    return true
+// PauseOptions configures how a paused slideshow looks.
+type PauseOptions struct {
+    // Dim darkens the screen while paused, from 0 (not at all) to 1 (black).
+    Dim float64
+    // HideIndicator drops the "Slideshow Paused" label.
+    HideIndicator bool
 }
 
-// SlideshowGame holds the state of our slideshow, including the slides, indexes, etc.
-type SlideshowGame struct {
-    slides            []Slide
-    currentIndex      int
-    currentTiledImages []*TiledImage
-    loadingError      error
+// pauseFade is how long the screen takes to dim or brighten on (un)pause.
+const pauseFade = 500 * time.Millisecond
+
+// ProgressOptions configures the slide timing bar along the bottom edge.
+type ProgressOptions struct {
+    Enabled  bool
+    Color    color.Color
+    Height   int
+    Position Position // Top or Bottom (the default)
+}
 
-    interval   time.Duration
-    switchTime time.Time
+// NewSlideshowGame creates a slideshow game that draws p. p should load its
+// images with NewImageLoader. The game takes p's notify handler, to show
+// the messages with Notify.
+func NewSlideshowGame(p *player.Player, opts Options) *SlideshowGame {
+    background := opts.Background
+    if background == nil {
+        background = color.Black
+    }
+    width, height := opts.Width, opts.Height
+    if width <= 0 || height <= 0 {
+        width, height = player.DefaultDisplayWidth, player.DefaultDisplayHeight
+    }
+    if opts.MaxFPS > 0 {
+        // Frames the game does not redraw keep the last one.
+        ebiten.SetScreenClearedEveryFrame(false)
+    }
+    g := &SlideshowGame{
+        Player:     p,
+        width:      width,
+        height:     height,
+        date:       opts.Date,
+        location:   opts.Location,
+        caption:    opts.Caption,
+        title:      opts.Title,
+        camera:     opts.Camera,
+        counter:    opts.Counter,
+        qr:         opts.QR,
+        mapOverlay: opts.Map,
+        background: background,
+        edgeFill:   opts.EdgeFill,
+        matchPairs: opts.MatchPairHeights,
+        clock:      opts.Clock,
+        progress:   opts.Progress,
+        pause:      opts.Pause,
+        nightDim:   opts.NightDim,
+        standby:    opts.Standby,
+        transition: opts.Transition,
+        fadeIn:     opts.FadeIn,
+        drift:      opts.Drift,
+        maxFPS:     opts.MaxFPS,
+        mirror:     opts.Mirror,
+        rotate:     opts.Rotate,
+        stop:       opts.Stop,
 
-    dateOverlay bool
-    paused      bool
+        fadeInPending: true,
+    }
+    p.SetNotifyHandler(g.Notify)
+    return g
+}
 
-    remoteCommandChan chan cec.RemoteCommand
+// NewImageLoader returns a player.ImageLoader that decodes photos into
+// Ebiten textures for SlideshowGame to draw, processed as opts says. A
+// current thumbnail from cmd/thumbgen under stateDir, made with the same
+// opts, is used in place of the original.
+func NewImageLoader(stateDir string, opts thumbnail.Options) player.ImageLoader {
+    return func(p photo.Photo) (player.Image, error) {
+        if thumb, ok := thumbnail.Lookup(stateDir, p.FilePath, opts); ok {
+            // Thumbnails are stored upright and reframed, with their
+            // levels applied and in sRGB.
+            p.FilePath = thumb
+            p.Orientation = 1
+            p.Transform = nil
+            return loadTiledEbitenImage(p, thumbnail.Options{})
+        }
+        return loadTiledEbitenImage(p, opts)
+    }
 }
 
-// NewSlideshowGame creates a slideshow game struct.
-func NewSlideshowGame(
-    slides []Slide,
-    interval time.Duration,
-    dateOverlay bool,
-) *SlideshowGame {
-    return &SlideshowGame{
-        slides:      slides,
-        interval:    interval,
-        switchTime:  time.Now().Add(interval),
-        dateOverlay: dateOverlay,
+// NewDecoder returns a player.Decoder that decodes photos as NewImageLoader
+// would, for a player.Preloader to prepare off the Update goroutine; see
+// UploadImage. Animated GIFs are not preloaded.
+func NewDecoder(stateDir string, opts thumbnail.Options) player.Decoder {
+    return func(p photo.Photo) (image.Image, error) {
+        if isGIF(p.FilePath) {
+            return nil, fmt.Errorf("%s: GIFs are not preloaded", p.FilePath)
+        }
+        if thumb, ok := thumbnail.Lookup(stateDir, p.FilePath, opts); ok {
+            p.FilePath = thumb
+            p.Orientation = 1
+            p.Transform = nil
+            return decodeImage(p, thumbnail.Options{})
+        }
+        return decodeImage(p, opts)
     }
 }
 
-// SetRemoteCommandChan allows us to inject the remote events channel.
-func (g *SlideshowGame) SetRemoteCommandChan(ch chan cec.RemoteCommand) {
-    g.remoteCommandChan = ch
+// UploadImage is the player.Uploader for pixels from NewDecoder.
+func UploadImage(src image.Image) player.Image {
+    return newTiledImage(src)
+}
+
+// NewStreamLoader returns a player.StreamLoader that decodes photos read from
+// a stream, such as a remote album's, into Ebiten textures, processed as
+// opts says; its size is ignored.
+func NewStreamLoader(opts thumbnail.Options) player.StreamLoader {
+    return func(r io.Reader, p photo.Photo) (player.Image, error) {
+        img, err := loadTiledEbitenImageFrom(r, p, opts)
+        if err != nil {
+            return nil, err
+        }
+        return img, nil
+    }
 }
 
-// Update is called by Ebiten ~60 times/sec. We read remote commands, handle them,
-// and also auto-advance slides if not paused.
+// Update is called by Ebiten ~60 times/sec and steps the player.
 func (g *SlideshowGame) Update() error {
-    // ESC to exit
+    // ESC (or Stop) to exit
+    select {
+    case <-g.stop:
+        return g.shutdown()
+    default:
+    }
     if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
-        return errors.New("exit requested")
+        return g.shutdown()
     }
-
-    // Non-blocking read of remote commands
-readLoop:
-    for {
-        select {
-        case cmd := <-g.remoteCommandChan:
-            g.handleRemoteCommand(cmd)
-        default:
-            break readLoop
-        }
+    if inpututil.IsKeyJustPressed(ebiten.KeyDelete) {
+        g.Command(cec.RemoteDelete)
     }
-
-    // If not paused, auto-advance slides on interval
-    if !g.paused && time.Now().After(g.switchTime) {
-        g.advanceSlide()
+    if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+        g.Command(cec.RemoteFavorite)
     }
-
+    if inpututil.IsKeyJustPressed(ebiten.KeyH) {
+        g.Command(cec.RemoteHold)
+    }
+    if inpututil.IsKeyJustPressed(ebiten.KeyB) {
+        g.Command(cec.RemoteBlank)
+    }
+    if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+        g.Command(cec.RemoteRescan)
+    }
+    if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+        g.Command(cec.RemoteFaster)
+    }
+    if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+        g.Command(cec.RemoteSlower)
+    }
+    g.Player.Update()
+    g.expireToasts(time.Now())
+    g.setTickRate()
+    g.updateDim()
+    g.updated = true
     return nil
 }
 
-// handleRemoteCommand adjusts the slideshow based on remote input.
-func (g *SlideshowGame) handleRemoteCommand(cmd cec.RemoteCommand) {
-    switch cmd {
-    case cec.RemoteLeft:
-        g.previousSlide()
-    case cec.RemoteRight:
-        g.advanceSlide()
-    case cec.RemoteSelect:
-        g.paused = !g.paused
-    default:
-        // Unknown or unhandled
+// shutdown frees the GPU resources held for drawing and ends the game loop.
+func (g *SlideshowGame) shutdown() error {
+    g.Player.Close()
+    g.disposeQRCode()
+    g.disposeMapImage()
+    g.disposeFrame()
+    if g.transitionFrom != nil {
+        g.transitionFrom.Dispose()
+        g.transitionTo.Dispose()
+        g.transitionFrom, g.transitionTo = nil, nil
+    }
+    if g.driftTarget != nil {
+        g.driftTarget.Dispose()
+        g.driftTarget = nil
+    }
+    return ebiten.Termination
+}
+
+// updateDim steps the pause fade one tick toward its target.
+func (g *SlideshowGame) updateDim() {
+    target := 0.0
+    if g.Paused() {
+        target = 1
+    }
+    step := 1 / (pauseFade.Seconds() * float64(ebiten.TPS()))
+    if g.dimLevel < target {
+        g.dimLevel = math.Min(g.dimLevel+step, target)
+    } else {
+        g.dimLevel = math.Max(g.dimLevel-step, target)
     }
 }
 
-// Draw is called every frame (~60fps). We render the current slide, plus any overlays.
+// Draw is called every frame (~60fps) to draw the frame due now.
 func (g *SlideshowGame) Draw(screen *ebiten.Image) {
-    // If there's a loading error, just display it
-    if g.loadingError != nil {
-        drawDebugString(screen, "Error loading image(s):\n"+g.loadingError.Error())
+    if !g.frameDue(screen) {
         return
     }
+    g.DrawAt(screen, time.Now())
+}
 
-    // If no slides
-    if len(g.slides) == 0 {
-        drawDebugString(screen, "No slides found.")
+// DrawAt renders the current slide as it looks at now, plus any overlays,
+// then dims the lot if it is night. target is the screen or any image of
+// the size Layout returns, such as an offscreen one to save as a picture.
+func (g *SlideshowGame) DrawAt(target *ebiten.Image, now time.Time) {
+    if g.Blanked() {
+        target.Fill(color.Black)
         return
     }
+    frame := g.frameTarget(target)
+    g.drawScreen(frame, now)
+    if g.nightDim.active(now) {
+        drawDimmer(frame, 1-g.nightDim.Brightness)
+    }
+    g.drawFrame(target, frame)
+}
 
-    // Draw the current slide
-    slide := g.slides[g.currentIndex]
-    drawSlide(screen, slide, g.currentTiledImages, g.dateOverlay)
+// drawScreen draws the slide with its overlays, or the error or standby screen.
+func (g *SlideshowGame) drawScreen(screen *ebiten.Image, now time.Time) {
+    if g.offHours(now) {
+        g.drawStandby(screen, now)
+        g.fadeInPending = true
+        return
+    }
 
-    // If paused, display an indicator in the top-left
-    if g.paused {
-        drawPauseIndicator(screen)
+    // If there's a loading error, just display it
+    if err := g.LoadingError(); err != nil {
+        drawDebugString(screen, "Error loading image(s):\n"+err.Error(), g.background)
+        g.fadeInPending = true
+        return
     }
-}
 
-// Layout sets the logical screen size. Ebiten will scale to the actual display.
-func (g *SlideshowGame) Layout(outsideWidth, outsideHeight int) (int, int) {
-    return 1920, 1080
-}
+    // If no slides, wait for a rescan to find some
+    slide, images, ok := g.CurrentSlide()
+    if !ok {
+        g.drawStandby(screen, now)
+        g.fadeInPending = true
+        return
+    }
+    if g.fadeInPending {
+        g.fadeInPending = false
+        g.fadeInSlide, g.fadeInStart = g.SlideStart(), now
+    }
 
-// LoadCurrentSlide loads the images for the current index's slide.
-func (g *SlideshowGame) LoadCurrentSlide() error {
-    if g.currentIndex < 0 || g.currentIndex >= len(g.slides) {
-        return nil
+    // Draw the current slide, sliding it in if it just replaced another or
+    // fading it in if it is the first
+    if !g.drawTransition(screen, slide, images, now) && !g.drawFadeIn(screen, slide, images, now) {
+        g.drawSlideContent(screen, slide, images, now)
     }
-    g.freeSlideImages()
 
-    slide := g.slides[g.currentIndex]
-    var newImages []*TiledImage
-    for _, p := range slide.Photos {
-        tiled, err := loadTiledEbitenImage(p)
-        if err != nil {
-            return err
+    if g.progress.Enabled && !g.Paused() && !g.Held() {
+        elapsed, interval := g.SlideTiming()
+        drawProgressBar(screen, elapsed, interval, g.progress)
+    }
+
+    // Text overlays claim their positions in a fixed order, so one that
+    // gives way always moves to the same place.
+    layout := newOverlayLayout()
+    if !slide.IsTitleCard() {
+        drawDateOverlay(screen, layout, slide, g.date)
+        drawOverlay(screen, layout, slideLocations(slide), g.location)
+        drawOverlay(screen, layout, slideCaptions(slide), g.caption)
+        drawOverlay(screen, layout, slideTitles(slide, g.title.Ignore), g.title.OverlayOptions)
+        drawOverlay(screen, layout, slideShots(slide), g.camera)
+    }
+    drawClockOverlay(screen, layout, now, g.clock)
+    if g.qr.Enabled && !slide.IsTitleCard() {
+        if code := g.qrCode(slide); code != nil {
+            drawOverlayImage(screen, layout, code, g.qr.Position)
+        }
+    }
+    if g.mapOverlay.Enabled && g.mapOverlay.Map != nil && !slide.IsTitleCard() {
+        if m := g.mapImage(slide); m != nil {
+            drawOverlayImage(screen, layout, m, g.mapOverlay.Position)
         }
-        newImages = append(newImages, tiled)
     }
+    drawOverlay(screen, layout, slideCounter(g.SlidePosition()), g.counter)
 
-    g.currentTiledImages = newImages
-    return nil
-}
+    // If paused, display an indicator in the top-left
+    if g.Paused() && !g.pause.HideIndicator {
+        drawPauseIndicator(screen, layout)
+    }
+    if g.Held() {
+        drawHoldIndicator(screen, layout)
+    }
+    g.drawToasts(screen, layout, now)
 
-// advanceSlide increments currentIndex (with wraparound) and loads that slide.
-func (g *SlideshowGame) advanceSlide() {
-    g.currentIndex = (g.currentIndex + 1) % len(g.slides)
-    g.reloadSlide()
+    if g.DeletePending() {
+        drawDeletePrompt(screen, len(slide.Photos))
+    }
 }
 
-// previousSlide decrements currentIndex (with wraparound) and loads that slide.
-func (g *SlideshowGame) previousSlide() {
-    g.currentIndex = (g.currentIndex - 1 + len(g.slides)) % len(g.slides)
-    g.reloadSlide()
+// drawSlideContent renders a title card or a slide's photos onto target.
+func (g *SlideshowGame) drawSlideContent(target *ebiten.Image, slide player.Slide, images []player.Image, now time.Time) {
+    if slide.IsTitleCard() {
+        drawTitleCard(target, slide.Title, g.background)
+        return
+    }
+    g.drawPhotos(target, slide, images, now)
 }
 
-// reloadSlide frees old images, loads new ones, and resets the slide timer.
-func (g *SlideshowGame) reloadSlide() {
-    g.freeSlideImages()
-    if err := g.LoadCurrentSlide(); err != nil {
-        g.loadingError = err
-    } else {
-        g.loadingError = nil
+// drawPhotos renders a slide's photos, drifting if it is the only slide.
+func (g *SlideshowGame) drawPhotos(screen *ebiten.Image, slide player.Slide, images []player.Image, now time.Time) {
+    if g.drifting() {
+        target := g.makeDriftTarget(screen)
+        g.drawStill(target, slide, images, now)
+        drawDrift(screen, target, now)
+        return
     }
-    g.switchTime = time.Now().Add(g.interval)
+    g.drawStill(screen, slide, images, now)
 }
 
-// freeSlideImages disposes Ebiten images of the current slide (if any).
-func (g *SlideshowGame) freeSlideImages() {
-    if len(g.currentTiledImages) == 0 {
-        return
+// drawStill renders a slide's photos with the pause dimmer and favorite
+// stars on top.
+func (g *SlideshowGame) drawStill(screen *ebiten.Image, slide player.Slide, images []player.Image, now time.Time) {
+    tiledImages := make([]*TiledImage, len(images))
+    for i, img := range images {
+        tiledImages[i] = img.(*TiledImage)
+        tiledImages[i].animate(now)
     }
-    for _, t := range g.currentTiledImages {
-        for _, tile := range t.tiles {
-            tile.Dispose()
+    drawSlide(screen, tiledImages, g.background, g.edgeFill, g.matchPairs, slide.Stacked)
+    drawDimmer(screen, g.pause.Dim*g.dimLevel)
+    for i, ph := range slide.Photos {
+        if g.IsFavorite(ph.FilePath) {
+            drawFavoriteStar(screen, i, len(slide.Photos), slide.Stacked)
         }
     }
-    g.currentTiledImages = nil
 }
 
-// For completeness, if you also want the "SetLoadingError" method:
-func (g *SlideshowGame) SetLoadingError(err error) {
-    g.loadingError = err
+// Layout sets the screen size: the logical size, turned back by the
+// rotation. Ebiten will scale to the actual display.
+func (g *SlideshowGame) Layout(outsideWidth, outsideHeight int) (int, int) {
+    return player.RotatedSize(g.width, g.height, g.rotate)
 }
@@ -0,0 +1,53 @@
+package slideshow
+
+import (
+	"image"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/electronjoe/OpenFrame/internal/player"
+)
+
+// MapOptions configures the overlay showing a small map of where the photo
+// on screen was taken.
+type MapOptions struct {
+	OverlayOptions
+	// Map returns the map of the place at lat, lon, or nil while it is not
+	// ready; it must not block. The overlay is left out while Map is nil.
+	Map func(lat, lon float64) image.Image
+}
+
+// mapImage returns the map for slide, placed by its first geotagged photo,
+// or nil if it has none or the map is not ready yet. A map that is ready is
+// kept until another slide replaces it.
+func (g *SlideshowGame) mapImage(slide player.Slide) *ebiten.Image {
+	paths := slide.Paths()
+	if g.mapMade && slices.Equal(paths, g.mapPaths) {
+		return g.mapImg
+	}
+	g.disposeMapImage()
+	for _, p := range slide.Photos {
+		if !p.HasLocation {
+			continue
+		}
+		// Asked again every frame until the map is ready.
+		img := g.mapOverlay.Map(p.Latitude, p.Longitude)
+		if img == nil {
+			return nil
+		}
+		g.mapImg = ebiten.NewImageFromImage(img)
+		break
+	}
+	g.mapPaths, g.mapMade = paths, true
+	return g.mapImg
+}
+
+// disposeMapImage frees the current map, if any.
+func (g *SlideshowGame) disposeMapImage() {
+	if g.mapImg != nil {
+		g.mapImg.Dispose()
+		g.mapImg = nil
+	}
+	g.mapPaths, g.mapMade = nil, false
+}
@@ -0,0 +1,34 @@
+package slideshow
+
+import "time"
+
+// NightDimOptions lowers the brightness of everything on screen during a
+// daily window, e.g. late at night.
+type NightDimOptions struct {
+	Enabled bool
+	// Start and End are offsets from local midnight. End may be earlier
+	// than Start, in which case the window wraps past midnight.
+	Start, End time.Duration
+	// Brightness is the fraction of normal brightness kept while dimmed.
+	Brightness float64
+}
+
+// active reports whether now falls inside the dimming window.
+func (n NightDimOptions) active(now time.Time) bool {
+	return n.Enabled && inDailyWindow(now, n.Start, n.End)
+}
+
+// inDailyWindow reports whether now falls between start and end, offsets
+// from local midnight; the window wraps past midnight when end is earlier
+// than start, and is empty when they are equal.
+func inDailyWindow(now time.Time, start, end time.Duration) bool {
+	if start == end {
+		return false
+	}
+	// Wall-clock time, so DST changes don't shift the window.
+	tod := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute + time.Duration(now.Second())*time.Second
+	if start < end {
+		return tod >= start && tod < end
+	}
+	return tod >= start || tod < end
+}
@@ -0,0 +1,76 @@
+package slideshow
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Mirror flips the whole composited frame, for a display seen through glass
+// or a mirror.
+type Mirror string
+
+const (
+	MirrorNone       Mirror = "none"
+	MirrorHorizontal Mirror = "horizontal" // left and right swap
+	MirrorVertical   Mirror = "vertical"   // top and bottom swap
+)
+
+// reoriented reports whether the frame is mirrored or rotated on its way to
+// the screen.
+func (g *SlideshowGame) reoriented() bool {
+	return g.mirror == MirrorHorizontal || g.mirror == MirrorVertical || g.rotate%360 != 0
+}
+
+// frameTarget returns the image Draw should draw the frame into: screen
+// itself, or an offscreen image of the logical screen size that drawFrame
+// then mirrors and rotates onto screen.
+func (g *SlideshowGame) frameTarget(screen *ebiten.Image) *ebiten.Image {
+	if !g.reoriented() {
+		return screen
+	}
+	if g.frame == nil {
+		g.frame = ebiten.NewImage(g.width, g.height)
+	}
+	// Unlike the screen, an offscreen image keeps the last frame.
+	g.frame.Clear()
+	return g.frame
+}
+
+// drawFrame draws frame, from frameTarget, onto screen: mirrored first and
+// then turned clockwise by the rotation.
+func (g *SlideshowGame) drawFrame(screen, frame *ebiten.Image) {
+	if frame == screen {
+		return
+	}
+	w, h := float64(g.width), float64(g.height)
+	op := &ebiten.DrawImageOptions{}
+	switch g.mirror {
+	case MirrorHorizontal:
+		op.GeoM.Scale(-1, 1)
+		op.GeoM.Translate(w, 0)
+	case MirrorVertical:
+		op.GeoM.Scale(1, -1)
+		op.GeoM.Translate(0, h)
+	}
+	switch g.rotate % 360 {
+	case 90:
+		op.GeoM.Rotate(math.Pi / 2)
+		op.GeoM.Translate(h, 0)
+	case 180:
+		op.GeoM.Rotate(math.Pi)
+		op.GeoM.Translate(w, h)
+	case 270:
+		op.GeoM.Rotate(3 * math.Pi / 2)
+		op.GeoM.Translate(0, w)
+	}
+	screen.DrawImage(frame, op)
+}
+
+// disposeFrame frees the offscreen image frameTarget made.
+func (g *SlideshowGame) disposeFrame() {
+	if g.frame != nil {
+		g.frame.Dispose()
+		g.frame = nil
+	}
+}
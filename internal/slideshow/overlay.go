@@ -0,0 +1,252 @@
+package slideshow
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+
+	"github.com/electronjoe/OpenFrame/internal/photo"
+	"github.com/electronjoe/OpenFrame/internal/player"
+)
+
+// Position names where an overlay sits: a corner, or centred along the top
+// or bottom edge.
+type Position string
+
+const (
+	TopLeft     Position = "topLeft"
+	Top         Position = "top"
+	TopRight    Position = "topRight"
+	BottomLeft  Position = "bottomLeft"
+	Bottom      Position = "bottom"
+	BottomRight Position = "bottomRight"
+)
+
+// positionRing orders the positions clockwise, which is the order a taken
+// position gives way in.
+var positionRing = []Position{TopLeft, Top, TopRight, BottomRight, Bottom, BottomLeft}
+
+// OverlayOptions turns a text overlay on and places it.
+type OverlayOptions struct {
+	Enabled  bool
+	Position Position
+}
+
+// overlayMargin is the distance kept between overlays and the screen edge.
+const overlayMargin = 20
+
+// overlayLayout hands out positions to the overlays drawn in one frame so
+// that no two land on each other.
+type overlayLayout struct {
+	taken map[Position]bool
+}
+
+func newOverlayLayout() *overlayLayout {
+	return &overlayLayout{taken: make(map[Position]bool)}
+}
+
+// claim reserves pos or, if another overlay already has it, the next free
+// position clockwise. ok is false once every position is taken.
+func (l *overlayLayout) claim(pos Position) (Position, bool) {
+	start := 0
+	for i, p := range positionRing {
+		if p == pos {
+			start = i
+		}
+	}
+	for i := range positionRing {
+		p := positionRing[(start+i)%len(positionRing)]
+		if !l.taken[p] {
+			l.taken[p] = true
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// drawOverlay draws msg at the position layout grants the overlay.
+func drawOverlay(screen *ebiten.Image, layout *overlayLayout, msg string, opts OverlayOptions) {
+	if !opts.Enabled || msg == "" {
+		return
+	}
+	if pos, ok := layout.claim(opts.Position); ok {
+		drawOverlayText(screen, msg, pos)
+	}
+}
+
+// drawOverlayText draws msg, which may have several lines, at pos.
+func drawOverlayText(screen *ebiten.Image, msg string, pos Position) {
+	drawOverlayLines(screen, strings.Split(msg, "\n"), nil, pos)
+}
+
+// drawOverlayLines draws lines as one block at pos, each in its colour from
+// colors, or white if colors is nil. Lines too wide for the screen are cut
+// short.
+func drawOverlayLines(screen *ebiten.Image, lines []string, colors []color.Color, pos Position) {
+	face := basicfont.Face7x13
+	sw, sh := screen.Size()
+
+	maxChars := (sw - 2*overlayMargin) / face.Advance
+	lines = slices.Clone(lines)
+	for i, line := range lines {
+		if r := []rune(line); len(r) > maxChars && maxChars > 3 {
+			lines[i] = string(r[:maxChars-3]) + "..."
+		}
+	}
+	bounds := text.BoundString(face, strings.Join(lines, "\n"))
+
+	// text.Draw places the first line's baseline at y, so shift by the bounds.
+	x, y := overlayOrigin(sw, sh, bounds.Dx(), bounds.Dy(), pos)
+	lineHeight := face.Metrics().Height.Round()
+	for i, line := range lines {
+		var clr color.Color = color.White
+		if colors != nil {
+			clr = colors[i]
+		}
+		text.Draw(screen, line, face, x-bounds.Min.X, y-bounds.Min.Y+i*lineHeight, clr)
+	}
+}
+
+// drawOverlayImage draws img at the position layout grants pos.
+func drawOverlayImage(screen *ebiten.Image, layout *overlayLayout, img *ebiten.Image, pos Position) {
+	pos, ok := layout.claim(pos)
+	if !ok {
+		return
+	}
+	sw, sh := screen.Size()
+	x, y := overlayOrigin(sw, sh, img.Bounds().Dx(), img.Bounds().Dy(), pos)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(x), float64(y))
+	screen.DrawImage(img, op)
+}
+
+// overlayOrigin returns the top-left corner of a w x h overlay at pos on an
+// sw x sh screen.
+func overlayOrigin(sw, sh, w, h int, pos Position) (x, y int) {
+	switch pos {
+	case TopLeft, BottomLeft:
+		x = overlayMargin
+	case Top, Bottom:
+		x = (sw - w) / 2
+	default:
+		x = sw - overlayMargin - w
+	}
+	y = overlayMargin
+	if pos == BottomLeft || pos == Bottom || pos == BottomRight {
+		y = sh - overlayMargin - h
+	}
+	return x, y
+}
+
+// slideDates is the date overlay text: the day each photo was taken, once
+// if both photos of a pair share it.
+func slideDates(slide player.Slide) string {
+	return joinDistinct(slide.Photos, photoDate)
+}
+
+// photoDate is the day p was taken.
+func photoDate(p photo.Photo) string {
+	return p.TakenTime.Format("2006-01-02")
+}
+
+// drawDateOverlay draws the date overlay for slide. A stacked pair has a
+// date for each photo: the top one's along the top edge and the bottom
+// one's along the bottom, on the side opts puts it.
+func drawDateOverlay(screen *ebiten.Image, layout *overlayLayout, slide player.Slide, opts OverlayOptions) {
+	if !slide.Stacked || len(slide.Photos) != 2 {
+		drawOverlay(screen, layout, slideDates(slide), opts)
+		return
+	}
+	top, bottom := stackedPositions(opts.Position)
+	drawOverlay(screen, layout, photoDate(slide.Photos[0]), OverlayOptions{Enabled: opts.Enabled, Position: top})
+	drawOverlay(screen, layout, photoDate(slide.Photos[1]), OverlayOptions{Enabled: opts.Enabled, Position: bottom})
+}
+
+// stackedPositions returns the positions on the top and bottom edges on
+// the same side as pos.
+func stackedPositions(pos Position) (top, bottom Position) {
+	switch pos {
+	case TopLeft, BottomLeft:
+		return TopLeft, BottomLeft
+	case Top, Bottom:
+		return Top, Bottom
+	}
+	return TopRight, BottomRight
+}
+
+// slideLocations is the location overlay text: each photo's place name from
+// cmd/geocode, or its GPS coordinates.
+func slideLocations(slide player.Slide) string {
+	return joinDistinct(slide.Photos, func(p photo.Photo) string {
+		if p.Place != "" {
+			return p.Place
+		}
+		if p.HasLocation {
+			return fmt.Sprintf("%.5f, %.5f", p.Latitude, p.Longitude)
+		}
+		return ""
+	})
+}
+
+// slideCaptions is the caption overlay text.
+func slideCaptions(slide player.Slide) string {
+	return joinDistinct(slide.Photos, func(p photo.Photo) string { return p.Caption })
+}
+
+// TitleOptions configures the file name title overlay.
+type TitleOptions struct {
+	OverlayOptions
+	// Ignore matches the file names, without their extension, that make no
+	// title, such as a camera's "IMG_1234"; nil ignores none.
+	Ignore *regexp.Regexp
+}
+
+// slideTitles is the title overlay text: a title from the file name of each
+// photo without a caption.
+func slideTitles(slide player.Slide, ignore *regexp.Regexp) string {
+	return joinDistinct(slide.Photos, func(p photo.Photo) string {
+		if p.Caption != "" {
+			return ""
+		}
+		return photo.FilenameTitle(p.FilePath, ignore)
+	})
+}
+
+// slideShots is the camera overlay text: each photo's camera settings, a
+// line for each photo of a pair unless both share them.
+func slideShots(slide player.Slide) string {
+	var lines []string
+	for _, p := range slide.Photos {
+		if s := p.Shot.String(); s != "" && !slices.Contains(lines, s) {
+			lines = append(lines, s)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// slideCounter is the counter overlay text, e.g. "42 / 1200", or "" when
+// there are no slides.
+func slideCounter(index, total int) string {
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d / %d", index+1, total)
+}
+
+// joinDistinct joins the non-empty, distinct values of field for photos,
+// left photo first.
+func joinDistinct(photos []photo.Photo, field func(photo.Photo) string) string {
+	var values []string
+	for _, p := range photos {
+		if v := field(p); v != "" && (len(values) == 0 || values[len(values)-1] != v) {
+			values = append(values, v)
+		}
+	}
+	return strings.Join(values, " | ")
+}
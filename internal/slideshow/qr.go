@@ -0,0 +1,56 @@
+package slideshow
+
+import (
+	"log"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	qrcode "github.com/skip2/go-qrcode"
+
+	"github.com/electronjoe/OpenFrame/internal/player"
+)
+
+// QROptions configures the QR code overlay that lets guests fetch the photo
+// on screen with their phone.
+type QROptions struct {
+	OverlayOptions
+	// Link returns the address to encode for the photos at paths, or "" to
+	// show no code for them.
+	Link func(paths []string) string
+}
+
+// qrSize is the width and height of the QR code, quiet zone included. It is
+// large enough to scan from across a room at 1080 logical pixels.
+const qrSize = 180
+
+// qrCode returns the QR code for slide, or nil if it has none. The code is
+// made when the slide first comes up and kept until another replaces it.
+func (g *SlideshowGame) qrCode(slide player.Slide) *ebiten.Image {
+	paths := slide.Paths()
+	if g.qrMade && slices.Equal(paths, g.qrPaths) {
+		return g.qrImage
+	}
+	g.disposeQRCode()
+	g.qrPaths, g.qrMade = paths, true
+
+	link := g.qr.Link(paths)
+	if link == "" {
+		return nil
+	}
+	code, err := qrcode.New(link, qrcode.Medium)
+	if err != nil {
+		log.Printf("QR code for %s: %v", link, err)
+		return nil
+	}
+	g.qrImage = ebiten.NewImageFromImage(code.Image(qrSize))
+	return g.qrImage
+}
+
+// disposeQRCode frees the current QR code, if any.
+func (g *SlideshowGame) disposeQRCode() {
+	if g.qrImage != nil {
+		g.qrImage.Dispose()
+		g.qrImage = nil
+	}
+	g.qrPaths, g.qrMade = nil, false
+}
@@ -0,0 +1,61 @@
+package slideshow
+
+import (
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/electronjoe/OpenFrame/internal/schedule"
+)
+
+// StandbyMode picks what the standby screen shows.
+type StandbyMode string
+
+const (
+	StandbyMessage StandbyMode = "message" // the player's standby message
+	StandbyBlack   StandbyMode = "black"
+	StandbyClock   StandbyMode = "clock" // a large clock and date
+)
+
+// StandbyOptions configures the screen shown while there are no slides and,
+// unless Mode is StandbyMessage, outside the scheduled on hours.
+type StandbyOptions struct {
+	// Mode is what the screen shows; "" is StandbyMessage.
+	Mode StandbyMode
+	// OffHours, when set, puts up the standby screen outside Hours in
+	// place of the slides. The slideshow carries on underneath.
+	OffHours bool
+	Hours    schedule.Week
+}
+
+// offHours reports whether the standby screen replaces the slides at now.
+// The message is only meant for an empty slideshow, so it does not.
+func (g *SlideshowGame) offHours(now time.Time) bool {
+	s := g.standby
+	if !s.OffHours || s.Mode == "" || s.Mode == StandbyMessage {
+		return false
+	}
+	return !s.Hours.IsOn(now)
+}
+
+// drawStandby draws the standby screen as it looks at now.
+func (g *SlideshowGame) drawStandby(screen *ebiten.Image, now time.Time) {
+	switch g.standby.Mode {
+	case StandbyBlack:
+		screen.Fill(color.Black)
+	case StandbyClock:
+		drawStandbyClock(screen, now, g.clock.Use12Hour, g.background)
+	default:
+		drawStandbyMessage(screen, g.StandbyMessage(), g.background)
+	}
+}
+
+// drawStandbyClock draws the time, and the date below it, centered and large
+// enough to read from across the room.
+func drawStandbyClock(screen *ebiten.Image, now time.Time, use12Hour bool, background color.Color) {
+	screen.Fill(background)
+	_, sh := screen.Size()
+	drawCenteredText(screen, formatClock(now, use12Hour), 12, float64(sh)/2-40)
+	drawCenteredText(screen, now.Format(clockDateFormat), 5, float64(sh)/2+70)
+}
@@ -0,0 +1,69 @@
+package slideshow
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	starRadius = 18
+	// starMargin keeps the star clear of the progress bar.
+	starMargin = 30
+)
+
+var (
+	starColor = color.NRGBA{R: 0xff, G: 0xd0, B: 0x40, A: 0xe0}
+
+	// whitePixel is the source texture for DrawTriangles; vertex colors
+	// tint it.
+	whitePixel = func() *ebiten.Image {
+		img := ebiten.NewImage(3, 3)
+		img.Fill(color.White)
+		return img.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+	}()
+)
+
+// drawFavoriteStar marks a favorited photo with a star at the bottom center
+// of its part of the screen: the whole width for a single photo, or half of
+// it (index 0 left, 1 right) for a side-by-side pair, or of the top or
+// bottom half for a stacked one.
+func drawFavoriteStar(screen *ebiten.Image, index, photos int, stacked bool) {
+	sw, sh := screen.Size()
+	slotWidth := float64(sw) / float64(photos)
+	cx := slotWidth*float64(index) + slotWidth/2
+	cy := float64(sh) - starMargin - starRadius
+	if stacked {
+		cx = float64(sw) / 2
+		cy = float64(sh)/2*float64(index+1) - starMargin - starRadius
+	}
+
+	var path vector.Path
+	for i := 0; i < 10; i++ {
+		r := float64(starRadius)
+		if i%2 == 1 {
+			r *= 0.45
+		}
+		angle := -math.Pi/2 + float64(i)*math.Pi/5
+		x, y := float32(cx+r*math.Cos(angle)), float32(cy+r*math.Sin(angle))
+		if i == 0 {
+			path.MoveTo(x, y)
+		} else {
+			path.LineTo(x, y)
+		}
+	}
+	path.Close()
+
+	vertices, indices := path.AppendVerticesAndIndicesForFilling(nil, nil)
+	for i := range vertices {
+		vertices[i].SrcX, vertices[i].SrcY = 1, 1
+		vertices[i].ColorR = float32(starColor.R) / 0xff
+		vertices[i].ColorG = float32(starColor.G) / 0xff
+		vertices[i].ColorB = float32(starColor.B) / 0xff
+		vertices[i].ColorA = float32(starColor.A) / 0xff
+	}
+	screen.DrawTriangles(vertices, indices, whitePixel, &ebiten.DrawTrianglesOptions{AntiAlias: true})
+}
@@ -1,9 +1,13 @@
 package slideshow
 
 import (
+    "bytes"
     "fmt"
     "image"
+    "image/color"
+    "io"
     "os"
+    "time"
 
     "github.com/hajimehoshi/ebiten/v2"
     // We include blank imports for standard image decoders
@@ -11,7 +15,12 @@ import (
     _ "image/jpeg"
     _ "image/png"
 
+    _ "golang.org/x/image/bmp"
+    _ "golang.org/x/image/tiff"
+
+    "github.com/electronjoe/OpenFrame/internal/imgproc"
     "github.com/electronjoe/OpenFrame/internal/photo"
+    "github.com/electronjoe/OpenFrame/internal/thumbnail"
 )
 
 const maxTileSize = 2048
@@ -22,185 +31,182 @@ type TiledImage struct {
     tiles       []*ebiten.Image
     totalWidth  int
     totalHeight int
+
+    // edgeColor is the average colour around the photo's border, for
+    // filling the letterbox bars; nil if it could not be sampled.
+    edgeColor color.Color
+
+    // Animated GIFs keep the tiles of every frame; tiles is the one on
+    // screen, picked by animate.
+    frames []animationFrame
+    start  time.Time
+}
+
+// Dispose releases the GPU textures backing every tile. It is safe to call
+// more than once.
+func (t *TiledImage) Dispose() {
+    for _, tile := range t.tiles {
+        tile.Dispose()
+    }
+    for _, f := range t.frames {
+        for _, tile := range f.tiles {
+            tile.Dispose()
+        }
+    }
+    t.tiles = nil
+    t.frames = nil
 }
 
-// loadTiledEbitenImage decodes an image from disk (using p.FilePath), applies any EXIF orientation
-// transform, then splits it into sub-tiles if it's larger than Ebiten’s max texture size.
-func loadTiledEbitenImage(p photo.Photo) (*TiledImage, error) {
+// loadTiledEbitenImage decodes an image from disk (using p.FilePath); see
+// loadTiledEbitenImageFrom.
+func loadTiledEbitenImage(p photo.Photo, opts thumbnail.Options) (*TiledImage, error) {
     file, err := os.Open(p.FilePath)
     if err != nil {
         return nil, fmt.Errorf("unable to open file %s: %w", p.FilePath, err)
     }
     defer file.Close()
+    return loadTiledEbitenImageFrom(file, p, opts)
+}
 
-    // Decode the raw image (ignoring orientation at first)
-    src, _, err := image.Decode(file)
+// loadTiledEbitenImageFrom decodes the image for p from r, which need not be a file (an
+// embedded FS, a network album, a test fixture). p.FilePath names the image for its format and
+// messages. It applies the EXIF orientation transform, read from r itself when p.Orientation is
+// 0 (unknown), then p.Transform and the quarter turn of p.RotatedToFill, and the processing in opts, then splits the image into sub-tiles
+// if it's larger than Ebiten’s max texture size. Animated GIFs are left as they are.
+func loadTiledEbitenImageFrom(r io.Reader, p photo.Photo, opts thumbnail.Options) (*TiledImage, error) {
+    // The stream may be read more than once (GIF frames, orientation), so make it rewindable.
+    rs, err := rewindable(r)
     if err != nil {
-        return nil, fmt.Errorf("unable to decode image %s: %w", p.FilePath, err)
+        return nil, fmt.Errorf("unable to read %s: %w", p.FilePath, err)
     }
 
-    // Apply orientation (rotate/flip if needed)
-    src = applyEXIFOrientation(src, p.Orientation)
-
-    // After orientation, determine final width & height
-    w := src.Bounds().Dx()
-    h := src.Bounds().Dy()
-
-    // Now slice the (possibly large) image into tiles
-    var tiles []*ebiten.Image
-    for y := 0; y < h; y += maxTileSize {
-        for x := 0; x < w; x += maxTileSize {
-            subRect := image.Rect(
-                x,
-                y,
-                minInt(x+maxTileSize, w),
-                minInt(y+maxTileSize, h),
-            )
-            subImg := src.(interface {
-                SubImage(r image.Rectangle) image.Image
-            }).SubImage(subRect)
-
-            tile := ebiten.NewImageFromImage(subImg)
-            tiles = append(tiles, tile)
+    if isGIF(p.FilePath) {
+        if animated, err := loadAnimatedGIF(rs, p.FilePath); err != nil || animated != nil {
+            return animated, err
+        }
+        if _, err := rs.Seek(0, io.SeekStart); err != nil {
+            return nil, err
         }
     }
 
-    return &TiledImage{
-        tiles:       tiles,
-        totalWidth:  w,
-        totalHeight: h,
-    }, nil
-}
-
-func minInt(a, b int) int {
-    if a < b {
-        return a
+    src, err := decodeImageFrom(rs, p, opts)
+    if err != nil {
+        return nil, err
     }
-    return b
+    return newTiledImage(src), nil
 }
 
-// applyEXIFOrientation rotates/flips the image based on the EXIF orientation value (1–8).
-// Orientation reference:
-//   1 - 0° (normal),   2 - flip horizontal,  3 - 180°,       4 - flip vertical
-//   5 - transpose,     6 - rotate 90 CW,     7 - transverse, 8 - rotate 270 CW
-func applyEXIFOrientation(src image.Image, orientation int) image.Image {
-    switch orientation {
-    case 2:
-        return flipHorizontal(src)
-    case 3:
-        return rotate180(src)
-    case 4:
-        return flipVertical(src)
-    case 5:
-        return transpose(src)
-    case 6:
-        return rotate90(src)
-    case 7:
-        return transverse(src)
-    case 8:
-        return rotate270(src)
-    default:
-        // 1 => no transform
-        return src
+// decodeImage decodes and processes the still image at p.FilePath, as
+// loadTiledEbitenImageFrom does, without uploading it.
+func decodeImage(p photo.Photo, opts thumbnail.Options) (image.Image, error) {
+    file, err := os.Open(p.FilePath)
+    if err != nil {
+        return nil, fmt.Errorf("unable to open file %s: %w", p.FilePath, err)
     }
+    defer file.Close()
+    return decodeImageFrom(file, p, opts)
 }
 
-// Below are helper functions for various flips/rotations.
-// Each allocates a new RGBA and copies pixels accordingly.
-
-// flipHorizontal: left-right mirror
-func flipHorizontal(src image.Image) image.Image {
-    b := src.Bounds()
-    w, h := b.Dx(), b.Dy()
-    dst := image.NewRGBA(b)
-    for y := 0; y < h; y++ {
-        for x := 0; x < w; x++ {
-            dst.Set(w-1-x, y, src.At(x+b.Min.X, y+b.Min.Y))
+// decodeImageFrom decodes the image for p from rs, oriented, reframed and
+// processed as opts says.
+func decodeImageFrom(rs io.ReadSeeker, p photo.Photo, opts thumbnail.Options) (image.Image, error) {
+    orientation := p.Orientation
+    if orientation == 0 {
+        orientation = photo.ReadOrientation(rs)
+        if _, err := rs.Seek(0, io.SeekStart); err != nil {
+            return nil, err
         }
     }
-    return dst
-}
 
-// flipVertical: top-bottom mirror
-func flipVertical(src image.Image) image.Image {
-    b := src.Bounds()
-    w, h := b.Dx(), b.Dy()
-    dst := image.NewRGBA(b)
-    for y := 0; y < h; y++ {
-        for x := 0; x < w; x++ {
-            dst.Set(x, h-1-y, src.At(x+b.Min.X, y+b.Min.Y))
+    var profile *imgproc.ColorProfile
+    if opts.ColorManagement {
+        profile = photo.ReadColorProfile(rs)
+        if _, err := rs.Seek(0, io.SeekStart); err != nil {
+            return nil, err
         }
     }
-    return dst
+
+    // Decode the raw image (ignoring orientation at first) and bring it to
+    // sRGB
+    src, err := photo.DecodeReader(rs, p.FilePath)
+    if err != nil {
+        return nil, err
+    }
+    src = profile.ToSRGB(src)
+
+    // Apply orientation (rotate/flip if needed), then any reframing from
+    // the photo's sidecar
+    src = imgproc.ApplyEXIFOrientation(src, orientation)
+    src = p.Transform.Apply(src)
+    if p.RotatedToFill {
+        src = imgproc.Rotate90(src)
+    }
+    return imgproc.AutoLevels(src, opts.AutoLevels), nil
 }
 
-// rotate180: 180° rotation
-func rotate180(src image.Image) image.Image {
-    // 180° is just horizontal + vertical flip
-    b := src.Bounds()
-    w, h := b.Dx(), b.Dy()
-    dst := image.NewRGBA(b)
-    for y := 0; y < h; y++ {
-        for x := 0; x < w; x++ {
-            dst.Set(w-1-x, h-1-y, src.At(x+b.Min.X, y+b.Min.Y))
-        }
+// newTiledImage uploads decoded pixels as a TiledImage.
+func newTiledImage(src image.Image) *TiledImage {
+    return &TiledImage{
+        tiles:       tileImage(src),
+        totalWidth:  src.Bounds().Dx(),
+        totalHeight: src.Bounds().Dy(),
+        edgeColor:   sampleEdgeColor(src),
     }
-    return dst
 }
 
-// rotate90: 90° clockwise
-func rotate90(src image.Image) image.Image {
-    b := src.Bounds()
-    w, h := b.Dx(), b.Dy()
-    // rotated image has size h x w
-    dst := image.NewRGBA(image.Rect(0, 0, h, w))
-    for y := 0; y < h; y++ {
-        for x := 0; x < w; x++ {
-            dst.Set(h-1-y, x, src.At(x+b.Min.X, y+b.Min.Y))
-        }
+// rewindable returns r itself if it can seek (a file), or else r read into memory.
+func rewindable(r io.Reader) (io.ReadSeeker, error) {
+    if rs, ok := r.(io.ReadSeeker); ok {
+        return rs, nil
+    }
+    data, err := io.ReadAll(r)
+    if err != nil {
+        return nil, err
     }
-    return dst
+    return bytes.NewReader(data), nil
 }
 
-// rotate270: 270° clockwise (or 90° CCW)
-func rotate270(src image.Image) image.Image {
-    // same as rotate90 three times
-    b := src.Bounds()
-    w, h := b.Dx(), b.Dy()
-    dst := image.NewRGBA(image.Rect(0, 0, h, w))
-    for y := 0; y < h; y++ {
-        for x := 0; x < w; x++ {
-            dst.Set(y, w-1-x, src.At(x+b.Min.X, y+b.Min.Y))
-        }
+// sampleEdgeColor returns src's border colour, or nil if it has none.
+func sampleEdgeColor(src image.Image) color.Color {
+    if c, ok := imgproc.EdgeColor(src); ok {
+        return c
     }
-    return dst
+    return nil
 }
 
-// transpose: flip over top-left/bottom-right diagonal (x,y) -> (y,x)
-func transpose(src image.Image) image.Image {
-    b := src.Bounds()
-    w, h := b.Dx(), b.Dy()
-    dst := image.NewRGBA(image.Rect(0, 0, h, w))
-    for y := 0; y < h; y++ {
-        for x := 0; x < w; x++ {
-            dst.Set(y, x, src.At(x+b.Min.X, y+b.Min.Y))
+// tileImage uploads src as one or more textures, slicing it into tiles if
+// it's larger than Ebiten’s max texture size.
+func tileImage(src image.Image) []*ebiten.Image {
+    w := src.Bounds().Dx()
+    h := src.Bounds().Dy()
+
+    var tiles []*ebiten.Image
+    for y := 0; y < h; y += maxTileSize {
+        for x := 0; x < w; x += maxTileSize {
+            subRect := image.Rect(
+                x,
+                y,
+                minInt(x+maxTileSize, w),
+                minInt(y+maxTileSize, h),
+            )
+            subImg := src.(interface {
+                SubImage(r image.Rectangle) image.Image
+            }).SubImage(subRect)
+
+            tile := ebiten.NewImageFromImage(subImg)
+            tiles = append(tiles, tile)
         }
     }
-    return dst
+    return tiles
 }
 
-// transverse: flip over top-right/bottom-left diagonal
-// This is like transpose, then flip horizontally or vertically.
-func transverse(src image.Image) image.Image {
-    // do transpose first
-    trans := transpose(src)
-    // then flip horizontally
-    return flipHorizontal(trans)
+func minInt(a, b int) int {
+    if a < b {
+        return a
+    }
+    return b
 }
 
-// Optionally, you could implement transpose in a single pass by direct indexing, etc.
-// The above approach is straightforward for clarity.
-
 // computeScale calculates a uniform scale so the image fits within screenW x screenH.
 func computeScale(imgW, imgH, screenW, screenH int) float64 {
     if imgW == 0 || imgH == 0 {
@@ -0,0 +1,77 @@
+package slideshow
+
+import (
+	"image/color"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// A toast is a short message from Notify, shown for toastDuration and faded
+// out over the last toastFade of it.
+type toast struct {
+	msg     string
+	expires time.Time
+}
+
+const (
+	toastDuration = 2 * time.Second
+	toastFade     = 500 * time.Millisecond
+	// maxToasts is how many messages are up at once; older ones go early.
+	maxToasts = 4
+)
+
+// Notify briefly shows msg, below any earlier messages still up, at the
+// bottom of the screen or the first position the overlays leave free. A
+// repeat of the newest message restarts its time instead. It must be called
+// from the same goroutine as Update, as the player's notify handler is.
+func (g *SlideshowGame) Notify(msg string) {
+	expires := time.Now().Add(toastDuration)
+	if n := len(g.toasts); n > 0 && g.toasts[n-1].msg == msg {
+		g.toasts[n-1].expires = expires
+		return
+	}
+	g.toasts = append(g.toasts, toast{msg: msg, expires: expires})
+	if len(g.toasts) > maxToasts {
+		g.toasts = slices.Delete(g.toasts, 0, len(g.toasts)-maxToasts)
+	}
+}
+
+// expireToasts drops the messages whose time is up.
+func (g *SlideshowGame) expireToasts(now time.Time) {
+	g.toasts = slices.DeleteFunc(g.toasts, func(t toast) bool {
+		return !now.Before(t.expires)
+	})
+}
+
+// toastsFading reports whether a message is fading out, which needs the
+// full frame rate.
+func (g *SlideshowGame) toastsFading(now time.Time) bool {
+	for _, t := range g.toasts {
+		if t.expires.Sub(now) < toastFade {
+			return true
+		}
+	}
+	return false
+}
+
+// drawToasts draws the messages from Notify after the overlays have taken
+// their positions, so that they never move one.
+func (g *SlideshowGame) drawToasts(screen *ebiten.Image, layout *overlayLayout, now time.Time) {
+	if len(g.toasts) == 0 {
+		return
+	}
+	pos, ok := layout.claim(Bottom)
+	if !ok {
+		return
+	}
+	lines := make([]string, len(g.toasts))
+	colors := make([]color.Color, len(g.toasts))
+	for i, t := range g.toasts {
+		alpha := min(1, max(0, t.expires.Sub(now).Seconds()/toastFade.Seconds()))
+		lines[i] = t.msg
+		colors[i] = color.NRGBA{R: 255, G: 255, B: 255, A: uint8(alpha * 255)}
+	}
+	drawOverlayLines(screen, lines, colors, pos)
+}
@@ -0,0 +1,176 @@
+package slideshow
+
+import (
+	"image/color"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"github.com/electronjoe/OpenFrame/internal/player"
+)
+
+// TransitionOptions configures the animation between slides.
+type TransitionOptions struct {
+	// Name is a key of transitions, "none" or "cut" for an instant change
+	// (the default), or "random" to pick a different effect every time.
+	Name     string
+	Duration time.Duration
+}
+
+// transition draws one frame of the change from the slide rendered in from to
+// the one in to, t of the way through (0 to 1). direction is +1 when moving
+// forward and -1 when moving back.
+type transition func(screen, from, to *ebiten.Image, direction int, t float64)
+
+// transitions holds every animated effect, keyed by its config name.
+var transitions = map[string]transition{
+	"crossfade": drawCrossfade,
+	"push":      drawPush,
+	"kenburns":  drawKenBurns,
+}
+
+// transitionNames returns the names of the animated transitions, sorted.
+func transitionNames() []string {
+	names := make([]string, 0, len(transitions))
+	for name := range transitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// transitionFor returns the effect for the slide change that started at
+// start, choosing a new one when Name is "random". It returns nil for an
+// instant change.
+func (g *SlideshowGame) transitionFor(start time.Time) transition {
+	if g.transition.Name != "random" {
+		return transitions[g.transition.Name]
+	}
+	if !start.Equal(g.transitionStart) {
+		g.transitionStart = start
+		g.transitionName = pickOther(transitionNames(), g.transitionName)
+	}
+	return transitions[g.transitionName]
+}
+
+// pickOther picks a random name other than last, when there is a choice.
+func pickOther(names []string, last string) string {
+	for {
+		name := names[rand.Intn(len(names))]
+		if name != last || len(names) == 1 {
+			return name
+		}
+	}
+}
+
+// drawTransition draws the current slide part way through replacing the
+// previous one. It reports false, drawing nothing, once the animation is over
+// or when there is nothing to animate from.
+func (g *SlideshowGame) drawTransition(screen *ebiten.Image, slide player.Slide, images []player.Image, now time.Time) bool {
+	if g.transition.Duration <= 0 {
+		return false
+	}
+	prev, prevImages, direction, ok := g.PreviousSlide()
+	if !ok {
+		return false
+	}
+	elapsed, _ := g.SlideTiming()
+	if elapsed >= g.transition.Duration {
+		return false
+	}
+	effect := g.transitionFor(g.SlideStart())
+	if effect == nil {
+		return false
+	}
+
+	g.makeTransitionTargets(screen)
+	g.drawSlideContent(g.transitionFrom, prev, prevImages, now)
+	g.drawSlideContent(g.transitionTo, slide, images, now)
+
+	effect(screen, g.transitionFrom, g.transitionTo, direction, easeInOut(float64(elapsed)/float64(g.transition.Duration)))
+	return true
+}
+
+// drawFadeIn draws the current slide part way through fading in from black,
+// when it is the first slide or the first after the error or standby screen.
+// It reports false, drawing nothing, otherwise or once the fade is over.
+func (g *SlideshowGame) drawFadeIn(screen *ebiten.Image, slide player.Slide, images []player.Image, now time.Time) bool {
+	if !g.fadingIn(now) {
+		return false
+	}
+	g.makeTransitionTargets(screen)
+	g.transitionFrom.Fill(color.Black)
+	g.drawSlideContent(g.transitionTo, slide, images, now)
+
+	elapsed := now.Sub(g.fadeInStart)
+	drawCrossfade(screen, g.transitionFrom, g.transitionTo, 1, easeInOut(float64(elapsed)/float64(g.fadeIn)))
+	return true
+}
+
+// fadingIn reports whether the slide on screen is still fading in at now.
+// Changing slides ends the fade.
+func (g *SlideshowGame) fadingIn(now time.Time) bool {
+	return g.fadeIn > 0 && g.SlideStart().Equal(g.fadeInSlide) && now.Sub(g.fadeInStart) < g.fadeIn
+}
+
+// makeTransitionTargets creates the offscreen targets for compositing
+// slides, the size of screen, on first use.
+func (g *SlideshowGame) makeTransitionTargets(screen *ebiten.Image) {
+	if g.transitionFrom != nil {
+		return
+	}
+	w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
+	g.transitionFrom = ebiten.NewImage(w, h)
+	g.transitionTo = ebiten.NewImage(w, h)
+}
+
+// drawCrossfade fades from out while to fades in.
+func drawCrossfade(screen, from, to *ebiten.Image, direction int, t float64) {
+	screen.DrawImage(from, nil)
+	op := &ebiten.DrawImageOptions{}
+	op.ColorScale.ScaleAlpha(float32(t))
+	screen.DrawImage(to, op)
+}
+
+// drawPush moves from off the screen and to on. Moving forward (direction +1)
+// pushes the content leftward, so the new slide enters from the right; moving
+// back does the reverse.
+func drawPush(screen, from, to *ebiten.Image, direction int, t float64) {
+	w := float64(screen.Bounds().Dx())
+	shift := -float64(direction) * w * t
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(shift, 0)
+	screen.DrawImage(from, op)
+
+	op = &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(shift+float64(direction)*w, 0)
+	screen.DrawImage(to, op)
+}
+
+// kenBurnsZoom is how far the incoming slide starts zoomed in.
+const kenBurnsZoom = 0.12
+
+// drawKenBurns fades to in while it settles from a slight zoom toward the
+// side it is coming from, in the manner of a documentary pan.
+func drawKenBurns(screen, from, to *ebiten.Image, direction int, t float64) {
+	screen.DrawImage(from, nil)
+
+	w, h := float64(screen.Bounds().Dx()), float64(screen.Bounds().Dy())
+	scale := 1 + kenBurnsZoom*(1-t)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-w/2, -h/2)
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(w/2+float64(direction)*w*kenBurnsZoom/2*(1-t), h/2)
+	op.ColorScale.ScaleAlpha(float32(t))
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(to, op)
+}
+
+// easeInOut maps linear progress in [0, 1] onto a curve that starts and ends
+// gently.
+func easeInOut(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
@@ -0,0 +1,210 @@
+// Package thumbnail maintains display-sized JPEG copies of album photos under
+// the state directory. Decoding a 1080p JPEG is far cheaper than decoding a
+// full-resolution original, so the slideshow prefers a thumbnail when one is
+// current.
+package thumbnail
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+
+	"github.com/electronjoe/OpenFrame/internal/imgproc"
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+const (
+	// MaxWidth and MaxHeight bound a thumbnail unless Options say
+	// otherwise: the slideshow's default logical screen size.
+	MaxWidth  = 1920
+	MaxHeight = 1080
+
+	dirName     = "thumbnails"
+	jpegQuality = 90
+)
+
+// Result says what Generate did for one photo.
+type Result int
+
+const (
+	// Generated means a new thumbnail was written.
+	Generated Result = iota
+	// UpToDate means the existing thumbnail already matches the source.
+	UpToDate
+	// NotNeeded means the slideshow loads the source directly: it is a
+	// GIF, or is not RAW, needs no processing and already fits the screen
+	// upright.
+	NotNeeded
+)
+
+// Options are the size and processing baked into a thumbnail besides
+// orientation.
+type Options struct {
+	// Width and Height bound the thumbnail, normally the slideshow's
+	// logical screen size; zero means MaxWidth x MaxHeight.
+	Width, Height int
+	// AutoLevels is the strength of imgproc.AutoLevels, 0 to skip it.
+	AutoLevels float64
+	// ColorManagement converts photos with an embedded colour profile to
+	// sRGB.
+	ColorManagement bool
+}
+
+// bounds returns the box thumbnails are scaled to fit.
+func (o Options) bounds() (int, int) {
+	if o.Width <= 0 || o.Height <= 0 {
+		return MaxWidth, MaxHeight
+	}
+	return o.Width, o.Height
+}
+
+// Path returns where the thumbnail for the photo at src is stored. Names are
+// a hash of the absolute source path, so albums can share base names, and of
+// any non-default size or processing, so changing them never picks up a
+// stale thumbnail.
+func Path(stateDir, src string, opts Options) string {
+	if abs, err := filepath.Abs(src); err == nil {
+		src = abs
+	}
+	key := src
+	if w, h := opts.bounds(); w != MaxWidth || h != MaxHeight {
+		key += fmt.Sprintf("|size=%dx%d", w, h)
+	}
+	if opts.AutoLevels > 0 {
+		key += fmt.Sprintf("|autoLevels=%g", opts.AutoLevels)
+	}
+	if opts.ColorManagement {
+		key += "|colorManagement"
+	}
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(stateDir, dirName, hex.EncodeToString(sum[:])+".jpg")
+}
+
+// Lookup returns the thumbnail for src if one exists and is current. Like the
+// metadata cache, a thumbnail carries its source's mod time, or its
+// transform sidecar's if that is later, so it is current exactly when the
+// two still match.
+func Lookup(stateDir, src string, opts Options) (string, bool) {
+	stamp, err := sourceStamp(src)
+	if err != nil {
+		return "", false
+	}
+	thumb := Path(stateDir, src, opts)
+	thumbInfo, err := os.Stat(thumb)
+	if err != nil || !thumbInfo.ModTime().Equal(stamp) {
+		return "", false
+	}
+	return thumb, true
+}
+
+// sourceStamp returns the mod time a thumbnail of src is stamped with: the
+// later of src's and its transform sidecar's. Adding, changing or removing
+// the sidecar thus makes the thumbnail stale.
+func sourceStamp(src string) (time.Time, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return time.Time{}, err
+	}
+	stamp := info.ModTime()
+	if sidecar, err := os.Stat(photo.TransformPath(src)); err == nil && sidecar.ModTime().After(stamp) {
+		stamp = sidecar.ModTime()
+	}
+	return stamp, nil
+}
+
+// Generate writes the thumbnail for p unless a current one exists. The EXIF
+// orientation, p's Transform and any opts processing are baked into the
+// pixels, so a thumbnail is always upright, in sRGB with ColorManagement,
+// and ready to show.
+func Generate(stateDir string, p photo.Photo, opts Options) (Result, error) {
+	if _, ok := Lookup(stateDir, p.FilePath, opts); ok {
+		return UpToDate, nil
+	}
+	file, err := os.Open(p.FilePath)
+	if err != nil {
+		return 0, fmt.Errorf("unable to open file %s: %w", p.FilePath, err)
+	}
+	defer file.Close()
+	var profile *imgproc.ColorProfile
+	if opts.ColorManagement {
+		profile = photo.ReadColorProfile(file)
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+	maxW, maxH := opts.bounds()
+	if !photo.IsRawFile(p.FilePath) && p.Orientation <= 1 && p.Transform == nil && p.Width <= maxW && p.Height <= maxH && opts.AutoLevels <= 0 && profile == nil {
+		return NotNeeded, nil
+	}
+	// A JPEG thumbnail would freeze an animated GIF on its first frame.
+	if strings.EqualFold(filepath.Ext(p.FilePath), ".gif") {
+		return NotNeeded, nil
+	}
+
+	stamp, err := sourceStamp(p.FilePath)
+	if err != nil {
+		return 0, err
+	}
+	src, err := photo.DecodeReader(file, p.FilePath)
+	if err != nil {
+		return 0, err
+	}
+	src = profile.ToSRGB(src)
+	thumb := scaleToFit(p.Transform.Apply(imgproc.ApplyEXIFOrientation(src, p.Orientation)), maxW, maxH)
+	thumb = imgproc.AutoLevels(thumb, opts.AutoLevels)
+
+	path := Path(stateDir, p.FilePath, opts)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, fmt.Errorf("create thumbnail directory: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, fmt.Errorf("write thumbnail: %w", err)
+	}
+	err = jpeg.Encode(out, thumb, &jpeg.Options{Quality: jpegQuality})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("write thumbnail for %s: %w", p.FilePath, err)
+	}
+	if err := os.Chtimes(tmpPath, stamp, stamp); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("stamp thumbnail: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return 0, fmt.Errorf("replace thumbnail: %w", err)
+	}
+	return Generated, nil
+}
+
+// scaleToFit shrinks src to fit within maxW x maxH, keeping its aspect ratio.
+// Images that already fit are returned unchanged.
+func scaleToFit(src image.Image, maxW, maxH int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxW && h <= maxH {
+		return src
+	}
+	if w*maxH > h*maxW {
+		h = max(1, h*maxW/w)
+		w = maxW
+	} else {
+		w = max(1, w*maxH/h)
+		h = maxH
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
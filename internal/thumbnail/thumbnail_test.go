@@ -0,0 +1,196 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/electronjoe/OpenFrame/internal/photo"
+)
+
+// writeJPEG writes a w x h JPEG whose left half is red and right half blue.
+func writeJPEG(t *testing.T, path string, w, h int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPathKeysOnOptions(t *testing.T) {
+	defaults := Path("/state", "/albums/a.jpg", Options{})
+	if got := Path("/state", "/albums/a.jpg", Options{Width: MaxWidth, Height: MaxHeight}); got != defaults {
+		t.Errorf("the default size spelled out gave %s, want %s", got, defaults)
+	}
+	if filepath.Dir(defaults) != filepath.Join("/state", dirName) || filepath.Ext(defaults) != ".jpg" {
+		t.Errorf("Path() = %s, want a .jpg under /state/%s", defaults, dirName)
+	}
+	seen := map[string]string{defaults: "defaults"}
+	for name, path := range map[string]string{
+		"other photo":      Path("/state", "/albums/b.jpg", Options{}),
+		"same name":        Path("/state", "/other/a.jpg", Options{}),
+		"size":             Path("/state", "/albums/a.jpg", Options{Width: 1280, Height: 720}),
+		"auto levels":      Path("/state", "/albums/a.jpg", Options{AutoLevels: 0.5}),
+		"auto levels 1":    Path("/state", "/albums/a.jpg", Options{AutoLevels: 1}),
+		"color management": Path("/state", "/albums/a.jpg", Options{ColorManagement: true}),
+	} {
+		if other, ok := seen[path]; ok {
+			t.Errorf("%s and %s share the thumbnail %s", name, other, path)
+		}
+		seen[path] = name
+	}
+}
+
+func TestLookupNoticesNewerSources(t *testing.T) {
+	dir := t.TempDir()
+	stateDir := filepath.Join(dir, "state")
+	src := filepath.Join(dir, "a.jpg")
+	writeJPEG(t, src, 40, 20)
+	taken := time.Date(2024, 7, 14, 16, 2, 5, 0, time.UTC)
+	os.Chtimes(src, taken, taken)
+	p := photo.Photo{FilePath: src, Width: 40, Height: 20, Orientation: 6}
+
+	if _, ok := Lookup(stateDir, src, Options{}); ok {
+		t.Fatal("Lookup() found a thumbnail before one was made")
+	}
+	if res, err := Generate(stateDir, p, Options{}); err != nil || res != Generated {
+		t.Fatalf("Generate() = %v, %v; want Generated", res, err)
+	}
+	if res, err := Generate(stateDir, p, Options{}); err != nil || res != UpToDate {
+		t.Fatalf("second Generate() = %v, %v; want UpToDate", res, err)
+	}
+	if thumb, ok := Lookup(stateDir, src, Options{}); !ok || thumb != Path(stateDir, src, Options{}) {
+		t.Fatalf("Lookup() = %s, %t; want the new thumbnail", thumb, ok)
+	}
+	if _, ok := Lookup(stateDir, src, Options{AutoLevels: 1}); ok {
+		t.Error("Lookup() with other options found the thumbnail")
+	}
+
+	// A sidecar written later makes the thumbnail stale...
+	sidecar := photo.TransformPath(src)
+	os.WriteFile(sidecar, []byte("{}"), 0o644)
+	os.Chtimes(sidecar, taken.Add(time.Hour), taken.Add(time.Hour))
+	if _, ok := Lookup(stateDir, src, Options{}); ok {
+		t.Error("Lookup() ignored a newer sidecar")
+	}
+	if res, err := Generate(stateDir, p, Options{}); err != nil || res != Generated {
+		t.Fatalf("Generate() after the sidecar = %v, %v; want Generated", res, err)
+	}
+	// ...and so does editing the photo.
+	os.Chtimes(src, taken.Add(2*time.Hour), taken.Add(2*time.Hour))
+	if _, ok := Lookup(stateDir, src, Options{}); ok {
+		t.Error("Lookup() ignored a newer source")
+	}
+	// Removing the source leaves nothing to look up.
+	os.Remove(src)
+	if _, ok := Lookup(stateDir, src, Options{}); ok {
+		t.Error("Lookup() found a thumbnail for a deleted photo")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h         int
+		orientation  int
+		opts         Options
+		want         Result
+		wantW, wantH int
+		wantRed      string // the side the source's left half ends up on
+	}{
+		{name: "small and upright", w: 40, h: 20, orientation: 1, want: NotNeeded},
+		{name: "rotated", w: 40, h: 20, orientation: 6, want: Generated, wantW: 20, wantH: 40, wantRed: "top"},
+		{name: "rotated the other way", w: 40, h: 20, orientation: 8, want: Generated, wantW: 20, wantH: 40, wantRed: "bottom"},
+		{name: "mirrored", w: 40, h: 20, orientation: 2, want: Generated, wantW: 40, wantH: 20, wantRed: "right"},
+		{name: "too large", w: 400, h: 100, orientation: 1, opts: Options{Width: 200, Height: 200}, want: Generated, wantW: 200, wantH: 50, wantRed: "left"},
+		{name: "rotated and too large", w: 400, h: 100, orientation: 6, opts: Options{Width: 200, Height: 200}, want: Generated, wantW: 50, wantH: 200, wantRed: "top"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "a.jpg")
+			writeJPEG(t, src, tt.w, tt.h)
+			p := photo.Photo{FilePath: src, Width: tt.w, Height: tt.h, Orientation: tt.orientation}
+			res, err := Generate(dir, p, tt.opts)
+			if err != nil || res != tt.want {
+				t.Fatalf("Generate() = %v, %v; want %v", res, err, tt.want)
+			}
+			if res != Generated {
+				return
+			}
+			f, err := os.Open(Path(dir, src, tt.opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			thumb, err := jpeg.Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := thumb.Bounds()
+			if b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Fatalf("thumbnail is %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+			// Sample a quarter of the way in from each side.
+			samples := map[string]image.Point{
+				"top":    {b.Dx() / 2, b.Dy() / 4},
+				"bottom": {b.Dx() / 2, 3 * b.Dy() / 4},
+				"left":   {b.Dx() / 4, b.Dy() / 2},
+				"right":  {3 * b.Dx() / 4, b.Dy() / 2},
+			}
+			opposite := map[string]string{"top": "bottom", "bottom": "top", "left": "right", "right": "left"}
+			red := func(side string) bool {
+				r, _, blue, _ := thumb.At(samples[side].X, samples[side].Y).RGBA()
+				return r > blue
+			}
+			if !red(tt.wantRed) || red(opposite[tt.wantRed]) {
+				t.Errorf("the red half is not on the %s", tt.wantRed)
+			}
+		})
+	}
+}
+
+func TestScaleToFit(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h         int
+		wantW, wantH int
+	}{
+		{name: "landscape", w: 3840, h: 2160, wantW: 1920, wantH: 1080},
+		{name: "wide landscape", w: 4000, h: 1000, wantW: 1920, wantH: 480},
+		{name: "portrait", w: 3000, h: 4000, wantW: 810, wantH: 1080},
+		{name: "already fits", w: 800, h: 600, wantW: 800, wantH: 600},
+		{name: "small is not upscaled", w: 10, h: 10, wantW: 10, wantH: 10},
+		{name: "sliver keeps a pixel", w: 10000, h: 2, wantW: 1920, wantH: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+			got := scaleToFit(src, MaxWidth, MaxHeight)
+			if b := got.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("scaleToFit(%dx%d) = %dx%d, want %dx%d", tt.w, tt.h, b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+			if tt.w <= MaxWidth && tt.h <= MaxHeight && got != image.Image(src) {
+				t.Error("an image that fits was copied")
+			}
+		})
+	}
+}